@@ -16,13 +16,17 @@ var rootCmd = &cobra.Command{
 支持以下功能：
 - HTTP API服务：提供委托账户查询接口
 - 定时任务：自动处理webhook数据和能量委托
-- Telegram Bot：实时监控和告警通知`,
+- Telegram Bot：实时监控和告警通知
+- 签名服务：在独立进程中保管委托账户私钥并按策略签名
+- 推送重放：按当前解析逻辑重新处理归档的原始 webhook 推送`,
 }
 
 func init() {
 	// 添加子命令
 	rootCmd.AddCommand(serverCmd)
 	rootCmd.AddCommand(botCmd)
+	rootCmd.AddCommand(signerCmd)
+	rootCmd.AddCommand(replayCmd)
 }
 
 func main() {
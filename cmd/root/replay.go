@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/spf13/cobra"
+	"github.com/sunjiangjun/xlog"
+
+	"lending-trx/internal/db"
+	"lending-trx/internal/webhook"
+)
+
+var (
+	replayIDs   []int64
+	replaySince string
+	replayUntil string
+	replayLimit int
+	replayCmd   = &cobra.Command{
+		Use:   "replay",
+		Short: "重放归档的原始 webhook 推送",
+		Long: `从 webhook_archive 读取原始推送，按当前的解析逻辑重新处理并写入支付数据：
+- 按推送ID（--ids）或接收时间范围（--since / --until，RFC3339）选择
+- 支付按 tx_hash 去重，重复重放不会产生重复记录
+
+例如修复解析逻辑后重新处理某一天的推送：
+
+  lending-trx replay --since 2026-01-01T00:00:00Z --until 2026-01-02T00:00:00Z`,
+		Run: runReplay,
+	}
+)
+
+func init() {
+	replayCmd.Flags().Int64SliceVar(&replayIDs, "ids", nil, "推送ID，逗号分隔")
+	replayCmd.Flags().StringVar(&replaySince, "since", "", "接收时间不早于（RFC3339）")
+	replayCmd.Flags().StringVar(&replayUntil, "until", "", "接收时间早于（RFC3339）")
+	replayCmd.Flags().IntVar(&replayLimit, "limit", webhook.DefaultReplayLimit, "最多重放的推送数")
+}
+
+func runReplay(cmd *cobra.Command, args []string) {
+	if err := godotenv.Load(); err != nil {
+		log.Println("⚠️ 未找到.env文件，使用系统环境变量")
+	}
+
+	filter, err := webhook.ParseArchiveFilter(replayIDs, replaySince, replayUntil, replayLimit)
+	if err != nil {
+		log.Fatal("❌ 重放条件错误:", err)
+	}
+	receivers, err := webhook.ReceiversFromEnv()
+	if err != nil {
+		log.Fatal("❌ 收款地址配置错误:", err)
+	}
+
+	ctx := context.Background()
+	pool, err := db.InitDB(ctx)
+	if err != nil {
+		log.Fatal("❌ 数据库初始化失败:", err)
+	}
+	defer pool.Close()
+
+	LOG := xlog.NewXLogger().
+		BuildOutType(xlog.FILE).
+		BuildLevel(xlog.InfoLevel).
+		BuildFormatter(xlog.FORMAT_JSON).
+		BuildFile("logs/lending-trx-replay.log", 24*time.Hour)
+
+	results, err := webhook.NewIngester(ctx, pool, LOG, receivers).Replay(filter)
+	if err != nil {
+		log.Fatal("❌ 查询归档推送失败:", err)
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+			fmt.Printf("❌ #%d: %s\n", result.ArchiveID, result.Error)
+			continue
+		}
+		fmt.Printf("✅ #%d: 写入 %d 条支付，重复 %d 条，跳过 %d 条交易\n", result.ArchiveID, result.Inserted, result.Duplicates, result.Stats.Skipped())
+	}
+	fmt.Printf("📦 共重放 %d 条推送，失败 %d 条\n", len(results), failed)
+}
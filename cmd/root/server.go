@@ -14,17 +14,28 @@ import (
 
 	"lending-trx/internal/cronjob"
 	"lending-trx/internal/db"
+	"lending-trx/internal/scanner"
+	"lending-trx/internal/tron"
 	"lending-trx/internal/webhook"
 )
 
+// 支付数据来源
+const (
+	ingestWebhook = "webhook" // 第三方 stream 推送到 POST /webhook
+	ingestScanner = "scanner" // 内置区块扫描
+	ingestBoth    = "both"    // 同时启用，tx_hash 重复的记录只保留一条
+)
+
 var (
-	serverPort string
-	serverCmd  = &cobra.Command{
+	serverPort   string
+	serverIngest string
+	serverCmd    = &cobra.Command{
 		Use:   "server",
 		Short: "启动完整的TRX委托服务 (HTTP API + 定时任务)",
 		Long: `启动完整的TRX委托服务，包括：
 - HTTP API服务：提供委托账户查询接口
 - 定时任务：自动处理webhook数据和能量委托
+- 支付数据来源：stream webhook 或内置区块扫描（--ingest）
 - 数据库连接：PostgreSQL数据库操作`,
 		Run: runServer,
 	}
@@ -32,6 +43,7 @@ var (
 
 func init() {
 	serverCmd.Flags().StringVarP(&serverPort, "port", "p", "8080", "HTTP服务端口")
+	serverCmd.Flags().StringVar(&serverIngest, "ingest", ingestWebhook, "支付数据来源: webhook / scanner / both")
 }
 
 func runServer(cmd *cobra.Command, args []string) {
@@ -42,6 +54,34 @@ func runServer(cmd *cobra.Command, args []string) {
 
 	fmt.Println("🚀 启动TRX委托服务...")
 
+	// 命令行未指定时使用环境变量 INGEST_MODE
+	ingest := serverIngest
+	if envIngest := os.Getenv("INGEST_MODE"); envIngest != "" && !cmd.Flags().Changed("ingest") {
+		ingest = envIngest
+	}
+	if ingest != ingestWebhook && ingest != ingestScanner && ingest != ingestBoth {
+		log.Fatalf("❌ 无效的支付数据来源 %q，可选 webhook / scanner / both", ingest)
+	}
+
+	// 接收 stream 推送时必须配置签名密钥和收款地址
+	var auth webhook.ProviderAuth
+	var receivers []string
+	if ingest != ingestScanner {
+		a, err := webhook.ProviderAuthFromEnv()
+		if err != nil {
+			log.Fatal("❌ Webhook 签名配置错误:", err)
+		}
+		auth = a
+		if receivers, err = webhook.ReceiversFromEnv(); err != nil {
+			log.Fatal("❌ 收款地址配置错误:", err)
+		}
+	}
+
+	// 远程签名服务不签署质押交易，启用质押管理时必须使用本地私钥或 keystore
+	if err := cronjob.CheckStakingConfig(); err != nil {
+		log.Fatal("❌ 质押管理配置错误:", err)
+	}
+
 	ctx := context.Background()
 	pool, err := db.InitDB(ctx)
 	if err != nil {
@@ -55,12 +95,41 @@ func runServer(cmd *cobra.Command, args []string) {
 		BuildFormatter(xlog.FORMAT_JSON).
 		BuildFile("logs/lending-trx.log", 24*time.Hour)
 
+	// 创建共享的 Tron 客户端，并启动节点健康检查
+	tronClient := tron.NewTronClientFromEnv()
+	tronClient.StartHealthCheck(ctx, tronHealthCheckInterval())
+
 	// 启动定时任务
-	cronjob.StartCron(ctx, pool, LOG)
+	cronjob.StartCron(ctx, pool, LOG, tronClient)
+
+	// 补齐 stream 推送缺失的区块，定期清理过期的原始推送
+	if ingest != ingestScanner {
+		if err := webhook.StartArchiveCleanup(ctx, pool, LOG); err != nil {
+			log.Fatal("❌ 原始推送清理任务启动失败:", err)
+		}
+		if err := scanner.StartBackfill(ctx, pool, LOG, tronClient, receivers); err != nil {
+			log.Fatal("❌ Stream 补齐任务启动失败:", err)
+		}
+	}
+
+	// 启动内置区块扫描
+	if ingest != ingestWebhook {
+		if err := scanner.Start(ctx, pool, LOG, tronClient); err != nil {
+			log.Fatal("❌ 区块扫描启动失败:", err)
+		}
+	}
 
-	// 启动 gin HTTP 服务
+	// 启动 gin HTTP 服务，stream 推送保存后由后台处理队列解析写入
 	r := gin.Default()
-	webhook.RegisterRoutes(r, ctx, pool, LOG)
+	if ingest != ingestScanner {
+		queue, err := webhook.StartIngestQueue(ctx, pool, LOG, receivers)
+		if err != nil {
+			log.Fatal("❌ Webhook 处理队列启动失败:", err)
+		}
+		webhook.RegisterWebhookRoutes(r, ctx, pool, LOG, auth, queue)
+		webhook.RegisterAdminRoutes(r, ctx, pool, LOG, receivers, webhook.AdminTokenFromEnv())
+	}
+	webhook.RegisterAPIRoutes(r, ctx, LOG, tronClient)
 
 	// 使用命令行参数或环境变量
 	port := serverPort
@@ -71,7 +140,19 @@ func runServer(cmd *cobra.Command, args []string) {
 	fmt.Printf("✅ TRX委托服务已启动，监听端口: %s\n", port)
 	fmt.Printf("📡 API地址: http://localhost:%s\n", port)
 	fmt.Printf("📊 委托账户查询: http://localhost:%s/api/delegation-account\n", port)
+	fmt.Printf("🛰️ 节点状态查询: http://localhost:%s/api/tron-nodes\n", port)
+	fmt.Printf("📥 支付数据来源: %s\n", ingest)
 	fmt.Printf("📝 日志文件: logs/lending-trx.log\n")
 
 	r.Run(":" + port)
 }
+
+// tronHealthCheckInterval 节点健康检查间隔，默认30秒
+func tronHealthCheckInterval() time.Duration {
+	if value := os.Getenv("TRON_HEALTH_CHECK_INTERVAL"); value != "" {
+		if interval, err := time.ParseDuration(value); err == nil && interval > 0 {
+			return interval
+		}
+	}
+	return 30 * time.Second
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/spf13/cobra"
+	"github.com/sunjiangjun/xlog"
+
+	"lending-trx/internal/signer"
+	"lending-trx/internal/tron"
+	"lending-trx/internal/units"
+)
+
+var (
+	signerListen string
+	keystoreOut  string
+	signerCmd    = &cobra.Command{
+		Use:   "signer",
+		Short: "启动交易签名服务",
+		Long: `启动本机交易签名服务，委托账户私钥只保存在该进程中：
+- 通过 HTTP 或 Unix socket 提供签名接口（SIGNER_LISTEN）
+- 只签名 DelegateResource / UnDelegateResource 交易
+- 只允许白名单中的委托方（SIGNER_ALLOWED_OWNERS），单笔金额不超过 SIGNER_MAX_AMOUNT
+
+主服务通过 SIGNER_URL 使用该签名服务。`,
+		Run: runSigner,
+	}
+	keystoreCmd = &cobra.Command{
+		Use:   "keystore",
+		Short: "将私钥加密为 keystore 文件",
+		Long: `从标准输入读取十六进制私钥，使用 SIGNER_KEYSTORE_PASSWORD（或 SIGNER_KEYSTORE_PASSWORD_FILE）
+加密后写入 keystore 文件，例如：
+
+  lending-trx signer keystore --out delegation.json < key.txt`,
+		Run: runKeystore,
+	}
+)
+
+func init() {
+	signerCmd.Flags().StringVarP(&signerListen, "listen", "l", "", "监听地址，host:port 或 unix:///path/to/signer.sock")
+	keystoreCmd.Flags().StringVarP(&keystoreOut, "out", "o", "keystore.json", "keystore 输出文件")
+	signerCmd.AddCommand(keystoreCmd)
+}
+
+func runSigner(cmd *cobra.Command, args []string) {
+	if err := godotenv.Load(); err != nil {
+		log.Println("⚠️ 未找到.env文件，使用系统环境变量")
+	}
+
+	config, err := signer.ConfigFromEnv()
+	if err != nil {
+		log.Fatal("❌ 签名服务配置错误:", err)
+	}
+	if signerListen != "" {
+		config.Listen = signerListen
+	}
+	local, err := config.LoadSigner()
+	if err != nil {
+		log.Fatal("❌ 加载签名私钥失败:", err)
+	}
+	if len(config.AllowedOwners) == 0 {
+		config.AllowedOwners = []string{local.Address()}
+	}
+	if config.AuthToken == "" && !strings.HasPrefix(config.Listen, "unix://") {
+		log.Println("⚠️ SIGNER_AUTH_TOKEN 未设置，TCP 监听的签名接口不需要认证")
+	}
+
+	LOG := xlog.NewXLogger().
+		BuildOutType(xlog.FILE).
+		BuildLevel(xlog.InfoLevel).
+		BuildFormatter(xlog.FORMAT_JSON).
+		BuildFile("logs/lending-trx-signer.log", 24*time.Hour)
+
+	listener, err := signer.Listen(config.Listen)
+	if err != nil {
+		log.Fatal("❌ 签名服务监听失败:", err)
+	}
+
+	policy := signer.Policy{AllowedOwners: config.AllowedOwners, MaxAmount: config.MaxAmount}
+	server := signer.NewServer(local, policy, config.AuthToken, LOG)
+
+	fmt.Printf("✅ 签名服务已启动，监听地址: %s\n", config.Listen)
+	fmt.Printf("🔑 签名地址: %s\n", local.Address())
+	fmt.Printf("👥 允许的委托方: %s\n", strings.Join(config.AllowedOwners, ", "))
+	fmt.Printf("💰 单笔上限: %.6f TRX\n", float64(config.MaxAmount)/float64(units.SunPerTRX))
+	fmt.Printf("📝 日志文件: logs/lending-trx-signer.log\n")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := server.Serve(ctx, listener); err != nil {
+		log.Fatal("❌ 签名服务异常退出:", err)
+	}
+}
+
+func runKeystore(cmd *cobra.Command, args []string) {
+	if err := godotenv.Load(); err != nil {
+		log.Println("⚠️ 未找到.env文件，使用系统环境变量")
+	}
+
+	password, err := tron.KeystorePasswordFromEnv("SIGNER_KEYSTORE_PASSWORD")
+	if err != nil {
+		log.Fatal("❌ 读取 keystore 密码失败:", err)
+	}
+	if password == "" {
+		log.Fatal("❌ SIGNER_KEYSTORE_PASSWORD 或 SIGNER_KEYSTORE_PASSWORD_FILE 未设置")
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		log.Fatal("❌ 从标准输入读取私钥失败:", err)
+	}
+	key, err := tron.ParsePrivateKey(strings.TrimSpace(line))
+	if err != nil {
+		log.Fatal("❌ 私钥格式错误:", err)
+	}
+
+	data, err := tron.EncryptKeystore(key, password, tron.KeystoreScryptN)
+	if err != nil {
+		log.Fatal("❌ 加密私钥失败:", err)
+	}
+	if err := os.WriteFile(keystoreOut, data, 0o600); err != nil {
+		log.Fatal("❌ 写入 keystore 失败:", err)
+	}
+	fmt.Printf("✅ keystore 已写入 %s，地址: %s\n", keystoreOut, tron.NewLocalSigner(key).Address())
+}
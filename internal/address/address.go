@@ -0,0 +1,220 @@
+package address
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// TRON 地址由 1 字节前缀 0x41 + 20 字节账户哈希组成，常见三种表示形式：
+//   - Base58Check: T 开头，34 个字符（节点 visible=true、钱包展示）
+//   - 41 前缀十六进制: 42 个十六进制字符（节点 visible=false）
+//   - 0x EVM 风格十六进制: 20 字节，不含 0x41 前缀（流式 webhook / JSON-RPC）
+// 系统内部统一使用 Base58Check 作为规范形式存储和调用 TRON API。
+
+const (
+	// Prefix TRON 主网地址前缀
+	Prefix byte = 0x41
+	// Length 带前缀的地址字节长度
+	Length = 21
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var (
+	ErrEmptyAddress    = errors.New("empty address")
+	ErrInvalidFormat   = errors.New("invalid address format")
+	ErrInvalidChecksum = errors.New("invalid address checksum")
+	ErrInvalidPrefix   = errors.New("invalid address prefix")
+)
+
+// Address TRON 地址（含 0x41 前缀的 21 字节）
+type Address [Length]byte
+
+// Parse 解析任意一种表示形式的 TRON 地址
+func Parse(s string) (Address, error) {
+	var addr Address
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return addr, ErrEmptyAddress
+	}
+
+	switch {
+	case strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X"):
+		raw, err := hex.DecodeString(s[2:])
+		if err != nil {
+			return addr, fmt.Errorf("%w: %s", ErrInvalidFormat, s)
+		}
+		switch len(raw) {
+		case Length - 1:
+			addr[0] = Prefix
+			copy(addr[1:], raw)
+			return addr, nil
+		case Length:
+			return fromBytes(raw)
+		}
+		return addr, fmt.Errorf("%w: %s", ErrInvalidFormat, s)
+	case len(s) == Length*2:
+		raw, err := hex.DecodeString(s)
+		if err != nil {
+			return addr, fmt.Errorf("%w: %s", ErrInvalidFormat, s)
+		}
+		return fromBytes(raw)
+	default:
+		return decodeBase58Check(s)
+	}
+}
+
+// MustParse 解析地址，失败时 panic，仅用于常量初始化
+func MustParse(s string) Address {
+	addr, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return addr
+}
+
+// IsValid 判断字符串是否为合法的 TRON 地址（任意表示形式）
+func IsValid(s string) bool {
+	_, err := Parse(s)
+	return err == nil
+}
+
+// Normalize 将任意表示形式的地址转换为规范的 Base58Check 形式
+func Normalize(s string) (string, error) {
+	addr, err := Parse(s)
+	if err != nil {
+		return "", err
+	}
+	return addr.Base58(), nil
+}
+
+// FromPublicKey 由未压缩公钥（65 字节，0x04 开头）推导 TRON 地址
+func FromPublicKey(pub []byte) (Address, error) {
+	var addr Address
+	if len(pub) != 65 || pub[0] != 0x04 {
+		return addr, fmt.Errorf("invalid uncompressed public key length: %d", len(pub))
+	}
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(pub[1:])
+	sum := hash.Sum(nil)
+
+	addr[0] = Prefix
+	copy(addr[1:], sum[12:])
+	return addr, nil
+}
+
+// Base58 返回 Base58Check 表示（T 开头）
+func (a Address) Base58() string {
+	return encodeBase58(append(a[:], checksum(a[:])...))
+}
+
+// Hex 返回 41 前缀的十六进制表示
+func (a Address) Hex() string {
+	return hex.EncodeToString(a[:])
+}
+
+// EVMHex 返回 0x 开头的 20 字节十六进制表示
+func (a Address) EVMHex() string {
+	return "0x" + hex.EncodeToString(a[1:])
+}
+
+// Bytes 返回含前缀的 21 字节
+func (a Address) Bytes() []byte {
+	return append([]byte(nil), a[:]...)
+}
+
+// String 默认使用 Base58Check 表示
+func (a Address) String() string {
+	return a.Base58()
+}
+
+// fromBytes 校验前缀并构造地址
+func fromBytes(raw []byte) (Address, error) {
+	var addr Address
+	if len(raw) != Length {
+		return addr, ErrInvalidFormat
+	}
+	if raw[0] != Prefix {
+		return addr, ErrInvalidPrefix
+	}
+	copy(addr[:], raw)
+	return addr, nil
+}
+
+// decodeBase58Check 解码 Base58Check 地址并校验校验和
+func decodeBase58Check(s string) (Address, error) {
+	var addr Address
+	raw, err := decodeBase58(s)
+	if err != nil {
+		return addr, err
+	}
+	if len(raw) != Length+4 {
+		return addr, fmt.Errorf("%w: %s", ErrInvalidFormat, s)
+	}
+	payload, sum := raw[:Length], raw[Length:]
+	if !bytes.Equal(checksum(payload), sum) {
+		return addr, fmt.Errorf("%w: %s", ErrInvalidChecksum, s)
+	}
+	return fromBytes(payload)
+}
+
+// checksum 双重 sha256 的前 4 字节
+func checksum(payload []byte) []byte {
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	return second[:4]
+}
+
+// encodeBase58 Base58 编码
+func encodeBase58(input []byte) string {
+	x := new(big.Int).SetBytes(input)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+
+	var out []byte
+	for x.Sign() > 0 {
+		x.DivMod(x, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, b := range input {
+		if b != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+// decodeBase58 Base58 解码
+func decodeBase58(s string) ([]byte, error) {
+	x := new(big.Int)
+	radix := big.NewInt(58)
+	for _, r := range s {
+		idx := strings.IndexRune(base58Alphabet, r)
+		if idx < 0 {
+			return nil, fmt.Errorf("%w: invalid base58 character %q", ErrInvalidFormat, r)
+		}
+		x.Mul(x, radix)
+		x.Add(x, big.NewInt(int64(idx)))
+	}
+
+	decoded := x.Bytes()
+	leadingZeros := 0
+	for _, r := range s {
+		if r != rune(base58Alphabet[0]) {
+			break
+		}
+		leadingZeros++
+	}
+	return append(make([]byte, leadingZeros), decoded...), nil
+}
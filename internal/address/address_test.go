@@ -0,0 +1,85 @@
+package address
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseAllForms(t *testing.T) {
+	tests := []struct {
+		input  string
+		base58 string
+		hex    string
+		evm    string
+	}{
+		{
+			input:  "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t",
+			base58: "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t",
+			hex:    "41a614f803b6fd780986a42c78ec9c7f77e6ded13c",
+			evm:    "0xa614f803b6fd780986a42c78ec9c7f77e6ded13c",
+		},
+		{
+			input:  "418840E6C55B9ADA326D211D818C34A994AECED808",
+			base58: "TNPeeaaFB7K9cmo4uQpcU32zGK8G1NYqeL",
+			hex:    "418840e6c55b9ada326d211d818c34a994aeced808",
+			evm:    "0x8840e6c55b9ada326d211d818c34a994aeced808",
+		},
+		{
+			input:  "0xa614f803b6fd780986a42c78ec9c7f77e6ded13c",
+			base58: "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t",
+			hex:    "41a614f803b6fd780986a42c78ec9c7f77e6ded13c",
+			evm:    "0xa614f803b6fd780986a42c78ec9c7f77e6ded13c",
+		},
+	}
+
+	for _, tc := range tests {
+		addr, err := Parse(tc.input)
+		if err != nil {
+			t.Errorf("Parse(%s) failed: %v", tc.input, err)
+			continue
+		}
+		if addr.Base58() != tc.base58 {
+			t.Errorf("Parse(%s).Base58() = %s, expected %s", tc.input, addr.Base58(), tc.base58)
+		}
+		if addr.Hex() != tc.hex {
+			t.Errorf("Parse(%s).Hex() = %s, expected %s", tc.input, addr.Hex(), tc.hex)
+		}
+		if addr.EVMHex() != tc.evm {
+			t.Errorf("Parse(%s).EVMHex() = %s, expected %s", tc.input, addr.EVMHex(), tc.evm)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		input string
+		err   error
+	}{
+		{"", ErrEmptyAddress},
+		{"TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6u", ErrInvalidChecksum},
+		{"TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj0t", ErrInvalidFormat},
+		{"0x1234", ErrInvalidFormat},
+		{"428840e6c55b9ada326d211d818c34a994aeced808", ErrInvalidPrefix},
+	}
+
+	for _, tc := range tests {
+		_, err := Parse(tc.input)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("Parse(%q) error = %v, expected %v", tc.input, err, tc.err)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	got, err := Normalize("0xb8a57ef5343f88712a4eee91e34290584c2d5998")
+	if err != nil {
+		t.Fatalf("Normalize failed: %v", err)
+	}
+	roundTrip, err := Parse(got)
+	if err != nil {
+		t.Fatalf("Parse(%s) failed: %v", got, err)
+	}
+	if roundTrip.EVMHex() != "0xb8a57ef5343f88712a4eee91e34290584c2d5998" {
+		t.Errorf("round trip = %s", roundTrip.EVMHex())
+	}
+}
@@ -0,0 +1,187 @@
+package cronjob
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"lending-trx/internal/db"
+	"lending-trx/internal/tron"
+)
+
+// defaultConfirmationTimeout 没有记录交易过期时间的历史订单，广播后按该时长代替过期时间
+const defaultConfirmationTimeout = 5 * time.Minute
+
+// processConfirmations 跟踪已广播的委托/回收交易，固化后才推进订单状态
+func (c *CronJob) processConfirmations() {
+	delegating, err := db.QueryWebhookDataByStatus(c.ctx, c.pool, db.StatusProcessing)
+	if err != nil {
+		c.log.Error("Failed to query processing data", err)
+	} else {
+		for _, item := range delegating {
+			c.confirmDelegation(item)
+		}
+	}
+
+	reclaiming, err := db.QueryWebhookDataByStatus(c.ctx, c.pool, db.StatusReclaiming)
+	if err != nil {
+		c.log.Error("Failed to query reclaiming data", err)
+	} else {
+		for _, item := range reclaiming {
+			c.confirmReclaim(item)
+		}
+	}
+}
+
+// confirmDelegation 检查委托交易确认状态
+func (c *CronJob) confirmDelegation(item *db.WebhookDataModel) {
+	if item.OriginalTxID == "" {
+		c.log.Error("Processing item has no delegation transaction ID, manual check required", "id", item.ID)
+		return
+	}
+
+	confirmation, err := c.tronClient.CheckConfirmation(c.ctx, item.OriginalTxID)
+	if err != nil {
+		c.log.Error("Failed to check delegation confirmation", err, "id", item.ID, "tx_id", item.OriginalTxID)
+		return
+	}
+
+	switch confirmation.State {
+	case tron.ConfirmationConfirmed, tron.ConfirmationFailed:
+		status := db.StatusAuthorized
+		if confirmation.State == tron.ConfirmationFailed {
+			status = db.StatusDelegateFailed
+		}
+		// 锁定期从委托交易所在区块开始计算
+		blockTime := confirmation.BlockTimeStamp
+		if blockTime == 0 {
+			blockTime = time.Now().UnixMilli()
+		}
+		expire := lockExpireTime(blockTime, item.LockPeriod)
+		if status != db.StatusAuthorized {
+			expire = 0
+		}
+		if err := db.ConfirmDelegationByID(c.ctx, c.pool, item.ID, status, confirmation.BlockNumber, txCost(confirmation), confirmation.Result, expire); err != nil {
+			c.log.Error("Failed to save delegation confirmation", err, "id", item.ID)
+			return
+		}
+		c.log.Info("Delegation transaction confirmed",
+			"id", item.ID,
+			"tx_id", item.OriginalTxID,
+			"state", confirmation.State.String(),
+			"block_number", confirmation.BlockNumber,
+			"fee", confirmation.Fee,
+			"net_usage", confirmation.Receipt.NetUsage,
+			"net_fee", confirmation.Receipt.NetFee,
+			"energy_fee", confirmation.Receipt.EnergyFee,
+			"result", confirmation.Result,
+			"lock_expire_time", expire,
+			"status", status,
+		)
+	case tron.ConfirmationNotFound:
+		dropped, err := c.transactionDropped(item.OriginalTxID, item.DelegateTxExpiration, item.UpdatedAt)
+		if err != nil {
+			c.log.Error("Failed to check dropped delegation", err, "id", item.ID, "tx_id", item.OriginalTxID)
+			return
+		}
+		if !dropped {
+			return
+		}
+		// 交易已过期且未上链，重新进入待处理，下次定时任务重新委托
+		if err := db.ResetDelegationByID(c.ctx, c.pool, item.ID); err != nil {
+			c.log.Error("Failed to reset dropped delegation", err, "id", item.ID)
+			return
+		}
+		c.log.Warn("Delegation transaction dropped, reset to pending", "id", item.ID, "tx_id", item.OriginalTxID)
+	}
+}
+
+// confirmReclaim 检查回收交易确认状态
+func (c *CronJob) confirmReclaim(item *db.WebhookDataModel) {
+	if item.ReclaimTxID == "" {
+		c.log.Error("Reclaiming item has no reclaim transaction ID, manual check required", "id", item.ID)
+		return
+	}
+
+	confirmation, err := c.tronClient.CheckConfirmation(c.ctx, item.ReclaimTxID)
+	if err != nil {
+		c.log.Error("Failed to check reclaim confirmation", err, "id", item.ID, "tx_id", item.ReclaimTxID)
+		return
+	}
+
+	switch confirmation.State {
+	case tron.ConfirmationConfirmed, tron.ConfirmationFailed:
+		status := db.StatusReclaimed
+		if confirmation.State == tron.ConfirmationFailed {
+			status = db.StatusReclaimFailed
+		}
+		if err := db.ConfirmReclaimByID(c.ctx, c.pool, item.ID, status, confirmation.BlockNumber, txCost(confirmation), confirmation.Result); err != nil {
+			c.log.Error("Failed to save reclaim confirmation", err, "id", item.ID)
+			return
+		}
+		c.log.Info("Reclaim transaction confirmed",
+			"id", item.ID,
+			"tx_id", item.ReclaimTxID,
+			"state", confirmation.State.String(),
+			"block_number", confirmation.BlockNumber,
+			"fee", confirmation.Fee,
+			"net_usage", confirmation.Receipt.NetUsage,
+			"net_fee", confirmation.Receipt.NetFee,
+			"energy_fee", confirmation.Receipt.EnergyFee,
+			"result", confirmation.Result,
+			"status", status,
+		)
+	case tron.ConfirmationNotFound:
+		dropped, err := c.transactionDropped(item.ReclaimTxID, item.ReclaimTxExpiration, item.UpdatedAt)
+		if err != nil {
+			c.log.Error("Failed to check dropped reclaim", err, "id", item.ID, "tx_id", item.ReclaimTxID)
+			return
+		}
+		if !dropped {
+			return
+		}
+		// 回收交易已过期且未上链，回到已授权状态，下次定时任务重新回收
+		if err := db.ResetReclaimByID(c.ctx, c.pool, item.ID); err != nil {
+			c.log.Error("Failed to reset dropped reclaim", err, "id", item.ID)
+			return
+		}
+		c.log.Warn("Reclaim transaction dropped, reset to authorized", "id", item.ID, "tx_id", item.ReclaimTxID)
+	}
+}
+
+// transactionDropped 判断查询不到的交易是否已被丢弃，丢弃后才能重新签名
+// 固化区块时间超过交易过期时间（毫秒时间戳）后交易不可能再上链，并且需要多个节点都查询不到该交易（见 tron.Client.ConfirmMissing），
+// 单个节点落后或切换节点时查询不到不会导致重复委托；历史订单没有过期时间时以状态更新时间加 CONFIRMATION_TIMEOUT 代替
+func (c *CronJob) transactionDropped(txID string, expiration int64, updatedAt time.Time) (bool, error) {
+	if expiration == 0 {
+		expiration = updatedAt.Add(confirmationTimeout()).UnixMilli()
+	}
+	solidified, err := c.tronClient.GetSolidifiedNowBlock(c.ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get solidified block: %w", err)
+	}
+	if solidified.Timestamp() <= expiration {
+		return false, nil
+	}
+	return c.tronClient.ConfirmMissing(c.ctx, txID)
+}
+
+// confirmationTimeout 从环境变量获取交易确认超时时间
+func confirmationTimeout() time.Duration {
+	if value := os.Getenv("CONFIRMATION_TIMEOUT"); value != "" {
+		if timeout, err := time.ParseDuration(value); err == nil {
+			return timeout
+		}
+	}
+	return defaultConfirmationTimeout
+}
+
+// txCost 回执中的资源消耗，按订单记录用于统计毛利
+func txCost(confirmation *tron.Confirmation) db.TxCost {
+	return db.TxCost{
+		Fee:       confirmation.Fee,
+		NetUsage:  confirmation.Receipt.NetUsage,
+		NetFee:    confirmation.Receipt.NetFee,
+		EnergyFee: confirmation.Receipt.EnergyFee,
+	}
+}
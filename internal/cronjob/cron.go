@@ -2,48 +2,63 @@ package cronjob
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"lending-trx/internal/address"
 	"lending-trx/internal/db"
 	"lending-trx/internal/tron"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/robfig/cron/v3"
 	"github.com/sunjiangjun/xlog"
 )
 
+// errDelegationSkipped 订单不满足委托条件，无需委托
+var errDelegationSkipped = errors.New("delegation skipped")
+
 // CronJob 定时任务结构体
 type CronJob struct {
 	ctx        context.Context
 	pool       *pgxpool.Pool
 	log        *xlog.XLog
-	tronClient *tron.TronClient
+	tronClient tron.Client
+	pricer     *tron.EnergyPricer   // 能量与质押金额换算，缓存全网资源总量
+	staking    *tron.StakingManager // 委托账户质押管理，未启用时为 nil
 }
 
 // NewCronJob 创建新的定时任务实例
-func NewCronJob(ctx context.Context, pool *pgxpool.Pool, log *xlog.XLog) *CronJob {
-	// 从环境变量获取 Tron API 配置
-	baseURL := os.Getenv("TRON_API_URL")
-	if baseURL == "" {
-		baseURL = "https://api.trongrid.io"
-	}
-
-	apiKey := os.Getenv("TRON_API_KEY")
-
-	tronClient := tron.NewTronClient(baseURL, apiKey)
+// tronClient 由调用方创建并与 HTTP 服务共享，节点健康状态统一维护
+func NewCronJob(ctx context.Context, pool *pgxpool.Pool, log *xlog.XLog, tronClient tron.Client) *CronJob {
+	// 签名私钥和权限ID，用于本地签名 delegate/undelegate 交易，启动时检查权限
+	configureSigners(ctx, log, tronClient)
 
-	return &CronJob{
+	job := &CronJob{
 		ctx:        ctx,
 		pool:       pool,
 		log:        log,
 		tronClient: tronClient,
+		pricer:     tron.NewEnergyPricer(tronClient, energyPriceTTL()),
 	}
+
+	// 质押管理，质押闲置余额使能量库存随收入增长；签名者无法签署质押交易时不启用
+	if stakingEnabled() {
+		from, err := getDelegationFromAddress()
+		if err == nil {
+			job.staking, err = newStakingManager(ctx, tronClient, from)
+		}
+		if err != nil {
+			log.Error("Staking manager disabled", err)
+		}
+	}
+	return job
 }
 
 // StartCron 启动定时任务
-func StartCron(ctx context.Context, pool *pgxpool.Pool, log *xlog.XLog) {
-	job := NewCronJob(ctx, pool, log)
+func StartCron(ctx context.Context, pool *pgxpool.Pool, log *xlog.XLog, tronClient tron.Client) {
+	job := NewCronJob(ctx, pool, log, tronClient)
 	job.start()
 }
 
@@ -63,6 +78,14 @@ func (c *CronJob) start() {
 		return
 	}
 
+	if c.staking != nil {
+		if _, err := cronScheduler.AddFunc(stakingSchedule(), c.processStaking); err != nil {
+			c.log.Error("Failed to add staking cron job", err)
+		} else {
+			c.log.Info("Staking cron job started", "schedule", stakingSchedule(), "reserve", c.staking.Config().Reserve)
+		}
+	}
+
 	c.log.Info("Cron job started", "schedule", cronSchedule)
 	go cronScheduler.Run()
 }
@@ -87,6 +110,9 @@ func (c *CronJob) processWebhookData() {
 		c.processPendingData(pendingData)
 	}
 
+	// 跟踪已广播交易的链上确认 (status=1/4)
+	c.processConfirmations()
+
 	// 处理已过期且已授权的数据 (status=2)
 	expiredData, err := db.QueryExpiredWebhookData(c.ctx, c.pool)
 	if err != nil {
@@ -102,6 +128,14 @@ func (c *CronJob) processWebhookData() {
 func (c *CronJob) processPendingData(data []*db.WebhookDataModel) {
 	c.log.Info("Processing pending data", "count", len(data))
 
+	// 支付需达到确认深度且所在区块已固化才委托，查询失败时本周期全部跳过
+	heads, err := c.currentHeads()
+	if err != nil {
+		c.log.Error("Failed to query chain heads, pending data deferred", err)
+		return
+	}
+	depth := paymentConfirmations()
+
 	for _, item := range data {
 		c.log.Info("Processing pending item",
 			"id", item.ID,
@@ -111,18 +145,55 @@ func (c *CronJob) processPendingData(data []*db.WebhookDataModel) {
 			"value", item.Value,
 		)
 
-		// TODO: 执行能量委托
-		err := c.executeEnergyDelegation(item)
+		if !paymentConfirmed(item.BlockHeight, heads, depth) {
+			c.log.Info("Payment awaiting confirmations",
+				"id", item.ID,
+				"block_height", item.BlockHeight,
+				"latest_block", heads.latest,
+				"solidified_block", heads.solidified,
+				"confirmations", depth,
+			)
+			continue
+		}
+		canonical, err := c.paymentCanonical(item)
 		if err != nil {
-			c.log.Error("Failed to execute energy delegation", err, "id", item.ID)
+			c.log.Error("Failed to check payment block", err, "id", item.ID, "block_height", item.BlockHeight)
+			continue
+		}
+		if !canonical {
+			// 支付所在区块已被重组回滚，交易可能不存在或在其他区块，不委托，需人工核对
+			c.log.Warn("Payment block no longer on canonical chain, marked orphaned", "id", item.ID, "block_height", item.BlockHeight, "block_hash", item.BlockHash)
+			c.updateStatus(item.ID, db.StatusOrphaned)
+			continue
+		}
+		verified, err := c.tokenPaymentVerified(item)
+		if err != nil {
+			c.log.Error("Failed to verify token payment receipt", err, "id", item.ID, "tx_hash", item.TxHash)
+			continue
+		}
+		if !verified {
+			// TRC20 调用执行失败或实际未转入收款地址，不委托，需人工核对
+			c.log.Warn("Token payment receipt has no matching transfer, marked invalid", "id", item.ID, "tx_hash", item.TxHash, "token_contract", item.TokenContract, "token_amount", item.TokenAmount)
+			c.updateStatus(item.ID, db.StatusPaymentInvalid)
 			continue
 		}
 
-		// 更新状态为处理中 (status=2)
-		if err := db.UpdateWebhookStatusByID(c.ctx, c.pool, item.ID, 2); err != nil {
-			c.log.Error("Failed to update status", err, "id", item.ID)
-		} else {
-			c.log.Info("Status updated successfully", "id", item.ID, "status", 2)
+		// 执行能量委托，广播前状态置为执行中 (status=1)，由确认跟踪更新为已授权
+		err = c.executeEnergyDelegation(item)
+		if errors.Is(err, errDelegationSkipped) {
+			c.updateStatus(item.ID, db.StatusSkipped)
+			continue
+		}
+		if err != nil {
+			action := classifyDelegationError(err)
+			c.log.Error("Failed to execute energy delegation", err, "id", item.ID, "action", action.String())
+			switch action {
+			case actionFail:
+				c.updateStatus(item.ID, db.StatusDelegateFailed)
+			case actionRefund:
+				c.updateStatus(item.ID, db.StatusRefundRequired)
+			}
+			continue
 		}
 	}
 }
@@ -131,6 +202,13 @@ func (c *CronJob) processPendingData(data []*db.WebhookDataModel) {
 func (c *CronJob) processExpiredData(data []*db.WebhookDataModel) {
 	c.log.Info("Processing expired data", "count", len(data))
 
+	// 同一接收方已广播但未确认的回收金额，计算剩余委托时需要扣除
+	pending, err := c.pendingReclaims()
+	if err != nil {
+		c.log.Error("Failed to query pending reclaims", err)
+		return
+	}
+
 	for _, item := range data {
 		c.log.Info("Processing expired item",
 			"id", item.ID,
@@ -138,20 +216,34 @@ func (c *CronJob) processExpiredData(data []*db.WebhookDataModel) {
 			"expire_time", item.ExpireTime,
 		)
 
-		// TODO: 取消能量委托
-		err := c.cancelEnergyDelegation(item)
+		// 取消能量委托，广播前状态置为回收中 (status=4)，由确认跟踪更新为已回收
+		err := c.cancelEnergyDelegation(item, pending)
+		if errors.Is(err, errReclaimDeferred) {
+			c.log.Info("Reclaim deferred until pending reclaims settle", "id", item.ID, "receiver", item.Receiver())
+			continue
+		}
+		if errors.Is(err, errReclaimLocked) {
+			c.log.Info("Reclaim deferred until delegation lock expires", "id", item.ID, "receiver", item.Receiver(), "lock_expire_time", item.LockExpireTime)
+			continue
+		}
 		if err != nil {
-			c.log.Error("Failed to cancel energy delegation", err, "id", item.ID)
+			action := classifyReclaimError(err)
+			c.log.Error("Failed to cancel energy delegation", err, "id", item.ID, "action", action.String())
+			if action == actionFail {
+				c.updateStatus(item.ID, db.StatusReclaimFailed)
+			}
 			continue
 		}
+	}
+}
 
-		// 更新状态为已回收 (status=3)
-		if err := db.UpdateWebhookStatusByID(c.ctx, c.pool, item.ID, 3); err != nil {
-			c.log.Error("Failed to update status", err, "id", item.ID)
-		} else {
-			c.log.Info("Status updated successfully", "id", item.ID, "status", 3)
-		}
+// updateStatus 更新单条记录状态并记录日志
+func (c *CronJob) updateStatus(id int64, status int16) {
+	if err := db.UpdateWebhookStatusByID(c.ctx, c.pool, id, status); err != nil {
+		c.log.Error("Failed to update status", err, "id", id)
+		return
 	}
+	c.log.Info("Status updated successfully", "id", id, "status", status)
 }
 
 // TODO: 实现具体的业务逻辑函数
@@ -161,102 +253,164 @@ func (c *CronJob) executeEnergyDelegation(data *db.WebhookDataModel) error {
 		"id", data.ID,
 		"from", data.FromAddress,
 		"to", data.ToAddress,
+		"beneficiary", data.Beneficiary,
 		"value", data.Value,
+		"token_contract", data.TokenContract,
+		"token_amount", data.TokenAmount,
 		"tx_hash", data.TxHash,
 	)
 
-	// 金额<1trx
-
-	valueInt, err := strconv.ParseInt(data.Value, 10, 64)
+	// 支付币种（TRX 或已配置的 TRC20 代币）和整币金额
+	tokens, err := trc20Tokens()
+	if err != nil {
+		return fmt.Errorf("invalid token configuration: %w", err)
+	}
+	currency, whole, err := paymentOf(data, tokens)
 	if err != nil {
-		c.log.Error("Failed to parse transaction amount", err, "value", data.Value)
+		c.log.Error("Failed to parse transaction amount", err, "value", data.Value, "token_amount", data.TokenAmount)
 		return fmt.Errorf("failed to parse transaction amount: %w", err)
 	}
-
-	if valueInt < 1000000 { // 小于 1 TRX (1,000,000 SUN)
-		c.log.Info("Transaction amount is less than 1 TRX, skipping energy delegation", "id", data.ID, "value", data.Value)
-		return nil
+	if currency == "" {
+		c.log.Info("Payment token is not accepted, skipping delegation", "id", data.ID, "token_contract", data.TokenContract)
+		return errDelegationSkipped
+	}
+	if whole < 1 {
+		c.log.Info("Transaction amount is less than 1 unit, skipping delegation", "id", data.ID, "currency", currency, "value", data.Value, "token_amount", data.TokenAmount)
+		return errDelegationSkipped
 	}
 
 	// 从环境变量获取统一的委托方地址
-	delegationFromAddress := os.Getenv("DELEGATION_FROM_ADDRESS")
-	if delegationFromAddress == "" {
-		return fmt.Errorf("environment variable DELEGATION_FROM_ADDRESS not set")
+	delegationFromAddress, err := getDelegationFromAddress()
+	if err != nil {
+		return err
+	}
+
+	// 接收方为备注中的受益地址或付款方，统一为 Base58Check（兼容历史上以 0x 形式存储的记录）
+	receiverAddress, err := address.Normalize(data.Receiver())
+	if err != nil {
+		return fmt.Errorf("invalid receiver address %q: %w", data.Receiver(), err)
 	}
 
 	c.log.Info("Using unified delegation address",
 		"original_from", data.FromAddress,
+		"receiver", receiverAddress,
 		"delegation_from", delegationFromAddress,
 	)
 
-	// 1. 验证委托方账户信息
-	accountInfo, err := c.tronClient.GetAccountInfo(c.ctx, delegationFromAddress)
+	// 1. 支付金额对应的资源类型（能量或带宽）和数量
+	energyTiers, bandwidthTiers, err := paymentTiers(currency)
 	if err != nil {
-		return fmt.Errorf("failed to get delegation account info: %w", err)
+		return fmt.Errorf("invalid pricing tiers: %w", err)
+	}
+	resource, resourceAmount := resourceForPayment(whole, energyTiers, bandwidthTiers)
+	if resourceAmount == 0 {
+		c.log.Info("Transaction amount does not match any pricing tier, skipping delegation", "id", data.ID, "currency", currency, "units", whole)
+		return errDelegationSkipped
 	}
 
-	c.log.Info("Delegation account info",
-		"address", accountInfo.Address,
-		"balance", accountInfo.Balance,
-		"energy", accountInfo.Energy,
-		"energy_limit", accountInfo.EnergyLimit,
-		"energy_used", accountInfo.EnergyUsed,
-	)
-
-	// 2. 计算可委托的能量数量
-	// 这里可以根据业务逻辑计算委托数量
-	// 例如：根据交易金额的百分比，或者固定数量
-	delegationAmount := c.calculateDelegationAmount(data.Value, accountInfo.Energy)
+	// 2. 按当前全网质押比例将资源数量换算为需要委托的质押金额
+	price, err := c.pricer.Price(c.ctx, delegationFromAddress)
+	if err != nil {
+		return fmt.Errorf("failed to get resource price: %w", err)
+	}
+	balance, err := delegationBalance(resource, resourceAmount, price, minDelegationBalance())
+	if err != nil {
+		return fmt.Errorf("failed to convert %s to delegation balance: %w", resource, err)
+	}
+	delegationAmount := strconv.FormatInt(balance, 10)
 
-	if delegationAmount == "0" {
-		return fmt.Errorf("no energy available for delegation")
+	// 3. 检查委托账户该资源可委托的质押余额，不足时等待补足后重试，避免少给资源
+	available, err := c.tronClient.GetCanDelegatedMaxSize(c.ctx, delegationFromAddress, resource)
+	if err != nil {
+		return fmt.Errorf("failed to get delegatable balance: %w", err)
 	}
 
 	c.log.Info("Calculated delegation amount",
 		"original_value", data.Value,
-		"available_energy", accountInfo.Energy,
+		"currency", currency,
+		"units", whole,
+		"resource", resource,
+		"resource_amount", resourceAmount,
 		"delegation_amount", delegationAmount,
+		"available_balance", available,
+		"total_energy_limit", price.TotalEnergyLimit,
+		"total_energy_weight", price.TotalEnergyWeight,
+		"total_net_limit", price.TotalNetLimit,
+		"total_net_weight", price.TotalNetWeight,
+		"burn_cost", price.BurnCostFor(resource, resourceAmount),
 	)
 
-	// 3. 构建委托请求
+	if balance > available {
+		return fmt.Errorf("need %d SUN, only %d SUN delegatable: %w", balance, available, tron.ErrInsufficientFrozenBalance)
+	}
+
+	// 4. 启用锁定时按剩余租期计算锁定期，锁定期内委托无法被提前回收
+	var lockPeriod int64
+	if delegationLockEnabled() {
+		resources, err := c.tronClient.GetDelegatedResourceV2(c.ctx, delegationFromAddress, receiverAddress)
+		if err != nil {
+			return fmt.Errorf("failed to get delegated resource: %w", err)
+		}
+		lockPeriod = lockPeriodFor(data.ExpireTime, resources, resource, time.Now())
+		c.log.Info("Calculated delegation lock period", "id", data.ID, "expire_time", data.ExpireTime, "lock_period", lockPeriod)
+	}
+
+	// 5. 构建委托请求
 	delegationReq := &tron.EnergyDelegationRequest{
 		FromAddress: delegationFromAddress, // 使用统一的委托方地址
-		ToAddress:   data.FromAddress,      // 委托给交易发起方
+		ToAddress:   receiverAddress,       // 委托给受益地址或交易发起方
 		Amount:      delegationAmount,
+		Resource:    resource,
+		Lock:        lockPeriod > 0,
+		LockPeriod:  lockPeriod,
 		TxHash:      data.TxHash,
 		BlockHeight: data.BlockHeight,
 	}
 
-	// 4. 执行能量委托
-	delegationResp, err := c.tronClient.DelegateEnergy(c.ctx, delegationReq)
+	// 6. 构建并签名委托交易，此时尚未广播，失败可以安全重试
+	signedTx, err := c.tronClient.BuildDelegation(c.ctx, delegationReq)
 	if err != nil {
 		return fmt.Errorf("energy delegation API call failed: %w", err)
 	}
+	expiration, err := signedTx.Expiration()
+	if err != nil {
+		return fmt.Errorf("failed to decode delegation transaction: %w", err)
+	}
+
+	// 7. 广播前保存委托交易ID、过期时间、资源类型、委托金额和锁定期，状态置为执行中
+	// 保存失败时不广播；保存成功后无论广播结果如何都不再重新签名，由确认跟踪按交易ID判断是否上链
+	if err := db.UpdateDelegationByID(c.ctx, c.pool, data.ID, signedTx.TxID, expiration, resource, delegationAmount, lockPeriod); err != nil {
+		return fmt.Errorf("failed to save delegation transaction %s: %w", signedTx.TxID, err)
+	}
+	c.log.Info("Original delegation transaction ID saved", "id", data.ID, "original_tx_id", signedTx.TxID, "expiration", expiration)
+
+	// 8. 广播委托交易，失败时节点可能已收到交易，交易过期前不能判定为未上链
+	if _, err := c.tronClient.BroadcastTransaction(c.ctx, signedTx); err != nil {
+		c.log.Warn("Energy delegation broadcast failed, waiting for confirmation tracking",
+			"id", data.ID,
+			"tx_id", signedTx.TxID,
+			"expiration", expiration,
+			"reason", err.Error(),
+		)
+		return nil
+	}
 
-	c.log.Info("Energy delegation successful",
-		"tx_id", delegationResp.TxID,
-		"message", delegationResp.Message,
+	c.log.Info("Energy delegation broadcast",
+		"tx_id", signedTx.TxID,
 		"from", delegationFromAddress,
-		"to", data.ToAddress,
+		"to", receiverAddress,
+		"resource", resource,
 		"amount", delegationAmount,
+		"lock_period", lockPeriod,
 	)
 
-	// 5. 保存原始委托交易ID到数据库
-	if delegationResp.TxID != "" {
-		err = db.UpdateOriginalTxIDByID(c.ctx, c.pool, data.ID, delegationResp.TxID)
-		if err != nil {
-			c.log.Error("Failed to save original delegation transaction ID", err, "id", data.ID, "tx_id", delegationResp.TxID)
-			// 不返回错误，因为委托已经成功
-		} else {
-			c.log.Info("Original delegation transaction ID saved", "id", data.ID, "original_tx_id", delegationResp.TxID)
-		}
-	}
-
 	return nil
 }
 
-// cancelEnergyDelegation 取消能量委托
-func (c *CronJob) cancelEnergyDelegation(data *db.WebhookDataModel) error {
+// cancelEnergyDelegation 取消订单的资源委托（能量或带宽）
+// 链上委托按 (owner, receiver, resource) 聚合，只回收该订单对应的金额，同一接收方的其他有效订单不受影响
+// pending 记录每个接收方每种资源已广播但未确认的回收金额（键见 pendingKey），保存回收交易后累加
+func (c *CronJob) cancelEnergyDelegation(data *db.WebhookDataModel, pending map[string]int64) error {
 	c.log.Info("Starting energy delegation cancellation",
 		"id", data.ID,
 		"from", data.FromAddress,
@@ -265,115 +419,116 @@ func (c *CronJob) cancelEnergyDelegation(data *db.WebhookDataModel) error {
 	)
 
 	// 从环境变量获取统一的委托方地址
-	delegationFromAddress := os.Getenv("DELEGATION_FROM_ADDRESS")
-	if delegationFromAddress == "" {
-		return fmt.Errorf("environment variable DELEGATION_FROM_ADDRESS not set")
+	delegationFromAddress, err := getDelegationFromAddress()
+	if err != nil {
+		return err
 	}
 
-	c.log.Info("Using unified delegation address for cancellation",
-		"original_from", data.FromAddress,
-		"delegation_from", delegationFromAddress,
-	)
+	// 接收方为备注中的受益地址或付款方，统一为 Base58Check（兼容历史上以 0x 形式存储的记录）
+	receiverAddress, err := address.Normalize(data.Receiver())
+	if err != nil {
+		return fmt.Errorf("invalid receiver address %q: %w", data.Receiver(), err)
+	}
 
-	// 1. 获取原始委托交易ID
-	originalTxID, err := db.GetOriginalTxIDByID(c.ctx, c.pool, data.ID)
+	resource, err := tron.NormalizeResource(data.Resource)
 	if err != nil {
-		return fmt.Errorf("failed to get original delegation transaction ID: %w", err)
+		return err
 	}
+	key := pendingKey(receiverAddress, resource)
 
-	if originalTxID == "" {
-		return fmt.Errorf("original delegation transaction ID is empty, cannot cancel delegation")
+	// 1. 锁定期内不能回收
+	if data.LockExpireTime > 0 && time.Now().UnixMilli() < data.LockExpireTime {
+		return errReclaimLocked
 	}
 
-	c.log.Info("Retrieved original delegation transaction ID", "id", data.ID, "original_tx_id", originalTxID)
+	// 2. 该订单委托的质押金额
+	orderAmount, err := parseSun(data.DelegateAmount)
+	if err != nil || orderAmount <= 0 {
+		return fmt.Errorf("delegate amount %q is empty, cannot cancel delegation", data.DelegateAmount)
+	}
 
-	// 2. 构建取消委托请求
-	cancelReq := &tron.CancelDelegationRequest{
-		FromAddress:  delegationFromAddress, // 使用统一的委托方地址
-		ToAddress:    data.FromAddress,      // 取消委托给交易发起方
-		OriginalTxID: originalTxID,
-		TxHash:       data.TxHash,
+	// 3. 查询链上该接收方的剩余委托，同一接收方的后续锁定会延长全部锁定部分，以链上到期时间为准
+	resources, err := c.tronClient.GetDelegatedResourceV2(c.ctx, delegationFromAddress, receiverAddress)
+	if err != nil {
+		return fmt.Errorf("failed to get delegated resource: %w", err)
 	}
+	outstanding := tron.DelegatedBalance(resources, resource)
+	reclaimable := tron.ReclaimableBalance(resources, resource, time.Now())
 
-	// 3. 执行取消委托
-	cancelResp, err := c.tronClient.CancelEnergyDelegation(c.ctx, cancelReq)
+	amount, err := planReclaim(orderAmount, outstanding, reclaimable, pending[key])
 	if err != nil {
-		return fmt.Errorf("cancel energy delegation API call failed: %w", err)
+		return err
 	}
 
-	c.log.Info("Energy delegation cancellation successful",
-		"tx_id", cancelResp.TxID,
-		"message", cancelResp.Message,
-		"from", delegationFromAddress,
-		"to", data.ToAddress,
+	c.log.Info("Planned reclaim amount",
+		"id", data.ID,
+		"receiver", receiverAddress,
+		"resource", resource,
+		"order_amount", orderAmount,
+		"outstanding", outstanding,
+		"reclaimable", reclaimable,
+		"pending_reclaim", pending[key],
+		"reclaim_amount", amount,
 	)
 
-	return nil
-}
-
-// calculateDelegationAmount 计算委托数量
-func (c *CronJob) calculateDelegationAmount(value string, availableEnergy string) string {
-	// 将字符串转换为数值进行计算
-	valueInt, err := strconv.ParseInt(value, 10, 64)
-	if err != nil {
-		c.log.Error("Failed to parse transaction amount", err, "value", value)
-		return "0"
+	// 4. 构建取消委托请求
+	cancelReq := &tron.CancelDelegationRequest{
+		FromAddress:  delegationFromAddress, // 使用统一的委托方地址
+		ToAddress:    receiverAddress,       // 从委托时的接收方回收
+		Amount:       strconv.FormatInt(amount, 10),
+		Resource:     resource,
+		OriginalTxID: data.OriginalTxID,
+		TxHash:       data.TxHash,
 	}
 
-	energyInt, err := strconv.ParseInt(availableEnergy, 10, 64)
+	// 5. 构建并签名回收交易，此时尚未广播，失败可以安全重试
+	signedTx, err := c.tronClient.BuildUndelegation(c.ctx, cancelReq)
 	if err != nil {
-		c.log.Error("Failed to parse available energy", err, "energy", availableEnergy)
-		return "0"
-	}
-
-	// 从环境变量获取委托配置
-	delegationBaseStr := os.Getenv("DELEGATION_BASE")
-	if delegationBaseStr == "" {
-		delegationBaseStr = "65000"
+		return fmt.Errorf("cancel energy delegation API call failed: %w", err)
 	}
-	delegationBase, err := strconv.ParseInt(delegationBaseStr, 10, 64)
+	expiration, err := signedTx.Expiration()
 	if err != nil {
-		c.log.Error("Failed to parse DELEGATION_BASE", err, "value", delegationBaseStr)
-		delegationBase = 65000
+		return fmt.Errorf("failed to decode reclaim transaction: %w", err)
 	}
 
-	minDelegationStr := os.Getenv("MIN_DELEGATION_AMOUNT")
-	if minDelegationStr == "" {
-		minDelegationStr = "1000000"
+	// 6. 广播前保存回收交易ID、过期时间和回收金额，状态置为回收中，保存失败时不广播
+	if err := db.UpdateReclaimByID(c.ctx, c.pool, data.ID, signedTx.TxID, expiration, cancelReq.Amount); err != nil {
+		return fmt.Errorf("failed to save reclaim transaction %s: %w", signedTx.TxID, err)
 	}
-	minDelegation, err := strconv.ParseInt(minDelegationStr, 10, 64)
-	if err != nil {
-		c.log.Error("Failed to parse MIN_DELEGATION_AMOUNT", err, "value", minDelegationStr)
-		minDelegation = 1000000
+	// 交易可能上链，计算同一接收方后续订单的剩余委托时扣除
+	pending[key] += amount
+
+	// 7. 广播回收交易，失败时由确认跟踪按交易ID判断是否上链
+	if _, err := c.tronClient.BroadcastTransaction(c.ctx, signedTx); err != nil {
+		c.log.Warn("Energy delegation cancellation broadcast failed, waiting for confirmation tracking",
+			"id", data.ID,
+			"tx_id", signedTx.TxID,
+			"expiration", expiration,
+			"reason", err.Error(),
+		)
+		return nil
 	}
 
-	// 业务逻辑：基于交易金额计算委托数量
-	// 1 TRX = 1,000,000 SUN
-	var delegationAmount int64
-
-	// 将 SUN 转换为 TRX 进行计算
-	trxValue := valueInt / 1000000 // 1 TRX = 1,000,000 SUN
+	c.log.Info("Energy delegation cancellation broadcast",
+		"tx_id", signedTx.TxID,
+		"from", delegationFromAddress,
+		"to", receiverAddress,
+		"resource", resource,
+		"amount", amount,
+	)
 
-	if trxValue == 1 {
-		// 1 TRX → 委托 delegationBase
-		delegationAmount = delegationBase
-	} else if trxValue == 2 {
-		// 2 TRX → 委托 2 * delegationBase
-		delegationAmount = 2 * delegationBase
-	} else {
-		// 其他情况，不进行委托
-		delegationAmount = 0
-	}
+	return nil
+}
 
-	// 确保不超过可用能量
-	if delegationAmount > energyInt {
-		delegationAmount = energyInt
+// getDelegationFromAddress 从环境变量获取统一委托方地址，并转换为 Base58Check
+func getDelegationFromAddress() (string, error) {
+	raw := os.Getenv("DELEGATION_FROM_ADDRESS")
+	if raw == "" {
+		return "", fmt.Errorf("environment variable DELEGATION_FROM_ADDRESS not set")
 	}
-
-	// 确保最小委托数量
-	if delegationAmount < minDelegation {
-		delegationAmount = 0
+	normalized, err := address.Normalize(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid DELEGATION_FROM_ADDRESS %q: %w", raw, err)
 	}
-
-	return strconv.FormatInt(delegationAmount, 10)
+	return normalized, nil
 }
@@ -1,9 +1,17 @@
 package cronjob
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"lending-trx/internal/db"
+	"lending-trx/internal/tron"
+	"lending-trx/internal/tron/fakechain"
+	"lending-trx/internal/units"
 	"os"
 	"strconv"
 	"testing"
+	"time"
 )
 
 func TestDelegationFromAddress(t *testing.T) {
@@ -19,106 +27,160 @@ func TestDelegationFromAddress(t *testing.T) {
 	}
 }
 
-func TestCalculateDelegationAmount(t *testing.T) {
-	// 根据实际代码逻辑更新测试用例
+func TestEnergyForPayment(t *testing.T) {
 	testCases := []struct {
-		value           string
-		availableEnergy string
-		expected        string
-		description     string
+		value       string
+		expected    int64
+		description string
 	}{
-		{
-			value:           "500000", // 500,000 SUN = 0.5 TRX (小于1 TRX)
-			availableEnergy: "100000",
-			expected:        "0", // 小于1 TRX，返回0
-			description:     "小于1 TRX交易，跳过委托",
-		},
-		{
-			value:           "1000000", // 1,000,000 SUN = 1 TRX
-			availableEnergy: "100000",
-			expected:        "65000", // 1 TRX → 委托 65000
-			description:     "1 TRX交易，委托基础数量",
-		},
-		{
-			value:           "2000000", // 2,000,000 SUN = 2 TRX
-			availableEnergy: "200000",
-			expected:        "130000", // 2 TRX → 委托 2 * 65000 = 130000
-			description:     "2 TRX交易，委托双倍数量",
-		},
-		{
-			value:           "1000000", // 1,000,000 SUN = 1 TRX
-			availableEnergy: "50000",   // 可用能量不足
-			expected:        "50000",   // 受可用能量限制
-			description:     "1 TRX交易，受可用能量限制",
-		},
-		{
-			value:           "1000000", // 1,000,000 SUN = 1 TRX
-			availableEnergy: "500000",  // 足够的可用能量，但委托数量小于最小值
-			expected:        "0",       // 65000 < 1000000，不满足最小委托要求
-			description:     "1 TRX交易，不满足最小委托要求",
-		},
-		{
-			value:           "3000000", // 3,000,000 SUN = 3 TRX
-			availableEnergy: "100000",
-			expected:        "0", // 3 TRX不在支持范围内
-			description:     "3 TRX交易，不支持的交易金额",
-		},
+		{"500000", 0, "小于1 TRX交易，跳过委托"},
+		{"1000000", 65000, "1 TRX交易，委托基础数量"},
+		{"1500000", 65000, "1.5 TRX交易，按1 TRX档位"},
+		{"2000000", 130000, "2 TRX交易，委托双倍数量"},
+		{"3000000", 0, "3 TRX交易，不支持的交易金额"},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.description, func(t *testing.T) {
-			// 模拟实际代码的计算逻辑
 			valueInt, err := strconv.ParseInt(tc.value, 10, 64)
 			if err != nil {
-				t.Errorf("解析交易金额失败: %v", err)
-				return
+				t.Fatalf("解析交易金额失败: %v", err)
 			}
 
-			energyInt, err := strconv.ParseInt(tc.availableEnergy, 10, 64)
-			if err != nil {
-				t.Errorf("解析可用能量失败: %v", err)
-				return
+			if energy := energyForPayment(valueInt, 65000); energy != tc.expected {
+				t.Errorf("期望能量数量为%d，实际为%d", tc.expected, energy)
 			}
+		})
+	}
+}
 
-			// 使用实际代码的逻辑
-			var delegationBase int64 = 65000
-			var minDelegation int64 = 1000000
-			var delegationAmount int64
-
-			// 将 SUN 转换为 TRX 进行计算
-			trxValue := valueInt / 1000000 // 1 TRX = 1,000,000 SUN
-
-			if trxValue == 1 {
-				// 1 TRX → 委托 delegationBase
-				delegationAmount = delegationBase
-			} else if trxValue == 2 {
-				// 2 TRX → 委托 2 * delegationBase
-				delegationAmount = 2 * delegationBase
-			} else {
-				// 其他情况，不进行委托
-				delegationAmount = 0
-			}
+func TestPaymentTiers(t *testing.T) {
+	t.Setenv("DELEGATION_BASE", "65000")
+	t.Setenv("ENERGY_TIERS", "")
+	t.Setenv("BANDWIDTH_TIERS", "3:1000, 5:2000")
 
-			// 确保不超过可用能量
-			if delegationAmount > energyInt {
-				delegationAmount = energyInt
-			}
+	energy, bandwidth, err := paymentTiers(currencyTRX)
+	if err != nil {
+		t.Fatalf("读取档位失败: %v", err)
+	}
+	testCases := []struct {
+		units    int64
+		resource string
+		amount   int64
+	}{
+		{1, tron.ResourceEnergy, 65000},
+		{2, tron.ResourceEnergy, 130000},
+		{3, tron.ResourceBandwidth, 1000},
+		{5, tron.ResourceBandwidth, 2000},
+		{4, "", 0},
+	}
+	for _, tc := range testCases {
+		resource, amount := resourceForPayment(tc.units, energy, bandwidth)
+		if resource != tc.resource || amount != tc.amount {
+			t.Errorf("%d TRX: 期望为%s %d，实际为%s %d", tc.units, tc.resource, tc.amount, resource, amount)
+		}
+	}
 
-			// 确保最小委托数量
-			if delegationAmount < minDelegation {
-				delegationAmount = 0
-			}
+	// 自定义能量档位
+	t.Setenv("ENERGY_TIERS", "10:650000")
+	if energy, _, err = paymentTiers(currencyTRX); err != nil || energy.amountFor(10) != 650000 || energy.amountFor(1) != 0 {
+		t.Errorf("期望使用 ENERGY_TIERS 档位，实际为%v, %v", energy, err)
+	}
+
+	// 其他币种使用带前缀的档位，未配置时不接受
+	t.Setenv("USDT_ENERGY_TIERS", "")
+	t.Setenv("USDT_BANDWIDTH_TIERS", "")
+	if energy, bandwidth, err = paymentTiers("USDT"); err != nil || len(energy) != 0 || len(bandwidth) != 0 {
+		t.Errorf("期望未配置 USDT 档位时为空，实际为%v %v, %v", energy, bandwidth, err)
+	}
+	t.Setenv("USDT_ENERGY_TIERS", "1:32000")
+	t.Setenv("USDT_BANDWIDTH_TIERS", "2:1000")
+	if energy, bandwidth, err = paymentTiers("USDT"); err != nil || energy.amountFor(1) != 32000 || bandwidth.amountFor(2) != 1000 {
+		t.Errorf("期望使用 USDT 档位，实际为%v %v, %v", energy, bandwidth, err)
+	}
+
+	// 同一金额不能同时用于能量和带宽
+	t.Setenv("BANDWIDTH_TIERS", "10:1000")
+	if _, _, err := paymentTiers(currencyTRX); err == nil {
+		t.Error("期望档位冲突时返回错误")
+	}
+	t.Setenv("BANDWIDTH_TIERS", "3-1000")
+	if _, _, err := paymentTiers(currencyTRX); err == nil {
+		t.Error("期望格式错误时返回错误")
+	}
+}
 
-			result := strconv.FormatInt(delegationAmount, 10)
+func TestPaymentOf(t *testing.T) {
+	t.Setenv("TRC20_TOKENS", "")
+	tokens, err := trc20Tokens()
+	if err != nil {
+		t.Fatalf("读取代币配置失败: %v", err)
+	}
 
-			if result != tc.expected {
-				t.Errorf("期望委托数量为%s，实际为%s", tc.expected, result)
+	testCases := []struct {
+		description string
+		data        *db.WebhookDataModel
+		currency    string
+		units       int64
+		wantErr     bool
+	}{
+		{"TRX 按整 TRX 舍去", &db.WebhookDataModel{Value: "2500000"}, currencyTRX, 2, false},
+		{"USDT 按6位小数换算", &db.WebhookDataModel{Value: "0", TokenContract: "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", TokenAmount: "3999999"}, "USDT", 3, false},
+		{"未配置的代币", &db.WebhookDataModel{Value: "0", TokenContract: "TKQbPHJ7vaPVc8KuYaQTs2ew6gb64r9xhK", TokenAmount: "1000000"}, "", 0, false},
+		{"代币金额超出范围", &db.WebhookDataModel{Value: "0", TokenContract: "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", TokenAmount: "115792089237316195423570985008687907853269984665640564039457584007913129639935"}, "", 0, true},
+		{"TRX 金额超出 int64 SUN", &db.WebhookDataModel{Value: "9300000000000000000000"}, currencyTRX, 9300000000000000, false},
+		{"TRX 整币金额超出范围", &db.WebhookDataModel{Value: "9300000000000000000000000000"}, "", 0, true},
+		{"TRX 金额格式错误", &db.WebhookDataModel{Value: "abc"}, "", 0, true},
+		{"TRX 金额为小数", &db.WebhookDataModel{Value: "1.5"}, "", 0, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			currency, units, err := paymentOf(tc.data, tokens)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("期望错误为%v，实际为%v", tc.wantErr, err)
 			}
+			if currency != tc.currency || units != tc.units {
+				t.Errorf("期望为%s %d，实际为%s %d", tc.currency, tc.units, currency, units)
+			}
+		})
+	}
+
+	if _, err := parseTokens("USDT:TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"); err == nil {
+		t.Error("期望代币配置格式错误时返回错误")
+	}
+}
+
+func TestDelegationBalance(t *testing.T) {
+	price := &tron.EnergyPrice{TotalEnergyLimit: 180000000000, TotalEnergyWeight: 19000000000}
+
+	testCases := []struct {
+		energy      int64
+		expected    int64
+		description string
+	}{
+		{65000, 6861111112, "65000能量按全网质押比例换算"},
+		{130000, 13722222223, "130000能量按全网质押比例换算"},
+		{5, 1000000, "不足最小委托金额时按1 TRX委托"},
+	}
 
-			t.Logf("交易金额: %s SUN (%.1f TRX), 可用能量: %s, 计算结果: %s",
-				tc.value, float64(trxValue), tc.availableEnergy, result)
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			balance, err := delegationBalance(tron.ResourceEnergy, tc.energy, price, 1000000)
+			if err != nil {
+				t.Fatalf("换算失败: %v", err)
+			}
+			if balance != tc.expected {
+				t.Errorf("期望委托金额为%d，实际为%d", tc.expected, balance)
+			}
 		})
 	}
+
+	// 全网质押量翻倍后，同样的能量需要两倍的质押金额
+	doubled := &tron.EnergyPrice{TotalEnergyLimit: 180000000000, TotalEnergyWeight: 38000000000}
+	balance, _ := delegationBalance(tron.ResourceEnergy, 65000, doubled, 1000000)
+	if balance != 13722222223 {
+		t.Errorf("期望委托金额为13722222223，实际为%d", balance)
+	}
 }
 
 func TestEnvironmentVariableValidation(t *testing.T) {
@@ -234,3 +296,609 @@ func TestTRXValueCalculation(t *testing.T) {
 		})
 	}
 }
+
+func TestClassifyDelegationError(t *testing.T) {
+	testCases := []struct {
+		err      error
+		expected failureAction
+	}{
+		{&tron.APIError{Kind: tron.ErrRateLimited, StatusCode: 429}, actionRetry},
+		{fmt.Errorf("energy delegation failed: %w", &tron.APIError{Kind: tron.ErrTransient}), actionRetry},
+		{&tron.APIError{Kind: tron.ErrInsufficientFrozenBalance}, actionRetry},
+		{&tron.APIError{Kind: tron.ErrAccountNotActivated}, actionRefund},
+		{&tron.APIError{Kind: tron.ErrContractValidate}, actionFail},
+		{fmt.Errorf("failed to sign transaction: %w", &tron.APIError{Kind: tron.ErrSignerPolicy, StatusCode: 403}), actionFail},
+		{fmt.Errorf("failed to get delegation account info"), actionRetry},
+	}
+
+	for _, tc := range testCases {
+		if action := classifyDelegationError(tc.err); action != tc.expected {
+			t.Errorf("错误 %v: 期望处理方式为%s，实际为%s", tc.err, tc.expected, action)
+		}
+	}
+}
+
+func TestClassifyReclaimError(t *testing.T) {
+	testCases := []struct {
+		err      error
+		expected failureAction
+	}{
+		{&tron.APIError{Kind: tron.ErrTransient}, actionRetry},
+		{&tron.APIError{Kind: tron.ErrInsufficientDelegatedBalance}, actionFail},
+		{&tron.APIError{Kind: tron.ErrContractValidate}, actionFail},
+		{&tron.APIError{Kind: tron.ErrSignerPolicy}, actionFail},
+	}
+
+	for _, tc := range testCases {
+		if action := classifyReclaimError(tc.err); action != tc.expected {
+			t.Errorf("错误 %v: 期望处理方式为%s，实际为%s", tc.err, tc.expected, action)
+		}
+	}
+}
+
+func TestClassifyFakeChainErrors(t *testing.T) {
+	chain := fakechain.New()
+	if err := chain.SetPrivateKey("b5a4cea271ff424d7c31dc12a3e43e401df7a40d7412a15750f3f0b6b5449a28"); err != nil {
+		t.Fatalf("设置私钥失败: %v", err)
+	}
+	owner := chain.SignerAddress()
+	chain.CreateAccount(owner, 100*units.SunPerTRX)
+	chain.Stake(owner, 10*units.SunPerTRX)
+
+	var client tron.Client = chain
+	ctx := context.Background()
+
+	// 接收方未激活：待退款
+	_, err := client.DelegateEnergy(ctx, &tron.EnergyDelegationRequest{
+		FromAddress: owner,
+		ToAddress:   "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t",
+		Amount:      "1000000",
+	})
+	if action := classifyDelegationError(err); action != actionRefund {
+		t.Errorf("期望处理方式为refund，实际为%s (%v)", action, err)
+	}
+
+	// 质押余额不足：重试
+	chain.CreateAccount("TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", 0)
+	_, err = client.DelegateEnergy(ctx, &tron.EnergyDelegationRequest{
+		FromAddress: owner,
+		ToAddress:   "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t",
+		Amount:      "50000000",
+	})
+	if action := classifyDelegationError(err); action != actionRetry {
+		t.Errorf("期望处理方式为retry，实际为%s (%v)", action, err)
+	}
+
+	// 回收不存在的委托：回收失败
+	_, err = client.CancelEnergyDelegation(ctx, &tron.CancelDelegationRequest{
+		FromAddress: owner,
+		ToAddress:   "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t",
+		Amount:      "1000000",
+	})
+	if action := classifyReclaimError(err); action != actionFail {
+		t.Errorf("期望处理方式为fail，实际为%s (%v)", action, err)
+	}
+}
+
+func TestPlanReclaim(t *testing.T) {
+	testCases := []struct {
+		orderAmount int64
+		outstanding int64
+		reclaimable int64
+		pending     int64
+		expected    int64
+		expectedErr error
+		description string
+	}{
+		{5000000, 12000000, 12000000, 0, 5000000, nil, "剩余委托充足，只回收本订单金额"},
+		{5000000, 12000000, 12000000, 7000000, 5000000, nil, "扣除回收中金额后仍充足"},
+		{5000000, 12000000, 12000000, 8000000, 0, errReclaimDeferred, "回收中的交易未确认，等待"},
+		{5000000, 3000000, 3000000, 0, 3000000, nil, "部分已被回收，只回收剩余部分"},
+		{5000000, 0, 0, 0, 0, tron.ErrInsufficientDelegatedBalance, "链上没有剩余委托"},
+		{5000000, 12000000, 7000000, 0, 5000000, nil, "未锁定部分足够回收本订单"},
+		{5000000, 12000000, 4000000, 0, 0, errReclaimLocked, "未锁定部分不足，等待锁定到期"},
+		{5000000, 3000000, 0, 0, 0, errReclaimLocked, "剩余部分仍在锁定期内"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			amount, err := planReclaim(tc.orderAmount, tc.outstanding, tc.reclaimable, tc.pending)
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Errorf("期望错误为%v，实际为%v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("期望无错误，实际为%v", err)
+			}
+			if amount != tc.expected {
+				t.Errorf("期望回收金额为%d，实际为%d", tc.expected, amount)
+			}
+		})
+	}
+}
+
+func TestReclaimOverlappingOrders(t *testing.T) {
+	chain := fakechain.New()
+	chain.SetPrivateKey("b5a4cea271ff424d7c31dc12a3e43e401df7a40d7412a15750f3f0b6b5449a28")
+	owner := chain.SignerAddress()
+	receiver := "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+	chain.CreateAccount(owner, 100*units.SunPerTRX)
+	chain.CreateAccount(receiver, 0)
+	chain.Stake(owner, 50*units.SunPerTRX)
+
+	var client tron.Client = chain
+	ctx := context.Background()
+
+	// 同一接收方两笔重叠的订单
+	orders := []int64{5 * units.SunPerTRX, 7 * units.SunPerTRX}
+	for _, amount := range orders {
+		if _, err := client.DelegateEnergy(ctx, &tron.EnergyDelegationRequest{
+			FromAddress: owner,
+			ToAddress:   receiver,
+			Amount:      strconv.FormatInt(amount, 10),
+		}); err != nil {
+			t.Fatalf("委托失败: %v", err)
+		}
+	}
+	chain.MineBlock()
+
+	// 第一笔订单到期，只回收该订单的金额
+	resources, err := client.GetDelegatedResourceV2(ctx, owner, receiver)
+	if err != nil {
+		t.Fatalf("查询委托失败: %v", err)
+	}
+	outstanding := tron.DelegatedBalance(resources, tron.ResourceEnergy)
+	if outstanding != 12*units.SunPerTRX {
+		t.Fatalf("期望剩余委托为%d，实际为%d", 12*units.SunPerTRX, outstanding)
+	}
+
+	amount, err := planReclaim(orders[0], outstanding, outstanding, 0)
+	if err != nil {
+		t.Fatalf("计算回收金额失败: %v", err)
+	}
+	if _, err := client.CancelEnergyDelegation(ctx, &tron.CancelDelegationRequest{
+		FromAddress: owner,
+		ToAddress:   receiver,
+		Amount:      strconv.FormatInt(amount, 10),
+	}); err != nil {
+		t.Fatalf("回收失败: %v", err)
+	}
+	chain.MineBlock()
+
+	// 第二笔订单的委托不受影响
+	if got := chain.Delegated(owner, receiver); got != orders[1] {
+		t.Errorf("期望第二笔订单的委托保持%d，实际为%d", orders[1], got)
+	}
+}
+
+func TestLockPeriodFor(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	// 订单到期时间以秒存储（ingest 写入），剩余1小时
+	if got := lockPeriodFor(now.Unix()+3600, nil, tron.ResourceEnergy, now); got != 1200 {
+		t.Errorf("期望锁定期为1200，实际为%d", got)
+	}
+	// 已到期不锁定
+	if got := lockPeriodFor(now.Unix()-1, nil, tron.ResourceEnergy, now); got != 0 {
+		t.Errorf("期望已到期订单不锁定，实际为%d", got)
+	}
+	// 现有锁定剩余时间更长时，锁定期不能更短
+	resources := []tron.DelegatedResource{{FrozenBalanceForEnergy: 1, ExpireTimeForEnergy: now.Add(2 * time.Hour).UnixMilli()}}
+	if got := lockPeriodFor(now.Unix()+3600, resources, tron.ResourceEnergy, now); got != 2400 {
+		t.Errorf("期望锁定期覆盖现有锁定为2400，实际为%d", got)
+	}
+	// 能量的锁定不影响带宽的锁定期
+	if got := lockPeriodFor(now.Unix()+3600, resources, tron.ResourceBandwidth, now); got != 1200 {
+		t.Errorf("期望带宽锁定期为1200，实际为%d", got)
+	}
+
+	if got := lockExpireTime(now.UnixMilli(), 1200); got != now.Add(time.Hour).UnixMilli() {
+		t.Errorf("期望锁定到期时间为%d，实际为%d", now.Add(time.Hour).UnixMilli(), got)
+	}
+	if got := lockExpireTime(now.UnixMilli(), 0); got != 0 {
+		t.Errorf("期望未锁定时到期时间为0，实际为%d", got)
+	}
+}
+
+func TestReclaimRespectsLock(t *testing.T) {
+	chain := fakechain.New()
+	chain.SetPrivateKey("b5a4cea271ff424d7c31dc12a3e43e401df7a40d7412a15750f3f0b6b5449a28")
+	owner := chain.SignerAddress()
+	receiver := "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+	chain.CreateAccount(owner, 100*units.SunPerTRX)
+	chain.CreateAccount(receiver, 0)
+	chain.Stake(owner, 50*units.SunPerTRX)
+
+	var client tron.Client = chain
+	ctx := context.Background()
+
+	// 租期1小时的订单，锁定到订单到期
+	orderAmount := int64(5 * units.SunPerTRX)
+	lockPeriod := lockPeriodFor(chain.Now().Add(time.Hour).Unix(), nil, tron.ResourceEnergy, chain.Now())
+	if _, err := client.DelegateEnergy(ctx, &tron.EnergyDelegationRequest{
+		FromAddress: owner,
+		ToAddress:   receiver,
+		Amount:      strconv.FormatInt(orderAmount, 10),
+		Lock:        true,
+		LockPeriod:  lockPeriod,
+	}); err != nil {
+		t.Fatalf("委托失败: %v", err)
+	}
+	chain.MineBlock()
+
+	plan := func() (int64, error) {
+		resources, err := client.GetDelegatedResourceV2(ctx, owner, receiver)
+		if err != nil {
+			t.Fatalf("查询委托失败: %v", err)
+		}
+		return planReclaim(orderAmount,
+			tron.DelegatedBalance(resources, tron.ResourceEnergy),
+			tron.ReclaimableBalance(resources, tron.ResourceEnergy, chain.Now()), 0)
+	}
+
+	// 锁定期内不尝试回收
+	if _, err := plan(); !errors.Is(err, errReclaimLocked) {
+		t.Fatalf("期望锁定期内等待，实际为%v", err)
+	}
+
+	chain.SkipBlocks(lockPeriod)
+	amount, err := plan()
+	if err != nil || amount != orderAmount {
+		t.Fatalf("期望锁定到期后回收%d，实际为%d, %v", orderAmount, amount, err)
+	}
+	if _, err := client.CancelEnergyDelegation(ctx, &tron.CancelDelegationRequest{
+		FromAddress: owner,
+		ToAddress:   receiver,
+		Amount:      strconv.FormatInt(amount, 10),
+	}); err != nil {
+		t.Fatalf("回收失败: %v", err)
+	}
+	chain.MineBlock()
+	if got := chain.Delegated(owner, receiver); got != 0 {
+		t.Errorf("期望回收后委托金额为0，实际为%d", got)
+	}
+}
+
+func TestSignerConfigFromEnv(t *testing.T) {
+	t.Setenv("DELEGATION_PRIVATE_KEY", "key0")
+	t.Setenv("DELEGATION_PRIVATE_KEYS", "")
+	if keys := signerKeysFromEnv(); len(keys) != 1 || keys[0] != "key0" {
+		t.Errorf("期望回退到 DELEGATION_PRIVATE_KEY，实际为%v", keys)
+	}
+
+	t.Setenv("DELEGATION_PRIVATE_KEYS", "key1, key2,")
+	if keys := signerKeysFromEnv(); len(keys) != 2 || keys[0] != "key1" || keys[1] != "key2" {
+		t.Errorf("期望读取两个私钥，实际为%v", keys)
+	}
+
+	t.Setenv("DELEGATION_PERMISSION_ID", "")
+	if id, err := permissionIDFromEnv(); err != nil || id != tron.OwnerPermissionID {
+		t.Errorf("期望默认使用 owner 权限，实际为%d, %v", id, err)
+	}
+	t.Setenv("DELEGATION_PERMISSION_ID", "2")
+	if id, err := permissionIDFromEnv(); err != nil || id != 2 {
+		t.Errorf("期望权限ID为2，实际为%d, %v", id, err)
+	}
+	t.Setenv("DELEGATION_PERMISSION_ID", "active")
+	if _, err := permissionIDFromEnv(); err == nil {
+		t.Error("期望非法的权限ID返回错误")
+	}
+}
+
+func TestStakingConfigFromEnv(t *testing.T) {
+	t.Setenv("STAKING_RESOURCE", "")
+	t.Setenv("STAKING_RESERVE", "")
+	t.Setenv("STAKING_MIN_FREEZE", "")
+	config, err := stakingConfig("owner")
+	if err != nil || config.Resource != tron.ResourceEnergy || config.Reserve != defaultStakingReserve || config.MinFreeze != defaultStakingMinFreeze {
+		t.Errorf("期望默认质押能量并使用默认保留余额和最少质押金额，实际为%+v, %v", config, err)
+	}
+
+	t.Setenv("STAKING_RESOURCE", "bandwidth")
+	t.Setenv("STAKING_RESERVE", "500000000")
+	t.Setenv("STAKING_MIN_FREEZE", "2000000")
+	config, err = stakingConfig("owner")
+	if err != nil || config.Owner != "owner" || config.Resource != tron.ResourceBandwidth || config.Reserve != 500*units.SunPerTRX || config.MinFreeze != 2*units.SunPerTRX {
+		t.Errorf("期望读取环境变量配置，实际为%+v, %v", config, err)
+	}
+
+	// 不支持的资源类型启动时拒绝
+	t.Setenv("STAKING_ENABLED", "true")
+	t.Setenv("SIGNER_URL", "")
+	t.Setenv("STAKING_RESOURCE", "TRON_POWER")
+	if err := CheckStakingConfig(); err == nil {
+		t.Error("期望不支持的 STAKING_RESOURCE 返回错误")
+	}
+	if _, err := stakingConfig("owner"); err == nil {
+		t.Error("期望不支持的 STAKING_RESOURCE 返回错误")
+	}
+}
+
+func TestNewStakingManager(t *testing.T) {
+	const key = "b5a4cea271ff424d7c31dc12a3e43e401df7a40d7412a15750f3f0b6b5449a28"
+	t.Setenv("STAKING_ENABLED", "true")
+	t.Setenv("SIGNER_URL", "")
+	chain := fakechain.New()
+	chain.SetPrivateKey(key)
+	owner := chain.SignerAddress()
+	chain.CreateAccount(owner, 100*units.SunPerTRX)
+	ctx := context.Background()
+
+	// owner 权限允许全部操作
+	if _, err := newStakingManager(ctx, chain, owner); err != nil {
+		t.Errorf("期望 owner 权限可以启用质押管理，实际为%v", err)
+	}
+
+	// 自定义权限只允许委托和回收
+	if err := chain.SetPermission(owner, tron.Permission{
+		Type: "Active", ID: 3, PermissionName: "delegate", Threshold: 1,
+		Operations: tron.OperationsFor(tron.DelegationContractTypes...),
+		Keys:       []tron.PermissionKey{{Address: owner, Weight: 1}},
+	}); err != nil {
+		t.Fatalf("设置权限失败: %v", err)
+	}
+	if err := chain.SetSigners([]string{key}, 3); err != nil {
+		t.Fatalf("设置签名私钥失败: %v", err)
+	}
+	if _, err := newStakingManager(ctx, chain, owner); err == nil {
+		t.Error("期望权限不包含质押操作时不启用质押管理")
+	}
+
+	// 远程签名服务不签署质押交易
+	t.Setenv("SIGNER_URL", "unix:///run/lending-trx/signer.sock")
+	if err := CheckStakingConfig(); err == nil {
+		t.Error("期望同时配置 STAKING_ENABLED 和 SIGNER_URL 时返回错误")
+	}
+	t.Setenv("STAKING_ENABLED", "false")
+	if err := CheckStakingConfig(); err != nil {
+		t.Errorf("期望未启用质押管理时无错误，实际为%v", err)
+	}
+}
+
+func TestPaymentConfirmed(t *testing.T) {
+	heads := chainHeads{latest: 1000, solidified: 980}
+	testCases := []struct {
+		description string
+		blockHeight int64
+		depth       int64
+		expected    bool
+	}{
+		{"已固化且达到确认深度", 980, 19, true},
+		{"未固化", 985, 10, false},
+		{"已固化但未达到确认深度", 980, 30, false},
+		{"确认深度为0时只要求固化", 980, 0, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			if got := paymentConfirmed(tc.blockHeight, heads, tc.depth); got != tc.expected {
+				t.Errorf("期望为%v，实际为%v", tc.expected, got)
+			}
+		})
+	}
+
+	t.Setenv("PAYMENT_CONFIRMATIONS", "")
+	if got := paymentConfirmations(); got != defaultPaymentConfirmations {
+		t.Errorf("期望默认确认深度为%d，实际为%d", defaultPaymentConfirmations, got)
+	}
+	t.Setenv("PAYMENT_CONFIRMATIONS", "40")
+	if got := paymentConfirmations(); got != 40 {
+		t.Errorf("期望确认深度为40，实际为%d", got)
+	}
+}
+
+func TestPaymentCanonical(t *testing.T) {
+	chain := fakechain.New()
+	chain.SetSolidityLag(19)
+	latest := chain.SkipBlocks(100)
+	job := &CronJob{ctx: context.Background(), tronClient: chain}
+
+	heads, err := job.currentHeads()
+	if err != nil {
+		t.Fatalf("查询最新区块失败: %v", err)
+	}
+	if heads.latest != latest || heads.solidified != latest-19 {
+		t.Errorf("期望最新区块%d、固化区块%d，实际为%+v", latest, latest-19, heads)
+	}
+
+	// stream 推送的区块哈希带 0x 前缀
+	item := &db.WebhookDataModel{ID: 1, BlockHeight: 80, BlockHash: "0x" + chain.BlockID(80)}
+	if canonical, err := job.paymentCanonical(item); err != nil || !canonical {
+		t.Errorf("期望区块在主链上，实际为%v, %v", canonical, err)
+	}
+
+	// 历史订单没有区块哈希
+	if canonical, err := job.paymentCanonical(&db.WebhookDataModel{ID: 2, BlockHeight: 80}); err != nil || !canonical {
+		t.Errorf("期望没有区块哈希时视为在主链上，实际为%v, %v", canonical, err)
+	}
+
+	// 区块80被重组后支付不在主链上
+	chain.Reorg(80)
+	if canonical, err := job.paymentCanonical(item); err != nil || canonical {
+		t.Errorf("期望重组后区块不在主链上，实际为%v, %v", canonical, err)
+	}
+
+	// 节点查不到区块时返回错误，订单保持待处理
+	if _, err := job.paymentCanonical(&db.WebhookDataModel{ID: 3, BlockHeight: latest + 1, BlockHash: chain.BlockID(80)}); err == nil {
+		t.Error("期望区块不存在时返回错误")
+	}
+}
+
+func TestTransactionDropped(t *testing.T) {
+	chain := fakechain.New()
+	chain.SetPrivateKey("b5a4cea271ff424d7c31dc12a3e43e401df7a40d7412a15750f3f0b6b5449a28")
+	owner := chain.SignerAddress()
+	receiver := "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+	chain.CreateAccount(owner, 100*units.SunPerTRX)
+	chain.CreateAccount(receiver, 0)
+	chain.Stake(owner, 50*units.SunPerTRX)
+	chain.SetSolidityLag(19)
+	job := &CronJob{ctx: context.Background(), tronClient: chain}
+
+	// 签名后未广播的交易，模拟广播失败
+	signed, err := chain.BuildDelegation(job.ctx, &tron.EnergyDelegationRequest{FromAddress: owner, ToAddress: receiver, Amount: "1000000"})
+	if err != nil {
+		t.Fatalf("构建委托交易失败: %v", err)
+	}
+	expiration, _ := signed.Expiration()
+
+	// 最新区块已超过过期时间，但固化区块尚未超过
+	chain.SkipBlocks(30)
+	if dropped, err := job.transactionDropped(signed.TxID, expiration, time.Now()); err != nil || dropped {
+		t.Errorf("期望固化区块未超过过期时间时不重置，实际为%v, %v", dropped, err)
+	}
+
+	chain.SkipBlocks(19)
+	if dropped, err := job.transactionDropped(signed.TxID, expiration, time.Now()); err != nil || !dropped {
+		t.Errorf("期望固化区块超过过期时间且查询不到时重置，实际为%v, %v", dropped, err)
+	}
+
+	// 节点查到交易时不重置
+	if _, err := chain.BroadcastTransaction(job.ctx, signed); err != nil {
+		t.Fatalf("广播失败: %v", err)
+	}
+	chain.MineBlock()
+	if dropped, err := job.transactionDropped(signed.TxID, expiration, time.Now()); err != nil || dropped {
+		t.Errorf("期望查到交易时不重置，实际为%v, %v", dropped, err)
+	}
+
+	// 历史订单没有过期时间，以状态更新时间加超时时间代替
+	t.Setenv("CONFIRMATION_TIMEOUT", "1h")
+	if dropped, err := job.transactionDropped("missing", 0, chain.Now()); err != nil || dropped {
+		t.Errorf("期望未超时时不重置，实际为%v, %v", dropped, err)
+	}
+}
+
+func TestTokenPaymentVerified(t *testing.T) {
+	chain := fakechain.New()
+	job := &CronJob{ctx: context.Background(), tronClient: chain}
+	item := &db.WebhookDataModel{
+		ID:            1,
+		TxHash:        "0x07e1f7519110b58ed7cdfbfccbe5b6d35ca00d7c59b21bb72ba96a77ce25675e",
+		FromAddress:   "TSoXbDPwgM1nhfbxVj53u15My8smJHWpFw",
+		ToAddress:     "0x678637325f9be6b2264db347021432a6a7b84c10", // 历史记录的 0x 形式
+		TokenContract: "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t",
+		TokenAmount:   "5000000",
+	}
+
+	// TRX 支付不检查回执
+	if verified, err := job.tokenPaymentVerified(&db.WebhookDataModel{ID: 2, TxHash: "0x01"}); err != nil || !verified {
+		t.Errorf("期望 TRX 支付不需要核实，实际为%v, %v", verified, err)
+	}
+
+	// 节点查不到回执时返回错误，订单保持待处理
+	if _, err := job.tokenPaymentVerified(item); err == nil {
+		t.Error("期望查不到回执时返回错误")
+	}
+
+	transferLog := tron.TransactionLog{
+		Address: "a614f803b6fd780986a42c78ec9c7f77e6ded13c",
+		Topics: []string{
+			"ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+			"000000000000000000000000b8a57ef5343f88712a4eee91e34290584c2d5998",
+			"000000000000000000000000678637325f9be6b2264db347021432a6a7b84c10",
+		},
+		Data: "00000000000000000000000000000000000000000000000000000000004c4b40",
+	}
+	txID := item.TxHash[2:]
+
+	// 调用回滚：推送中的 transfer 调用参数不代表已到账
+	chain.AddReceipt(tron.TransactionInfo{ID: txID, Result: "FAILED", Receipt: tron.TransactionReceipt{Result: "REVERT"}})
+	if verified, err := job.tokenPaymentVerified(item); err != nil || verified {
+		t.Errorf("期望回滚的调用未到账，实际为%v, %v", verified, err)
+	}
+
+	chain.AddReceipt(tron.TransactionInfo{ID: txID, Receipt: tron.TransactionReceipt{Result: "SUCCESS"}, Log: []tron.TransactionLog{transferLog}})
+	if verified, err := job.tokenPaymentVerified(item); err != nil || !verified {
+		t.Errorf("期望执行成功且有匹配的 Transfer 事件时已到账，实际为%v, %v", verified, err)
+	}
+}
+
+func TestSignersFromEnv(t *testing.T) {
+	key, err := tron.ParsePrivateKey("b5a4cea271ff424d7c31dc12a3e43e401df7a40d7412a15750f3f0b6b5449a28")
+	if err != nil {
+		t.Fatalf("解析私钥失败: %v", err)
+	}
+	data, err := tron.EncryptKeystore(key, "secret", tron.KeystoreLightScryptN)
+	if err != nil {
+		t.Fatalf("生成 keystore 失败: %v", err)
+	}
+	dir := t.TempDir()
+	keystorePath := dir + "/keystore.json"
+	passwordPath := dir + "/password"
+	if err := os.WriteFile(keystorePath, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(passwordPath, []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("SIGNER_URL", "")
+	t.Setenv("DELEGATION_KEYSTORE", keystorePath)
+	t.Setenv("DELEGATION_KEYSTORE_PASSWORD", "")
+	t.Setenv("DELEGATION_KEYSTORE_PASSWORD_FILE", passwordPath)
+	t.Setenv("DELEGATION_PRIVATE_KEYS", "")
+	t.Setenv("DELEGATION_PRIVATE_KEY", "8e812436a0e3323166e1f0e8ba79e19e217b2c4a53c970d4cca0cfb1078979df")
+
+	signers, err := signersFromEnv(context.Background())
+	if err != nil {
+		t.Fatalf("创建签名者失败: %v", err)
+	}
+	if len(signers) != 2 || signers[0].Address() != tron.NewLocalSigner(key).Address() {
+		t.Errorf("期望 keystore 和私钥两个签名者，实际为%d个", len(signers))
+	}
+
+	t.Setenv("DELEGATION_KEYSTORE_PASSWORD_FILE", "")
+	t.Setenv("DELEGATION_KEYSTORE_PASSWORD", "wrong")
+	if _, err := signersFromEnv(context.Background()); !errors.Is(err, tron.ErrKeystorePassword) {
+		t.Errorf("期望 keystore 密码错误，实际为%v", err)
+	}
+
+	t.Setenv("DELEGATION_KEYSTORE", "")
+	t.Setenv("SIGNER_URL", "ftp://127.0.0.1:8091")
+	if _, err := signersFromEnv(context.Background()); err == nil {
+		t.Error("期望无效的签名服务地址返回错误")
+	}
+}
+
+func TestTxCost(t *testing.T) {
+	chain := fakechain.New()
+	if err := chain.SetPrivateKey("b5a4cea271ff424d7c31dc12a3e43e401df7a40d7412a15750f3f0b6b5449a28"); err != nil {
+		t.Fatalf("设置私钥失败: %v", err)
+	}
+	owner := chain.SignerAddress()
+	receiver := "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+	chain.CreateAccount(owner, 100*units.SunPerTRX)
+	chain.CreateAccount(receiver, 0)
+	chain.Stake(owner, 10*units.SunPerTRX)
+	ctx := context.Background()
+
+	delegate := func() db.TxCost {
+		t.Helper()
+		resp, err := chain.DelegateEnergy(ctx, &tron.EnergyDelegationRequest{FromAddress: owner, ToAddress: receiver, Amount: "1000000"})
+		if err != nil {
+			t.Fatalf("委托失败: %v", err)
+		}
+		chain.MineBlock()
+		confirmation, err := chain.CheckConfirmation(ctx, resp.TxID)
+		if err != nil {
+			t.Fatalf("查询回执失败: %v", err)
+		}
+		return txCost(confirmation)
+	}
+
+	// 使用免费带宽：只消耗带宽，不燃烧 TRX
+	if cost := delegate(); cost.Fee != 0 || cost.NetFee != 0 || cost.NetUsage == 0 {
+		t.Errorf("期望只消耗带宽，实际为%+v", cost)
+	}
+
+	// 带宽不足：燃烧 TRX
+	chain.SetNetFee(267000)
+	if cost := delegate(); cost.Fee != 267000 || cost.NetFee != 267000 || cost.NetUsage != 0 || cost.EnergyFee != 0 {
+		t.Errorf("期望燃烧267000 SUN，实际为%+v", cost)
+	}
+	if account, _ := chain.Account(owner); account.Balance != 90*units.SunPerTRX-267000 {
+		t.Errorf("期望委托方余额扣除手续费，实际为%d", account.Balance)
+	}
+}
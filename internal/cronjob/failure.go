@@ -0,0 +1,69 @@
+package cronjob
+
+import (
+	"errors"
+	"lending-trx/internal/tron"
+)
+
+// failureAction 委托/回收失败后的处理方式
+type failureAction int
+
+const (
+	actionRetry  failureAction = iota // 保持当前状态，下个周期重试
+	actionFail                        // 标记为失败，需人工处理
+	actionRefund                      // 标记为待退款，订单无法履约
+)
+
+// String 返回处理方式名称，用于日志
+func (a failureAction) String() string {
+	switch a {
+	case actionFail:
+		return "fail"
+	case actionRefund:
+		return "refund"
+	default:
+		return "retry"
+	}
+}
+
+// classifyDelegationError 根据委托失败的错误决定订单的处理方式
+// 只处理保存交易ID之前的错误（构建、签名、数据库等），此时交易未广播，重试会签名新交易但不会重复委托；
+// 保存交易ID之后的广播失败不经过这里，由确认跟踪处理
+//   - 网络抖动、限流、交易过期：重试
+//   - 质押余额不足、手续费不足、签名错误：委托账户自身的问题，补足资源或修正配置后重试
+//   - 接收方账户未激活：无法委托，标记待退款
+//   - 其他合约校验错误、远程签名服务按策略拒绝（如超过单笔上限）：标记委托失败
+//   - 非节点错误（数据库、配置等）：重试
+func classifyDelegationError(err error) failureAction {
+	switch {
+	case tron.IsRetryable(err),
+		errors.Is(err, tron.ErrInsufficientFrozenBalance),
+		errors.Is(err, tron.ErrInsufficientBalance),
+		errors.Is(err, tron.ErrSignature):
+		return actionRetry
+	case errors.Is(err, tron.ErrAccountNotActivated):
+		return actionRefund
+	case errors.Is(err, tron.ErrContractValidate),
+		errors.Is(err, tron.ErrSignerPolicy),
+		errors.Is(err, tron.ErrBadRequest):
+		return actionFail
+	default:
+		return actionRetry
+	}
+}
+
+// classifyReclaimError 根据回收失败的错误决定订单的处理方式，与 classifyDelegationError 相同只处理广播前的错误
+// 已委托余额不足说明资源已被回收或从未委托成功，重试也不会成功，标记回收失败；签名服务拒绝签名同样需人工处理
+func classifyReclaimError(err error) failureAction {
+	switch {
+	case tron.IsRetryable(err):
+		return actionRetry
+	case errors.Is(err, tron.ErrInsufficientDelegatedBalance),
+		errors.Is(err, tron.ErrContractValidate),
+		errors.Is(err, tron.ErrSignerPolicy),
+		errors.Is(err, tron.ErrBadRequest):
+		return actionFail
+	default:
+		return actionRetry
+	}
+}
@@ -0,0 +1,111 @@
+package cronjob
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"lending-trx/internal/address"
+	"lending-trx/internal/db"
+	"lending-trx/internal/tron"
+	"lending-trx/internal/units"
+)
+
+// defaultPaymentConfirmations 支付所在区块之后至少需要的区块数
+// 主网 27 个超级代表中 19 个确认后区块固化，与固化深度保持一致
+const defaultPaymentConfirmations = 19
+
+// paymentConfirmations 从环境变量获取支付确认深度，0 表示只要求区块已固化
+func paymentConfirmations() int64 {
+	if value := os.Getenv("PAYMENT_CONFIRMATIONS"); value != "" {
+		if depth, err := strconv.ParseInt(value, 10, 64); err == nil && depth >= 0 {
+			return depth
+		}
+	}
+	return defaultPaymentConfirmations
+}
+
+// chainHeads 本周期开始时的最新区块和最新固化区块高度
+type chainHeads struct {
+	latest     int64
+	solidified int64
+}
+
+// currentHeads 查询最新区块和最新固化区块
+func (c *CronJob) currentHeads() (chainHeads, error) {
+	latest, err := c.tronClient.GetNowBlock(c.ctx)
+	if err != nil {
+		return chainHeads{}, err
+	}
+	solidified, err := c.tronClient.GetSolidifiedNowBlock(c.ctx)
+	if err != nil {
+		return chainHeads{}, err
+	}
+	return chainHeads{latest: latest.Number(), solidified: solidified.Number()}, nil
+}
+
+// paymentConfirmed 支付所在区块是否已固化且距最新区块不少于 depth 个区块
+func paymentConfirmed(blockHeight int64, heads chainHeads, depth int64) bool {
+	return blockHeight <= heads.solidified && heads.latest-blockHeight >= depth
+}
+
+// paymentCanonical 支付所在区块是否仍在主链上：比对入库时的区块哈希与主链同高度区块的哈希
+// 没有记录区块哈希的历史订单视为在主链上；主链查不到该高度的区块时返回错误，下个周期重试
+func (c *CronJob) paymentCanonical(item *db.WebhookDataModel) (bool, error) {
+	if item.BlockHash == "" {
+		return true, nil
+	}
+	block, err := c.tronClient.GetBlockByNum(c.ctx, item.BlockHeight)
+	if err != nil {
+		return false, err
+	}
+	if !block.Found() {
+		return false, fmt.Errorf("block %d not found on node", item.BlockHeight)
+	}
+	return tron.SameBlockHash(block.BlockID, item.BlockHash), nil
+}
+
+// tokenPaymentVerified TRC20 支付按固化的交易回执（/walletsolidity/gettransactioninfobyid）核实：
+// receipt.result 为 SUCCESS，且有代币合约从付款方转入收款地址、数量与记录一致的 Transfer 事件
+// 推送中的 transfer 调用参数可能来自已回滚的调用，数据源也可能不提供执行结果，因此所有 TRC20 支付都要核实；TRX 支付不检查
+// 支付区块已固化但节点查不到回执时返回错误（节点落后），下个周期重试
+func (c *CronJob) tokenPaymentVerified(item *db.WebhookDataModel) (bool, error) {
+	if item.TokenContract == "" {
+		return true, nil
+	}
+	amount, err := units.ParseAmount(item.TokenAmount)
+	if err != nil {
+		return false, fmt.Errorf("invalid token amount %q: %w", item.TokenAmount, err)
+	}
+	contract, err := address.Normalize(item.TokenContract)
+	if err != nil {
+		return false, fmt.Errorf("invalid token contract %q: %w", item.TokenContract, err)
+	}
+	from, to, err := normalizePayment(item.FromAddress, item.ToAddress)
+	if err != nil {
+		return false, err
+	}
+
+	info, err := c.tronClient.GetSolidifiedTransactionInfo(c.ctx, strings.TrimPrefix(item.TxHash, "0x"))
+	if err != nil {
+		return false, err
+	}
+	if !info.Found() {
+		return false, fmt.Errorf("transaction %s not found on solidity node", item.TxHash)
+	}
+	return info.HasTRC20Transfer(contract, from, to, amount), nil
+}
+
+// normalizePayment 将付款方和收款地址统一为 Base58Check（兼容历史上以 0x 形式存储的记录）
+func normalizePayment(from, to string) (string, string, error) {
+	fromAddr, err := address.Normalize(from)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid payer address %q: %w", from, err)
+	}
+	toAddr, err := address.Normalize(to)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid receiving address %q: %w", to, err)
+	}
+	return fromAddr, toAddr, nil
+}
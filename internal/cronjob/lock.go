@@ -0,0 +1,53 @@
+package cronjob
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"lending-trx/internal/tron"
+)
+
+// errReclaimLocked 委托仍在锁定期内，锁定到期前不能回收，等待下个周期
+var errReclaimLocked = errors.New("reclaim locked")
+
+// delegationLockEnabled 是否按租期锁定委托（DELEGATION_LOCK，默认不锁定）
+func delegationLockEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("DELEGATION_LOCK"))
+	return enabled
+}
+
+// lockPeriodFor 计算委托的锁定期（区块数），锁定到订单到期为止
+//   - expireTime: 订单到期时间（秒级时间戳，见 db.WebhookDataModel.ExpireTime）
+//   - resources: 链上该接收方的现有委托，新的锁定会覆盖该资源全部锁定部分，锁定期不能短于现有锁定的剩余时间
+//   - resource: 委托的资源类型，能量和带宽的锁定分别计算
+//
+// 订单已到期时返回0（不锁定）
+func lockPeriodFor(expireTime int64, resources []tron.DelegatedResource, resource string, now time.Time) int64 {
+	remaining := time.Unix(expireTime, 0).Sub(now)
+	if remaining <= 0 {
+		return 0
+	}
+	for _, r := range resources {
+		expire := r.ExpireTimeForEnergy
+		if resource == tron.ResourceBandwidth {
+			expire = r.ExpireTimeForBandwidth
+		}
+		if expire == 0 {
+			continue
+		}
+		if locked := time.UnixMilli(expire).Sub(now); locked > remaining {
+			remaining = locked
+		}
+	}
+	return tron.LockPeriodForDuration(remaining)
+}
+
+// lockExpireTime 委托锁定到期时间（毫秒时间戳），blockTimeStamp 为委托交易所在区块时间（毫秒），未锁定返回0
+func lockExpireTime(blockTimeStamp int64, lockPeriod int64) int64 {
+	if lockPeriod <= 0 {
+		return 0
+	}
+	return blockTimeStamp + lockPeriod*tron.BlockInterval.Milliseconds()
+}
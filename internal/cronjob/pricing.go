@@ -0,0 +1,252 @@
+package cronjob
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"lending-trx/internal/address"
+	"lending-trx/internal/db"
+	"lending-trx/internal/tron"
+	"lending-trx/internal/units"
+)
+
+const (
+	// defaultDelegationBase 支付 1 TRX 对应的能量数量
+	defaultDelegationBase = 65000
+	// defaultMinDelegationBalance 链上单笔委托的最小质押金额（SUN），即 1 TRX
+	defaultMinDelegationBalance = units.SunPerTRX
+	// defaultEnergyPriceTTL 能量换算比例的缓存有效期
+	defaultEnergyPriceTTL = 10 * time.Minute
+)
+
+const (
+	// currencyTRX TRX 支付的币种
+	currencyTRX = "TRX"
+	// defaultTRC20Tokens 默认接受的 TRC20 代币：主网 USDT（6位小数）
+	defaultTRC20Tokens = "USDT:TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t:6"
+)
+
+// tiers 支付档位：支付金额（整币单位，如整 TRX / 整 USDT）→ 资源数量（能量或带宽）
+type tiers map[int64]int64
+
+// amountFor 支付金额（整币单位）对应的资源数量，不支持的金额返回0
+func (t tiers) amountFor(units int64) int64 {
+	return t[units]
+}
+
+// token 接受支付的 TRC20 代币
+type token struct {
+	Symbol   string // 币种，用于选择档位配置，如 USDT
+	Decimals int    // 小数位数
+}
+
+// parseTokens 解析 TRC20 代币配置，格式为逗号分隔的 "币种:合约地址:小数位数"，返回以合约地址（Base58Check）为键的代币
+func parseTokens(value string) (map[string]token, error) {
+	result := make(map[string]token)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid token %q, expected SYMBOL:contract:decimals", entry)
+		}
+		symbol := strings.ToUpper(strings.TrimSpace(parts[0]))
+		contract, err := address.Normalize(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid token contract %q: %w", parts[1], err)
+		}
+		decimals, err := strconv.Atoi(strings.TrimSpace(parts[2]))
+		if err != nil || decimals < 0 || decimals > 18 || symbol == "" || symbol == currencyTRX {
+			return nil, fmt.Errorf("invalid token %q, expected SYMBOL:contract:decimals", entry)
+		}
+		if _, ok := result[contract]; ok {
+			return nil, fmt.Errorf("duplicate token contract %s", contract)
+		}
+		result[contract] = token{Symbol: symbol, Decimals: decimals}
+	}
+	return result, nil
+}
+
+// trc20Tokens 从环境变量 TRC20_TOKENS 读取接受支付的 TRC20 代币，未设置时为主网 USDT
+func trc20Tokens() (map[string]token, error) {
+	value := os.Getenv("TRC20_TOKENS")
+	if value == "" {
+		value = defaultTRC20Tokens
+	}
+	tokens, err := parseTokens(value)
+	if err != nil {
+		return nil, fmt.Errorf("TRC20_TOKENS: %w", err)
+	}
+	return tokens, nil
+}
+
+// paymentOf 订单的支付币种和金额（整币单位，不足1个单位的部分舍去）
+// 金额按任意精度整数换算，TRC20 支付按 tokens 中的合约查找币种，未配置的代币返回空币种；整币金额超出 int64 时返回错误
+func paymentOf(data *db.WebhookDataModel, tokens map[string]token) (currency string, whole int64, err error) {
+	if data.TokenContract == "" {
+		value, err := units.ParseAmount(data.Value)
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid TRX amount %q: %w", data.Value, err)
+		}
+		if whole, err = value.Whole(units.TRXDecimals).Int64(); err != nil {
+			return "", 0, fmt.Errorf("TRX amount %s: %w", data.Value, err)
+		}
+		return currencyTRX, whole, nil
+	}
+
+	contract, err := address.Normalize(data.TokenContract)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid token contract %q: %w", data.TokenContract, err)
+	}
+	t, ok := tokens[contract]
+	if !ok {
+		return "", 0, nil
+	}
+	amount, err := units.ParseAmount(data.TokenAmount)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid token amount %q: %w", data.TokenAmount, err)
+	}
+	if whole, err = amount.Whole(t.Decimals).Int64(); err != nil {
+		return "", 0, fmt.Errorf("token amount %s %s: %w", data.TokenAmount, t.Symbol, err)
+	}
+	return t.Symbol, whole, nil
+}
+
+// parseSun 解析数据库中的 SUN 金额（十进制字符串），链上接口的金额为 int64，超出时返回错误
+func parseSun(value string) (int64, error) {
+	amount, err := units.ParseAmount(value)
+	if err != nil {
+		return 0, err
+	}
+	return amount.Int64()
+}
+
+// defaultEnergyTiers 默认能量档位
+//   - 1 TRX → base
+//   - 2 TRX → 2 * base
+func defaultEnergyTiers(base int64) tiers {
+	return tiers{1: base, 2: 2 * base}
+}
+
+// energyForPayment 默认能量档位下支付金额（SUN）对应的能量数量，按整 TRX 匹配（1.5 TRX 按 1 TRX 档位），不支持的金额返回0
+func energyForPayment(valueSun int64, base int64) int64 {
+	return defaultEnergyTiers(base).amountFor(valueSun / units.SunPerTRX)
+}
+
+// parseTiers 解析档位配置，格式为逗号分隔的 "金额:数量"（金额为整币单位），如 "1:65000,2:130000"
+func parseTiers(value string) (tiers, error) {
+	result := make(tiers)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid tier %q, expected units:amount", entry)
+		}
+		units, err1 := strconv.ParseInt(strings.TrimSpace(parts[0]), 10, 64)
+		amount, err2 := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
+		if err1 != nil || err2 != nil || units <= 0 || amount <= 0 {
+			return nil, fmt.Errorf("invalid tier %q, expected positive units:amount", entry)
+		}
+		if _, ok := result[units]; ok {
+			return nil, fmt.Errorf("duplicate tier for %d", units)
+		}
+		result[units] = amount
+	}
+	return result, nil
+}
+
+// paymentTiers 读取指定币种的能量和带宽档位
+//   - TRX: ENERGY_TIERS 能量档位，未设置时按 DELEGATION_BASE 使用默认档位；BANDWIDTH_TIERS 带宽档位，未设置时不出租带宽
+//   - 其他币种（如 USDT）: <币种>_ENERGY_TIERS / <币种>_BANDWIDTH_TIERS，未设置时不接受该币种
+//
+// 同一支付金额不能同时出现在两种资源的档位中
+func paymentTiers(currency string) (energy tiers, bandwidth tiers, err error) {
+	prefix := ""
+	if currency != currencyTRX {
+		prefix = currency + "_"
+	}
+
+	energy = tiers{}
+	if currency == currencyTRX {
+		energy = defaultEnergyTiers(delegationBase())
+	}
+	if value := os.Getenv(prefix + "ENERGY_TIERS"); value != "" {
+		if energy, err = parseTiers(value); err != nil {
+			return nil, nil, fmt.Errorf("%sENERGY_TIERS: %w", prefix, err)
+		}
+	}
+	if bandwidth, err = parseTiers(os.Getenv(prefix + "BANDWIDTH_TIERS")); err != nil {
+		return nil, nil, fmt.Errorf("%sBANDWIDTH_TIERS: %w", prefix, err)
+	}
+	for units := range bandwidth {
+		if _, ok := energy[units]; ok {
+			return nil, nil, fmt.Errorf("%d %s is configured for both energy and bandwidth", units, currency)
+		}
+	}
+	return energy, bandwidth, nil
+}
+
+// resourceForPayment 支付金额（整币单位）对应的资源类型和数量，不匹配任何档位时数量为0
+func resourceForPayment(units int64, energy, bandwidth tiers) (string, int64) {
+	if amount := energy.amountFor(units); amount > 0 {
+		return tron.ResourceEnergy, amount
+	}
+	if amount := bandwidth.amountFor(units); amount > 0 {
+		return tron.ResourceBandwidth, amount
+	}
+	return "", 0
+}
+
+// delegationBalance 计算获得指定数量的资源（能量或带宽）需要委托的质押金额（SUN）
+// 不足链上最小委托金额时按最小金额委托，保证用户至少获得购买的资源
+func delegationBalance(resource string, amount int64, price *tron.EnergyPrice, minBalance int64) (int64, error) {
+	balance, err := price.BalanceFor(resource, amount)
+	if err != nil {
+		return 0, err
+	}
+	if balance < minBalance {
+		balance = minBalance
+	}
+	return balance, nil
+}
+
+// delegationBase 从环境变量 DELEGATION_BASE 读取支付 1 TRX 对应的能量数量
+func delegationBase() int64 {
+	return int64FromEnv("DELEGATION_BASE", defaultDelegationBase)
+}
+
+// minDelegationBalance 从环境变量 MIN_DELEGATION_AMOUNT 读取最小委托金额（SUN）
+func minDelegationBalance() int64 {
+	return int64FromEnv("MIN_DELEGATION_AMOUNT", defaultMinDelegationBalance)
+}
+
+// energyPriceTTL 从环境变量 ENERGY_PRICE_CACHE_TTL 读取能量/带宽换算比例的缓存有效期
+func energyPriceTTL() time.Duration {
+	if value := os.Getenv("ENERGY_PRICE_CACHE_TTL"); value != "" {
+		if ttl, err := time.ParseDuration(value); err == nil {
+			return ttl
+		}
+	}
+	return defaultEnergyPriceTTL
+}
+
+// int64FromEnv 读取整数环境变量，未设置或格式错误时返回默认值
+func int64FromEnv(key string, defaultValue int64) int64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return parsed
+}
@@ -0,0 +1,78 @@
+package cronjob
+
+import (
+	"errors"
+	"fmt"
+
+	"lending-trx/internal/address"
+	"lending-trx/internal/db"
+	"lending-trx/internal/tron"
+)
+
+// errReclaimDeferred 同一接收方同一资源还有未确认的回收交易，剩余委托不足以回收本订单，等待下个周期
+var errReclaimDeferred = errors.New("reclaim deferred")
+
+// planReclaim 计算订单需要回收的质押金额
+//   - orderAmount: 订单委托的金额
+//   - outstanding: 链上该接收方该资源的剩余委托金额
+//   - reclaimable: 剩余委托中当前可回收的金额（未锁定或锁定已到期）
+//   - pending: 该接收方该资源已广播但未确认的回收金额
+//
+// 剩余委托扣除回收中的金额后足够时回收订单金额；不足且有回收中的交易时等待其确认；
+// 没有回收中的交易时只回收剩余部分（其余部分已被回收）；链上没有剩余委托时返回
+// tron.ErrInsufficientDelegatedBalance，由调用方标记回收失败人工核查。
+// 需要回收的金额仍在锁定期内时返回 errReclaimLocked，等待锁定到期
+func planReclaim(orderAmount, outstanding, reclaimable, pending int64) (int64, error) {
+	if outstanding-pending >= orderAmount {
+		if reclaimable-pending < orderAmount {
+			return 0, errReclaimLocked
+		}
+		return orderAmount, nil
+	}
+	if pending > 0 {
+		return 0, errReclaimDeferred
+	}
+	if outstanding > 0 {
+		if reclaimable < outstanding {
+			return 0, errReclaimLocked
+		}
+		return outstanding, nil
+	}
+	return 0, fmt.Errorf("no outstanding delegation for receiver: %w", tron.ErrInsufficientDelegatedBalance)
+}
+
+// pendingKey 回收中金额的汇总键，链上委托按 (接收方, 资源) 聚合
+func pendingKey(receiver, resource string) string {
+	return receiver + "/" + resource
+}
+
+// pendingReclaims 按接收方和资源汇总回收中 (status=4) 订单的回收金额，键见 pendingKey
+func (c *CronJob) pendingReclaims() (map[string]int64, error) {
+	reclaiming, err := db.QueryWebhookDataByStatus(c.ctx, c.pool, db.StatusReclaiming)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make(map[string]int64)
+	for _, item := range reclaiming {
+		receiver, err := address.Normalize(item.Receiver())
+		if err != nil {
+			continue
+		}
+		amount := item.ReclaimAmount
+		if amount == "" || amount == "0" {
+			// 旧记录没有回收金额，按委托金额计算
+			amount = item.DelegateAmount
+		}
+		value, err := parseSun(amount)
+		if err != nil {
+			continue
+		}
+		resource, err := tron.NormalizeResource(item.Resource)
+		if err != nil {
+			continue
+		}
+		pending[pendingKey(receiver, resource)] += value
+	}
+	return pending, nil
+}
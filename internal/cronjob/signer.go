@@ -0,0 +1,138 @@
+package cronjob
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"lending-trx/internal/tron"
+
+	"github.com/sunjiangjun/xlog"
+)
+
+// signerCheckTimeout 启动时检查签名权限的超时时间
+const signerCheckTimeout = 10 * time.Second
+
+// signerKeysFromEnv 读取签名私钥（十六进制）
+//   - DELEGATION_PRIVATE_KEYS: 逗号分隔的多个私钥，用于多签
+//
+// 未配置时回退到单个私钥 DELEGATION_PRIVATE_KEY
+func signerKeysFromEnv() []string {
+	var keys []string
+	for _, key := range strings.Split(os.Getenv("DELEGATION_PRIVATE_KEYS"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		if key := strings.TrimSpace(os.Getenv("DELEGATION_PRIVATE_KEY")); key != "" {
+			keys = []string{key}
+		}
+	}
+	return keys
+}
+
+// signersFromEnv 按环境变量创建签名者，多个来源同时配置时全部使用（多签）
+//   - SIGNER_URL: 逗号分隔的远程签名服务地址（http(s):// 或 unix://），SIGNER_AUTH_TOKEN 为认证令牌
+//   - DELEGATION_KEYSTORE: keystore 文件，密码为 DELEGATION_KEYSTORE_PASSWORD 或 DELEGATION_KEYSTORE_PASSWORD_FILE 指定的文件
+//   - DELEGATION_PRIVATE_KEYS / DELEGATION_PRIVATE_KEY: 进程内私钥
+func signersFromEnv(ctx context.Context) ([]tron.Signer, error) {
+	var signers []tron.Signer
+	for _, endpoint := range strings.Split(os.Getenv("SIGNER_URL"), ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint == "" {
+			continue
+		}
+		signer, err := tron.NewRemoteSigner(ctx, endpoint, os.Getenv("SIGNER_AUTH_TOKEN"))
+		if err != nil {
+			return nil, fmt.Errorf("remote signer %s: %w", endpoint, err)
+		}
+		signers = append(signers, signer)
+	}
+
+	if path := os.Getenv("DELEGATION_KEYSTORE"); path != "" {
+		password, err := tron.KeystorePasswordFromEnv("DELEGATION_KEYSTORE_PASSWORD")
+		if err != nil {
+			return nil, err
+		}
+		signer, err := tron.LoadKeystore(path, password)
+		if err != nil {
+			return nil, err
+		}
+		signers = append(signers, signer)
+	}
+
+	for i, key := range signerKeysFromEnv() {
+		signer, err := tron.ParseLocalSigner(key)
+		if err != nil {
+			return nil, fmt.Errorf("private key #%d: %w", i+1, err)
+		}
+		signers = append(signers, signer)
+	}
+	return signers, nil
+}
+
+// permissionIDFromEnv 读取签名使用的权限ID（DELEGATION_PERMISSION_ID），未配置时使用 owner 权限 (0)
+func permissionIDFromEnv() (int, error) {
+	value := os.Getenv("DELEGATION_PERMISSION_ID")
+	if value == "" {
+		return tron.OwnerPermissionID, nil
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("invalid DELEGATION_PERMISSION_ID %q", value)
+	}
+	return id, nil
+}
+
+// configureSigners 配置签名者和权限ID，并检查委托账户的权限是否允许这些签名者签署委托和回收交易
+// 配置错误或权限不足时只记录错误，委托/回收交易会在广播时被节点拒绝
+func configureSigners(ctx context.Context, log *xlog.XLog, tronClient tron.Client) {
+	permissionID, err := permissionIDFromEnv()
+	if err != nil {
+		log.Error("Failed to load delegation permission id", err)
+		return
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, signerCheckTimeout)
+	defer cancel()
+	signers, err := signersFromEnv(checkCtx)
+	if err != nil {
+		log.Error("Failed to load delegation signers", err)
+		return
+	}
+	if len(signers) == 0 {
+		log.Warn("No signer configured (SIGNER_URL / DELEGATION_KEYSTORE / DELEGATION_PRIVATE_KEY), delegation transactions cannot be signed")
+		return
+	}
+	if err := tronClient.UseSigners(signers, permissionID); err != nil {
+		log.Error("Failed to configure delegation signers", err)
+		return
+	}
+
+	from, err := getDelegationFromAddress()
+	if err != nil {
+		log.Warn("DELEGATION_FROM_ADDRESS not set, skipping signer permission check")
+		return
+	}
+	if permissionID == tron.OwnerPermissionID && len(signers) == 1 && from != tronClient.SignerAddress() {
+		log.Warn("Signer address does not match DELEGATION_FROM_ADDRESS",
+			"signer", tronClient.SignerAddress(), "delegation_from", from)
+	}
+
+	if err := tron.VerifySigners(checkCtx, tronClient, from); err != nil {
+		log.Error("Delegation signers cannot sign delegate/undelegate transactions", err,
+			"delegation_from", from,
+			"permission_id", permissionID,
+			"signers", strings.Join(tronClient.SignerAddresses(), ","),
+		)
+		return
+	}
+	log.Info("Delegation signers verified",
+		"delegation_from", from,
+		"permission_id", permissionID,
+		"signers", strings.Join(tronClient.SignerAddresses(), ","),
+	)
+}
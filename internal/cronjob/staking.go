@@ -0,0 +1,122 @@
+package cronjob
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"lending-trx/internal/tron"
+	"lending-trx/internal/units"
+)
+
+const (
+	// defaultStakingSchedule 质押管理任务的执行间隔
+	defaultStakingSchedule = "@every 10m"
+	// defaultStakingReserve 保留的可用余额（SUN），用于支付手续费
+	defaultStakingReserve = 100 * units.SunPerTRX
+	// defaultStakingMinFreeze 单次最少质押金额（SUN）
+	defaultStakingMinFreeze = 10 * units.SunPerTRX
+)
+
+// stakingEnabled 是否启用质押管理（STAKING_ENABLED，默认不启用）
+func stakingEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("STAKING_ENABLED"))
+	return enabled
+}
+
+// CheckStakingConfig 检查质押管理配置：远程签名服务只签署委托和回收交易，不能与 STAKING_ENABLED 同时使用；
+// STAKING_RESOURCE 只能为 ENERGY 或 BANDWIDTH
+func CheckStakingConfig() error {
+	if !stakingEnabled() {
+		return nil
+	}
+	if strings.TrimSpace(os.Getenv("SIGNER_URL")) != "" {
+		return fmt.Errorf("STAKING_ENABLED requires DELEGATION_PRIVATE_KEY(S) or DELEGATION_KEYSTORE, the remote signer (SIGNER_URL) only signs delegate/undelegate transactions")
+	}
+	if _, err := stakingResource(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// stakingResource 质押的资源类型（STAKING_RESOURCE），默认 ENERGY，出租带宽时配置为 BANDWIDTH
+func stakingResource() (string, error) {
+	resource, err := tron.NormalizeResource(os.Getenv("STAKING_RESOURCE"))
+	if err != nil {
+		return "", fmt.Errorf("invalid STAKING_RESOURCE: %w", err)
+	}
+	return resource, nil
+}
+
+// stakingSchedule 质押管理任务的执行间隔（STAKING_SCHEDULE）
+func stakingSchedule() string {
+	if schedule := os.Getenv("STAKING_SCHEDULE"); schedule != "" {
+		return schedule
+	}
+	return defaultStakingSchedule
+}
+
+// stakingConfig 从环境变量读取质押管理配置
+//   - STAKING_RESOURCE: 质押的资源类型，见 stakingResource
+//   - STAKING_RESERVE: 保留的可用余额（SUN）
+//   - STAKING_MIN_FREEZE: 单次最少质押金额（SUN）
+func stakingConfig(owner string) (tron.StakingConfig, error) {
+	resource, err := stakingResource()
+	if err != nil {
+		return tron.StakingConfig{}, err
+	}
+	return tron.StakingConfig{
+		Owner:     owner,
+		Resource:  resource,
+		Reserve:   int64FromEnv("STAKING_RESERVE", defaultStakingReserve),
+		MinFreeze: int64FromEnv("STAKING_MIN_FREEZE", defaultStakingMinFreeze),
+	}, nil
+}
+
+// newStakingManager 检查签名配置和委托账户权限能否签署质押、解质押、提取交易后创建质押管理
+func newStakingManager(ctx context.Context, tronClient tron.Client, owner string) (*tron.StakingManager, error) {
+	if err := CheckStakingConfig(); err != nil {
+		return nil, err
+	}
+	config, err := stakingConfig(owner)
+	if err != nil {
+		return nil, err
+	}
+	checkCtx, cancel := context.WithTimeout(ctx, signerCheckTimeout)
+	defer cancel()
+	if err := tron.VerifySigners(checkCtx, tronClient, owner, tron.StakingContractTypes...); err != nil {
+		return nil, fmt.Errorf("signers cannot sign staking transactions: %w", err)
+	}
+	return tron.NewStakingManager(tronClient, config), nil
+}
+
+// processStaking 质押管理：闲置余额超出保留余额时质押为 STAKING_RESOURCE，可用余额不足时解质押，提取到期的解质押
+func (c *CronJob) processStaking() {
+	if c.staking == nil {
+		return
+	}
+
+	result, err := c.staking.Run(c.ctx, time.Now())
+	if err != nil {
+		c.log.Error("Failed to manage delegation account staking", err, "owner", c.staking.Config().Owner)
+		return
+	}
+
+	plan := result.Plan
+	if !plan.Withdraw && plan.Freeze == 0 && plan.Unfreeze == 0 {
+		return
+	}
+	c.log.Info("Delegation account staking updated",
+		"owner", c.staking.Config().Owner,
+		"resource", c.staking.Config().Resource,
+		"reserve", c.staking.Config().Reserve,
+		"withdraw_tx_id", result.WithdrawTxID,
+		"freeze", plan.Freeze,
+		"freeze_tx_id", result.FreezeTxID,
+		"unfreeze", plan.Unfreeze,
+		"unfreeze_tx_id", result.UnfreezeTxID,
+	)
+}
@@ -0,0 +1,274 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const createWebhookArchiveTableSQL = `
+CREATE TABLE IF NOT EXISTS webhook_archive (
+  id BIGSERIAL PRIMARY KEY,
+  receive_time TIMESTAMP NOT NULL DEFAULT NOW(),
+  provider VARCHAR(32) NOT NULL DEFAULT 'quicknode',
+  remote_addr VARCHAR(64),
+  headers JSONB,
+  body BYTEA NOT NULL,
+  body_size INT NOT NULL,
+  replay_count INT NOT NULL DEFAULT 0,
+  last_replay_time TIMESTAMP,
+  ingest_status VARCHAR(16) NOT NULL DEFAULT 'pending',
+  attempts INT NOT NULL DEFAULT 0,
+  available_time TIMESTAMP NOT NULL DEFAULT NOW(),
+  claim_time TIMESTAMP,
+  processed_time TIMESTAMP,
+  inserted_count INT NOT NULL DEFAULT 0,
+  skipped_count INT NOT NULL DEFAULT 0,
+  last_error TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_archive_receive_time ON webhook_archive (receive_time);
+CREATE INDEX IF NOT EXISTS idx_webhook_archive_queue ON webhook_archive (available_time)
+  WHERE ingest_status IN ('pending', 'processing');`
+
+// webhook_archive 的 ingest_status，推送保存后由后台处理队列解析写入
+const (
+	IngestPending    = "pending"    // 等待处理（包括失败后等待重试）
+	IngestProcessing = "processing" // 处理中
+	IngestDone       = "done"       // 已写入
+	IngestFailed     = "failed"     // 无法解析或重试次数用尽
+)
+
+// webhookArchiveColumns 查询 webhook_archive 的列，与 scanWebhookArchive 对应
+const webhookArchiveColumns = `id, receive_time, provider, COALESCE(remote_addr, ''), COALESCE(headers::TEXT, '{}'), body, body_size,
+	replay_count, last_replay_time, ingest_status, attempts, processed_time, inserted_count, skipped_count,
+	COALESCE(last_error, '')`
+
+// WebhookArchive 原始 webhook 推送，请求体 gzip 压缩后保存，用于修复解析逻辑后重新处理
+type WebhookArchive struct {
+	ID             int64           `json:"id"`
+	ReceiveTime    time.Time       `json:"receive_time"`     // 接收时间
+	Provider       string          `json:"provider"`         // 推送数据源，决定解析时使用的适配器
+	RemoteAddr     string          `json:"remote_addr"`      // 推送方地址
+	Headers        json.RawMessage `json:"headers"`          // 请求头（JSON）
+	Body           []byte          `json:"-"`                // gzip 压缩的请求体
+	BodySize       int             `json:"body_size"`        // 压缩前的请求体大小
+	ReplayCount    int             `json:"replay_count"`     // 已重放次数
+	LastReplayTime *time.Time      `json:"last_replay_time"` // 最近一次重放时间，未重放为空
+	IngestStatus   string          `json:"ingest_status"`    // 处理状态，见 Ingest* 常量
+	Attempts       int             `json:"attempts"`         // 已尝试处理的次数
+	ProcessedTime  *time.Time      `json:"processed_time"`   // 处理完成（写入或最终失败）时间
+	InsertedCount  int             `json:"inserted_count"`   // 写入的支付数
+	SkippedCount   int             `json:"skipped_count"`    // 跳过的交易数
+	LastError      string          `json:"last_error"`       // 最近一次处理失败的原因
+}
+
+// IngestQueueDepth 处理队列的积压情况
+type IngestQueueDepth struct {
+	Pending       int64      `json:"pending"`        // 等待处理
+	Processing    int64      `json:"processing"`     // 处理中
+	Failed        int64      `json:"failed"`         // 最终失败，需修复后重放
+	OldestPending *time.Time `json:"oldest_pending"` // 最早的未处理推送的接收时间，队列为空时为空
+}
+
+// ArchiveFilter 选择要重放的推送，IDs 和时间范围同时指定时需同时满足
+type ArchiveFilter struct {
+	IDs   []int64   // 指定的推送ID
+	Since time.Time // 接收时间不早于 Since，零值不限制
+	Until time.Time // 接收时间早于 Until，零值不限制
+	Limit int       // 最多返回的记录数，0 不限制
+}
+
+// InsertWebhookArchive 保存原始推送并加入处理队列，接收时间为数据库当前时间，返回记录ID
+func InsertWebhookArchive(ctx context.Context, pool *pgxpool.Pool, archive *WebhookArchive) (int64, error) {
+	query := `
+		INSERT INTO webhook_archive (receive_time, provider, remote_addr, headers, body, body_size)
+		VALUES (NOW(), $1, $2, $3, $4, $5)
+		RETURNING id
+	`
+	var id int64
+	err := pool.QueryRow(ctx, query, archive.Provider, archive.RemoteAddr, string(archive.Headers), archive.Body, archive.BodySize).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert webhook archive: %w", err)
+	}
+	return id, nil
+}
+
+// ListWebhookArchives 按条件查询原始推送，按ID升序返回
+func ListWebhookArchives(ctx context.Context, pool *pgxpool.Pool, filter ArchiveFilter) ([]*WebhookArchive, error) {
+	var conditions []string
+	var args []interface{}
+	if len(filter.IDs) > 0 {
+		args = append(args, filter.IDs)
+		conditions = append(conditions, fmt.Sprintf("id = ANY($%d)", len(args)))
+	}
+	if !filter.Since.IsZero() {
+		args = append(args, filter.Since)
+		conditions = append(conditions, fmt.Sprintf("receive_time >= $%d", len(args)))
+	}
+	if !filter.Until.IsZero() {
+		args = append(args, filter.Until)
+		conditions = append(conditions, fmt.Sprintf("receive_time < $%d", len(args)))
+	}
+
+	query := `SELECT ` + webhookArchiveColumns + ` FROM webhook_archive`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id ASC"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	rows, err := pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook archives: %w", err)
+	}
+
+	return collectWebhookArchives(rows)
+}
+
+// GetWebhookArchive 按ID查询原始推送及其处理状态，不存在时返回 nil
+func GetWebhookArchive(ctx context.Context, pool *pgxpool.Pool, id int64) (*WebhookArchive, error) {
+	rows, err := pool.Query(ctx, `SELECT `+webhookArchiveColumns+` FROM webhook_archive WHERE id = $1`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook archive: %w", err)
+	}
+	archives, err := collectWebhookArchives(rows)
+	if err != nil || len(archives) == 0 {
+		return nil, err
+	}
+	return archives[0], nil
+}
+
+// ClaimWebhookArchive 领取一条待处理的推送并标记为处理中，没有可处理的推送时返回 nil
+// 处理中超过 claimTimeout 的推送（处理进程退出）视为待处理，多个进程并发领取时不会领到同一条
+func ClaimWebhookArchive(ctx context.Context, pool *pgxpool.Pool, claimTimeout time.Duration) (*WebhookArchive, error) {
+	query := `
+		UPDATE webhook_archive
+		SET ingest_status = 'processing', claim_time = NOW(), attempts = attempts + 1
+		WHERE id = (
+			SELECT id FROM webhook_archive
+			WHERE (ingest_status = 'pending' AND available_time <= NOW())
+			   OR (ingest_status = 'processing' AND claim_time < NOW() - make_interval(secs => $1))
+			ORDER BY id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + webhookArchiveColumns
+	rows, err := pool.Query(ctx, query, claimTimeout.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook archive: %w", err)
+	}
+	archives, err := collectWebhookArchives(rows)
+	if err != nil || len(archives) == 0 {
+		return nil, err
+	}
+	return archives[0], nil
+}
+
+// CompleteWebhookArchive 记录推送已写入
+func CompleteWebhookArchive(ctx context.Context, pool *pgxpool.Pool, id int64, inserted, skipped int) error {
+	query := `
+		UPDATE webhook_archive
+		SET ingest_status = 'done', processed_time = NOW(), inserted_count = $2, skipped_count = $3, last_error = NULL
+		WHERE id = $1
+	`
+	if _, err := pool.Exec(ctx, query, id, inserted, skipped); err != nil {
+		return fmt.Errorf("failed to complete webhook archive: %w", err)
+	}
+	return nil
+}
+
+// RetryWebhookArchive 记录处理失败，delay 之后重新处理
+func RetryWebhookArchive(ctx context.Context, pool *pgxpool.Pool, id int64, reason string, delay time.Duration) error {
+	query := `
+		UPDATE webhook_archive
+		SET ingest_status = 'pending', available_time = NOW() + make_interval(secs => $3), last_error = $2
+		WHERE id = $1
+	`
+	if _, err := pool.Exec(ctx, query, id, reason, delay.Seconds()); err != nil {
+		return fmt.Errorf("failed to retry webhook archive: %w", err)
+	}
+	return nil
+}
+
+// FailWebhookArchive 记录推送最终处理失败，不再自动重试，修复后可重放
+func FailWebhookArchive(ctx context.Context, pool *pgxpool.Pool, id int64, reason string) error {
+	query := `
+		UPDATE webhook_archive
+		SET ingest_status = 'failed', processed_time = NOW(), last_error = $2
+		WHERE id = $1
+	`
+	if _, err := pool.Exec(ctx, query, id, reason); err != nil {
+		return fmt.Errorf("failed to mark webhook archive failed: %w", err)
+	}
+	return nil
+}
+
+// GetIngestQueueDepth 查询处理队列的积压情况
+func GetIngestQueueDepth(ctx context.Context, pool *pgxpool.Pool) (*IngestQueueDepth, error) {
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE ingest_status = 'pending'),
+			COUNT(*) FILTER (WHERE ingest_status = 'processing'),
+			COUNT(*) FILTER (WHERE ingest_status = 'failed'),
+			MIN(receive_time) FILTER (WHERE ingest_status IN ('pending', 'processing'))
+		FROM webhook_archive
+	`
+	var depth IngestQueueDepth
+	if err := pool.QueryRow(ctx, query).Scan(&depth.Pending, &depth.Processing, &depth.Failed, &depth.OldestPending); err != nil {
+		return nil, fmt.Errorf("failed to query ingest queue depth: %w", err)
+	}
+	return &depth, nil
+}
+
+// collectWebhookArchives 读取 webhookArchiveColumns 查询结果
+func collectWebhookArchives(rows pgx.Rows) ([]*WebhookArchive, error) {
+	defer rows.Close()
+
+	var result []*WebhookArchive
+	for rows.Next() {
+		var a WebhookArchive
+		var headers string
+		if err := rows.Scan(&a.ID, &a.ReceiveTime, &a.Provider, &a.RemoteAddr, &headers, &a.Body, &a.BodySize,
+			&a.ReplayCount, &a.LastReplayTime, &a.IngestStatus, &a.Attempts, &a.ProcessedTime,
+			&a.InsertedCount, &a.SkippedCount, &a.LastError); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook archive: %w", err)
+		}
+		a.Headers = json.RawMessage(headers)
+		result = append(result, &a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while iterating rows: %w", err)
+	}
+	return result, nil
+}
+
+// MarkWebhookArchiveReplayed 记录推送已重放，最终处理失败的推送重放成功后标记为已写入
+func MarkWebhookArchiveReplayed(ctx context.Context, pool *pgxpool.Pool, id int64) error {
+	query := `
+		UPDATE webhook_archive
+		SET replay_count = replay_count + 1, last_replay_time = NOW(),
+		    ingest_status = CASE WHEN ingest_status = 'failed' THEN 'done' ELSE ingest_status END
+		WHERE id = $1
+	`
+	if _, err := pool.Exec(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to mark webhook archive replayed: %w", err)
+	}
+	return nil
+}
+
+// DeleteWebhookArchivesBefore 删除 before 之前接收且已处理完成的推送，返回删除的记录数
+func DeleteWebhookArchivesBefore(ctx context.Context, pool *pgxpool.Pool, before time.Time) (int64, error) {
+	query := `DELETE FROM webhook_archive WHERE receive_time < $1 AND ingest_status IN ('done', 'failed')`
+	tag, err := pool.Exec(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete webhook archives: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -10,40 +11,119 @@ import (
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"lending-trx/internal/units"
 )
 
 // WebhookDataModel 用于表示 webhook_data 表结构
-// 注意：Value 用 string 以兼容大数，时间戳用 int64
+// 注意：金额用 string 以兼容大数（NUMERIC 列，读写时用 units.Amount 校验），时间戳用 int64
 // create_time, update_time 用 string（或 time.Time，视序列化需求）
 type WebhookDataModel struct {
-	ID           int64  `json:"id"`             // 主键唯一ID
-	BlockHeight  int64  `json:"block_height"`   // 区块高度
-	TxHash       string `json:"tx_hash"`        // 交易哈希
-	FromAddress  string `json:"from_address"`   // 发送方地址
-	ToAddress    string `json:"to_address"`     // 接收方地址
-	Value        string `json:"value"`          // 交易金额（大整数，字符串存储）
-	BlockTime    int64  `json:"block_time"`     // 区块时间（毫秒时间戳）
-	CreateTime   string `json:"create_time"`    // 创建时间
-	UpdateTime   string `json:"update_time"`    // 更新时间
-	ExpireTime   int64  `json:"expire_time"`    // 有效期（毫秒时间戳）
-	Status       int16  `json:"status"`         // 状态（0:初始化，1:执行中，2:已授权，3:已回收）
-	OriginalTxID string `json:"original_tx_id"` // 原始委托交易ID
+	ID             int64  `json:"id"`               // 主键唯一ID
+	BlockHeight    int64  `json:"block_height"`     // 区块高度
+	BlockHash      string `json:"block_hash"`       // 区块哈希，委托前与主链比对，不一致说明支付已被重组回滚
+	TxHash         string `json:"tx_hash"`          // 交易哈希
+	FromAddress    string `json:"from_address"`     // 发送方地址
+	ToAddress      string `json:"to_address"`       // 接收方地址
+	Value          string `json:"value"`            // 交易金额（大整数，字符串存储）
+	TokenContract  string `json:"token_contract"`   // TRC20 支付的代币合约地址，TRX 支付为空
+	TokenAmount    string `json:"token_amount"`     // TRC20 支付的代币数量（最小单位，大整数），TRX 支付为空
+	Beneficiary    string `json:"beneficiary"`      // 交易备注中指定的受益地址，为空时委托给付款方
+	BlockTime      int64  `json:"block_time"`       // 区块时间（秒级时间戳）
+	CreateTime     string `json:"create_time"`      // 创建时间
+	UpdateTime     string `json:"update_time"`      // 更新时间
+	ExpireTime     int64  `json:"expire_time"`      // 有效期（秒级时间戳），区块时间后1小时
+	Status         int16  `json:"status"`           // 状态，见 Status* 常量
+	OriginalTxID   string `json:"original_tx_id"`   // 原始委托交易ID
+	Resource       string `json:"resource"`         // 租用的资源类型 ENERGY / BANDWIDTH，委托时按支付档位确定，历史订单为 ENERGY
+	DelegateAmount string `json:"delegate_amount"`  // 实际委托的质押金额（SUN），回收时按此金额 undelegate
+	LockPeriod     int64  `json:"lock_period"`      // 委托锁定期（区块数），0 表示未锁定
+	LockExpireTime int64  `json:"lock_expire_time"` // 委托锁定到期时间（毫秒时间戳），到期前不能回收
+	// DelegateTxExpiration / ReclaimTxExpiration 委托、回收交易的过期时间（毫秒时间戳），过期前交易仍可能上链
+	DelegateTxExpiration int64 `json:"delegate_tx_expiration"`
+	ReclaimTxExpiration  int64 `json:"reclaim_tx_expiration"`
+	// 链上确认信息
+	DelegateBlockNumber int64     `json:"delegate_block_number"` // 委托交易所在区块
+	DelegateFee         int64     `json:"delegate_fee"`          // 委托交易手续费（SUN）
+	DelegateNetUsage    int64     `json:"delegate_net_usage"`    // 委托交易消耗的带宽
+	DelegateNetFee      int64     `json:"delegate_net_fee"`      // 委托交易带宽不足时燃烧的 TRX（SUN）
+	DelegateEnergyFee   int64     `json:"delegate_energy_fee"`   // 委托交易能量不足时燃烧的 TRX（SUN）
+	DelegateResult      string    `json:"delegate_result"`       // 委托交易回执结果
+	ReclaimTxID         string    `json:"reclaim_tx_id"`         // 回收交易ID
+	ReclaimAmount       string    `json:"reclaim_amount"`        // 回收交易 undelegate 的质押金额（SUN）
+	ReclaimBlockNumber  int64     `json:"reclaim_block_number"`  // 回收交易所在区块
+	ReclaimFee          int64     `json:"reclaim_fee"`           // 回收交易手续费（SUN）
+	ReclaimNetUsage     int64     `json:"reclaim_net_usage"`     // 回收交易消耗的带宽
+	ReclaimNetFee       int64     `json:"reclaim_net_fee"`       // 回收交易带宽不足时燃烧的 TRX（SUN）
+	ReclaimEnergyFee    int64     `json:"reclaim_energy_fee"`    // 回收交易能量不足时燃烧的 TRX（SUN）
+	ReclaimResult       string    `json:"reclaim_result"`        // 回收交易回执结果
+	UpdatedAt           time.Time `json:"-"`                     // update_time 原始值，用于判断交易广播是否超时
+}
+
+// webhook_data 状态
+const (
+	StatusInit           int16 = 0  // 初始化，等待委托
+	StatusProcessing     int16 = 1  // 执行中，委托交易已广播，等待链上固化
+	StatusAuthorized     int16 = 2  // 已授权，委托交易已固化
+	StatusReclaimed      int16 = 3  // 已回收，回收交易已固化
+	StatusReclaiming     int16 = 4  // 回收中，回收交易已广播，等待链上固化
+	StatusDelegateFailed int16 = 5  // 委托失败，回执显示交易执行失败
+	StatusReclaimFailed  int16 = 6  // 回收失败，回执显示交易执行失败
+	StatusSkipped        int16 = 7  // 已跳过，金额不满足委托条件
+	StatusRefundRequired int16 = 8  // 待退款，订单无法履约（如接收方账户未激活）
+	StatusOrphaned       int16 = 9  // 已回滚，支付所在区块已不在主链上，不委托
+	StatusPaymentInvalid int16 = 10 // 支付无效，TRC20 交易回执显示执行失败或没有匹配的 Transfer 事件，不委托
+)
+
+// ErrStatusChanged 订单状态已被其他进程修改，本次更新未生效
+var ErrStatusChanged = errors.New("webhook data status changed")
+
+// Receiver 资源委托的接收方：交易备注指定了受益地址时为受益地址，否则为付款方
+func (d *WebhookDataModel) Receiver() string {
+	if d.Beneficiary != "" {
+		return d.Beneficiary
+	}
+	return d.FromAddress
 }
 
 const createWebhookTableSQL = `
 CREATE TABLE IF NOT EXISTS webhook_data (
   id SERIAL PRIMARY KEY,
   block_height BIGINT,
+  block_hash VARCHAR(128),
   tx_hash VARCHAR(128) UNIQUE,
   from_address VARCHAR(128),
   to_address VARCHAR(128),
   value NUMERIC(36,0),
+  token_contract VARCHAR(64),
+  token_amount NUMERIC(78,0),
+  beneficiary VARCHAR(64),
   block_time BIGINT,
   create_time TIMESTAMP NOT NULL DEFAULT NOW(),
   update_time TIMESTAMP NOT NULL DEFAULT NOW(),
   expire_time BIGINT,
   status SMALLINT,
-  original_tx_id VARCHAR(255) UNIQUE
+  original_tx_id VARCHAR(255) UNIQUE,
+  resource VARCHAR(16),
+  delegate_amount NUMERIC(36,0),
+  lock_period BIGINT,
+  lock_expire_time BIGINT,
+  delegate_tx_expiration BIGINT,
+  delegate_block_number BIGINT,
+  delegate_fee BIGINT,
+  delegate_net_usage BIGINT,
+  delegate_net_fee BIGINT,
+  delegate_energy_fee BIGINT,
+  delegate_result VARCHAR(255),
+  reclaim_tx_id VARCHAR(255) UNIQUE,
+  reclaim_amount NUMERIC(36,0),
+  reclaim_tx_expiration BIGINT,
+  reclaim_block_number BIGINT,
+  reclaim_fee BIGINT,
+  reclaim_net_usage BIGINT,
+  reclaim_net_fee BIGINT,
+  reclaim_energy_fee BIGINT,
+  reclaim_result VARCHAR(255)
 );`
 
 const createLogTableSQL = `
@@ -92,6 +172,18 @@ func InitDB(ctx context.Context) (*pgxpool.Pool, error) {
 		pool.Close()
 		return nil, fmt.Errorf("创建 logs 表失败: %w", err)
 	}
+	if _, err := pool.Exec(ctx, createScanCursorTableSQL); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("创建 scan_cursor 表失败: %w", err)
+	}
+	if _, err := pool.Exec(ctx, createStreamBatchesTableSQL); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("创建 stream_batches 表失败: %w", err)
+	}
+	if _, err := pool.Exec(ctx, createWebhookArchiveTableSQL); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("创建 webhook_archive 表失败: %w", err)
+	}
 	return pool, nil
 }
 
@@ -114,7 +206,7 @@ func UpdateWebhookStatus(ctx context.Context, pool *pgxpool.Pool, ids []int64, s
 	return err
 }
 
-// UpdateWebhookStatusAndExpireTime 批量更新指定 id 的 status 和 expire_time
+// UpdateWebhookStatusAndExpireTime 批量更新指定 id 的 status 和 expire_time（秒级时间戳）
 func UpdateWebhookStatusAndExpireTime(ctx context.Context, pool *pgxpool.Pool, ids []int64, status int16, expireTime int64) error {
 	if len(ids) == 0 {
 		return nil
@@ -133,42 +225,87 @@ func UpdateWebhookStatusAndExpireTime(ctx context.Context, pool *pgxpool.Pool, i
 	return err
 }
 
-// BatchInsertWebhookData 批量插入 webhook_data 记录
-func BatchInsertWebhookData(ctx context.Context, pool *pgxpool.Pool, data []*WebhookDataModel) error {
-	if len(data) == 0 {
-		return nil
-	}
+// webhookInsertColumns 批量插入 webhook_data 时的列，需与 batchInsertWebhookDataQuery 的参数顺序一致
+const webhookInsertColumns = "block_height, block_hash, tx_hash, from_address, to_address, value, token_contract, token_amount, beneficiary, block_time, expire_time, status, create_time"
+
+// batchInsertWebhookDataQuery 构建批量插入语句和参数，tx_hash 已存在的记录忽略
+// TRX 支付的 token_contract / token_amount、没有受益地址的 beneficiary 存为 NULL
+// 金额按任意精度整数校验，不是非负整数或超出列精度时返回错误，不写入截断的金额
+// 金额已在分类时逐笔校验（见 webhook.ClassifyParsed），这里的检查只作为兜底
+func batchInsertWebhookDataQuery(data []*WebhookDataModel) (string, []interface{}, error) {
+	const columnCount = 13
 	valueStrings := make([]string, 0, len(data))
-	valueArgs := make([]interface{}, 0, len(data)*9)
+	valueArgs := make([]interface{}, 0, len(data)*columnCount)
 	for i, d := range data {
-		idx := i * 9
-		valueStrings = append(valueStrings, fmt.Sprintf("($%d,$%d,$%d,$%d,$%d,$%d,$%d,$%d,$%d)",
-			idx+1, idx+2, idx+3, idx+4, idx+5, idx+6, idx+7, idx+8, idx+9))
+		placeholders := make([]string, columnCount)
+		for j := range placeholders {
+			placeholders[j] = fmt.Sprintf("$%d", i*columnCount+j+1)
+		}
+		valueStrings = append(valueStrings, "("+strings.Join(placeholders, ",")+")")
+
+		value, err := numericArg(d.Value, units.AmountPrecision)
+		if err != nil {
+			return "", nil, fmt.Errorf("tx %s value: %w", d.TxHash, err)
+		}
+		var tokenContract, tokenAmount, beneficiary interface{}
+		if d.TokenContract != "" {
+			tokenContract = d.TokenContract
+			if tokenAmount, err = numericArg(d.TokenAmount, units.TokenAmountPrecision); err != nil {
+				return "", nil, fmt.Errorf("tx %s token amount: %w", d.TxHash, err)
+			}
+		}
+		if d.Beneficiary != "" {
+			beneficiary = d.Beneficiary
+		}
 		valueArgs = append(valueArgs,
-			d.BlockHeight, d.TxHash, d.FromAddress, d.ToAddress, d.Value, d.BlockTime, d.ExpireTime, d.Status, time.Now())
+			d.BlockHeight, d.BlockHash, d.TxHash, d.FromAddress, d.ToAddress, value, tokenContract, tokenAmount, beneficiary, d.BlockTime, d.ExpireTime, d.Status, time.Now())
 	}
-	query := "INSERT INTO webhook_data (block_height, tx_hash, from_address, to_address, value, block_time, expire_time, status, create_time) VALUES " + strings.Join(valueStrings, ",") + " ON CONFLICT (tx_hash) DO NOTHING"
-	_, err := pool.Exec(ctx, query, valueArgs...)
-	return err
+	query := "INSERT INTO webhook_data (" + webhookInsertColumns + ") VALUES " + strings.Join(valueStrings, ",") + " ON CONFLICT (tx_hash) DO NOTHING"
+	return query, valueArgs, nil
 }
 
-// BatchInsertWebhookDataTx 批量插入 webhook_data 记录（事务版本）
-func BatchInsertWebhookDataTx(ctx context.Context, tx pgx.Tx, data []*WebhookDataModel) error {
+// numericArg 将十进制金额字符串转换为 NUMERIC(precision,0) 列的参数
+func numericArg(value string, precision int) (units.Amount, error) {
+	amount, err := units.ParseAmount(value)
+	if err != nil {
+		return units.Amount{}, err
+	}
+	if err := amount.CheckPrecision(precision); err != nil {
+		return units.Amount{}, err
+	}
+	return amount, nil
+}
+
+// BatchInsertWebhookData 批量插入 webhook_data 记录，返回实际写入的记录数，tx_hash 已存在的记录不计入
+func BatchInsertWebhookData(ctx context.Context, pool *pgxpool.Pool, data []*WebhookDataModel) (int64, error) {
 	if len(data) == 0 {
-		return nil
+		return 0, nil
 	}
-	valueStrings := make([]string, 0, len(data))
-	valueArgs := make([]interface{}, 0, len(data)*9)
-	for i, d := range data {
-		idx := i * 9
-		valueStrings = append(valueStrings, fmt.Sprintf("($%d,$%d,$%d,$%d,$%d,$%d,$%d,$%d,$%d)",
-			idx+1, idx+2, idx+3, idx+4, idx+5, idx+6, idx+7, idx+8, idx+9))
-		valueArgs = append(valueArgs,
-			d.BlockHeight, d.TxHash, d.FromAddress, d.ToAddress, d.Value, d.BlockTime, d.ExpireTime, d.Status, time.Now())
+	query, valueArgs, err := batchInsertWebhookDataQuery(data)
+	if err != nil {
+		return 0, err
 	}
-	query := "INSERT INTO webhook_data (block_height, tx_hash, from_address, to_address, value, block_time, expire_time, status, create_time) VALUES " + strings.Join(valueStrings, ",") + " ON CONFLICT (tx_hash) DO NOTHING"
-	_, err := tx.Exec(ctx, query, valueArgs...)
-	return err
+	tag, err := pool.Exec(ctx, query, valueArgs...)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// BatchInsertWebhookDataTx 批量插入 webhook_data 记录（事务版本），返回实际写入的记录数
+func BatchInsertWebhookDataTx(ctx context.Context, tx pgx.Tx, data []*WebhookDataModel) (int64, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+	query, valueArgs, err := batchInsertWebhookDataQuery(data)
+	if err != nil {
+		return 0, err
+	}
+	tag, err := tx.Exec(ctx, query, valueArgs...)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
 }
 
 // UpdateWebhookStatusTx 批量更新指定 id 的 status（事务版本）
@@ -190,11 +327,25 @@ func UpdateWebhookStatusTx(ctx context.Context, tx pgx.Tx, ids []int64, status i
 	return err
 }
 
+// webhookDataColumns 查询 webhook_data 时的列顺序，需与 scanWebhookDataRows 保持一致
+const webhookDataColumns = `id, block_height, tx_hash, from_address, to_address, value,
+		       block_time, create_time, update_time, expire_time, status, original_tx_id,
+		       COALESCE(delegate_amount, 0)::TEXT,
+		       COALESCE(delegate_block_number, 0), COALESCE(delegate_fee, 0), COALESCE(delegate_result, ''),
+		       COALESCE(reclaim_tx_id, ''), COALESCE(reclaim_block_number, 0), COALESCE(reclaim_fee, 0),
+		       COALESCE(reclaim_result, ''), COALESCE(reclaim_amount, 0)::TEXT,
+		       COALESCE(lock_period, 0), COALESCE(lock_expire_time, 0),
+		       COALESCE(resource, 'ENERGY'),
+		       COALESCE(token_contract, ''), COALESCE(token_amount, 0)::TEXT,
+		       COALESCE(block_hash, ''), COALESCE(beneficiary, ''),
+		       COALESCE(delegate_net_usage, 0), COALESCE(delegate_net_fee, 0), COALESCE(delegate_energy_fee, 0),
+		       COALESCE(reclaim_net_usage, 0), COALESCE(reclaim_net_fee, 0), COALESCE(reclaim_energy_fee, 0),
+		       COALESCE(delegate_tx_expiration, 0), COALESCE(reclaim_tx_expiration, 0)`
+
 // QueryPendingWebhookData 查询待处理的数据 (status=0)
 func QueryPendingWebhookData(ctx context.Context, pool *pgxpool.Pool) ([]*WebhookDataModel, error) {
 	query := `
-		SELECT id, block_height, tx_hash, from_address, to_address, value, 
-		       block_time, create_time, update_time, expire_time, status, original_tx_id 
+		SELECT ` + webhookDataColumns + `
 		FROM webhook_data 
 		WHERE status=0
 		ORDER BY create_time ASC
@@ -203,12 +354,11 @@ func QueryPendingWebhookData(ctx context.Context, pool *pgxpool.Pool) ([]*Webhoo
 	return queryWebhookData(ctx, pool, query)
 }
 
-// QueryExpiredWebhookData 查询已过期且已授权的数据 (status=2)
+// QueryExpiredWebhookData 查询已过期且已授权的数据 (status=2)，expire_time 为秒级时间戳
 func QueryExpiredWebhookData(ctx context.Context, pool *pgxpool.Pool) ([]*WebhookDataModel, error) {
-	now := time.Now().UnixMilli()
+	now := time.Now().Unix()
 	query := `
-		SELECT id, block_height, tx_hash, from_address, to_address, value, 
-		       block_time, create_time, update_time, expire_time, status, original_tx_id 
+		SELECT ` + webhookDataColumns + `
 		FROM webhook_data 
 		WHERE status=2 AND expire_time < $1
 		ORDER BY expire_time ASC
@@ -217,6 +367,18 @@ func QueryExpiredWebhookData(ctx context.Context, pool *pgxpool.Pool) ([]*Webhoo
 	return queryWebhookDataWithParams(ctx, pool, query, now)
 }
 
+// QueryWebhookDataByStatus 查询指定状态的数据，按更新时间排序
+func QueryWebhookDataByStatus(ctx context.Context, pool *pgxpool.Pool, status int16) ([]*WebhookDataModel, error) {
+	query := `
+		SELECT ` + webhookDataColumns + `
+		FROM webhook_data 
+		WHERE status=$1
+		ORDER BY update_time ASC
+	`
+
+	return queryWebhookDataWithParams(ctx, pool, query, status)
+}
+
 // UpdateWebhookStatusByID 更新单个记录的status
 func UpdateWebhookStatusByID(ctx context.Context, pool *pgxpool.Pool, id int64, status int16) error {
 	query := `
@@ -264,6 +426,17 @@ func scanWebhookDataRows(rows pgx.Rows) ([]*WebhookDataModel, error) {
 			&data.ID, &data.BlockHeight, &data.TxHash, &data.FromAddress,
 			&data.ToAddress, &data.Value, &data.BlockTime, &createTime,
 			&updateTime, &data.ExpireTime, &data.Status, &originalTxID,
+			&data.DelegateAmount,
+			&data.DelegateBlockNumber, &data.DelegateFee, &data.DelegateResult,
+			&data.ReclaimTxID, &data.ReclaimBlockNumber, &data.ReclaimFee,
+			&data.ReclaimResult, &data.ReclaimAmount,
+			&data.LockPeriod, &data.LockExpireTime,
+			&data.Resource,
+			&data.TokenContract, &data.TokenAmount,
+			&data.BlockHash, &data.Beneficiary,
+			&data.DelegateNetUsage, &data.DelegateNetFee, &data.DelegateEnergyFee,
+			&data.ReclaimNetUsage, &data.ReclaimNetFee, &data.ReclaimEnergyFee,
+			&data.DelegateTxExpiration, &data.ReclaimTxExpiration,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan data: %w", err)
@@ -272,6 +445,7 @@ func scanWebhookDataRows(rows pgx.Rows) ([]*WebhookDataModel, error) {
 		// 将时间转换为字符串
 		data.CreateTime = createTime.Format("2006-01-02 15:04:05")
 		data.UpdateTime = updateTime.Format("2006-01-02 15:04:05")
+		data.UpdatedAt = updateTime
 
 		// 处理可能为 NULL 的 original_tx_id
 		if originalTxID.Valid {
@@ -330,6 +504,102 @@ func UpdateOriginalTxIDByID(ctx context.Context, pool *pgxpool.Pool, id int64, o
 	return err
 }
 
+// UpdateDelegationByID 委托交易签名后、广播前，记录委托交易ID、过期时间（毫秒时间戳）、资源类型、委托金额和锁定期（区块数，0 表示未锁定），
+// 状态从待处理置为执行中，广播结果由确认跟踪处理；订单已不是待处理状态时返回 ErrStatusChanged，调用方不能广播
+func UpdateDelegationByID(ctx context.Context, pool *pgxpool.Pool, id int64, originalTxID string, txExpiration int64, resource string, delegateAmount string, lockPeriod int64) error {
+	query := `
+		UPDATE webhook_data 
+		SET original_tx_id = $1, delegate_tx_expiration = $2, resource = $3, delegate_amount = $4, lock_period = $5, status = $6, update_time = NOW() 
+		WHERE id = $7 AND status = $8
+	`
+
+	tag, err := pool.Exec(ctx, query, originalTxID, txExpiration, resource, delegateAmount, lockPeriod, StatusProcessing, id, StatusInit)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStatusChanged
+	}
+	return nil
+}
+
+// TxCost 交易回执中的资源消耗，执行失败的交易同样消耗资源
+type TxCost struct {
+	Fee       int64 // 交易总消耗（SUN），含带宽和能量燃烧
+	NetUsage  int64 // 消耗的带宽（免费带宽或质押带宽）
+	NetFee    int64 // 带宽不足时燃烧的 TRX（SUN）
+	EnergyFee int64 // 能量不足时燃烧的 TRX（SUN）
+}
+
+// ConfirmDelegationByID 记录委托交易回执、资源消耗和锁定到期时间（毫秒时间戳，未锁定为0），并更新状态（已授权或委托失败）
+func ConfirmDelegationByID(ctx context.Context, pool *pgxpool.Pool, id int64, status int16, blockNumber int64, cost TxCost, result string, lockExpireTime int64) error {
+	query := `
+		UPDATE webhook_data 
+		SET status = $1, delegate_block_number = $2, delegate_fee = $3, delegate_net_usage = $4, delegate_net_fee = $5,
+		    delegate_energy_fee = $6, delegate_result = $7, lock_expire_time = $8, update_time = NOW() 
+		WHERE id = $9 AND status = $10
+	`
+
+	_, err := pool.Exec(ctx, query, status, blockNumber, cost.Fee, cost.NetUsage, cost.NetFee, cost.EnergyFee, result, lockExpireTime, id, StatusProcessing)
+	return err
+}
+
+// ResetDelegationByID 委托交易未上链（被丢弃或过期），清空交易ID并重新进入待处理
+func ResetDelegationByID(ctx context.Context, pool *pgxpool.Pool, id int64) error {
+	query := `
+		UPDATE webhook_data 
+		SET status = $1, original_tx_id = NULL, delegate_tx_expiration = NULL, delegate_amount = NULL, lock_period = NULL, update_time = NOW() 
+		WHERE id = $2 AND status = $3
+	`
+
+	_, err := pool.Exec(ctx, query, StatusInit, id, StatusProcessing)
+	return err
+}
+
+// UpdateReclaimByID 回收交易签名后、广播前，记录回收交易ID、过期时间（毫秒时间戳）和回收金额，状态从已授权置为回收中，
+// 广播结果由确认跟踪处理；订单已不是已授权状态时返回 ErrStatusChanged，调用方不能广播
+func UpdateReclaimByID(ctx context.Context, pool *pgxpool.Pool, id int64, reclaimTxID string, txExpiration int64, reclaimAmount string) error {
+	query := `
+		UPDATE webhook_data 
+		SET reclaim_tx_id = $1, reclaim_tx_expiration = $2, reclaim_amount = $3, status = $4, update_time = NOW() 
+		WHERE id = $5 AND status = $6
+	`
+
+	tag, err := pool.Exec(ctx, query, reclaimTxID, txExpiration, reclaimAmount, StatusReclaiming, id, StatusAuthorized)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStatusChanged
+	}
+	return nil
+}
+
+// ConfirmReclaimByID 记录回收交易回执和资源消耗，并更新状态（已回收或回收失败）
+func ConfirmReclaimByID(ctx context.Context, pool *pgxpool.Pool, id int64, status int16, blockNumber int64, cost TxCost, result string) error {
+	query := `
+		UPDATE webhook_data 
+		SET status = $1, reclaim_block_number = $2, reclaim_fee = $3, reclaim_net_usage = $4, reclaim_net_fee = $5,
+		    reclaim_energy_fee = $6, reclaim_result = $7, update_time = NOW() 
+		WHERE id = $8 AND status = $9
+	`
+
+	_, err := pool.Exec(ctx, query, status, blockNumber, cost.Fee, cost.NetUsage, cost.NetFee, cost.EnergyFee, result, id, StatusReclaiming)
+	return err
+}
+
+// ResetReclaimByID 回收交易未上链，清空回收交易ID并回到已授权状态，等待下次回收
+func ResetReclaimByID(ctx context.Context, pool *pgxpool.Pool, id int64) error {
+	query := `
+		UPDATE webhook_data 
+		SET status = $1, reclaim_tx_id = NULL, reclaim_tx_expiration = NULL, reclaim_amount = NULL, update_time = NOW() 
+		WHERE id = $2 AND status = $3
+	`
+
+	_, err := pool.Exec(ctx, query, StatusAuthorized, id, StatusReclaiming)
+	return err
+}
+
 // UpdateOriginalTxIDByTxHash 根据交易哈希更新original_tx_id字段
 func UpdateOriginalTxIDByTxHash(ctx context.Context, pool *pgxpool.Pool, txHash string, originalTxID string) error {
 	query := `
@@ -1,8 +1,11 @@
 package db
 
 import (
+	"strings"
 	"testing"
 	"time"
+
+	"lending-trx/internal/units"
 )
 
 func TestUpdateOriginalTxIDByID(t *testing.T) {
@@ -128,10 +131,10 @@ func TestWebhookDataModelWithOriginalTxID(t *testing.T) {
 		FromAddress:  "TQn9Y2khDD95J42FQtQTdwVVRyc2jBEsVs",
 		ToAddress:    "TRX7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t",
 		Value:        "1000000000",
-		BlockTime:    time.Now().UnixMilli(),
+		BlockTime:    time.Now().Unix(),
 		CreateTime:   "2024-01-01 12:00:00",
 		UpdateTime:   "2024-01-01 12:00:00",
-		ExpireTime:   time.Now().Add(time.Hour).UnixMilli(),
+		ExpireTime:   time.Now().Add(time.Hour).Unix(),
 		Status:       0,
 		OriginalTxID: "tx_original_123456",
 	}
@@ -142,3 +145,73 @@ func TestWebhookDataModelWithOriginalTxID(t *testing.T) {
 
 	t.Logf("WebhookDataModel 结构体测试通过: %+v", data)
 }
+
+func TestWebhookDataModelReceiver(t *testing.T) {
+	data := &WebhookDataModel{FromAddress: "TQn9Y2khDD95J42FQtQTdwVVRyc2jBEsVs"}
+	if data.Receiver() != data.FromAddress {
+		t.Errorf("期望没有受益地址时委托给付款方，实际为 %s", data.Receiver())
+	}
+
+	data.Beneficiary = "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+	if data.Receiver() != data.Beneficiary {
+		t.Errorf("期望委托给受益地址，实际为 %s", data.Receiver())
+	}
+
+	query, args, err := batchInsertWebhookDataQuery([]*WebhookDataModel{data, {FromAddress: data.FromAddress}})
+	if err != nil {
+		t.Fatalf("构建插入语句失败: %v", err)
+	}
+	if len(args) != 26 || args[8] != data.Beneficiary || args[13+8] != nil {
+		t.Errorf("期望受益地址按列插入、为空时为 NULL，实际参数为 %v", args)
+	}
+	if !strings.Contains(query, "beneficiary") {
+		t.Errorf("期望插入语句包含 beneficiary 列，实际为 %s", query)
+	}
+}
+
+func TestBatchInsertWebhookDataAmounts(t *testing.T) {
+	// 超出 int64 的 TRC20 数量按原值写入
+	data := &WebhookDataModel{TxHash: "0x01", Value: "0", TokenContract: "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", TokenAmount: "115792089237316195423570985008687907853269984665640564039457584007913129639935"}
+	_, args, err := batchInsertWebhookDataQuery([]*WebhookDataModel{data})
+	if err != nil {
+		t.Fatalf("构建插入语句失败: %v", err)
+	}
+	if amount, ok := args[7].(units.Amount); !ok || amount.String() != data.TokenAmount {
+		t.Errorf("期望 token_amount 为 %s，实际为 %v", data.TokenAmount, args[7])
+	}
+
+	// 超出 NUMERIC(36,0) 或格式错误的金额拒绝写入
+	for _, value := range []string{strings.Repeat("9", 37), "1.5", "-1", "abc"} {
+		data := &WebhookDataModel{TxHash: "0x02", Value: value}
+		if _, _, err := batchInsertWebhookDataQuery([]*WebhookDataModel{data}); err == nil {
+			t.Errorf("期望金额 %s 返回错误", value)
+		}
+	}
+}
+
+func TestFindGaps(t *testing.T) {
+	testCases := []struct {
+		description string
+		ranges      []BlockRange
+		expected    []BlockRange
+	}{
+		{"没有批次", nil, nil},
+		{"连续的批次", []BlockRange{{100, 100}, {101, 105}, {106, 106}}, nil},
+		{"缺失中间的区块", []BlockRange{{100, 100}, {104, 105}, {110, 110}}, []BlockRange{{101, 103}, {106, 109}}},
+		{"乱序和重叠的批次", []BlockRange{{106, 110}, {100, 105}, {103, 104}, {112, 112}}, []BlockRange{{111, 111}}},
+		{"补齐后连续", []BlockRange{{100, 100}, {101, 103}, {104, 105}}, nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			gaps := FindGaps(tc.ranges)
+			if len(gaps) != len(tc.expected) {
+				t.Fatalf("期望缺失%v，实际为%v", tc.expected, gaps)
+			}
+			for i := range gaps {
+				if gaps[i] != tc.expected[i] {
+					t.Errorf("期望缺失%v，实际为%v", tc.expected, gaps)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// 订单收益统计只包含委托交易已上链（delegate_block_number 非空，成功或执行失败）的订单，
+// 成本为委托和回收交易燃烧的 TRX；尚未回收的订单不含回收成本
+const (
+	// marginPaymentExpr 支付金额：TRX 为 SUN，TRC20 为代币最小单位
+	marginPaymentExpr = `CASE WHEN token_contract IS NULL THEN COALESCE(value, 0) ELSE COALESCE(token_amount, 0) END`
+	// marginCostExpr 委托和回收交易燃烧的 TRX（SUN）
+	marginCostExpr = `COALESCE(delegate_fee, 0) + COALESCE(reclaim_fee, 0)`
+	// marginNetUsageExpr 委托和回收交易消耗的带宽
+	marginNetUsageExpr = `COALESCE(delegate_net_usage, 0) + COALESCE(reclaim_net_usage, 0)`
+	// marginFilter 委托交易已上链且在统计区间内的订单
+	marginFilter = `delegate_block_number IS NOT NULL AND create_time >= $1`
+)
+
+// OrderMargin 单个订单的收入和链上成本
+type OrderMargin struct {
+	ID             int64  `json:"id"`
+	TxHash         string `json:"tx_hash"`
+	Status         int16  `json:"status"`
+	Resource       string `json:"resource"`        // 委托的资源类型 ENERGY / BANDWIDTH
+	TokenContract  string `json:"token_contract"`  // TRC20 支付的代币合约，TRX 支付为空
+	Payment        string `json:"payment"`         // 支付金额，TRX 为 SUN，TRC20 为代币最小单位
+	DelegateAmount string `json:"delegate_amount"` // 委托的质押金额（SUN）
+	Cost           int64  `json:"cost"`            // 委托和回收交易燃烧的 TRX（SUN）
+	NetUsage       int64  `json:"net_usage"`       // 委托和回收交易消耗的带宽
+	Margin         string `json:"margin"`          // Payment - Cost（SUN），仅 TRX 支付；TRC20 支付币种不同，为空
+}
+
+// TierMargin 按支付档位（币种、支付金额、资源类型）汇总的收入和链上成本
+type TierMargin struct {
+	TokenContract string `json:"token_contract"` // TRC20 支付的代币合约，TRX 支付为空
+	Payment       string `json:"payment"`        // 档位的支付金额，TRX 为 SUN，TRC20 为代币最小单位
+	Resource      string `json:"resource"`
+	Orders        int64  `json:"orders"`    // 订单数
+	Revenue       string `json:"revenue"`   // 支付总额
+	Cost          int64  `json:"cost"`      // 链上成本合计（SUN）
+	NetUsage      int64  `json:"net_usage"` // 消耗的带宽合计
+	Margin        string `json:"margin"`    // Revenue - Cost（SUN），仅 TRX 档位
+}
+
+// QueryOrderMargins 查询 since 之后创建的订单的收入、链上成本和毛利，按订单ID排序
+func QueryOrderMargins(ctx context.Context, pool *pgxpool.Pool, since time.Time) ([]*OrderMargin, error) {
+	query := `
+		SELECT id, tx_hash, status, COALESCE(resource, 'ENERGY'), COALESCE(token_contract, ''),
+		       (` + marginPaymentExpr + `)::TEXT,
+		       COALESCE(delegate_amount, 0)::TEXT,
+		       ` + marginCostExpr + `,
+		       ` + marginNetUsageExpr + `,
+		       CASE WHEN token_contract IS NULL THEN (COALESCE(value, 0) - (` + marginCostExpr + `))::TEXT ELSE '' END
+		FROM webhook_data
+		WHERE ` + marginFilter + `
+		ORDER BY id ASC
+	`
+
+	rows, err := pool.Query(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query order margins: %w", err)
+	}
+	defer rows.Close()
+
+	var result []*OrderMargin
+	for rows.Next() {
+		var m OrderMargin
+		if err := rows.Scan(&m.ID, &m.TxHash, &m.Status, &m.Resource, &m.TokenContract,
+			&m.Payment, &m.DelegateAmount, &m.Cost, &m.NetUsage, &m.Margin); err != nil {
+			return nil, fmt.Errorf("failed to scan order margin: %w", err)
+		}
+		result = append(result, &m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while iterating rows: %w", err)
+	}
+	return result, nil
+}
+
+// QueryTierMargins 按支付档位汇总 since 之后创建的订单的收入和链上成本，毛利低的档位在前
+func QueryTierMargins(ctx context.Context, pool *pgxpool.Pool, since time.Time) ([]*TierMargin, error) {
+	query := `
+		SELECT COALESCE(token_contract, ''), payment::TEXT, resource, COUNT(*),
+		       SUM(payment)::TEXT, SUM(cost)::BIGINT, SUM(net_usage)::BIGINT,
+		       CASE WHEN token_contract IS NULL THEN (SUM(payment) - SUM(cost))::TEXT ELSE '' END AS margin
+		FROM (
+			SELECT token_contract, COALESCE(resource, 'ENERGY') AS resource,
+			       ` + marginPaymentExpr + ` AS payment,
+			       ` + marginCostExpr + ` AS cost,
+			       ` + marginNetUsageExpr + ` AS net_usage
+			FROM webhook_data
+			WHERE ` + marginFilter + `
+		) orders
+		GROUP BY token_contract, payment, resource
+		ORDER BY token_contract NULLS FIRST, SUM(payment) - SUM(cost) ASC
+	`
+
+	rows, err := pool.Query(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tier margins: %w", err)
+	}
+	defer rows.Close()
+
+	var result []*TierMargin
+	for rows.Next() {
+		var m TierMargin
+		if err := rows.Scan(&m.TokenContract, &m.Payment, &m.Resource, &m.Orders,
+			&m.Revenue, &m.Cost, &m.NetUsage, &m.Margin); err != nil {
+			return nil, fmt.Errorf("failed to scan tier margin: %w", err)
+		}
+		result = append(result, &m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while iterating rows: %w", err)
+	}
+	return result, nil
+}
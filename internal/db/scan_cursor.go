@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const createScanCursorTableSQL = `
+CREATE TABLE IF NOT EXISTS scan_cursor (
+  name VARCHAR(64) PRIMARY KEY,
+  block_number BIGINT NOT NULL,
+  block_hash VARCHAR(128),
+  update_time TIMESTAMP NOT NULL DEFAULT NOW()
+);`
+
+// ScanCursor 区块扫描进度，block_number 及之前的区块已处理完成
+type ScanCursor struct {
+	Name        string `json:"name"`         // 扫描器名称
+	BlockNumber int64  `json:"block_number"` // 已处理的最后一个区块高度
+	BlockHash   string `json:"block_hash"`   // 已处理的最后一个区块哈希
+}
+
+// GetScanCursor 查询扫描进度，不存在时返回 nil
+func GetScanCursor(ctx context.Context, pool *pgxpool.Pool, name string) (*ScanCursor, error) {
+	cursor := ScanCursor{Name: name}
+	query := `SELECT block_number, COALESCE(block_hash, '') FROM scan_cursor WHERE name = $1`
+	err := pool.QueryRow(ctx, query, name).Scan(&cursor.BlockNumber, &cursor.BlockHash)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scan cursor: %w", err)
+	}
+	return &cursor, nil
+}
+
+// SaveScanCursorTx 保存扫描进度（事务版本），与该批区块的 webhook_data 在同一事务中写入
+func SaveScanCursorTx(ctx context.Context, tx pgx.Tx, cursor *ScanCursor) error {
+	query := `
+		INSERT INTO scan_cursor (name, block_number, block_hash, update_time)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (name) DO UPDATE
+		SET block_number = EXCLUDED.block_number, block_hash = EXCLUDED.block_hash, update_time = NOW()
+	`
+	if _, err := tx.Exec(ctx, query, cursor.Name, cursor.BlockNumber, cursor.BlockHash); err != nil {
+		return fmt.Errorf("failed to save scan cursor: %w", err)
+	}
+	return nil
+}
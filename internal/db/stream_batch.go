@@ -0,0 +1,156 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const createStreamBatchesTableSQL = `
+CREATE TABLE IF NOT EXISTS stream_batches (
+  id SERIAL PRIMARY KEY,
+  stream_id VARCHAR(64) NOT NULL,
+  network VARCHAR(64),
+  start_block BIGINT NOT NULL,
+  end_block BIGINT NOT NULL,
+  tx_count INT NOT NULL DEFAULT 0,
+  payment_count INT NOT NULL DEFAULT 0,
+  source VARCHAR(16) NOT NULL DEFAULT 'stream',
+  create_time TIMESTAMP NOT NULL DEFAULT NOW(),
+  UNIQUE (stream_id, start_block, end_block)
+);
+CREATE INDEX IF NOT EXISTS idx_stream_batches_range ON stream_batches (stream_id, end_block);`
+
+// stream_batches 的 source
+const (
+	BatchSourceStream   = "stream"   // stream 推送
+	BatchSourceBackfill = "backfill" // 从节点补齐的缺失区块
+)
+
+// StreamBatch 一批已处理的区块范围 [StartBlock, EndBlock]
+type StreamBatch struct {
+	StreamID     string `json:"stream_id"`     // stream ID，区分不同的推送源
+	Network      string `json:"network"`       // 网络，如 tron-mainnet
+	StartBlock   int64  `json:"start_block"`   // 批次起始区块（batch_start_range）
+	EndBlock     int64  `json:"end_block"`     // 批次结束区块（batch_end_range），包含
+	TxCount      int    `json:"tx_count"`      // 批次中的交易数
+	PaymentCount int    `json:"payment_count"` // 批次中转入收款地址的支付数
+	Source       string `json:"source"`        // 来源，见 BatchSource* 常量
+}
+
+// BlockRange 区块范围 [Start, End]，包含两端
+type BlockRange struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// StreamHead 一个 stream 已接收到的最高区块
+type StreamHead struct {
+	StreamID string `json:"stream_id"`
+	Network  string `json:"network"`
+	EndBlock int64  `json:"end_block"`
+}
+
+// InsertStreamBatchTx 记录已处理的批次（事务版本），与该批次的支付在同一事务中写入，重复推送的批次忽略
+func InsertStreamBatchTx(ctx context.Context, tx pgx.Tx, batch *StreamBatch) error {
+	query := `
+		INSERT INTO stream_batches (stream_id, network, start_block, end_block, tx_count, payment_count, source)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (stream_id, start_block, end_block) DO NOTHING
+	`
+	source := batch.Source
+	if source == "" {
+		source = BatchSourceStream
+	}
+	if _, err := tx.Exec(ctx, query, batch.StreamID, batch.Network, batch.StartBlock, batch.EndBlock, batch.TxCount, batch.PaymentCount, source); err != nil {
+		return fmt.Errorf("failed to insert stream batch: %w", err)
+	}
+	return nil
+}
+
+// GetStreamHead 查询 stream 已接收到的最高区块，没有记录时返回 nil
+func GetStreamHead(ctx context.Context, pool *pgxpool.Pool, streamID string) (*StreamHead, error) {
+	heads, err := queryStreamHeads(ctx, pool, `WHERE stream_id = $1`, streamID)
+	if err != nil || len(heads) == 0 {
+		return nil, err
+	}
+	return &heads[0], nil
+}
+
+// ListStreamHeads 查询所有 stream 已接收到的最高区块
+func ListStreamHeads(ctx context.Context, pool *pgxpool.Pool) ([]StreamHead, error) {
+	return queryStreamHeads(ctx, pool, "")
+}
+
+// queryStreamHeads 按 stream 汇总最高区块
+func queryStreamHeads(ctx context.Context, pool *pgxpool.Pool, where string, args ...interface{}) ([]StreamHead, error) {
+	query := `
+		SELECT stream_id, COALESCE(MAX(network), ''), MAX(end_block)
+		FROM stream_batches ` + where + `
+		GROUP BY stream_id
+		ORDER BY stream_id
+	`
+	rows, err := pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stream heads: %w", err)
+	}
+	defer rows.Close()
+
+	var result []StreamHead
+	for rows.Next() {
+		var head StreamHead
+		if err := rows.Scan(&head.StreamID, &head.Network, &head.EndBlock); err != nil {
+			return nil, fmt.Errorf("failed to scan stream head: %w", err)
+		}
+		result = append(result, head)
+	}
+	return result, rows.Err()
+}
+
+// ListStreamRanges 查询 stream 中结束区块不早于 fromBlock 的批次范围，按起始区块排序
+func ListStreamRanges(ctx context.Context, pool *pgxpool.Pool, streamID string, fromBlock int64) ([]BlockRange, error) {
+	query := `
+		SELECT start_block, end_block FROM stream_batches
+		WHERE stream_id = $1 AND end_block >= $2
+		ORDER BY start_block, end_block
+	`
+	rows, err := pool.Query(ctx, query, streamID, fromBlock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stream ranges: %w", err)
+	}
+	defer rows.Close()
+
+	var result []BlockRange
+	for rows.Next() {
+		var r BlockRange
+		if err := rows.Scan(&r.Start, &r.End); err != nil {
+			return nil, fmt.Errorf("failed to scan stream range: %w", err)
+		}
+		result = append(result, r)
+	}
+	return result, rows.Err()
+}
+
+// FindGaps 找出已处理范围之间缺失的区块范围，范围可以重叠或乱序，第一个范围之前不视为缺失
+func FindGaps(ranges []BlockRange) []BlockRange {
+	if len(ranges) == 0 {
+		return nil
+	}
+	sorted := append([]BlockRange(nil), ranges...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	var gaps []BlockRange
+	covered := sorted[0].End
+	for _, r := range sorted[1:] {
+		if r.Start > covered+1 {
+			gaps = append(gaps, BlockRange{Start: covered + 1, End: r.Start - 1})
+		}
+		if r.End > covered {
+			covered = r.End
+		}
+	}
+	return gaps
+}
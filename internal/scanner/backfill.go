@@ -0,0 +1,209 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sunjiangjun/xlog"
+
+	"lending-trx/internal/db"
+	"lending-trx/internal/tron"
+	"lending-trx/internal/webhook"
+)
+
+const (
+	// defaultBackfillInterval 检查 stream 缺失区块的间隔
+	defaultBackfillInterval = time.Minute
+	// defaultBackfillLookback 只检查最高区块之前这么多个区块内的缺失，约1天
+	defaultBackfillLookback = 28800
+	// backfillDelayBlocks 缺失范围结束后至少再推送这么多个区块才补齐，等待乱序到达的批次
+	backfillDelayBlocks = 20
+)
+
+// BackfillConfig stream 缺失区块补齐配置
+type BackfillConfig struct {
+	Interval time.Duration // 检查间隔，0 表示不启用
+	Lookback int64         // 检查范围（区块数）
+}
+
+// BackfillConfigFromEnv 从环境变量读取补齐配置
+//   - STREAM_BACKFILL_INTERVAL: 检查间隔，默认 1m，0 表示不启用
+//   - STREAM_BACKFILL_LOOKBACK: 检查最高区块之前多少个区块内的缺失，默认 28800（约1天）
+func BackfillConfigFromEnv() (BackfillConfig, error) {
+	config := BackfillConfig{Interval: defaultBackfillInterval, Lookback: defaultBackfillLookback}
+	if value := os.Getenv("STREAM_BACKFILL_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval < 0 {
+			return BackfillConfig{}, fmt.Errorf("invalid STREAM_BACKFILL_INTERVAL %q", value)
+		}
+		config.Interval = interval
+	}
+	if value := os.Getenv("STREAM_BACKFILL_LOOKBACK"); value != "" {
+		lookback, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || lookback <= 0 {
+			return BackfillConfig{}, fmt.Errorf("invalid STREAM_BACKFILL_LOOKBACK %q", value)
+		}
+		config.Lookback = lookback
+	}
+	return config, nil
+}
+
+// Backfiller 补齐 stream 推送缺失的区块：按 stream_batches 找出不连续的范围，从节点拉取区块，
+// 按与 webhook 相同的方式分类后写入支付，并以 backfill 来源记录批次使范围连续
+type Backfiller struct {
+	ctx       context.Context
+	pool      *pgxpool.Pool
+	log       *xlog.XLog
+	source    BlockSource
+	receivers []string
+	config    BackfillConfig
+}
+
+// NewBackfiller 创建补齐任务
+func NewBackfiller(ctx context.Context, pool *pgxpool.Pool, log *xlog.XLog, source BlockSource, receivers []string, config BackfillConfig) *Backfiller {
+	return &Backfiller{ctx: ctx, pool: pool, log: log, source: source, receivers: receivers, config: config}
+}
+
+// StartBackfill 从环境变量读取配置并在后台启动补齐任务，STREAM_BACKFILL_INTERVAL=0 时不启动
+func StartBackfill(ctx context.Context, pool *pgxpool.Pool, log *xlog.XLog, source BlockSource, receivers []string) error {
+	config, err := BackfillConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	if config.Interval == 0 {
+		log.Info("Stream backfill disabled")
+		return nil
+	}
+	b := NewBackfiller(ctx, pool, log, source, receivers, config)
+	log.Info("Stream backfill started", "interval", config.Interval.String(), "lookback", config.Lookback)
+	go b.run()
+	return nil
+}
+
+// run 按 Interval 循环补齐
+func (b *Backfiller) run() {
+	ticker := time.NewTicker(b.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := b.BackfillOnce(); err != nil {
+				b.log.Error("Stream backfill failed", err)
+			}
+		}
+	}
+}
+
+// BackfillOnce 补齐所有 stream 当前缺失的区块，返回补齐的区块数
+func (b *Backfiller) BackfillOnce() (int64, error) {
+	heads, err := db.ListStreamHeads(b.ctx, b.pool)
+	if err != nil {
+		return 0, err
+	}
+
+	var filled int64
+	for _, head := range heads {
+		ranges, err := db.ListStreamRanges(b.ctx, b.pool, head.StreamID, head.EndBlock-b.config.Lookback)
+		if err != nil {
+			return filled, err
+		}
+		for _, gap := range db.FindGaps(ranges) {
+			// 处理队列并发写入，批次可能乱序提交，延迟之后仍缺失才告警和补齐
+			if gap.End > head.EndBlock-backfillDelayBlocks {
+				continue
+			}
+			b.log.Error("Stream batch gap detected",
+				"stream_id", head.StreamID,
+				"network", head.Network,
+				"gap_start", gap.Start,
+				"gap_end", gap.End,
+				"missing_blocks", gap.End-gap.Start+1,
+			)
+			n, err := b.backfillGap(head, gap)
+			filled += n
+			if err != nil {
+				return filled, fmt.Errorf("stream %s blocks %d-%d: %w", head.StreamID, gap.Start, gap.End, err)
+			}
+		}
+	}
+	return filled, nil
+}
+
+// backfillGap 按节点单次请求上限分批补齐缺失范围，每批的支付和批次记录在同一事务中写入
+func (b *Backfiller) backfillGap(head db.StreamHead, gap db.BlockRange) (int64, error) {
+	b.log.Warn("Backfilling stream gap", "stream_id", head.StreamID, "gap_start", gap.Start, "gap_end", gap.End)
+
+	var filled int64
+	for start := gap.Start; start <= gap.End; {
+		end := start + tron.MaxBlocksPerRequest - 1
+		if end > gap.End {
+			end = gap.End
+		}
+		payments, stats, last, err := b.fetch(start, end)
+		if err != nil {
+			return filled, err
+		}
+
+		batch := &db.StreamBatch{
+			StreamID:     head.StreamID,
+			Network:      head.Network,
+			StartBlock:   start,
+			EndBlock:     last,
+			TxCount:      stats.Total,
+			PaymentCount: len(payments),
+			Source:       db.BatchSourceBackfill,
+		}
+		err = db.WithTransaction(b.ctx, b.pool, func(tx pgx.Tx) error {
+			if _, err := db.BatchInsertWebhookDataTx(b.ctx, tx, webhook.ConvertToWebhookDataModelSlice(payments)); err != nil {
+				return fmt.Errorf("failed to insert payments: %w", err)
+			}
+			return db.InsertStreamBatchTx(b.ctx, tx, batch)
+		})
+		if err != nil {
+			return filled, err
+		}
+
+		b.log.Info("Stream gap backfilled",
+			"stream_id", head.StreamID,
+			"from_block", start,
+			"to_block", last,
+			"total", stats.Total,
+			"payments", len(payments),
+			"skipped", stats.Skipped(),
+		)
+		filled += last - start + 1
+		start = last + 1
+	}
+	return filled, nil
+}
+
+// fetch 拉取 [start, end] 的区块，按 webhook 的分类规则返回转入收款地址的支付，以及已处理的最后一个区块
+// 节点只返回部分区块时只处理从 start 开始连续的部分
+func (b *Backfiller) fetch(start, end int64) ([]webhook.WebhookData, webhook.BatchStats, int64, error) {
+	blocks, err := b.source.GetBlocksByLimitNext(b.ctx, start, end+1)
+	if err != nil {
+		return nil, webhook.BatchStats{}, 0, err
+	}
+	if len(blocks) == 0 || blocks[0].Number() != start {
+		return nil, webhook.BatchStats{}, 0, fmt.Errorf("block %d not returned by node", start)
+	}
+
+	var txs []webhook.TransactionData
+	last := start
+	for i := range blocks {
+		if blocks[i].Number() != start+int64(i) || blocks[i].Number() > end {
+			break
+		}
+		last = blocks[i].Number()
+		txs = append(txs, webhook.BlockTransactions(&blocks[i])...)
+	}
+	payments, stats := webhook.ClassifyBatch(txs, b.receivers)
+	return payments, stats, last, nil
+}
@@ -0,0 +1,67 @@
+package scanner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sunjiangjun/xlog"
+
+	"lending-trx/internal/tron"
+)
+
+func TestBackfillFetch(t *testing.T) {
+	source := &stubSource{blocks: []tron.Block{
+		newBlock(100, newTransaction("t1", tron.ContractTransfer, "SUCCESS", tron.ContractValue{OwnerAddress: testPayer, ToAddress: testReceiver, Amount: 2000000})),
+		newBlock(101,
+			newTransaction("t2", tron.ContractTransfer, "SUCCESS", tron.ContractValue{OwnerAddress: testReceiver, ToAddress: testPayer, Amount: 1000000}),
+			newTransaction("t3", tron.ContractTransfer, "SUCCESS", tron.ContractValue{OwnerAddress: testPayer, ToAddress: testReceiver, Amount: 1000000}),
+		),
+		newBlock(102),
+		newBlock(103, newTransaction("t4", tron.ContractTransfer, "SUCCESS", tron.ContractValue{OwnerAddress: testPayer, ToAddress: testReceiver, Amount: 1000000})),
+	}}
+
+	b := NewBackfiller(context.Background(), nil, xlog.NewXLogger(), source, []string{testReceiver}, BackfillConfig{})
+	payments, stats, last, err := b.fetch(100, 102)
+	if err != nil {
+		t.Fatalf("拉取区块失败: %v", err)
+	}
+	if last != 102 {
+		t.Errorf("期望处理到缺失范围的结束区块102，实际为%d", last)
+	}
+	if len(payments) != 2 || payments[0].TxHash != "0xt1" || payments[1].TxHash != "0xt3" {
+		t.Errorf("期望补齐2笔支付，实际为%+v", payments)
+	}
+	if stats.Total != 3 || stats.Irrelevant != 1 {
+		t.Errorf("期望3笔交易、1笔无关，实际为%+v", stats)
+	}
+
+	if _, _, _, err := b.fetch(104, 105); err == nil {
+		t.Error("期望起始区块缺失时返回错误")
+	}
+}
+
+func TestBackfillConfigFromEnv(t *testing.T) {
+	t.Setenv("STREAM_BACKFILL_INTERVAL", "")
+	t.Setenv("STREAM_BACKFILL_LOOKBACK", "")
+	config, err := BackfillConfigFromEnv()
+	if err != nil || config.Interval != defaultBackfillInterval || config.Lookback != defaultBackfillLookback {
+		t.Errorf("期望默认配置，实际为%+v, %v", config, err)
+	}
+
+	t.Setenv("STREAM_BACKFILL_INTERVAL", "0")
+	t.Setenv("STREAM_BACKFILL_LOOKBACK", "1200")
+	if config, err = BackfillConfigFromEnv(); err != nil || config.Interval != 0 || config.Lookback != 1200 {
+		t.Errorf("期望不启用且检查1200个区块，实际为%+v, %v", config, err)
+	}
+
+	t.Setenv("STREAM_BACKFILL_INTERVAL", "-1s")
+	if _, err := BackfillConfigFromEnv(); err == nil {
+		t.Error("期望间隔为负数时返回错误")
+	}
+	t.Setenv("STREAM_BACKFILL_INTERVAL", time.Minute.String())
+	t.Setenv("STREAM_BACKFILL_LOOKBACK", "0")
+	if _, err := BackfillConfigFromEnv(); err == nil {
+		t.Error("期望检查范围为0时返回错误")
+	}
+}
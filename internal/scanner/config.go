@@ -0,0 +1,68 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"lending-trx/internal/webhook"
+)
+
+const (
+	// defaultScannerName 扫描进度在 scan_cursor 表中的名称
+	defaultScannerName = "tron"
+	// defaultBatchSize 每批拉取的区块数
+	defaultBatchSize = 20
+	// defaultInterval 追上最新区块后的轮询间隔，约为一个区块
+	defaultInterval = 3 * time.Second
+)
+
+// Config 区块扫描配置
+type Config struct {
+	Name       string        // 扫描进度名称，多个扫描器共用数据库时区分
+	Receivers  []string      // 收款地址（Base58Check），只记录转入这些地址的支付
+	StartBlock int64         // 没有扫描进度时的起始区块，0 表示从当前最新区块开始
+	BatchSize  int64         // 每批拉取的区块数，上限 tron.MaxBlocksPerRequest
+	Interval   time.Duration // 追上最新区块后的轮询间隔
+}
+
+// ConfigFromEnv 从环境变量读取区块扫描配置
+//   - RECEIVING_ADDRESSES: 收款地址（逗号分隔），未设置时使用 DELEGATION_FROM_ADDRESS
+//   - SCANNER_NAME: 扫描进度名称，默认 tron
+//   - SCANNER_START_BLOCK: 没有扫描进度时的起始区块
+//   - SCANNER_BATCH_SIZE: 每批拉取的区块数，默认 20
+//   - SCANNER_INTERVAL: 轮询间隔，默认 3s
+func ConfigFromEnv() (Config, error) {
+	config := Config{
+		Name:      defaultScannerName,
+		BatchSize: defaultBatchSize,
+		Interval:  defaultInterval,
+	}
+
+	receivers, err := webhook.ReceiversFromEnv()
+	if err != nil {
+		return Config{}, err
+	}
+	config.Receivers = receivers
+
+	if name := os.Getenv("SCANNER_NAME"); name != "" {
+		config.Name = name
+	}
+	if value := os.Getenv("SCANNER_START_BLOCK"); value != "" {
+		if config.StartBlock, err = strconv.ParseInt(value, 10, 64); err != nil || config.StartBlock < 0 {
+			return Config{}, fmt.Errorf("invalid SCANNER_START_BLOCK %q", value)
+		}
+	}
+	if value := os.Getenv("SCANNER_BATCH_SIZE"); value != "" {
+		if config.BatchSize, err = strconv.ParseInt(value, 10, 64); err != nil || config.BatchSize <= 0 {
+			return Config{}, fmt.Errorf("invalid SCANNER_BATCH_SIZE %q", value)
+		}
+	}
+	if value := os.Getenv("SCANNER_INTERVAL"); value != "" {
+		if config.Interval, err = time.ParseDuration(value); err != nil || config.Interval <= 0 {
+			return Config{}, fmt.Errorf("invalid SCANNER_INTERVAL %q", value)
+		}
+	}
+	return config, nil
+}
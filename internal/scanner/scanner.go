@@ -0,0 +1,180 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sunjiangjun/xlog"
+
+	"lending-trx/internal/db"
+	"lending-trx/internal/tron"
+	"lending-trx/internal/webhook"
+)
+
+// BlockSource 区块数据来源，tron.TronClient 为基于节点 HTTP API 的实现
+type BlockSource interface {
+	// GetNowBlock 获取最新区块
+	GetNowBlock(ctx context.Context) (*tron.Block, error)
+	// GetBlocksByLimitNext 获取 [start, end) 范围内的区块，按高度升序返回
+	GetBlocksByLimitNext(ctx context.Context, start, end int64) ([]tron.Block, error)
+}
+
+var _ BlockSource = (*tron.TronClient)(nil)
+
+// Scanner 内置区块扫描：按高度顺序拉取区块，筛选转入收款地址的支付，写入 webhook_data
+// 扫描进度保存在 scan_cursor 表，与该批区块的支付在同一事务中写入，重启后从下一个区块继续
+type Scanner struct {
+	ctx    context.Context
+	pool   *pgxpool.Pool
+	log    *xlog.XLog
+	source BlockSource
+	config Config
+}
+
+// NewScanner 创建区块扫描器
+func NewScanner(ctx context.Context, pool *pgxpool.Pool, log *xlog.XLog, source BlockSource, config Config) *Scanner {
+	return &Scanner{ctx: ctx, pool: pool, log: log, source: source, config: config}
+}
+
+// Start 从环境变量读取配置并在后台启动区块扫描
+func Start(ctx context.Context, pool *pgxpool.Pool, log *xlog.XLog, source BlockSource) error {
+	config, err := ConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	s := NewScanner(ctx, pool, log, source, config)
+	log.Info("Block scanner started",
+		"name", config.Name,
+		"receivers", config.Receivers,
+		"start_block", config.StartBlock,
+		"batch_size", config.BatchSize,
+		"interval", config.Interval.String(),
+	)
+	go s.run()
+	return nil
+}
+
+// run 循环扫描，追上最新区块后按 Interval 等待新区块
+func (s *Scanner) run() {
+	for {
+		scanned, err := s.ScanOnce()
+		if err != nil {
+			s.log.Error("Block scan failed", err, "name", s.config.Name)
+		}
+		// 本批已达到上限说明仍落后于最新区块，立即继续
+		if err == nil && scanned >= s.config.BatchSize {
+			continue
+		}
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-time.After(s.config.Interval):
+		}
+	}
+}
+
+// ScanOnce 扫描下一批区块，返回处理的区块数
+func (s *Scanner) ScanOnce() (int64, error) {
+	cursor, err := db.GetScanCursor(s.ctx, s.pool, s.config.Name)
+	if err != nil {
+		return 0, err
+	}
+
+	head, err := s.source.GetNowBlock(s.ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	last := s.config.StartBlock - 1
+	switch {
+	case cursor != nil:
+		last = cursor.BlockNumber
+	case s.config.StartBlock <= 0:
+		// 未配置起始区块时从当前最新区块开始
+		last = head.Number() - 1
+	}
+
+	start, end, ok := nextRange(last, head.Number(), s.config.BatchSize)
+	if !ok {
+		return 0, nil
+	}
+
+	models, stats, lastBlock, err := s.collect(start, end)
+	if err != nil {
+		return 0, err
+	}
+
+	err = db.WithTransaction(s.ctx, s.pool, func(tx pgx.Tx) error {
+		if _, err := db.BatchInsertWebhookDataTx(s.ctx, tx, models); err != nil {
+			return fmt.Errorf("failed to insert payments: %w", err)
+		}
+		return db.SaveScanCursorTx(s.ctx, tx, &db.ScanCursor{
+			Name:        s.config.Name,
+			BlockNumber: lastBlock.Number(),
+			BlockHash:   lastBlock.BlockID,
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	scanned := lastBlock.Number() - start + 1
+	if len(models) > 0 || stats.Failed > 0 || stats.Invalid > 0 {
+		s.log.Info("Block scan found payments",
+			"name", s.config.Name,
+			"from_block", start,
+			"to_block", lastBlock.Number(),
+			"total", stats.Total,
+			"trx_payments", stats.TRXPayments,
+			"token_payments", stats.TokenPayments,
+			"unverified_token_payments", stats.Unverified,
+			"skipped_failed", stats.Failed,
+			"skipped_invalid", stats.Invalid,
+		)
+	}
+	return scanned, nil
+}
+
+// collect 拉取 [start, end) 的区块并按收款地址分类，返回转入收款地址的支付记录、分类统计和已处理的最后一个区块
+// 节点可能只返回部分区块，只处理从 start 开始连续的部分，其余留到下一批
+// 区块中包含任意合约调用，执行失败或无法解析的交易不可能是支付，计入统计而不阻塞扫描
+func (s *Scanner) collect(start, end int64) ([]*db.WebhookDataModel, webhook.BatchStats, *tron.Block, error) {
+	blocks, err := s.source.GetBlocksByLimitNext(s.ctx, start, end)
+	if err != nil {
+		return nil, webhook.BatchStats{}, nil, err
+	}
+	if len(blocks) == 0 || blocks[0].Number() != start {
+		return nil, webhook.BatchStats{}, nil, fmt.Errorf("block %d not returned by node", start)
+	}
+
+	var txs []webhook.TransactionData
+	var lastBlock *tron.Block
+	for i := range blocks {
+		if blocks[i].Number() != start+int64(i) {
+			break
+		}
+		lastBlock = &blocks[i]
+		txs = append(txs, webhook.BlockTransactions(lastBlock)...)
+	}
+	payments, stats := webhook.ClassifyBatch(txs, s.config.Receivers)
+	return webhook.ConvertToWebhookDataModelSlice(payments), stats, lastBlock, nil
+}
+
+// nextRange 已处理到 last 时下一批区块的范围 [start, end)，最多 batch 个且不超过 head，没有新区块时 ok 为 false
+func nextRange(last, head, batch int64) (start int64, end int64, ok bool) {
+	start = last + 1
+	if start > head {
+		return 0, 0, false
+	}
+	if batch <= 0 || batch > tron.MaxBlocksPerRequest {
+		batch = tron.MaxBlocksPerRequest
+	}
+	end = start + batch
+	if end > head+1 {
+		end = head + 1
+	}
+	return start, end, true
+}
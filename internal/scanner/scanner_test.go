@@ -0,0 +1,178 @@
+package scanner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sunjiangjun/xlog"
+
+	"lending-trx/internal/tron"
+	"lending-trx/internal/webhook"
+)
+
+const (
+	testPayer    = "TSoXbDPwgM1nhfbxVj53u15My8smJHWpFw"
+	testReceiver = "TKQbPHJ7vaPVc8KuYaQTs2ew6gb64r9xhK"
+	testUSDT     = "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+)
+
+// stubSource 返回固定区块的 BlockSource
+type stubSource struct {
+	blocks []tron.Block
+}
+
+func (s *stubSource) GetNowBlock(ctx context.Context) (*tron.Block, error) {
+	return &s.blocks[len(s.blocks)-1], nil
+}
+
+func (s *stubSource) GetBlocksByLimitNext(ctx context.Context, start, end int64) ([]tron.Block, error) {
+	var result []tron.Block
+	for _, b := range s.blocks {
+		if b.Number() >= start && b.Number() < end {
+			result = append(result, b)
+		}
+	}
+	return result, nil
+}
+
+func newBlock(number int64, txs ...tron.BlockTransaction) tron.Block {
+	var b tron.Block
+	b.BlockID = "block"
+	b.BlockHeader.RawData.Number = number
+	b.BlockHeader.RawData.Timestamp = 1753271856000 + number*3000
+	b.Transactions = txs
+	return b
+}
+
+func newTransaction(txID, contractType, result string, value tron.ContractValue) tron.BlockTransaction {
+	var tx tron.BlockTransaction
+	tx.TxID = txID
+	tx.Ret = append(tx.Ret, struct {
+		ContractRet string `json:"contractRet"`
+	}{result})
+	contract := tron.BlockContract{Type: contractType}
+	contract.Parameter.Value = value
+	tx.RawData.Contract = []tron.BlockContract{contract}
+	return tx
+}
+
+func TestNextRange(t *testing.T) {
+	testCases := []struct {
+		description string
+		last, head  int64
+		batch       int64
+		start, end  int64
+		ok          bool
+	}{
+		{"落后较多时按批次大小", 100, 500, 20, 101, 121, true},
+		{"不超过最新区块", 100, 105, 20, 101, 106, true},
+		{"没有新区块", 105, 105, 20, 0, 0, false},
+		{"批次大小不超过节点上限", 0, 1000, 500, 1, 1 + tron.MaxBlocksPerRequest, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			start, end, ok := nextRange(tc.last, tc.head, tc.batch)
+			if start != tc.start || end != tc.end || ok != tc.ok {
+				t.Errorf("期望为[%d, %d) %v，实际为[%d, %d) %v", tc.start, tc.end, tc.ok, start, end, ok)
+			}
+		})
+	}
+}
+
+func TestCollect(t *testing.T) {
+	transferInput := "a9059cbb" +
+		"000000000000000000000000678637325f9be6b2264db347021432a6a7b84c10" +
+		"00000000000000000000000000000000000000000000000000000000004c4b40"
+
+	// t1 的备注指定了受益地址
+	memo := newTransaction("t1", tron.ContractTransfer, "SUCCESS", tron.ContractValue{OwnerAddress: testPayer, ToAddress: testReceiver, Amount: 2000000})
+	memo.RawData.Data = "746f3a5452374e48716a654b5178475443693871385a5934704c386f74537a676a4c6a3674"
+
+	source := &stubSource{blocks: []tron.Block{
+		newBlock(100,
+			// 转入收款地址的 TRX
+			memo,
+			// 转入其他地址的 TRX
+			newTransaction("t2", tron.ContractTransfer, "SUCCESS", tron.ContractValue{OwnerAddress: testReceiver, ToAddress: testPayer, Amount: 1000000}),
+		),
+		newBlock(101,
+			// 转入收款地址的 USDT
+			newTransaction("t3", tron.ContractTriggerSmartContract, "SUCCESS", tron.ContractValue{OwnerAddress: testPayer, ContractAddress: testUSDT, Data: transferInput}),
+			// 执行失败的 USDT 转账
+			newTransaction("t4", tron.ContractTriggerSmartContract, "REVERT", tron.ContractValue{OwnerAddress: testPayer, ContractAddress: testUSDT, Data: transferInput}),
+			// 无法解析的合约调用
+			newTransaction("t5", tron.ContractTriggerSmartContract, "SUCCESS", tron.ContractValue{OwnerAddress: testPayer, ContractAddress: testUSDT, Data: "a9059cbb00"}),
+		),
+		// 区块102缺失，103 留到下一批
+		newBlock(103),
+	}}
+
+	s := NewScanner(context.Background(), nil, xlog.NewXLogger(), source, Config{Receivers: []string{testReceiver}})
+	models, stats, lastBlock, err := s.collect(100, 104)
+	if err != nil {
+		t.Fatalf("扫描区块失败: %v", err)
+	}
+	if stats.TRXPayments != 1 || stats.Unverified != 1 || stats.Invalid != 1 {
+		t.Errorf("期望1笔 TRX 支付、1笔待核实的代币支付和1笔无法解析的交易，实际为%+v", stats)
+	}
+	if lastBlock.Number() != 101 {
+		t.Errorf("期望只处理连续的区块到101，实际为%d", lastBlock.Number())
+	}
+	if len(models) != 2 {
+		t.Fatalf("期望找到2笔支付，实际为%d", len(models))
+	}
+
+	trx := models[0]
+	if trx.TxHash != "0xt1" || trx.FromAddress != testPayer || trx.ToAddress != testReceiver || trx.Value != "2000000" || trx.TokenContract != "" {
+		t.Errorf("期望为 TRX 支付 2000000 SUN，实际为%+v", trx)
+	}
+	if trx.BlockHeight != 100 || trx.BlockHash != "0xblock" || trx.BlockTime != 1753271856+300 {
+		t.Errorf("期望区块100、时间%d，实际为%d、%s、%d", 1753271856+300, trx.BlockHeight, trx.BlockHash, trx.BlockTime)
+	}
+	if trx.Beneficiary != testUSDT {
+		t.Errorf("期望受益地址为%s，实际为%s", testUSDT, trx.Beneficiary)
+	}
+
+	usdt := models[1]
+	if usdt.TxHash != "0xt3" || usdt.ToAddress != testReceiver || usdt.TokenContract != testUSDT || usdt.TokenAmount != "5000000" {
+		t.Errorf("期望为 USDT 支付 5000000，实际为%+v", usdt)
+	}
+
+	if _, _, _, err := s.collect(102, 104); err == nil {
+		t.Error("期望起始区块缺失时返回错误")
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("RECEIVING_ADDRESSES", "")
+	t.Setenv("DELEGATION_FROM_ADDRESS", "0x678637325f9be6b2264db347021432a6a7b84c10")
+	t.Setenv("SCANNER_START_BLOCK", "74204442")
+	t.Setenv("SCANNER_BATCH_SIZE", "")
+	t.Setenv("SCANNER_INTERVAL", "")
+
+	config, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("读取配置失败: %v", err)
+	}
+	if len(config.Receivers) != 1 || config.Receivers[0] != testReceiver {
+		t.Errorf("期望未设置 RECEIVING_ADDRESSES 时使用委托地址，实际为%v", config.Receivers)
+	}
+	if config.StartBlock != 74204442 || config.BatchSize != defaultBatchSize || config.Interval != defaultInterval {
+		t.Errorf("期望起始区块74204442及默认批次和间隔，实际为%+v", config)
+	}
+
+	t.Setenv("RECEIVING_ADDRESSES", testReceiver+", "+testPayer)
+	if config, err = ConfigFromEnv(); err != nil || len(config.Receivers) != 2 {
+		t.Errorf("期望2个收款地址，实际为%v, %v", config.Receivers, err)
+	}
+
+	t.Setenv("RECEIVING_ADDRESSES", "invalid")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("期望收款地址无效时返回错误")
+	}
+	t.Setenv("RECEIVING_ADDRESSES", "")
+	t.Setenv("DELEGATION_FROM_ADDRESS", "")
+	if _, err := ConfigFromEnv(); err != webhook.ErrNoReceivers {
+		t.Errorf("期望未配置收款地址时返回 ErrNoReceivers，实际为%v", err)
+	}
+}
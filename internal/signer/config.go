@@ -0,0 +1,78 @@
+package signer
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"lending-trx/internal/address"
+	"lending-trx/internal/tron"
+)
+
+// defaultListen 签名服务默认只监听本机
+const defaultListen = "127.0.0.1:8091"
+
+// Config 签名服务配置
+type Config struct {
+	Listen           string   // 监听地址：host:port 或 unix:///path/to/signer.sock
+	AuthToken        string   // Bearer 认证令牌，为空时不认证（仅建议用于 Unix socket）
+	Keystore         string   // keystore 文件路径
+	KeystorePassword string   // keystore 密码
+	PrivateKey       string   // 未使用 keystore 时的十六进制私钥
+	AllowedOwners    []string // 允许签名的委托方地址（Base58Check），为空时为签名地址本身
+	MaxAmount        int64    // 单笔委托/回收的最大质押金额（SUN）
+}
+
+// ConfigFromEnv 从环境变量读取签名服务配置
+//   - SIGNER_LISTEN: 监听地址，默认 127.0.0.1:8091
+//   - SIGNER_AUTH_TOKEN: 认证令牌
+//   - SIGNER_KEYSTORE / SIGNER_KEYSTORE_PASSWORD（或 SIGNER_KEYSTORE_PASSWORD_FILE）: keystore 文件及密码
+//   - SIGNER_PRIVATE_KEY: 未使用 keystore 时的私钥
+//   - SIGNER_ALLOWED_OWNERS: 允许的委托方地址（逗号分隔）
+//   - SIGNER_MAX_AMOUNT: 单笔最大质押金额（SUN），必须配置
+func ConfigFromEnv() (Config, error) {
+	config := Config{
+		Listen:     defaultListen,
+		AuthToken:  os.Getenv("SIGNER_AUTH_TOKEN"),
+		Keystore:   os.Getenv("SIGNER_KEYSTORE"),
+		PrivateKey: strings.TrimSpace(os.Getenv("SIGNER_PRIVATE_KEY")),
+	}
+	if listen := os.Getenv("SIGNER_LISTEN"); listen != "" {
+		config.Listen = listen
+	}
+
+	password, err := tron.KeystorePasswordFromEnv("SIGNER_KEYSTORE_PASSWORD")
+	if err != nil {
+		return Config{}, err
+	}
+	config.KeystorePassword = password
+	if config.Keystore == "" && config.PrivateKey == "" {
+		return Config{}, fmt.Errorf("SIGNER_KEYSTORE or SIGNER_PRIVATE_KEY is required")
+	}
+
+	for _, entry := range strings.Split(os.Getenv("SIGNER_ALLOWED_OWNERS"), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		owner, err := address.Normalize(entry)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid SIGNER_ALLOWED_OWNERS entry %q: %w", entry, err)
+		}
+		config.AllowedOwners = append(config.AllowedOwners, owner)
+	}
+
+	value := os.Getenv("SIGNER_MAX_AMOUNT")
+	if config.MaxAmount, err = strconv.ParseInt(value, 10, 64); err != nil || config.MaxAmount <= 0 {
+		return Config{}, fmt.Errorf("invalid SIGNER_MAX_AMOUNT %q, a positive amount in SUN is required", value)
+	}
+	return config, nil
+}
+
+// LoadSigner 按配置加载签名私钥，优先使用 keystore
+func (c Config) LoadSigner() (*tron.LocalSigner, error) {
+	if c.Keystore != "" {
+		return tron.LoadKeystore(c.Keystore, c.KeystorePassword)
+	}
+	return tron.ParseLocalSigner(c.PrivateKey)
+}
@@ -0,0 +1,67 @@
+package signer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"lending-trx/internal/tron"
+)
+
+// ErrPolicyViolation 交易不符合签名策略
+var ErrPolicyViolation = errors.New("signer policy violation")
+
+// Policy 签名策略：只签署允许的委托方发起的委托/回收交易，且单笔金额不超过上限
+// 交易内容从 raw_data_hex 解码，签名的正是 raw_data_hex 的哈希，raw_data JSON 不可信
+type Policy struct {
+	AllowedOwners []string // 允许的委托方地址（Base58Check）
+	MaxAmount     int64    // 单笔最大质押金额（SUN）
+}
+
+// Check 校验交易，通过时返回解码的委托/回收合约
+func (p Policy) Check(tx *tron.Transaction) (*tron.ResourceContract, *tron.RawContract, error) {
+	raw, err := hex.DecodeString(tx.RawDataHex)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: invalid raw_data_hex", ErrPolicyViolation)
+	}
+	hash := sha256.Sum256(raw)
+	if !strings.EqualFold(hex.EncodeToString(hash[:]), tx.TxID) {
+		return nil, nil, fmt.Errorf("%w: txID does not match raw_data_hex", ErrPolicyViolation)
+	}
+
+	data, err := tron.DecodeRawData(tx.RawDataHex)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrPolicyViolation, err)
+	}
+	if len(data.Contracts) != 1 {
+		return nil, nil, fmt.Errorf("%w: expected 1 contract, got %d", ErrPolicyViolation, len(data.Contracts))
+	}
+	contract := &data.Contracts[0]
+	if contract.Type != tron.ContractTypeDelegateResource && contract.Type != tron.ContractTypeUnDelegateResource {
+		return nil, nil, fmt.Errorf("%w: contract type %d not allowed", ErrPolicyViolation, contract.Type)
+	}
+
+	resource, err := contract.ResourceContract()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrPolicyViolation, err)
+	}
+	if !p.ownerAllowed(resource.OwnerAddress) {
+		return nil, nil, fmt.Errorf("%w: owner %s not allowed", ErrPolicyViolation, resource.OwnerAddress)
+	}
+	if resource.Balance <= 0 || resource.Balance > p.MaxAmount {
+		return nil, nil, fmt.Errorf("%w: amount %d exceeds limit %d", ErrPolicyViolation, resource.Balance, p.MaxAmount)
+	}
+	return resource, contract, nil
+}
+
+// ownerAllowed 委托方是否在允许列表中
+func (p Policy) ownerAllowed(owner string) bool {
+	for _, allowed := range p.AllowedOwners {
+		if allowed == owner {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package signer
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sunjiangjun/xlog"
+
+	"lending-trx/internal/tron"
+)
+
+// Server 签名服务：私钥只在本进程中，按策略校验后签名
+type Server struct {
+	signer tron.Signer
+	policy Policy
+	token  string
+	log    *xlog.XLog
+}
+
+// NewServer 创建签名服务
+func NewServer(signer tron.Signer, policy Policy, token string, log *xlog.XLog) *Server {
+	return &Server{signer: signer, policy: policy, token: token, log: log}
+}
+
+// Handler 返回签名服务的 HTTP handler
+func (s *Server) Handler() http.Handler {
+	r := gin.New()
+	r.Use(gin.Recovery(), s.authMiddleware())
+	r.GET(tron.SignerAddressPath, func(c *gin.Context) {
+		c.JSON(http.StatusOK, tron.SignerAddressResponse{Address: s.signer.Address()})
+	})
+	r.POST(tron.SignerSignPath, s.handleSign)
+	return r
+}
+
+// authMiddleware 校验 Bearer 令牌，未配置令牌时不校验
+func (s *Server) authMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.token == "" {
+			c.Next()
+			return
+		}
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			c.JSON(http.StatusUnauthorized, tron.SignResponse{Error: "unauthorized"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// handleSign 校验签名策略后签名，违反策略返回 403
+func (s *Server) handleSign(c *gin.Context) {
+	var req tron.SignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, tron.SignResponse{Error: "invalid request: " + err.Error()})
+		return
+	}
+	tx := req.Transaction
+
+	resource, contract, err := s.policy.Check(&tx)
+	if err != nil {
+		s.log.Warn("Sign request rejected", "tx_id", tx.TxID, "reason", err.Error())
+		c.JSON(http.StatusForbidden, tron.SignResponse{Error: err.Error()})
+		return
+	}
+
+	tx.Signature = nil
+	if err := s.signer.Sign(c.Request.Context(), &tx); err != nil {
+		s.log.Error("Failed to sign transaction", err, "tx_id", tx.TxID)
+		c.JSON(http.StatusInternalServerError, tron.SignResponse{Error: err.Error()})
+		return
+	}
+
+	s.log.Info("Transaction signed",
+		"tx_id", tx.TxID,
+		"contract_type", contract.Type,
+		"permission_id", contract.PermissionID,
+		"owner", resource.OwnerAddress,
+		"receiver", resource.ReceiverAddress,
+		"resource", resource.Resource,
+		"amount", resource.Balance,
+	)
+	c.JSON(http.StatusOK, tron.SignResponse{Signature: tx.Signature[0]})
+}
+
+// Listen 监听 host:port 或 unix:///path/to/signer.sock，Unix socket 文件权限为 0600
+func Listen(endpoint string) (net.Listener, error) {
+	socket, ok := strings.CutPrefix(endpoint, "unix://")
+	if !ok {
+		return net.Listen("tcp", endpoint)
+	}
+	if socket == "" {
+		return nil, fmt.Errorf("invalid listen address %q", endpoint)
+	}
+	// 清理上次退出遗留的 socket 文件
+	if err := os.Remove(socket); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(socket, 0o600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to chmod socket: %w", err)
+	}
+	return listener, nil
+}
+
+// Serve 在 listener 上提供签名服务，ctx 结束时优雅关闭
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	server := &http.Server{Handler: s.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sunjiangjun/xlog"
+
+	"lending-trx/internal/tron"
+	"lending-trx/internal/tron/fakechain"
+	"lending-trx/internal/units"
+)
+
+const (
+	testPrivateKey = "b5a4cea271ff424d7c31dc12a3e43e401df7a40d7412a15750f3f0b6b5449a28"
+	testReceiver   = "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+	testToken      = "signer-token"
+)
+
+// newTestChain 创建模拟链，委托账户为签名私钥对应的地址，已质押 1000 TRX
+func newTestChain(t *testing.T, owner string) *fakechain.Chain {
+	t.Helper()
+	chain := fakechain.New()
+	chain.CreateAccount(owner, 2000*units.SunPerTRX)
+	chain.CreateAccount(testReceiver, 0)
+	if err := chain.Stake(owner, 1000*units.SunPerTRX); err != nil {
+		t.Fatalf("质押失败: %v", err)
+	}
+	return chain
+}
+
+// newTestServer 启动签名服务，只允许 owner 签署不超过 maxAmount 的委托/回收
+func newTestServer(t *testing.T, maxAmount int64) (*Server, *tron.LocalSigner) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	local, err := tron.ParseLocalSigner(testPrivateKey)
+	if err != nil {
+		t.Fatalf("解析私钥失败: %v", err)
+	}
+	policy := Policy{AllowedOwners: []string{local.Address()}, MaxAmount: maxAmount}
+	return NewServer(local, policy, testToken, xlog.NewXLogger()), local
+}
+
+func TestRemoteSignerHTTP(t *testing.T) {
+	server, local := newTestServer(t, 10*units.SunPerTRX)
+	signerServer := httptest.NewServer(server.Handler())
+	t.Cleanup(signerServer.Close)
+
+	ctx := context.Background()
+	if _, err := tron.NewRemoteSigner(ctx, signerServer.URL, "wrong"); !errors.Is(err, tron.ErrSignature) {
+		t.Errorf("期望令牌错误时返回签名错误，实际为%v", err)
+	}
+	remote, err := tron.NewRemoteSigner(ctx, signerServer.URL, testToken)
+	if err != nil {
+		t.Fatalf("连接签名服务失败: %v", err)
+	}
+	if remote.Address() != local.Address() {
+		t.Errorf("期望签名地址为%s，实际为%s", local.Address(), remote.Address())
+	}
+
+	// 节点客户端不持有私钥，交易由签名服务签名
+	chain := newTestChain(t, local.Address())
+	node := httptest.NewServer(chain.Handler())
+	t.Cleanup(node.Close)
+	client := tron.NewTronClient(node.URL, "")
+	client.SetRetryPolicy(tron.RetryPolicy{MaxAttempts: 1})
+	if err := client.UseSigners([]tron.Signer{remote}, tron.OwnerPermissionID); err != nil {
+		t.Fatal(err)
+	}
+	runPolicyChecks(t, chain, client, local.Address())
+}
+
+func TestRemoteSignerUnixSocket(t *testing.T) {
+	server, local := newTestServer(t, 10*units.SunPerTRX)
+	socket := filepath.Join(t.TempDir(), "signer.sock")
+	listener, err := Listen("unix://" + socket)
+	if err != nil {
+		t.Fatalf("监听失败: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go server.Serve(ctx, listener)
+
+	remote, err := tron.NewRemoteSigner(ctx, "unix://"+socket, testToken)
+	if err != nil {
+		t.Fatalf("连接签名服务失败: %v", err)
+	}
+
+	// 模拟链进程内使用远程签名者，广播时从签名恢复签名地址
+	chain := newTestChain(t, local.Address())
+	if err := chain.UseSigners([]tron.Signer{remote}, tron.OwnerPermissionID); err != nil {
+		t.Fatal(err)
+	}
+	runPolicyChecks(t, chain, chain, local.Address())
+}
+
+// runPolicyChecks 上限内的委托和回收可以签名，超过上限和质押交易被拒绝
+func runPolicyChecks(t *testing.T, chain *fakechain.Chain, client tron.Client, owner string) {
+	t.Helper()
+	ctx := context.Background()
+	amount := strconv.FormatInt(5*units.SunPerTRX, 10)
+
+	if _, err := client.DelegateEnergy(ctx, &tron.EnergyDelegationRequest{FromAddress: owner, ToAddress: testReceiver, Amount: amount}); err != nil {
+		t.Fatalf("委托失败: %v", err)
+	}
+	chain.MineBlock()
+	if got := chain.Delegated(owner, testReceiver); got != 5*units.SunPerTRX {
+		t.Errorf("期望委托%d，实际为%d", 5*units.SunPerTRX, got)
+	}
+	if _, err := client.CancelEnergyDelegation(ctx, &tron.CancelDelegationRequest{FromAddress: owner, ToAddress: testReceiver, Amount: amount}); err != nil {
+		t.Fatalf("回收失败: %v", err)
+	}
+	chain.MineBlock()
+
+	overLimit := strconv.FormatInt(11*units.SunPerTRX, 10)
+	_, err := client.DelegateEnergy(ctx, &tron.EnergyDelegationRequest{FromAddress: owner, ToAddress: testReceiver, Amount: overLimit})
+	if !errors.Is(err, tron.ErrSignerPolicy) {
+		t.Errorf("期望超过单笔上限被拒绝，实际为%v", err)
+	}
+
+	if _, err := client.FreezeBalanceV2(ctx, owner, units.SunPerTRX, tron.ResourceEnergy); !errors.Is(err, tron.ErrSignerPolicy) {
+		t.Errorf("期望质押交易被拒绝，实际为%v", err)
+	}
+	if got := chain.Delegated(owner, testReceiver); got != 0 {
+		t.Errorf("期望被拒绝的交易不上链，实际委托为%d", got)
+	}
+}
+
+func TestPolicyRejectsTamperedTransaction(t *testing.T) {
+	policy := Policy{AllowedOwners: []string{"TSoXbDPwgM1nhfbxVj53u15My8smJHWpFw"}, MaxAmount: units.SunPerTRX}
+	tx := &tron.Transaction{TxID: "00", RawDataHex: "0a02451a"}
+	if _, _, err := policy.Check(tx); !errors.Is(err, ErrPolicyViolation) {
+		t.Errorf("期望 txID 与 raw_data_hex 不一致时拒绝，实际为%v", err)
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("SIGNER_LISTEN", "")
+	t.Setenv("SIGNER_KEYSTORE", "")
+	t.Setenv("SIGNER_KEYSTORE_PASSWORD_FILE", "")
+	t.Setenv("SIGNER_PRIVATE_KEY", testPrivateKey)
+	t.Setenv("SIGNER_ALLOWED_OWNERS", "")
+	t.Setenv("SIGNER_MAX_AMOUNT", "")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("期望未配置单笔上限时返回错误")
+	}
+
+	t.Setenv("SIGNER_MAX_AMOUNT", "100000000")
+	t.Setenv("SIGNER_ALLOWED_OWNERS", "0x678637325f9be6b2264db347021432a6a7b84c10")
+	config, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("读取配置失败: %v", err)
+	}
+	if config.Listen != defaultListen || config.MaxAmount != 100000000 {
+		t.Errorf("期望默认监听地址和上限100 TRX，实际为%+v", config)
+	}
+	if len(config.AllowedOwners) != 1 || config.AllowedOwners[0] != "TKQbPHJ7vaPVc8KuYaQTs2ew6gb64r9xhK" {
+		t.Errorf("期望委托方地址转换为 Base58Check，实际为%v", config.AllowedOwners)
+	}
+}
@@ -0,0 +1,143 @@
+package tron
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 区块扫描关注的合约类型
+const (
+	ContractTransfer             = "TransferContract"     // TRX 转账
+	ContractTriggerSmartContract = "TriggerSmartContract" // 合约调用（TRC20 转账）
+)
+
+// MaxBlocksPerRequest /wallet/getblockbylimitnext 单次最多返回的区块数
+const MaxBlocksPerRequest = 100
+
+// Block /wallet/getnowblock、/wallet/getblockbylimitnext 返回的区块（visible=true，地址为 Base58Check）
+type Block struct {
+	BlockID     string `json:"blockID"`
+	BlockHeader struct {
+		RawData struct {
+			Number     int64  `json:"number"`
+			Timestamp  int64  `json:"timestamp"` // 区块时间（毫秒）
+			ParentHash string `json:"parentHash"`
+		} `json:"raw_data"`
+	} `json:"block_header"`
+	Transactions []BlockTransaction `json:"transactions"`
+}
+
+// Found 区块是否存在，节点查询不到区块时返回空对象
+func (b *Block) Found() bool {
+	return b != nil && b.BlockID != ""
+}
+
+// Number 区块高度
+func (b *Block) Number() int64 {
+	return b.BlockHeader.RawData.Number
+}
+
+// Timestamp 区块时间（毫秒）
+func (b *Block) Timestamp() int64 {
+	return b.BlockHeader.RawData.Timestamp
+}
+
+// SameBlockHash 比较两个区块哈希，忽略 0x 前缀和大小写（stream 推送的 blockHash 带 0x 前缀）
+func SameBlockHash(a, b string) bool {
+	return strings.EqualFold(strings.TrimPrefix(a, "0x"), strings.TrimPrefix(b, "0x"))
+}
+
+// BlockTransaction 区块中的交易
+type BlockTransaction struct {
+	TxID string `json:"txID"`
+	Ret  []struct {
+		ContractRet string `json:"contractRet"`
+	} `json:"ret"`
+	RawData struct {
+		Contract []BlockContract `json:"contract"`
+		Data     string          `json:"data,omitempty"` // 交易备注（十六进制）
+	} `json:"raw_data"`
+}
+
+// Succeeded 交易是否执行成功，系统合约成功时 contractRet 为 SUCCESS
+func (t *BlockTransaction) Succeeded() bool {
+	return len(t.Ret) == 0 || t.Ret[0].ContractRet == "" || t.Ret[0].ContractRet == "SUCCESS"
+}
+
+// Contract 交易的合约（TRON 交易只包含一个合约），没有合约时返回 nil
+func (t *BlockTransaction) Contract() *BlockContract {
+	if len(t.RawData.Contract) == 0 {
+		return nil
+	}
+	return &t.RawData.Contract[0]
+}
+
+// BlockContract 交易中的合约调用
+type BlockContract struct {
+	Type      string `json:"type"`
+	Parameter struct {
+		Value ContractValue `json:"value"`
+	} `json:"parameter"`
+}
+
+// ContractValue 合约参数中区块扫描需要的字段
+//   - TransferContract: owner_address → to_address，amount（SUN）
+//   - TriggerSmartContract: owner_address 调用 contract_address，data 为调用数据（十六进制），call_value（SUN）
+type ContractValue struct {
+	OwnerAddress    string `json:"owner_address"`
+	ToAddress       string `json:"to_address,omitempty"`
+	Amount          int64  `json:"amount,omitempty"`
+	ContractAddress string `json:"contract_address,omitempty"`
+	Data            string `json:"data,omitempty"`
+	CallValue       int64  `json:"call_value,omitempty"`
+}
+
+// GetNowBlock 获取最新区块
+func (c *TronClient) GetNowBlock(ctx context.Context) (*Block, error) {
+	var block Block
+	if err := c.postJSON(ctx, "/wallet/getnowblock", map[string]interface{}{"visible": true}, &block); err != nil {
+		return nil, fmt.Errorf("failed to get now block: %w", err)
+	}
+	return &block, nil
+}
+
+// GetSolidifiedNowBlock 获取最新固化区块（solidity 节点），固化区块不会被回滚
+func (c *TronClient) GetSolidifiedNowBlock(ctx context.Context) (*Block, error) {
+	var block Block
+	if err := c.postJSON(ctx, "/walletsolidity/getnowblock", map[string]interface{}{"visible": true}, &block); err != nil {
+		return nil, fmt.Errorf("failed to get solidified now block: %w", err)
+	}
+	return &block, nil
+}
+
+// GetBlockByNum 获取主链上指定高度的区块，区块尚未产生时 Found() 为 false
+func (c *TronClient) GetBlockByNum(ctx context.Context, num int64) (*Block, error) {
+	var block Block
+	payload := map[string]interface{}{"num": num, "visible": true}
+	if err := c.postJSON(ctx, "/wallet/getblockbynum", payload, &block); err != nil {
+		return nil, fmt.Errorf("failed to get block %d: %w", num, err)
+	}
+	return &block, nil
+}
+
+// GetBlocksByLimitNext 获取 [start, end) 范围内的区块，按高度升序返回
+// 单次最多 MaxBlocksPerRequest 个区块，节点尚未产生的区块不返回
+func (c *TronClient) GetBlocksByLimitNext(ctx context.Context, start, end int64) ([]Block, error) {
+	if start < 0 || end <= start || end-start > MaxBlocksPerRequest {
+		return nil, fmt.Errorf("invalid block range [%d, %d)", start, end)
+	}
+
+	var result struct {
+		Block []Block `json:"block"`
+	}
+	payload := map[string]interface{}{"startNum": start, "endNum": end, "visible": true}
+	if err := c.postJSON(ctx, "/wallet/getblockbylimitnext", payload, &result); err != nil {
+		return nil, fmt.Errorf("failed to get blocks [%d, %d): %w", start, end, err)
+	}
+	sort.Slice(result.Block, func(i, j int) bool {
+		return result.Block[i].Number() < result.Block[j].Number()
+	})
+	return result.Block, nil
+}
@@ -0,0 +1,58 @@
+package tron
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetBlocksByLimitNext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wallet/getblockbylimitnext" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var payload struct {
+			StartNum int64 `json:"startNum"`
+			EndNum   int64 `json:"endNum"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		if payload.StartNum != 100 || payload.EndNum != 102 {
+			t.Errorf("期望请求区块范围为[100, 102)，实际为[%d, %d)", payload.StartNum, payload.EndNum)
+		}
+		// 节点返回的区块不保证顺序
+		w.Write([]byte(`{"block":[
+			{"blockID":"b101","block_header":{"raw_data":{"number":101,"timestamp":1753271859000}},"transactions":[
+				{"txID":"t1","ret":[{"contractRet":"REVERT"}],"raw_data":{"contract":[{"type":"TriggerSmartContract","parameter":{"value":{"owner_address":"TSoXbDPwgM1nhfbxVj53u15My8smJHWpFw","contract_address":"TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t","data":"a9059cbb"}}}]}}
+			]},
+			{"blockID":"b100","block_header":{"raw_data":{"number":100,"timestamp":1753271856000}},"transactions":[
+				{"txID":"t0","ret":[{"contractRet":"SUCCESS"}],"raw_data":{"contract":[{"type":"TransferContract","parameter":{"value":{"owner_address":"TSoXbDPwgM1nhfbxVj53u15My8smJHWpFw","to_address":"TKQbPHJ7vaPVc8KuYaQTs2ew6gb64r9xhK","amount":2000000}}}]}}
+			]}
+		]}`))
+	}))
+	defer server.Close()
+
+	client := NewTronClient(server.URL, "")
+	blocks, err := client.GetBlocksByLimitNext(context.Background(), 100, 102)
+	if err != nil {
+		t.Fatalf("查询区块失败: %v", err)
+	}
+	if len(blocks) != 2 || blocks[0].Number() != 100 || blocks[1].Number() != 101 {
+		t.Fatalf("期望按高度升序返回区块100和101，实际为%+v", blocks)
+	}
+
+	transfer := blocks[0].Transactions[0]
+	contract := transfer.Contract()
+	if !transfer.Succeeded() || contract == nil || contract.Type != ContractTransfer || contract.Parameter.Value.Amount != 2000000 {
+		t.Errorf("期望为成功的 TRX 转账 2000000 SUN，实际为%+v", transfer)
+	}
+	if blocks[1].Transactions[0].Succeeded() {
+		t.Error("期望 REVERT 的交易为失败")
+	}
+
+	if _, err := client.GetBlocksByLimitNext(context.Background(), 100, 100+MaxBlocksPerRequest+1); err == nil {
+		t.Error("期望超过单次区块数上限时返回错误")
+	}
+}
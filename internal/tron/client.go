@@ -1,38 +1,181 @@
 package tron
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
+
+	"lending-trx/internal/address"
 )
 
+// Client TRON 客户端接口
+// TronClient 为基于节点 HTTP API 的实现，fakechain 包提供用于测试的内存实现
+type Client interface {
+	// SetPrivateKey 设置用于签名的委托账户私钥（十六进制），使用 owner 权限签名
+	SetPrivateKey(hexKey string) error
+	// SetSigners 设置多个签名私钥（十六进制）和签名使用的权限ID，用于多签或自定义 active 权限
+	SetSigners(hexKeys []string, permissionID int) error
+	// UseSigners 设置签名者（进程内私钥、keystore 或远程签名服务）和签名使用的权限ID
+	UseSigners(signers []Signer, permissionID int) error
+	// SignerAddress 返回第一个签名者的地址，未配置签名者时返回空字符串
+	SignerAddress() string
+	// SignerAddresses 返回全部签名者的地址
+	SignerAddresses() []string
+	// PermissionID 返回签名使用的权限ID，0 为 owner 权限
+	PermissionID() int
+	// GetAccountPermission 查询账户的 owner / active 权限
+	GetAccountPermission(ctx context.Context, addr string) (*AccountPermission, error)
+	// GetAccountInfo 获取账户信息
+	GetAccountInfo(ctx context.Context, addr string) (*AccountInfo, error)
+	// GetAccountResource 获取账户资源及全网资源总量
+	GetAccountResource(ctx context.Context, addr string) (*AccountResource, error)
+	// GetChainParameters 获取链参数
+	GetChainParameters(ctx context.Context) (ChainParameters, error)
+	// GetCanDelegatedMaxSize 获取账户当前可委托的最大质押金额（SUN）
+	GetCanDelegatedMaxSize(ctx context.Context, owner string, resource string) (int64, error)
+	// GetDelegatedResourceV2 查询 from 委托给 to 的资源
+	GetDelegatedResourceV2(ctx context.Context, from, to string) ([]DelegatedResource, error)
+	// DelegateEnergy 签名并广播资源委托交易（能量或带宽）
+	DelegateEnergy(ctx context.Context, req *EnergyDelegationRequest) (*EnergyDelegationResponse, error)
+	// CancelEnergyDelegation 签名并广播回收资源交易（能量或带宽）
+	CancelEnergyDelegation(ctx context.Context, req *CancelDelegationRequest) (*CancelDelegationResponse, error)
+	// BuildDelegation 构建并签名资源委托交易，不广播；调用方可在广播前保存交易ID
+	BuildDelegation(ctx context.Context, req *EnergyDelegationRequest) (*Transaction, error)
+	// BuildUndelegation 构建并签名回收资源交易，不广播
+	BuildUndelegation(ctx context.Context, req *CancelDelegationRequest) (*Transaction, error)
+	// BroadcastTransaction 广播已签名交易，同一交易重复广播视为成功
+	BroadcastTransaction(ctx context.Context, tx *Transaction) (*BroadcastResponse, error)
+	// GetSolidifiedTransactionInfo 获取已固化的交易回执，交易未固化时 Found() 为 false
+	GetSolidifiedTransactionInfo(ctx context.Context, txID string) (*TransactionInfo, error)
+	// CheckConfirmation 查询交易确认状态
+	CheckConfirmation(ctx context.Context, txID string) (*Confirmation, error)
+	// ConfirmMissing 向多个节点确认交易未被收录，用于判断已过期的交易是否被丢弃
+	ConfirmMissing(ctx context.Context, txID string) (bool, error)
+	// GetStakeAccount 查询账户余额和 Stake 2.0 质押状态
+	GetStakeAccount(ctx context.Context, addr string) (*StakeAccount, error)
+	// FreezeBalanceV2 签名并广播质押交易
+	FreezeBalanceV2(ctx context.Context, owner string, amount int64, resource string) (string, error)
+	// UnfreezeBalanceV2 签名并广播解质押交易
+	UnfreezeBalanceV2(ctx context.Context, owner string, amount int64, resource string) (string, error)
+	// WithdrawExpireUnfreeze 签名并广播提取到期解质押的交易
+	WithdrawExpireUnfreeze(ctx context.Context, owner string) (string, error)
+	// GetNowBlock 获取最新区块
+	GetNowBlock(ctx context.Context) (*Block, error)
+	// GetSolidifiedNowBlock 获取最新固化区块
+	GetSolidifiedNowBlock(ctx context.Context) (*Block, error)
+	// GetBlockByNum 获取主链上指定高度的区块
+	GetBlockByNum(ctx context.Context, num int64) (*Block, error)
+}
+
+var _ Client = (*TronClient)(nil)
+
 // TronClient Tron API 客户端
+// 支持配置多个全节点，请求按节点健康状态和延迟路由，节点故障时自动切换
 type TronClient struct {
-	baseURL    string
-	httpClient *http.Client
-	apiKey     string
+	nodes        []*node
+	httpClient   *http.Client
+	signers      []Signer    // 签名者，多签时每个签名者各签一次
+	permissionID int         // 签名使用的权限ID，0 为 owner 权限
+	retryPolicy  RetryPolicy // 所有节点都失败时的重试策略
 }
 
-// NewTronClient 创建新的 Tron 客户端
+// NewTronClient 创建新的 Tron 客户端（单节点）
 func NewTronClient(baseURL, apiKey string) *TronClient {
+	return NewTronClientWithNodes([]NodeConfig{{Name: "default", URL: baseURL, APIKey: apiKey}})
+}
+
+// NewTronClientWithNodes 创建使用多个全节点的 Tron 客户端，列表顺序即初始优先级
+func NewTronClientWithNodes(configs []NodeConfig) *TronClient {
+	nodes := make([]*node, 0, len(configs))
+	for i, config := range configs {
+		config.URL = strings.TrimRight(config.URL, "/")
+		if config.Name == "" {
+			config.Name = fmt.Sprintf("node-%d", i+1)
+		}
+		nodes = append(nodes, &node{config: config})
+	}
 	return &TronClient{
-		baseURL: baseURL,
+		nodes: nodes,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		apiKey: apiKey,
+		retryPolicy: DefaultRetryPolicy(),
+	}
+}
+
+// SetRetryPolicy 设置重试策略
+func (c *TronClient) SetRetryPolicy(policy RetryPolicy) {
+	c.retryPolicy = policy
+}
+
+// SetPrivateKey 设置用于本地签名的委托账户私钥（十六进制），使用 owner 权限签名
+func (c *TronClient) SetPrivateKey(hexKey string) error {
+	return c.SetSigners([]string{hexKey}, OwnerPermissionID)
+}
+
+// SetSigners 设置多个签名私钥（十六进制）和签名使用的权限ID
+// 交易按 permissionID 对应的权限校验，各私钥签名权重之和需达到该权限的阈值
+func (c *TronClient) SetSigners(hexKeys []string, permissionID int) error {
+	if len(hexKeys) == 0 {
+		return fmt.Errorf("no private key provided")
+	}
+	if permissionID < 0 {
+		return fmt.Errorf("invalid permission id: %d", permissionID)
+	}
+	signers := make([]Signer, 0, len(hexKeys))
+	for i, hexKey := range hexKeys {
+		signer, err := ParseLocalSigner(hexKey)
+		if err != nil {
+			return fmt.Errorf("private key #%d: %w", i+1, err)
+		}
+		signers = append(signers, signer)
+	}
+	return c.UseSigners(signers, permissionID)
+}
+
+// UseSigners 设置签名者和签名使用的权限ID
+func (c *TronClient) UseSigners(signers []Signer, permissionID int) error {
+	if len(signers) == 0 {
+		return fmt.Errorf("no signer provided")
 	}
+	if permissionID < 0 {
+		return fmt.Errorf("invalid permission id: %d", permissionID)
+	}
+	c.signers = signers
+	c.permissionID = permissionID
+	return nil
+}
+
+// SignerAddress 返回第一个签名者的 TRON 地址（Base58Check），未配置签名者时返回空字符串
+func (c *TronClient) SignerAddress() string {
+	if len(c.signers) == 0 {
+		return ""
+	}
+	return c.signers[0].Address()
+}
+
+// SignerAddresses 返回全部签名者的 TRON 地址
+func (c *TronClient) SignerAddresses() []string {
+	return signerAddresses(c.signers)
+}
+
+// PermissionID 返回签名使用的权限ID
+func (c *TronClient) PermissionID() int {
+	return c.permissionID
 }
 
-// EnergyDelegationRequest 能量委托请求
+// EnergyDelegationRequest 资源委托请求（能量或带宽）
 type EnergyDelegationRequest struct {
 	FromAddress string `json:"from_address"` // 委托方地址
 	ToAddress   string `json:"to_address"`   // 接收方地址
-	Amount      string `json:"amount"`       // 委托的能量数量
+	Amount      string `json:"amount"`       // 委托的质押金额（SUN），对应 delegateresource 的 balance
+	Resource    string `json:"resource"`     // 资源类型 ENERGY / BANDWIDTH，为空时委托能量
+	Lock        bool   `json:"lock"`         // 是否锁定委托，锁定期内委托方无法回收
+	LockPeriod  int64  `json:"lock_period"`  // 锁定期（区块数，每块3秒），Lock 为 true 时必须大于0
 	// 以下字段用于业务追踪，不是 Tron API 必需字段
 	TxHash      string `json:"tx_hash,omitempty"`      // 原始交易哈希（业务追踪）
 	BlockHeight int64  `json:"block_height,omitempty"` // 区块高度（业务追踪）
@@ -47,12 +190,15 @@ type EnergyDelegationResponse struct {
 }
 
 // CancelDelegationRequest 取消委托请求
+// 链上委托按 (owner, receiver, resource) 聚合，回收按金额进行，与具体的委托交易无关
 type CancelDelegationRequest struct {
-	FromAddress  string `json:"from_address"`   // 委托方地址
-	ToAddress    string `json:"to_address"`     // 接收方地址
-	OriginalTxID string `json:"original_tx_id"` // 原始委托交易ID
+	FromAddress string `json:"from_address"` // 委托方地址
+	ToAddress   string `json:"to_address"`   // 接收方地址
+	Amount      string `json:"amount"`       // 回收的质押金额（SUN），对应 undelegateresource 的 balance
+	Resource    string `json:"resource"`     // 资源类型 ENERGY / BANDWIDTH，为空时回收能量
 	// 以下字段用于业务追踪，不是 Tron API 必需字段
-	TxHash string `json:"tx_hash,omitempty"` // 原始交易哈希（业务追踪）
+	OriginalTxID string `json:"original_tx_id,omitempty"` // 原始委托交易ID（业务追踪）
+	TxHash       string `json:"tx_hash,omitempty"`        // 原始交易哈希（业务追踪）
 }
 
 // CancelDelegationResponse 取消委托响应
@@ -76,157 +222,234 @@ type AccountInfo struct {
 }
 
 // GetAccountInfo 获取账户信息
-func (c *TronClient) GetAccountInfo(ctx context.Context, address string) (*AccountInfo, error) {
-	url := fmt.Sprintf("%s/v1/accounts/%s", c.baseURL, address)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	if c.apiKey != "" {
-		req.Header.Set("TRON-PRO-API-KEY", c.apiKey)
-	}
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+func (c *TronClient) GetAccountInfo(ctx context.Context, addr string) (*AccountInfo, error) {
+	normalized, err := address.Normalize(addr)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed, status code: %d, response: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("invalid account address %q: %w", addr, err)
 	}
 
 	var accountInfo AccountInfo
-	if err := json.Unmarshal(body, &accountInfo); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err := c.doRequest(ctx, "GET", "/v1/accounts/"+normalized, nil, &accountInfo); err != nil {
+		return nil, err
 	}
 
 	return &accountInfo, nil
 }
 
-// DelegateEnergy 执行能量委托
+// DelegateEnergy 执行资源委托（能量或带宽，由 req.Resource 指定）
+// 通过 /wallet/delegateresource 构建 DelegateResourceContract，本地签名后广播
 func (c *TronClient) DelegateEnergy(ctx context.Context, req *EnergyDelegationRequest) (*EnergyDelegationResponse, error) {
-	url := fmt.Sprintf("%s/v1/energy/delegate", c.baseURL)
-
-	jsonData, err := json.Marshal(req)
+	tx, err := c.BuildDelegation(ctx, req)
+	if err == nil {
+		_, err = c.BroadcastTransaction(ctx, tx)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to serialize request: %w", err)
+		return &EnergyDelegationResponse{Success: false, Error: err.Error()}, fmt.Errorf("energy delegation failed: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	return &EnergyDelegationResponse{
+		Success: true,
+		TxID:    tx.TxID,
+		Message: "delegate resource transaction broadcast",
+	}, nil
+}
+
+// BuildDelegation 构建并签名资源委托交易，不广播
+func (c *TronClient) BuildDelegation(ctx context.Context, req *EnergyDelegationRequest) (*Transaction, error) {
+	balance, err := strconv.ParseInt(req.Amount, 10, 64)
+	if err != nil || balance <= 0 {
+		return nil, fmt.Errorf("invalid delegation amount: %s", req.Amount)
 	}
 
-	httpReq.Header.Set("Content-Type", "application/json")
-	if c.apiKey != "" {
-		httpReq.Header.Set("TRON-PRO-API-KEY", c.apiKey)
+	if req.Lock && (req.LockPeriod <= 0 || req.LockPeriod > MaxLockPeriod) {
+		// lock_period 为0时节点默认锁定 86400 个区块（3天），必须显式指定
+		return nil, fmt.Errorf("invalid lock period: %d", req.LockPeriod)
 	}
 
-	resp, err := c.httpClient.Do(httpReq)
+	resource, err := NormalizeResource(req.Resource)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	owner, receiver, err := normalizePair(req.FromAddress, req.ToAddress)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, err
 	}
 
-	var response EnergyDelegationResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	payload := map[string]interface{}{
+		"owner_address":    owner,
+		"receiver_address": receiver,
+		"balance":          balance,
+		"resource":         resource,
+		"lock":             req.Lock,
+		"visible":          true,
 	}
-
-	if !response.Success {
-		return &response, fmt.Errorf("energy delegation failed: %s", response.Error)
+	want := expectedContract{
+		Type:     ContractTypeDelegateResource,
+		Owner:    owner,
+		Receiver: receiver,
+		Resource: resource,
+		Balance:  balance,
+		Lock:     req.Lock,
+	}
+	if req.Lock {
+		payload["lock_period"] = req.LockPeriod
+		want.LockPeriod = req.LockPeriod
 	}
 
-	return &response, nil
+	return c.buildAndSign(ctx, "/wallet/delegateresource", payload, want)
 }
 
-// CancelEnergyDelegation 取消能量委托
+// CancelEnergyDelegation 取消资源委托（能量或带宽，由 req.Resource 指定）
+// 通过 /wallet/undelegateresource 构建 UnDelegateResourceContract，本地签名后广播
 func (c *TronClient) CancelEnergyDelegation(ctx context.Context, req *CancelDelegationRequest) (*CancelDelegationResponse, error) {
-	url := fmt.Sprintf("%s/v1/energy/cancel-delegate", c.baseURL)
-
-	jsonData, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to serialize request: %w", err)
+	tx, err := c.BuildUndelegation(ctx, req)
+	if err == nil {
+		_, err = c.BroadcastTransaction(ctx, tx)
 	}
-
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return &CancelDelegationResponse{Success: false, Error: err.Error()}, fmt.Errorf("cancel energy delegation failed: %w", err)
 	}
 
-	httpReq.Header.Set("Content-Type", "application/json")
-	if c.apiKey != "" {
-		httpReq.Header.Set("TRON-PRO-API-KEY", c.apiKey)
+	return &CancelDelegationResponse{
+		Success: true,
+		TxID:    tx.TxID,
+		Message: "undelegate resource transaction broadcast",
+	}, nil
+}
+
+// BuildUndelegation 构建并签名回收资源交易，不广播
+func (c *TronClient) BuildUndelegation(ctx context.Context, req *CancelDelegationRequest) (*Transaction, error) {
+	balance, err := strconv.ParseInt(req.Amount, 10, 64)
+	if err != nil || balance <= 0 {
+		return nil, fmt.Errorf("invalid undelegation amount: %s", req.Amount)
 	}
 
-	resp, err := c.httpClient.Do(httpReq)
+	resource, err := NormalizeResource(req.Resource)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	owner, receiver, err := normalizePair(req.FromAddress, req.ToAddress)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, err
 	}
 
-	var response CancelDelegationResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	payload := map[string]interface{}{
+		"owner_address":    owner,
+		"receiver_address": receiver,
+		"balance":          balance,
+		"resource":         resource,
+		"visible":          true,
 	}
 
-	if !response.Success {
-		return &response, fmt.Errorf("cancel energy delegation failed: %s", response.Error)
+	want := expectedContract{
+		Type:     ContractTypeUnDelegateResource,
+		Owner:    owner,
+		Receiver: receiver,
+		Resource: resource,
+		Balance:  balance,
 	}
-
-	return &response, nil
+	return c.buildAndSign(ctx, "/wallet/undelegateresource", payload, want)
 }
 
-// GetTransactionInfo 获取交易信息
-func (c *TronClient) GetTransactionInfo(ctx context.Context, txID string) (map[string]interface{}, error) {
-	url := fmt.Sprintf("%s/v1/transactions/%s", c.baseURL, txID)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+// normalizePair 将委托方和接收方地址转换为 Base58Check（请求使用 visible=true）
+func normalizePair(owner, receiver string) (string, string, error) {
+	ownerAddr, err := address.Normalize(owner)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return "", "", fmt.Errorf("invalid owner address %q: %w", owner, err)
 	}
-
-	if c.apiKey != "" {
-		req.Header.Set("TRON-PRO-API-KEY", c.apiKey)
+	receiverAddr, err := address.Normalize(receiver)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid receiver address %q: %w", receiver, err)
 	}
+	return ownerAddr, receiverAddr, nil
+}
 
-	resp, err := c.httpClient.Do(req)
+// buildSignAndBroadcast 调用节点构建交易，由签名者签名后广播
+func (c *TronClient) buildSignAndBroadcast(ctx context.Context, path string, payload map[string]interface{}, want expectedContract) (*BroadcastResponse, error) {
+	tx, err := c.buildAndSign(ctx, path, payload, want)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return c.BroadcastTransaction(ctx, tx)
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+// buildAndSign 调用节点构建交易，与 want 比对一致后由签名者签名，不一致时返回 ErrUnexpectedTransaction
+// 使用自定义权限时在请求中携带 Permission_id，由节点写入交易的合约中
+func (c *TronClient) buildAndSign(ctx context.Context, path string, payload map[string]interface{}, want expectedContract) (*Transaction, error) {
+	if len(c.signers) == 0 {
+		return nil, fmt.Errorf("signer not configured, cannot sign transaction")
+	}
+	if c.permissionID != OwnerPermissionID {
+		payload["Permission_id"] = c.permissionID
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed, status code: %d, response: %s", resp.StatusCode, string(body))
+	var built transactionResponse
+	if err := c.postJSON(ctx, path, payload, &built); err != nil {
+		return nil, fmt.Errorf("failed to build transaction: %w", err)
+	}
+	if built.Error != "" {
+		return nil, fmt.Errorf("node rejected transaction: %w", classifyNodeMessage(decodeNodeMessage(built.Error)))
+	}
+	if built.TxID == "" || built.RawDataHex == "" {
+		return nil, fmt.Errorf("node returned empty transaction")
 	}
 
-	var txInfo map[string]interface{}
-	if err := json.Unmarshal(body, &txInfo); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	tx := built.Transaction
+	if err := verifyBuilt(&tx, want, c.permissionID); err != nil {
+		return nil, err
 	}
+	for _, signer := range c.signers {
+		if err := signer.Sign(ctx, &tx); err != nil {
+			return nil, fmt.Errorf("failed to sign transaction with %s: %w", signer.Address(), err)
+		}
+	}
+	return &tx, nil
+}
 
-	return txInfo, nil
+// BroadcastTransaction 广播已签名交易
+// 同一笔已签名交易重复广播是幂等的：节点返回 DUP_TRANSACTION_ERROR 时视为成功，
+// 因此广播失败（例如响应丢失）可以安全地重试同一笔交易，而不会重复委托
+func (c *TronClient) BroadcastTransaction(ctx context.Context, tx *Transaction) (*BroadcastResponse, error) {
+	var resp BroadcastResponse
+	if err := c.postJSON(ctx, "/wallet/broadcasttransaction", tx, &resp); err != nil {
+		return nil, fmt.Errorf("failed to broadcast transaction: %w", err)
+	}
+	if !resp.Result {
+		apiErr := classifyBroadcast(resp.Code, resp.Message)
+		if !errors.Is(apiErr, ErrDuplicateTransaction) {
+			return &resp, fmt.Errorf("broadcast rejected: %w", apiErr)
+		}
+		resp.Result = true
+	}
+	if resp.TxID == "" {
+		resp.TxID = tx.TxID
+	}
+	return &resp, nil
+}
+
+// postJSON 向节点发送 JSON POST 请求并解析响应
+func (c *TronClient) postJSON(ctx context.Context, path string, payload interface{}, out interface{}) error {
+	return c.doRequest(ctx, "POST", path, payload, out)
+}
+
+// GetTransactionInfo 获取交易回执（全节点，已打包但可能尚未固化）
+// 交易尚未打包时节点返回空对象，此时 Found() 为 false
+func (c *TronClient) GetTransactionInfo(ctx context.Context, txID string) (*TransactionInfo, error) {
+	return c.getTransactionInfo(ctx, "/wallet/gettransactioninfobyid", txID)
+}
+
+// GetSolidifiedTransactionInfo 获取已固化的交易回执（solidity 节点）
+func (c *TronClient) GetSolidifiedTransactionInfo(ctx context.Context, txID string) (*TransactionInfo, error) {
+	return c.getTransactionInfo(ctx, "/walletsolidity/gettransactioninfobyid", txID)
+}
+
+// getTransactionInfo 按交易ID查询回执
+func (c *TronClient) getTransactionInfo(ctx context.Context, path string, txID string) (*TransactionInfo, error) {
+	var info TransactionInfo
+	if err := c.postJSON(ctx, path, map[string]interface{}{"value": txID}, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
 }
@@ -10,12 +10,16 @@ func TestNewTronClient(t *testing.T) {
 
 	client := NewTronClient(baseURL, apiKey)
 
-	if client.baseURL != baseURL {
-		t.Errorf("期望baseURL为%s，实际为%s", baseURL, client.baseURL)
+	if len(client.nodes) != 1 {
+		t.Fatalf("期望1个节点，实际为%d", len(client.nodes))
 	}
 
-	if client.apiKey != apiKey {
-		t.Errorf("期望apiKey为%s，实际为%s", apiKey, client.apiKey)
+	if client.nodes[0].config.URL != baseURL {
+		t.Errorf("期望baseURL为%s，实际为%s", baseURL, client.nodes[0].config.URL)
+	}
+
+	if client.nodes[0].config.APIKey != apiKey {
+		t.Errorf("期望apiKey为%s，实际为%s", apiKey, client.nodes[0].config.APIKey)
 	}
 
 	if client.httpClient == nil {
@@ -0,0 +1,104 @@
+package tron
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultBaseURL 未配置节点时使用的 TronGrid 主网地址
+const defaultBaseURL = "https://api.trongrid.io"
+
+// NodeConfigsFromEnv 从环境变量读取全节点列表
+//   - TRON_API_URLS: 逗号分隔的节点地址，顺序即初始优先级
+//   - TRON_API_KEYS: 逗号分隔的 API Key，与 TRON_API_URLS 按位置对应，自建节点可留空
+//
+// 未配置 TRON_API_URLS 时回退到单节点的 TRON_API_URL / TRON_API_KEY
+func NodeConfigsFromEnv() []NodeConfig {
+	urls := splitList(os.Getenv("TRON_API_URLS"))
+	keys := strings.Split(os.Getenv("TRON_API_KEYS"), ",")
+
+	if len(urls) == 0 {
+		baseURL := os.Getenv("TRON_API_URL")
+		if baseURL == "" {
+			baseURL = defaultBaseURL
+		}
+		urls = []string{baseURL}
+		keys = []string{os.Getenv("TRON_API_KEY")}
+	}
+
+	configs := make([]NodeConfig, 0, len(urls))
+	for i, rawURL := range urls {
+		config := NodeConfig{Name: nodeName(rawURL), URL: rawURL}
+		if i < len(keys) {
+			config.APIKey = strings.TrimSpace(keys[i])
+		}
+		configs = append(configs, config)
+	}
+	return configs
+}
+
+// NewTronClientFromEnv 根据环境变量创建 Tron 客户端
+func NewTronClientFromEnv() *TronClient {
+	client := NewTronClientWithNodes(NodeConfigsFromEnv())
+	client.SetRetryPolicy(RetryPolicyFromEnv())
+	return client
+}
+
+// RetryPolicyFromEnv 从环境变量读取重试策略，未配置的项使用默认值
+//   - TRON_RETRY_MAX_ATTEMPTS: 最大尝试次数
+//   - TRON_RETRY_BASE_DELAY: 首次重试等待时间，如 500ms
+//   - TRON_RETRY_MAX_DELAY: 最长等待时间，如 5s
+func RetryPolicyFromEnv() RetryPolicy {
+	policy := DefaultRetryPolicy()
+	if value := os.Getenv("TRON_RETRY_MAX_ATTEMPTS"); value != "" {
+		if attempts, err := strconv.Atoi(value); err == nil {
+			policy.MaxAttempts = attempts
+		}
+	}
+	if value := os.Getenv("TRON_RETRY_BASE_DELAY"); value != "" {
+		if delay, err := time.ParseDuration(value); err == nil {
+			policy.BaseDelay = delay
+		}
+	}
+	if value := os.Getenv("TRON_RETRY_MAX_DELAY"); value != "" {
+		if delay, err := time.ParseDuration(value); err == nil {
+			policy.MaxDelay = delay
+		}
+	}
+	return policy
+}
+
+// KeystorePasswordFromEnv 读取 keystore 密码：优先读取 <name>_FILE 指定的文件（去除末尾换行），否则读取 name
+func KeystorePasswordFromEnv(name string) (string, error) {
+	if path := os.Getenv(name + "_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s_FILE: %w", name, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	return os.Getenv(name), nil
+}
+
+// nodeName 使用节点地址的 host 作为名称
+func nodeName(rawURL string) string {
+	if parsed, err := url.Parse(rawURL); err == nil && parsed.Host != "" {
+		return parsed.Host
+	}
+	return rawURL
+}
+
+// splitList 拆分逗号分隔的列表，忽略空项
+func splitList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
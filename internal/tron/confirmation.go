@@ -0,0 +1,181 @@
+package tron
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// TransactionInfo /wallet/gettransactioninfobyid 返回的交易回执
+type TransactionInfo struct {
+	ID             string             `json:"id"`
+	Fee            int64              `json:"fee"`            // 交易总消耗（SUN），含带宽和能量燃烧
+	BlockNumber    int64              `json:"blockNumber"`    // 所在区块高度
+	BlockTimeStamp int64              `json:"blockTimeStamp"` // 区块时间（毫秒）
+	ContractResult []string           `json:"contractResult"` // 合约返回值
+	Receipt        TransactionReceipt `json:"receipt"`        // 资源消耗回执
+	Result         string             `json:"result"`         // 执行失败时为 FAILED
+	ResMessage     string             `json:"resMessage"`     // 失败原因（十六进制编码）
+	Log            []TransactionLog   `json:"log"`            // 合约事件日志，执行失败的交易没有日志
+}
+
+// TransactionLog 合约事件日志，address 为不带 41 前缀的 20 字节十六进制，topics / data 为不带 0x 的十六进制
+type TransactionLog struct {
+	Address string   `json:"address"`
+	Topics  []string `json:"topics"`
+	Data    string   `json:"data"`
+}
+
+// TransactionReceipt 交易资源消耗回执
+type TransactionReceipt struct {
+	EnergyUsage       int64  `json:"energy_usage"`
+	EnergyFee         int64  `json:"energy_fee"`
+	OriginEnergyUsage int64  `json:"origin_energy_usage"`
+	EnergyUsageTotal  int64  `json:"energy_usage_total"`
+	NetUsage          int64  `json:"net_usage"`
+	NetFee            int64  `json:"net_fee"`
+	Result            string `json:"result"` // 智能合约执行结果，系统合约为空
+}
+
+// Found 交易是否已被节点收录
+func (i *TransactionInfo) Found() bool {
+	return i != nil && i.ID != ""
+}
+
+// Failed 回执是否显示交易执行失败
+func (i *TransactionInfo) Failed() bool {
+	if i.Result == "FAILED" {
+		return true
+	}
+	return i.Receipt.Result != "" && i.Receipt.Result != "SUCCESS"
+}
+
+// ResultText 回执结果描述，成功时为 SUCCESS
+func (i *TransactionInfo) ResultText() string {
+	if !i.Failed() {
+		return "SUCCESS"
+	}
+	if i.ResMessage != "" {
+		return decodeNodeMessage(i.ResMessage)
+	}
+	if i.Receipt.Result != "" {
+		return i.Receipt.Result
+	}
+	return i.Result
+}
+
+// ConfirmationState 交易确认状态
+type ConfirmationState int
+
+const (
+	ConfirmationNotFound  ConfirmationState = iota // 节点未收录（未打包或已被丢弃）
+	ConfirmationInBlock                            // 已打包，尚未固化
+	ConfirmationConfirmed                          // 已固化且执行成功
+	ConfirmationFailed                             // 已固化但回执显示失败
+)
+
+// String 确认状态描述
+func (s ConfirmationState) String() string {
+	switch s {
+	case ConfirmationInBlock:
+		return "in_block"
+	case ConfirmationConfirmed:
+		return "confirmed"
+	case ConfirmationFailed:
+		return "failed"
+	default:
+		return "not_found"
+	}
+}
+
+// Confirmation 交易确认结果
+type Confirmation struct {
+	TxID           string
+	State          ConfirmationState
+	BlockNumber    int64
+	BlockTimeStamp int64 // 区块时间（毫秒）
+	Fee            int64
+	Result         string
+	Receipt        TransactionReceipt
+}
+
+// CheckConfirmation 查询交易确认状态
+// 只有在 solidity 节点查到回执时才认为交易最终确认，避免分叉回滚
+func (c *TronClient) CheckConfirmation(ctx context.Context, txID string) (*Confirmation, error) {
+	solid, err := c.GetSolidifiedTransactionInfo(ctx, txID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get solidified transaction info: %w", err)
+	}
+	if solid.Found() {
+		return newConfirmation(txID, solid), nil
+	}
+
+	info, err := c.GetTransactionInfo(ctx, txID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction info: %w", err)
+	}
+	if info.Found() {
+		confirmation := newConfirmation(txID, info)
+		confirmation.State = ConfirmationInBlock
+		return confirmation, nil
+	}
+
+	return &Confirmation{TxID: txID, State: ConfirmationNotFound}, nil
+}
+
+// minMissingNodes 判定交易未被收录需要一致查询不到的最少节点数
+const minMissingNodes = 2
+
+// ConfirmMissing 逐个节点查询交易回执，判断交易是否确实未被收录
+// 至少 minMissingNodes 个节点查询不到（只配置一个节点时为该节点）且没有节点查到时返回 true，
+// 单个节点区块落后或请求切换到其他节点时不会误判为交易已丢弃
+func (c *TronClient) ConfirmMissing(ctx context.Context, txID string) (bool, error) {
+	payload, err := json.Marshal(map[string]interface{}{"value": txID})
+	if err != nil {
+		return false, fmt.Errorf("failed to serialize request: %w", err)
+	}
+
+	var missing int
+	var lastErr error
+	for _, n := range c.nodes {
+		var info TransactionInfo
+		start := time.Now()
+		if err := c.doNodeRequest(ctx, n, "POST", "/wallet/gettransactioninfobyid", payload, &info); err != nil {
+			n.recordFailure(err)
+			lastErr = fmt.Errorf("node %s: %w", n.config.Name, err)
+			continue
+		}
+		n.recordSuccess(time.Since(start))
+		if info.Found() {
+			return false, nil
+		}
+		missing++
+	}
+
+	required := minMissingNodes
+	if len(c.nodes) < required {
+		required = len(c.nodes)
+	}
+	if missing == 0 && lastErr != nil {
+		return false, fmt.Errorf("failed to get transaction info: %w", lastErr)
+	}
+	return missing >= required, nil
+}
+
+// newConfirmation 根据回执构建确认结果
+func newConfirmation(txID string, info *TransactionInfo) *Confirmation {
+	state := ConfirmationConfirmed
+	if info.Failed() {
+		state = ConfirmationFailed
+	}
+	return &Confirmation{
+		TxID:           txID,
+		State:          state,
+		BlockNumber:    info.BlockNumber,
+		BlockTimeStamp: info.BlockTimeStamp,
+		Fee:            info.Fee,
+		Result:         info.ResultText(),
+		Receipt:        info.Receipt,
+	}
+}
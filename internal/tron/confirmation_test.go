@@ -0,0 +1,138 @@
+package tron
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newConfirmationServer(solid, full string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/walletsolidity/gettransactioninfobyid":
+			w.Write([]byte(solid))
+		case "/wallet/gettransactioninfobyid":
+			w.Write([]byte(full))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestCheckConfirmation(t *testing.T) {
+	testCases := []struct {
+		description string
+		solid       string
+		full        string
+		expected    ConfirmationState
+		blockNumber int64
+		fee         int64
+	}{
+		{
+			description: "未打包",
+			solid:       `{}`,
+			full:        `{}`,
+			expected:    ConfirmationNotFound,
+		},
+		{
+			description: "已打包未固化",
+			solid:       `{}`,
+			full:        `{"id":"abc","blockNumber":100,"fee":0}`,
+			expected:    ConfirmationInBlock,
+			blockNumber: 100,
+		},
+		{
+			description: "已固化成功",
+			solid:       `{"id":"abc","blockNumber":100,"fee":268000,"receipt":{"net_fee":268000}}`,
+			full:        `{"id":"abc","blockNumber":100}`,
+			expected:    ConfirmationConfirmed,
+			blockNumber: 100,
+			fee:         268000,
+		},
+		{
+			description: "已固化失败",
+			solid:       `{"id":"abc","blockNumber":101,"result":"FAILED","resMessage":"6f7574206f6620656e65726779"}`,
+			full:        `{}`,
+			expected:    ConfirmationFailed,
+			blockNumber: 101,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			server := newConfirmationServer(tc.solid, tc.full)
+			defer server.Close()
+
+			client := NewTronClient(server.URL, "")
+			confirmation, err := client.CheckConfirmation(context.Background(), "abc")
+			if err != nil {
+				t.Fatalf("查询确认状态失败: %v", err)
+			}
+
+			if confirmation.State != tc.expected {
+				t.Errorf("期望状态为%s，实际为%s", tc.expected, confirmation.State)
+			}
+			if confirmation.BlockNumber != tc.blockNumber {
+				t.Errorf("期望区块高度为%d，实际为%d", tc.blockNumber, confirmation.BlockNumber)
+			}
+			if confirmation.Fee != tc.fee {
+				t.Errorf("期望手续费为%d，实际为%d", tc.fee, confirmation.Fee)
+			}
+		})
+	}
+}
+
+func TestTransactionInfoResultText(t *testing.T) {
+	info := &TransactionInfo{ID: "abc", Result: "FAILED", ResMessage: "6f7574206f6620656e65726779"}
+	if info.ResultText() != "out of energy" {
+		t.Errorf("期望失败原因为out of energy，实际为%s", info.ResultText())
+	}
+
+	success := &TransactionInfo{ID: "abc"}
+	if success.ResultText() != "SUCCESS" {
+		t.Errorf("期望结果为SUCCESS，实际为%s", success.ResultText())
+	}
+}
+
+func TestConfirmMissing(t *testing.T) {
+	server := func(body string, status int) *httptest.Server {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		}))
+		t.Cleanup(s.Close)
+		return s
+	}
+	missing := server(`{}`, http.StatusOK)
+	found := server(`{"id":"abc","blockNumber":1}`, http.StatusOK)
+	down := server(`busy`, http.StatusServiceUnavailable)
+
+	testCases := []struct {
+		description string
+		urls        []string
+		expected    bool
+	}{
+		{"两个节点都查不到", []string{missing.URL, missing.URL}, true},
+		{"落后节点查不到但其他节点查到", []string{missing.URL, found.URL}, false},
+		{"只有一个节点响应", []string{missing.URL, down.URL}, false},
+		{"只配置一个节点", []string{missing.URL}, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			var configs []NodeConfig
+			for i, url := range tc.urls {
+				configs = append(configs, NodeConfig{Name: fmt.Sprintf("node%d", i), URL: url})
+			}
+			got, err := NewTronClientWithNodes(configs).ConfirmMissing(context.Background(), "abc")
+			if err != nil || got != tc.expected {
+				t.Errorf("期望为%v，实际为%v, %v", tc.expected, got, err)
+			}
+		})
+	}
+
+	if _, err := NewTronClientWithNodes([]NodeConfig{{Name: "down", URL: down.URL}}).ConfirmMissing(context.Background(), "abc"); err == nil {
+		t.Error("期望所有节点都失败时返回错误")
+	}
+}
@@ -0,0 +1,168 @@
+package tron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 节点错误分类，使用 errors.Is 判断
+var (
+	ErrInsufficientFrozenBalance    = errors.New("insufficient frozen balance")              // 可委托的质押余额不足
+	ErrInsufficientDelegatedBalance = errors.New("insufficient delegated balance")           // 回收金额超过已委托余额
+	ErrInsufficientBalance          = errors.New("insufficient balance for fee")             // 账户余额/带宽不足以支付手续费
+	ErrAccountNotActivated          = errors.New("account not activated")                    // 账户未激活
+	ErrContractValidate             = errors.New("contract validation failed")               // 合约校验失败（参数错误等）
+	ErrSignature                    = errors.New("signature error")                          // 签名错误或权限不足
+	ErrSignerPolicy                 = errors.New("rejected by signer policy")                // 远程签名服务按策略拒绝签名
+	ErrUnexpectedTransaction        = errors.New("built transaction does not match request") // 节点构建的交易与请求不一致，拒绝签名
+	ErrTransactionExpired           = errors.New("transaction expired")                      // 交易过期，需要重新构建
+	ErrDuplicateTransaction         = errors.New("duplicate transaction")                    // 交易已广播过
+	ErrBadRequest                   = errors.New("bad request")                              // HTTP 4xx（429 除外）
+	ErrRateLimited                  = errors.New("rate limited")                             // HTTP 429
+	ErrTransient                    = errors.New("transient network or node failure")        // 网络错误、5xx、节点繁忙
+)
+
+// APIError 节点返回的错误，Kind 为上面的分类之一
+type APIError struct {
+	Kind       error  // 错误分类
+	Code       string // 广播返回的错误码，如 CONTRACT_VALIDATE_ERROR
+	StatusCode int    // HTTP 状态码
+	Message    string // 节点返回的原始错误信息
+}
+
+// Error 实现 error 接口
+func (e *APIError) Error() string {
+	var parts []string
+	if e.Code != "" {
+		parts = append(parts, "code="+e.Code)
+	}
+	if e.StatusCode != 0 {
+		parts = append(parts, fmt.Sprintf("status=%d", e.StatusCode))
+	}
+	if e.Message != "" {
+		parts = append(parts, "message="+e.Message)
+	}
+	if len(parts) == 0 {
+		return e.Kind.Error()
+	}
+	return fmt.Sprintf("%s: %s", e.Kind.Error(), strings.Join(parts, ", "))
+}
+
+// Unwrap 返回错误分类，支持 errors.Is
+func (e *APIError) Unwrap() error {
+	return e.Kind
+}
+
+// validationMessages 合约校验错误信息关键字与分类的对应关系（按顺序匹配）
+var validationMessages = []struct {
+	keyword string
+	kind    error
+}{
+	{"available FreezeEnergyV2 balance", ErrInsufficientFrozenBalance},
+	{"available FreezeBandwidthV2 balance", ErrInsufficientFrozenBalance},
+	{"insufficient delegatedFrozenBalance", ErrInsufficientDelegatedBalance},
+	{"delegated Resource does not exist", ErrInsufficientDelegatedBalance},
+	{"does not exist", ErrAccountNotActivated},
+	{"not exists", ErrAccountNotActivated},
+	{"balance is not sufficient", ErrInsufficientBalance},
+	{"Validate signature error", ErrSignature},
+	{"permission", ErrSignature},
+}
+
+// classifyNodeMessage 根据构建交易接口返回的 Error 文本分类
+func classifyNodeMessage(message string) *APIError {
+	for _, m := range validationMessages {
+		if strings.Contains(message, m.keyword) {
+			return &APIError{Kind: m.kind, Message: message}
+		}
+	}
+	return &APIError{Kind: ErrContractValidate, Message: message}
+}
+
+// classifyBroadcast 根据广播返回的错误码分类
+func classifyBroadcast(code, message string) *APIError {
+	message = decodeNodeMessage(message)
+	var kind error
+	switch code {
+	case "SIGERROR":
+		kind = ErrSignature
+	case "CONTRACT_VALIDATE_ERROR", "CONTRACT_EXE_ERROR":
+		classified := classifyNodeMessage(message)
+		classified.Code = code
+		return classified
+	case "BANDWITH_ERROR":
+		kind = ErrInsufficientBalance
+	case "TRANSACTION_EXPIRATION_ERROR", "TAPOS_ERROR":
+		kind = ErrTransactionExpired
+	case "DUP_TRANSACTION_ERROR":
+		kind = ErrDuplicateTransaction
+	case "SERVER_BUSY", "NO_CONNECTION", "NOT_ENOUGH_EFFECTIVE_CONNECTION", "BLOCK_UNSOLIDIFIED":
+		kind = ErrTransient
+	default:
+		kind = ErrContractValidate
+	}
+	return &APIError{Kind: kind, Code: code, Message: message}
+}
+
+// IsRetryable 错误是否可以重试（网络抖动、限流、节点繁忙、交易过期）
+// 校验类错误重试也不会成功，返回 false
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	return errors.Is(err, ErrTransient) ||
+		errors.Is(err, ErrRateLimited) ||
+		errors.Is(err, ErrTransactionExpired) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+// RetryPolicy 重试策略
+type RetryPolicy struct {
+	MaxAttempts int                  // 最大尝试次数（含第一次），<=1 表示不重试
+	BaseDelay   time.Duration        // 首次重试等待时间，之后指数增长
+	MaxDelay    time.Duration        // 最长等待时间
+	Retryable   func(err error) bool // 判断错误是否可重试，为空时使用 IsRetryable
+}
+
+// DefaultRetryPolicy 默认重试策略：最多3次，500ms 起指数退避，最长5秒
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+	}
+}
+
+// Do 按策略执行操作，只重试可重试的错误
+func (p RetryPolicy) Do(ctx context.Context, operation func() error) error {
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = operation()
+		if err == nil || attempt >= p.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		select {
+		case <-time.After(p.backoff(attempt)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// backoff 第 attempt 次失败后的等待时间
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay << uint(attempt-1)
+	if p.MaxDelay > 0 && (delay > p.MaxDelay || delay <= 0) {
+		delay = p.MaxDelay
+	}
+	return delay
+}
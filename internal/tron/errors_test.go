@@ -0,0 +1,163 @@
+package tron
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClassifyNodeMessage(t *testing.T) {
+	tests := []struct {
+		message string
+		want    error
+	}{
+		{"class org.tron.core.exceptions.ContractValidateException : delegateBalance must be less than or equal to available FreezeEnergyV2 balance", ErrInsufficientFrozenBalance},
+		{"class org.tron.core.exceptions.ContractValidateException : Account[41a614f803b6fd780986a42c78ec9c7f77e6ded13c] does not exist", ErrAccountNotActivated},
+		{"class org.tron.core.exceptions.ContractValidateException : insufficient delegatedFrozenBalance(Energy), request=2000000, unfreezeBalance=1000000", ErrInsufficientDelegatedBalance},
+		{"class org.tron.core.exceptions.ContractValidateException : resource type is not supported", ErrContractValidate},
+	}
+
+	for _, tt := range tests {
+		err := classifyNodeMessage(tt.message)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("期望 %q 分类为 %v，实际为 %v", tt.message, tt.want, err.Kind)
+		}
+		if IsRetryable(err) {
+			t.Errorf("期望校验错误不可重试: %q", tt.message)
+		}
+	}
+}
+
+func TestClassifyBroadcast(t *testing.T) {
+	tests := []struct {
+		code      string
+		message   string
+		want      error
+		retryable bool
+	}{
+		{"SIGERROR", "", ErrSignature, false},
+		{"CONTRACT_VALIDATE_ERROR", hex.EncodeToString([]byte("delegateBalance must be less than or equal to available FreezeEnergyV2 balance")), ErrInsufficientFrozenBalance, false},
+		{"BANDWITH_ERROR", "", ErrInsufficientBalance, false},
+		{"TRANSACTION_EXPIRATION_ERROR", "", ErrTransactionExpired, true},
+		{"SERVER_BUSY", "", ErrTransient, true},
+		{"DUP_TRANSACTION_ERROR", "", ErrDuplicateTransaction, false},
+	}
+
+	for _, tt := range tests {
+		err := classifyBroadcast(tt.code, tt.message)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("期望 %s 分类为 %v，实际为 %v", tt.code, tt.want, err.Kind)
+		}
+		if IsRetryable(err) != tt.retryable {
+			t.Errorf("期望 %s 可重试为 %v", tt.code, tt.retryable)
+		}
+	}
+}
+
+func TestDelegateEnergyInsufficientFrozenBalance(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Error":"class org.tron.core.exceptions.ContractValidateException : delegateBalance must be less than or equal to available FreezeEnergyV2 balance"}`))
+	}))
+	defer server.Close()
+
+	client := NewTronClient(server.URL, "")
+	client.SetPrivateKey(testPrivateKey)
+
+	_, err := client.DelegateEnergy(context.Background(), &EnergyDelegationRequest{
+		FromAddress: "TNPeeaaFB7K9cmo4uQpcU32zGK8G1NYqeL",
+		ToAddress:   "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t",
+		Amount:      "1000000",
+	})
+	if !errors.Is(err, ErrInsufficientFrozenBalance) {
+		t.Errorf("期望错误为 ErrInsufficientFrozenBalance，实际为 %v", err)
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message == "" {
+		t.Errorf("期望错误包含节点原始信息，实际为 %v", err)
+	}
+}
+
+func TestRateLimitedIsRetried(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"id":"abc","blockNumber":100}`))
+	}))
+	defer server.Close()
+
+	client := NewTronClient(server.URL, "")
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+
+	info, err := client.GetTransactionInfo(context.Background(), "abc")
+	if err != nil {
+		t.Fatalf("期望重试后成功，实际错误: %v", err)
+	}
+	if info.BlockNumber != 100 {
+		t.Errorf("期望BlockNumber为100，实际为%d", info.BlockNumber)
+	}
+	if calls != 3 {
+		t.Errorf("期望请求3次，实际为%d", calls)
+	}
+}
+
+func TestRateLimitedExhausted(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := NewTronClient(server.URL, "")
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+
+	_, err := client.GetTransactionInfo(context.Background(), "abc")
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("期望错误为 ErrRateLimited，实际为 %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("期望请求2次，实际为%d", calls)
+	}
+}
+
+func TestRetryPolicyDoesNotRetryValidation(t *testing.T) {
+	attempts := 0
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Millisecond}
+
+	err := policy.Do(context.Background(), func() error {
+		attempts++
+		return &APIError{Kind: ErrContractValidate, Message: "invalid"}
+	})
+	if !errors.Is(err, ErrContractValidate) {
+		t.Errorf("期望返回校验错误，实际为 %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("期望只尝试1次，实际为%d", attempts)
+	}
+}
+
+func TestBroadcastDuplicateIsSuccess(t *testing.T) {
+	tx := newTestTransaction("0a0202a2")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result":false,"code":"DUP_TRANSACTION_ERROR","message":"` + hex.EncodeToString([]byte("dup transaction")) + `"}`))
+	}))
+	defer server.Close()
+
+	client := NewTronClient(server.URL, "")
+	resp, err := client.BroadcastTransaction(context.Background(), tx)
+	if err != nil {
+		t.Fatalf("期望重复广播视为成功，实际错误: %v", err)
+	}
+	if resp.TxID != tx.TxID {
+		t.Errorf("期望TxID为%s，实际为%s", tx.TxID, resp.TxID)
+	}
+}
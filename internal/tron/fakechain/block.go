@@ -0,0 +1,89 @@
+package fakechain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"lending-trx/internal/tron"
+)
+
+// BlockID 主链上区块 n 的哈希，与节点一致前 8 字节为区块高度
+// 区块内容不模拟，哈希由高度和该高度被重组的次数决定
+func (c *Chain) BlockID(n int64) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.blockID(n)
+}
+
+// blockID 区块哈希，调用方需持有锁
+func (c *Chain) blockID(n int64) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("fakechain-block-%d-%d", n, c.forks[n])))
+	binary.BigEndian.PutUint64(hash[:8], uint64(n))
+	return hex.EncodeToString(hash[:])
+}
+
+// Reorg 模拟链重组：from 到最新区块的哈希都发生变化（已打包交易的状态不回滚）
+// 用于测试按区块哈希识别被回滚的支付
+func (c *Chain) Reorg(from int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for n := from; n <= c.block; n++ {
+		c.forks[n]++
+	}
+}
+
+// blockAt 生成区块 n 的头信息，区块尚未产生时返回空区块，调用方需持有锁
+func (c *Chain) blockAt(n int64) *tron.Block {
+	var block tron.Block
+	if n < 0 || n > c.block {
+		return &block
+	}
+	block.BlockID = c.blockID(n)
+	block.BlockHeader.RawData.Number = n
+	block.BlockHeader.RawData.Timestamp = c.blockTime(n).UnixMilli()
+	if n > 0 {
+		block.BlockHeader.RawData.ParentHash = c.blockID(n - 1)
+	}
+	return &block
+}
+
+// solidBlock 最新固化区块高度，调用方需持有锁
+func (c *Chain) solidBlock() int64 {
+	if c.block < c.solidityLag {
+		return 0
+	}
+	return c.block - c.solidityLag
+}
+
+// GetNowBlock 获取最新区块
+func (c *Chain) GetNowBlock(ctx context.Context) (*tron.Block, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.takeFailure(OpBlock); err != nil {
+		return nil, err
+	}
+	return c.blockAt(c.block), nil
+}
+
+// GetSolidifiedNowBlock 获取最新固化区块，落后最新区块 solidityLag 个区块
+func (c *Chain) GetSolidifiedNowBlock(ctx context.Context) (*tron.Block, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.takeFailure(OpBlock); err != nil {
+		return nil, err
+	}
+	return c.blockAt(c.solidBlock()), nil
+}
+
+// GetBlockByNum 获取主链上指定高度的区块
+func (c *Chain) GetBlockByNum(ctx context.Context, num int64) (*tron.Block, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.takeFailure(OpBlock); err != nil {
+		return nil, err
+	}
+	return c.blockAt(num), nil
+}
@@ -0,0 +1,1190 @@
+// Package fakechain 提供内存中的 TRON 链模拟，用于测试委托/回收全流程
+//
+// Chain 直接实现 tron.Client，可在进程内使用；Handler() 提供与全节点 HTTP API 兼容的接口，
+// 可作为 tron.TronClient 的本地节点使用（交易在客户端签名，由模拟链验证签名）。
+package fakechain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"lending-trx/internal/address"
+	"lending-trx/internal/tron"
+	"lending-trx/internal/units"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// 默认链参数，与主网量级一致
+const (
+	DefaultTotalEnergyLimit  = 180000000000
+	DefaultTotalEnergyWeight = 19000000000
+	DefaultEnergyFee         = 420
+	DefaultTotalNetLimit     = 43200000000
+	DefaultTotalNetWeight    = 26000000000
+	DefaultTransactionFee    = 1000
+	// delegateNetUsage 委托/回收交易消耗的带宽
+	delegateNetUsage = 280
+	// defaultLockPeriod lock 为 true 且未指定 lock_period 时节点使用的锁定期（区块数，3天）
+	defaultLockPeriod = 86400
+)
+
+// Operation 可注入故障的操作，取值与全节点 HTTP 接口名一致
+type Operation string
+
+const (
+	OpDelegate           Operation = "delegateresource"
+	OpUndelegate         Operation = "undelegateresource"
+	OpBroadcast          Operation = "broadcasttransaction"
+	OpTransactionInfo    Operation = "gettransactioninfobyid"
+	OpAccount            Operation = "getaccount"
+	OpAccountResource    Operation = "getaccountresource"
+	OpChainParameters    Operation = "getchainparameters"
+	OpCanDelegateMaxSize Operation = "getcandelegatedmaxsize"
+	OpDelegatedResource  Operation = "getdelegatedresourcev2"
+	OpFreeze             Operation = "freezebalancev2"
+	OpUnfreeze           Operation = "unfreezebalancev2"
+	OpWithdraw           Operation = "withdrawexpireunfreeze"
+	OpBlock              Operation = "getblock" // getnowblock / getblockbynum
+)
+
+// Account 模拟账户状态
+type Account struct {
+	Address            string
+	Balance            int64 // 可用余额（SUN）
+	FrozenEnergy       int64 // 为能量质押且未委托出去的金额（SUN）
+	DelegatedEnergy    int64 // 已委托给他人的能量质押金额（SUN）
+	AcquiredEnergy     int64 // 他人委托给本账户的能量质押金额（SUN）
+	FrozenBandwidth    int64 // 为带宽质押且未委托出去的金额（SUN）
+	DelegatedBandwidth int64 // 已委托给他人的带宽质押金额（SUN）
+	AcquiredBandwidth  int64 // 他人委托给本账户的带宽质押金额（SUN）
+}
+
+// stake 指定资源的质押、已委托、获得委托金额字段
+func (a *Account) stake(resource string) (frozen, delegated, acquired *int64) {
+	if resource == tron.ResourceBandwidth {
+		return &a.FrozenBandwidth, &a.DelegatedBandwidth, &a.AcquiredBandwidth
+	}
+	return &a.FrozenEnergy, &a.DelegatedEnergy, &a.AcquiredEnergy
+}
+
+// txKind 交易类型
+type txKind int
+
+const (
+	txDelegate txKind = iota
+	txUndelegate
+	txFreeze
+	txUnfreeze
+	txWithdraw
+)
+
+// contractType 交易类型对应的合约类型
+func (k txKind) contractType() int {
+	switch k {
+	case txUndelegate:
+		return tron.ContractTypeUnDelegateResource
+	case txFreeze:
+		return tron.ContractTypeFreezeBalanceV2
+	case txUnfreeze:
+		return tron.ContractTypeUnfreezeBalanceV2
+	case txWithdraw:
+		return tron.ContractTypeWithdrawExpireUnfreeze
+	default:
+		return tron.ContractTypeDelegateResource
+	}
+}
+
+// transaction 已构建的交易
+type transaction struct {
+	id       string
+	kind     txKind
+	owner    string
+	receiver string
+	resource string // ENERGY / BANDWIDTH
+	balance  int64
+	lock     int64 // 锁定期（区块数），0 表示不锁定
+	// permissionID 签名使用的权限ID（Permission_id）
+	permissionID int
+	rawHex       string
+
+	broadcast bool
+	block     int64 // 所在区块，0 表示未打包
+	netFee    int64 // 打包时燃烧的 TRX（SUN）
+	failed    bool  // 回执显示执行失败
+	result    string
+}
+
+// delegationKey 链上委托按 (owner, receiver, resource) 聚合
+type delegationKey struct {
+	receiver string
+	resource string
+}
+
+// delegation owner 委托给 receiver 的一种资源，锁定和未锁定部分分开记录（与链上一致）
+type delegation struct {
+	unlocked    int64 // 未锁定的质押金额（SUN）
+	locked      int64 // 锁定的质押金额（SUN）
+	expireBlock int64 // 锁定到期的区块高度
+}
+
+// total 委托的质押金额合计
+func (d *delegation) total() int64 {
+	return d.unlocked + d.locked
+}
+
+// reclaimable 在 block 高度可以回收的质押金额：未锁定部分，锁定到期后加上锁定部分
+func (d *delegation) reclaimable(block int64) int64 {
+	if d.expireBlock <= block {
+		return d.total()
+	}
+	return d.unlocked
+}
+
+// release 锁定到期后将锁定部分并入未锁定部分
+func (d *delegation) release(block int64) {
+	if d.locked > 0 && d.expireBlock <= block {
+		d.unlocked += d.locked
+		d.locked = 0
+		d.expireBlock = 0
+	}
+}
+
+// Chain 内存中的 TRON 链
+type Chain struct {
+	mu sync.Mutex
+
+	accounts    map[string]*Account
+	permissions map[string]*tron.AccountPermission       // 账户权限，创建账户时生成默认的 owner / active 权限
+	delegations map[string]map[delegationKey]*delegation // owner -> (receiver, resource) -> 委托
+	unfreezing  map[string][]unfreezing                  // owner -> 进行中的解质押
+	txs         map[string]*transaction
+	receipts    map[string]*tron.TransactionInfo // 模拟链之外的交易（如 TRC20 支付）的回执，见 AddReceipt
+	mempool     []*transaction
+	nonce       int64
+
+	genesis     time.Time       // 0 号区块时间，区块 n 的时间为 genesis + n*3s
+	block       int64           // 最新区块高度
+	solidityLag int64           // 固化区块落后最新区块的数量
+	forks       map[int64]int64 // 区块高度 -> 被重组的次数，用于生成不同的区块哈希
+	// unfreezeDelay 解质押到可提取需要的区块数
+	unfreezeDelay int64
+
+	totalEnergyLimit  int64
+	totalEnergyWeight int64
+	energyFee         int64
+	netFee            int64 // 每笔交易燃烧的 TRX（SUN），0 表示使用免费带宽
+	totalNetLimit     int64
+	totalNetWeight    int64
+
+	failures          map[Operation][]error // 按操作排队的故障，每次调用消费一个
+	executionFailures map[txKind]int        // 下 N 笔该类型交易回执显示失败
+
+	signers      []tron.Signer // 进程内使用时的签名者
+	permissionID int           // 进程内使用时签名的权限ID
+}
+
+// New 创建模拟链，区块高度从 1 开始，交易打包后立即固化
+func New() *Chain {
+	return &Chain{
+		accounts:          make(map[string]*Account),
+		permissions:       make(map[string]*tron.AccountPermission),
+		delegations:       make(map[string]map[delegationKey]*delegation),
+		unfreezing:        make(map[string][]unfreezing),
+		txs:               make(map[string]*transaction),
+		receipts:          make(map[string]*tron.TransactionInfo),
+		genesis:           time.Now().Add(-tron.BlockInterval),
+		block:             1,
+		forks:             make(map[int64]int64),
+		unfreezeDelay:     defaultUnfreezeDelay,
+		totalEnergyLimit:  DefaultTotalEnergyLimit,
+		totalEnergyWeight: DefaultTotalEnergyWeight,
+		energyFee:         DefaultEnergyFee,
+		totalNetLimit:     DefaultTotalNetLimit,
+		totalNetWeight:    DefaultTotalNetWeight,
+		failures:          make(map[Operation][]error),
+		executionFailures: make(map[txKind]int),
+	}
+}
+
+// CreateAccount 创建（激活）账户，balance 为可用余额（SUN）
+func (c *Chain) CreateAccount(addr string, balance int64) error {
+	normalized, err := address.Normalize(addr)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.accounts[normalized] = &Account{Address: normalized, Balance: balance}
+	c.permissions[normalized] = defaultPermission(normalized)
+	return nil
+}
+
+// defaultPermission 新账户的默认权限：owner 和 active 权限都只有账户自身一个公钥，阈值为1
+func defaultPermission(addr string) *tron.AccountPermission {
+	keys := []tron.PermissionKey{{Address: addr, Weight: 1}}
+	contractTypes := make([]int, 0, tron.ContractTypeUnDelegateResource+1)
+	for t := 0; t <= tron.ContractTypeUnDelegateResource; t++ {
+		contractTypes = append(contractTypes, t)
+	}
+	return &tron.AccountPermission{
+		Address:         addr,
+		OwnerPermission: &tron.Permission{Type: "Owner", ID: tron.OwnerPermissionID, PermissionName: "owner", Threshold: 1, Keys: keys},
+		ActivePermission: []tron.Permission{{
+			Type: "Active", ID: 2, PermissionName: "active", Threshold: 1,
+			Operations: tron.OperationsFor(contractTypes...), Keys: keys,
+		}},
+	}
+}
+
+// SetPermission 设置账户权限（模拟 AccountPermissionUpdateContract），ID 为0时替换 owner 权限，否则替换或新增 active 权限
+func (c *Chain) SetPermission(addr string, permission tron.Permission) error {
+	normalized, err := address.Normalize(addr)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	account, ok := c.permissions[normalized]
+	if !ok {
+		return fmt.Errorf("account %s not exists", normalized)
+	}
+	if permission.ID == tron.OwnerPermissionID {
+		account.OwnerPermission = &permission
+		return nil
+	}
+	for i := range account.ActivePermission {
+		if account.ActivePermission[i].ID == permission.ID {
+			account.ActivePermission[i] = permission
+			return nil
+		}
+	}
+	account.ActivePermission = append(account.ActivePermission, permission)
+	return nil
+}
+
+// Stake 为账户质押能量，从可用余额中扣除
+func (c *Chain) Stake(addr string, amount int64) error {
+	return c.stake(addr, amount, tron.ResourceEnergy)
+}
+
+// StakeBandwidth 为账户质押带宽，从可用余额中扣除
+func (c *Chain) StakeBandwidth(addr string, amount int64) error {
+	return c.stake(addr, amount, tron.ResourceBandwidth)
+}
+
+// stake 为账户质押指定资源
+func (c *Chain) stake(addr string, amount int64, resource string) error {
+	normalized, err := address.Normalize(addr)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	account, ok := c.accounts[normalized]
+	if !ok {
+		return fmt.Errorf("account %s not exists", normalized)
+	}
+	if account.Balance < amount {
+		return fmt.Errorf("balance %d is not sufficient to stake %d", account.Balance, amount)
+	}
+	account.Balance -= amount
+	frozen, _, _ := account.stake(resource)
+	*frozen += amount
+	*c.totalWeight(resource) += amount / units.SunPerTRX
+	return nil
+}
+
+// totalWeight 全网为指定资源质押的 TRX 总量，调用方需持有锁
+func (c *Chain) totalWeight(resource string) *int64 {
+	if resource == tron.ResourceBandwidth {
+		return &c.totalNetWeight
+	}
+	return &c.totalEnergyWeight
+}
+
+// Account 返回账户状态快照
+func (c *Chain) Account(addr string) (Account, bool) {
+	normalized, err := address.Normalize(addr)
+	if err != nil {
+		return Account{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	account, ok := c.accounts[normalized]
+	if !ok {
+		return Account{}, false
+	}
+	return *account, true
+}
+
+// Delegated 返回 owner 委托给 receiver 的能量质押金额（SUN）
+func (c *Chain) Delegated(owner, receiver string) int64 {
+	return c.DelegatedResource(owner, receiver, tron.ResourceEnergy)
+}
+
+// DelegatedResource 返回 owner 委托给 receiver 的指定资源质押金额（SUN）
+func (c *Chain) DelegatedResource(owner, receiver, resource string) int64 {
+	ownerAddr, err1 := address.Normalize(owner)
+	receiverAddr, err2 := address.Normalize(receiver)
+	if err1 != nil || err2 != nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if d, ok := c.delegations[ownerAddr][delegationKey{receiverAddr, resource}]; ok {
+		return d.total()
+	}
+	return 0
+}
+
+// Locked 返回 owner 委托给 receiver 的能量锁定金额（SUN）和锁定到期的区块高度
+func (c *Chain) Locked(owner, receiver string) (int64, int64) {
+	ownerAddr, err1 := address.Normalize(owner)
+	receiverAddr, err2 := address.Normalize(receiver)
+	if err1 != nil || err2 != nil {
+		return 0, 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if d, ok := c.delegations[ownerAddr][delegationKey{receiverAddr, tron.ResourceEnergy}]; ok {
+		return d.locked, d.expireBlock
+	}
+	return 0, 0
+}
+
+// SetEnergyTotals 设置全网能量总量和能量质押总量（TRX），模拟全网质押量变化
+func (c *Chain) SetEnergyTotals(limit, weight int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.totalEnergyLimit = limit
+	c.totalEnergyWeight = weight
+}
+
+// SetNetFee 设置每笔交易燃烧的 TRX（SUN），模拟委托方没有可用带宽，默认0即使用免费带宽
+// 打包时从委托方余额扣除，执行失败的交易同样扣除
+func (c *Chain) SetNetFee(fee int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.netFee = fee
+}
+
+// SetSolidityLag 设置固化区块落后最新区块的数量，默认0即打包后立即固化
+func (c *Chain) SetSolidityLag(lag int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.solidityLag = lag
+}
+
+// FailNext 让下一次 op 调用返回 err，可多次调用排队
+// err 通常为 *tron.APIError，HTTP 模式下按其类型渲染为对应的节点响应
+func (c *Chain) FailNext(op Operation, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures[op] = append(c.failures[op], err)
+}
+
+// FailNextExecution 让下一笔委托（undelegate=false）或回收交易打包后回执显示失败
+func (c *Chain) FailNextExecution(undelegate bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	kind := txDelegate
+	if undelegate {
+		kind = txUndelegate
+	}
+	c.executionFailures[kind]++
+}
+
+// AddReceipt 添加模拟链之外的交易回执（如 TRC20 支付），按 info.ID 查询；BlockNumber 为0时记为最新区块
+func (c *Chain) AddReceipt(info tron.TransactionInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if info.BlockNumber == 0 {
+		info.BlockNumber = c.block
+		info.BlockTimeStamp = c.blockTime(c.block).UnixMilli()
+	}
+	c.receipts[info.ID] = &info
+}
+
+// BlockNumber 返回最新区块高度
+func (c *Chain) BlockNumber() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.block
+}
+
+// Now 返回最新区块时间，锁定到期判断以区块时间为准
+func (c *Chain) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.blockTime(c.block)
+}
+
+// blockTime 区块时间
+func (c *Chain) blockTime(block int64) time.Time {
+	return c.genesis.Add(time.Duration(block) * tron.BlockInterval)
+}
+
+// SkipBlocks 产生 n 个不含交易的区块，用于推进时间（如等待委托锁定到期）
+func (c *Chain) SkipBlocks(n int64) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.block += n
+	return c.block
+}
+
+// MineBlock 产生一个新区块，打包所有已广播的交易并应用状态变化
+// 打包时重新校验交易，校验失败的交易被丢弃（与节点行为一致）
+func (c *Chain) MineBlock() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.block++
+	for _, tx := range c.mempool {
+		if err := c.validate(tx); err != nil {
+			delete(c.txs, tx.id)
+			continue
+		}
+		tx.block = c.block
+		if c.netFee > 0 {
+			tx.netFee = c.netFee
+			c.accounts[tx.owner].Balance -= c.netFee
+		}
+		if c.executionFailures[tx.kind] > 0 {
+			c.executionFailures[tx.kind]--
+			tx.failed = true
+			tx.result = "FAILED"
+			continue
+		}
+		c.apply(tx)
+	}
+	c.mempool = nil
+	return c.block
+}
+
+// DropPending 丢弃所有已广播但未打包的交易，模拟交易过期
+func (c *Chain) DropPending() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, tx := range c.mempool {
+		delete(c.txs, tx.id)
+	}
+	c.mempool = nil
+}
+
+// takeFailure 取出 op 排队的故障，调用方需持有锁
+func (c *Chain) takeFailure(op Operation) error {
+	queue := c.failures[op]
+	if len(queue) == 0 {
+		return nil
+	}
+	c.failures[op] = queue[1:]
+	return queue[0]
+}
+
+// validate 按 java-tron 的校验规则检查委托/回收交易，调用方需持有锁
+func (c *Chain) validate(tx *transaction) error {
+	owner, receiver, balance := tx.owner, tx.receiver, tx.balance
+	ownerAccount, ok := c.accounts[owner]
+	if !ok {
+		return validationError(tron.ErrAccountNotActivated, "Account[%s] not exists", owner)
+	}
+	switch tx.kind {
+	case txFreeze, txUnfreeze, txWithdraw:
+		return c.validateStaking(tx, ownerAccount)
+	}
+	if owner == receiver {
+		return validationError(tron.ErrContractValidate, "receiverAddress must not be the same as ownerAddress")
+	}
+
+	if tx.resource != tron.ResourceEnergy && tx.resource != tron.ResourceBandwidth {
+		return validationError(tron.ErrContractValidate, "ResourceCode error, valid ResourceCode[BANDWIDTH、ENERGY]")
+	}
+	key := delegationKey{receiver, tx.resource}
+
+	if tx.kind == txDelegate {
+		if balance < units.SunPerTRX {
+			return validationError(tron.ErrContractValidate, "delegateBalance must be greater than or equal to 1 TRX")
+		}
+		if _, ok := c.accounts[receiver]; !ok {
+			return validationError(tron.ErrAccountNotActivated, "Account[%s] does not exist", receiver)
+		}
+		if frozen, _, _ := ownerAccount.stake(tx.resource); balance > *frozen {
+			if tx.resource == tron.ResourceBandwidth {
+				return validationError(tron.ErrInsufficientFrozenBalance, "delegateBalance must be less than or equal to available FreezeBandwidthV2 balance")
+			}
+			return validationError(tron.ErrInsufficientFrozenBalance, "delegateBalance must be less than or equal to available FreezeEnergyV2 balance")
+		}
+		if tx.lock < 0 || tx.lock > tron.MaxLockPeriod {
+			return validationError(tron.ErrContractValidate, "The lock period of delegate resource cannot be less than 0 and cannot exceed %d!", tron.MaxLockPeriod)
+		}
+		// 新的锁定会覆盖该接收方该资源全部锁定部分的到期时间，不能早于上一次锁定的剩余时间
+		if d, ok := c.delegations[owner][key]; ok && tx.lock > 0 && d.locked > 0 && d.expireBlock > c.block+tx.lock {
+			remaining := (d.expireBlock - c.block) * int64(tron.BlockInterval/time.Second)
+			return validationError(tron.ErrContractValidate, "The lock period for %s this time cannot be less than the remaining time[%ds] of the last lock period for %s!", tx.resource, remaining, tx.resource)
+		}
+		return nil
+	}
+
+	d, ok := c.delegations[owner][key]
+	if !ok || d.total() == 0 {
+		return validationError(tron.ErrInsufficientDelegatedBalance, "delegated Resource does not exist")
+	}
+	if reclaimable := d.reclaimable(c.block); balance > reclaimable {
+		name := "Energy"
+		if tx.resource == tron.ResourceBandwidth {
+			name = "Bandwidth"
+		}
+		return validationError(tron.ErrInsufficientDelegatedBalance, "insufficient delegatedFrozenBalance(%s), request=%d, unfreezeBalance=%d", name, balance, reclaimable)
+	}
+	return nil
+}
+
+// apply 应用已打包交易的状态变化，调用方需持有锁
+func (c *Chain) apply(tx *transaction) {
+	owner := c.accounts[tx.owner]
+	receiver := c.accounts[tx.receiver]
+
+	key := delegationKey{tx.receiver, tx.resource}
+
+	switch tx.kind {
+	case txDelegate:
+		frozen, delegated, _ := owner.stake(tx.resource)
+		_, _, acquired := receiver.stake(tx.resource)
+		*frozen -= tx.balance
+		*delegated += tx.balance
+		*acquired += tx.balance
+		if c.delegations[tx.owner] == nil {
+			c.delegations[tx.owner] = make(map[delegationKey]*delegation)
+		}
+		d, ok := c.delegations[tx.owner][key]
+		if !ok {
+			d = &delegation{}
+			c.delegations[tx.owner][key] = d
+		}
+		d.release(c.block)
+		if tx.lock > 0 {
+			d.locked += tx.balance
+			d.expireBlock = c.block + tx.lock
+		} else {
+			d.unlocked += tx.balance
+		}
+	case txUndelegate:
+		frozen, delegated, _ := owner.stake(tx.resource)
+		*frozen += tx.balance
+		*delegated -= tx.balance
+		if receiver != nil {
+			_, _, acquired := receiver.stake(tx.resource)
+			*acquired -= tx.balance
+		}
+		d := c.delegations[tx.owner][key]
+		d.release(c.block)
+		d.unlocked -= tx.balance
+		if d.total() == 0 {
+			delete(c.delegations[tx.owner], key)
+		}
+	case txFreeze, txUnfreeze, txWithdraw:
+		c.applyStaking(tx, owner)
+	}
+}
+
+// build 校验并构建交易，tx 由调用方填写交易类型、地址、资源、金额、锁定期和权限ID，调用方需持有锁
+func (c *Chain) build(tx *transaction) (*transaction, error) {
+	if err := c.validate(tx); err != nil {
+		return nil, err
+	}
+
+	c.nonce++
+	raw := encodeRawData(tx, c.block, c.blockTime(c.block).UnixMilli(), c.nonce)
+	hash := sha256.Sum256(raw)
+	tx.id = hex.EncodeToString(hash[:])
+	tx.rawHex = hex.EncodeToString(raw)
+	c.txs[tx.id] = tx
+	return tx, nil
+}
+
+// broadcast 将已签名的交易放入待打包队列，signers 为各签名私钥对应的地址，调用方需持有锁
+func (c *Chain) broadcast(txID string, signers []string) error {
+	tx, ok := c.txs[txID]
+	if !ok {
+		return &tron.APIError{Kind: tron.ErrTransactionExpired, Code: "TRANSACTION_EXPIRATION_ERROR", Message: "transaction expired"}
+	}
+	if tx.broadcast {
+		return &tron.APIError{Kind: tron.ErrDuplicateTransaction, Code: "DUP_TRANSACTION_ERROR", Message: "dup transaction"}
+	}
+	if err := c.checkSignature(tx, signers); err != nil {
+		return err
+	}
+	tx.broadcast = true
+	c.mempool = append(c.mempool, tx)
+	return nil
+}
+
+// checkSignature 按交易指定的权限校验签名：权限存在且允许该合约类型，签名地址都在权限中，权重之和达到阈值，调用方需持有锁
+func (c *Chain) checkSignature(tx *transaction, signers []string) error {
+	sigError := func(format string, args ...interface{}) error {
+		return &tron.APIError{Kind: tron.ErrSignature, Code: "SIGERROR", Message: "Validate signature error: " + fmt.Sprintf(format, args...)}
+	}
+
+	account, ok := c.permissions[tx.owner]
+	if !ok {
+		return sigError("account %s not exists", tx.owner)
+	}
+	permission := account.Permission(tx.permissionID)
+	if permission == nil {
+		return sigError("permission %d does not exist", tx.permissionID)
+	}
+	if !permission.Allows(tx.kind.contractType()) {
+		return sigError("permission denied")
+	}
+	for _, signer := range signers {
+		if permission.Weight([]string{signer}) == 0 {
+			return sigError("%s but it is not contained of permission", signer)
+		}
+	}
+	if weight := permission.Weight(signers); weight < permission.Threshold {
+		return sigError("sign weight %d is less than threshold %d", weight, permission.Threshold)
+	}
+	return nil
+}
+
+// transactionInfo 按节点格式生成交易回执，solid 为 true 时只返回已固化的交易，调用方需持有锁
+func (c *Chain) transactionInfo(txID string, solid bool) *tron.TransactionInfo {
+	if receipt, ok := c.receipts[txID]; ok {
+		if solid && receipt.BlockNumber > c.solidBlock() {
+			return &tron.TransactionInfo{}
+		}
+		info := *receipt
+		return &info
+	}
+	tx, ok := c.txs[txID]
+	if !ok || tx.block == 0 {
+		return &tron.TransactionInfo{}
+	}
+	if solid && tx.block > c.solidBlock() {
+		return &tron.TransactionInfo{}
+	}
+	info := &tron.TransactionInfo{
+		ID:             tx.id,
+		BlockNumber:    tx.block,
+		BlockTimeStamp: c.blockTime(tx.block).UnixMilli(),
+		Receipt:        tron.TransactionReceipt{NetUsage: delegateNetUsage},
+	}
+	if tx.netFee > 0 {
+		// 带宽不足时按字节燃烧 TRX，不消耗带宽
+		info.Fee = tx.netFee
+		info.Receipt = tron.TransactionReceipt{NetFee: tx.netFee}
+	}
+	if tx.failed {
+		info.Result = tx.result
+		info.ResMessage = hex.EncodeToString([]byte("execution failed"))
+	}
+	return info
+}
+
+// validationError 构建合约校验错误，message 与 java-tron 的错误信息一致
+func validationError(kind error, format string, args ...interface{}) *tron.APIError {
+	return &tron.APIError{Kind: kind, Code: "CONTRACT_VALIDATE_ERROR", Message: "class org.tron.core.exceptions.ContractValidateException : " + fmt.Sprintf(format, args...)}
+}
+
+// signerAddress 私钥对应的地址
+func signerAddress(key *secp256k1.PrivateKey) string {
+	if key == nil {
+		return ""
+	}
+	addr, err := address.FromPublicKey(key.PubKey().SerializeUncompressed())
+	if err != nil {
+		return ""
+	}
+	return addr.Base58()
+}
+
+// ---- tron.Client 实现 ----
+
+var _ tron.Client = (*Chain)(nil)
+
+// SetPrivateKey 设置进程内签名使用的私钥，使用 owner 权限签名
+func (c *Chain) SetPrivateKey(hexKey string) error {
+	return c.SetSigners([]string{hexKey}, tron.OwnerPermissionID)
+}
+
+// SetSigners 设置进程内签名使用的多个私钥和权限ID
+func (c *Chain) SetSigners(hexKeys []string, permissionID int) error {
+	if len(hexKeys) == 0 {
+		return fmt.Errorf("no private key provided")
+	}
+	signers := make([]tron.Signer, 0, len(hexKeys))
+	for _, hexKey := range hexKeys {
+		signer, err := tron.ParseLocalSigner(hexKey)
+		if err != nil {
+			return err
+		}
+		signers = append(signers, signer)
+	}
+	return c.UseSigners(signers, permissionID)
+}
+
+// UseSigners 设置进程内使用的签名者（可以是远程签名服务）和权限ID
+func (c *Chain) UseSigners(signers []tron.Signer, permissionID int) error {
+	if len(signers) == 0 {
+		return fmt.Errorf("no signer provided")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.signers = signers
+	c.permissionID = permissionID
+	return nil
+}
+
+// SignerAddress 返回第一个签名私钥对应的地址
+func (c *Chain) SignerAddress() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.signers) == 0 {
+		return ""
+	}
+	return c.signers[0].Address()
+}
+
+// SignerAddresses 返回全部签名私钥对应的地址
+func (c *Chain) SignerAddresses() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.signerAddresses()
+}
+
+// signerAddresses 全部签名私钥对应的地址，调用方需持有锁
+func (c *Chain) signerAddresses() []string {
+	addresses := make([]string, 0, len(c.signers))
+	for _, signer := range c.signers {
+		addresses = append(addresses, signer.Address())
+	}
+	return addresses
+}
+
+// PermissionID 返回进程内签名使用的权限ID
+func (c *Chain) PermissionID() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.permissionID
+}
+
+// GetAccountPermission 查询账户权限
+func (c *Chain) GetAccountPermission(ctx context.Context, addr string) (*tron.AccountPermission, error) {
+	normalized, err := address.Normalize(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid account address %q: %w", addr, err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.takeFailure(OpAccount); err != nil {
+		return nil, err
+	}
+	account, ok := c.accountPermission(normalized)
+	if !ok {
+		return nil, fmt.Errorf("account %s: %w", normalized, tron.ErrAccountNotActivated)
+	}
+	return account, nil
+}
+
+// accountPermission 账户权限的副本，调用方需持有锁
+func (c *Chain) accountPermission(addr string) (*tron.AccountPermission, bool) {
+	account, ok := c.permissions[addr]
+	if !ok {
+		return nil, false
+	}
+	copied := *account
+	copied.ActivePermission = append([]tron.Permission(nil), account.ActivePermission...)
+	return &copied, true
+}
+
+// GetAccountInfo 获取账户信息
+func (c *Chain) GetAccountInfo(ctx context.Context, addr string) (*tron.AccountInfo, error) {
+	normalized, err := address.Normalize(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid account address %q: %w", addr, err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.takeFailure(OpAccount); err != nil {
+		return nil, err
+	}
+	return c.accountInfo(normalized), nil
+}
+
+// accountInfo 生成账户信息，调用方需持有锁
+func (c *Chain) accountInfo(addr string) *tron.AccountInfo {
+	account, ok := c.accounts[addr]
+	if !ok {
+		return &tron.AccountInfo{Address: addr, Balance: "0", Energy: "0", Frozen: "0"}
+	}
+	price := c.price()
+	energy := price.EnergyForBalance(account.FrozenEnergy + account.AcquiredEnergy)
+	bandwidth := price.BandwidthForBalance(account.FrozenBandwidth + account.AcquiredBandwidth)
+	frozen := account.FrozenEnergy + account.DelegatedEnergy + account.FrozenBandwidth + account.DelegatedBandwidth
+	return &tron.AccountInfo{
+		Address:     addr,
+		Balance:     strconv.FormatInt(account.Balance, 10),
+		Energy:      strconv.FormatInt(energy, 10),
+		Frozen:      strconv.FormatInt(frozen, 10),
+		EnergyLimit: strconv.FormatInt(energy, 10),
+		EnergyUsed:  "0",
+		NetUsed:     "0",
+		NetLimit:    strconv.FormatInt(bandwidth, 10),
+	}
+}
+
+// GetAccountResource 获取账户资源及全网资源总量
+func (c *Chain) GetAccountResource(ctx context.Context, addr string) (*tron.AccountResource, error) {
+	normalized, err := address.Normalize(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid account address %q: %w", addr, err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.takeFailure(OpAccountResource); err != nil {
+		return nil, err
+	}
+	return c.accountResource(normalized), nil
+}
+
+// accountResource 生成账户资源，调用方需持有锁
+func (c *Chain) accountResource(addr string) *tron.AccountResource {
+	resource := &tron.AccountResource{
+		TotalEnergyLimit:  c.totalEnergyLimit,
+		TotalEnergyWeight: c.totalEnergyWeight,
+		TotalNetLimit:     c.totalNetLimit,
+		TotalNetWeight:    c.totalNetWeight,
+	}
+	if account, ok := c.accounts[addr]; ok {
+		price := c.price()
+		resource.EnergyLimit = price.EnergyForBalance(account.FrozenEnergy + account.AcquiredEnergy)
+		resource.NetLimit = price.BandwidthForBalance(account.FrozenBandwidth + account.AcquiredBandwidth)
+	}
+	return resource
+}
+
+// price 当前能量、带宽换算比例，调用方需持有锁
+func (c *Chain) price() *tron.EnergyPrice {
+	return &tron.EnergyPrice{
+		TotalEnergyLimit:  c.totalEnergyLimit,
+		TotalEnergyWeight: c.totalEnergyWeight,
+		EnergyFee:         c.energyFee,
+		TotalNetLimit:     c.totalNetLimit,
+		TotalNetWeight:    c.totalNetWeight,
+		TransactionFee:    DefaultTransactionFee,
+	}
+}
+
+// GetChainParameters 获取链参数
+func (c *Chain) GetChainParameters(ctx context.Context) (tron.ChainParameters, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.takeFailure(OpChainParameters); err != nil {
+		return nil, err
+	}
+	return c.chainParameters(), nil
+}
+
+// chainParameters 生成链参数，调用方需持有锁
+func (c *Chain) chainParameters() tron.ChainParameters {
+	return tron.ChainParameters{
+		"getEnergyFee":               c.energyFee,
+		"getTransactionFee":          DefaultTransactionFee,
+		"getAllowDelegateResource":   1,
+		"getUnfreezeDelayDays":       14,
+		"getTotalEnergyCurrentLimit": c.totalEnergyLimit,
+		"getMaxDelegateLockPeriod":   tron.MaxLockPeriod,
+	}
+}
+
+// GetCanDelegatedMaxSize 获取账户当前可委托的最大质押金额（SUN）
+func (c *Chain) GetCanDelegatedMaxSize(ctx context.Context, owner string, resource string) (int64, error) {
+	normalized, err := address.Normalize(owner)
+	if err != nil {
+		return 0, fmt.Errorf("invalid owner address %q: %w", owner, err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.takeFailure(OpCanDelegateMaxSize); err != nil {
+		return 0, err
+	}
+	return c.canDelegatedMaxSize(normalized, resource), nil
+}
+
+// canDelegatedMaxSize 可委托的最大质押金额，调用方需持有锁
+func (c *Chain) canDelegatedMaxSize(owner string, resource string) int64 {
+	account, ok := c.accounts[owner]
+	if !ok {
+		return 0
+	}
+	frozen, _, _ := account.stake(resource)
+	return *frozen
+}
+
+// GetDelegatedResourceV2 查询 from 委托给 to 的资源
+func (c *Chain) GetDelegatedResourceV2(ctx context.Context, from, to string) ([]tron.DelegatedResource, error) {
+	fromAddr, err := address.Normalize(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid owner address %q: %w", from, err)
+	}
+	toAddr, err := address.Normalize(to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid receiver address %q: %w", to, err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.takeFailure(OpDelegatedResource); err != nil {
+		return nil, err
+	}
+	return c.delegatedResource(fromAddr, toAddr), nil
+}
+
+// delegatedResource 生成委托记录，未锁定和锁定部分各一条（能量和带宽合并在同一条记录中），
+// 没有委托时返回空列表，调用方需持有锁
+func (c *Chain) delegatedResource(from, to string) []tron.DelegatedResource {
+	energy, hasEnergy := c.delegations[from][delegationKey{to, tron.ResourceEnergy}]
+	bandwidth, hasBandwidth := c.delegations[from][delegationKey{to, tron.ResourceBandwidth}]
+	if !hasEnergy && !hasBandwidth {
+		return nil
+	}
+	if energy == nil {
+		energy = &delegation{}
+	}
+	if bandwidth == nil {
+		bandwidth = &delegation{}
+	}
+
+	var resources []tron.DelegatedResource
+	if energy.unlocked > 0 || bandwidth.unlocked > 0 {
+		resources = append(resources, tron.DelegatedResource{
+			From:                      from,
+			To:                        to,
+			FrozenBalanceForEnergy:    energy.unlocked,
+			FrozenBalanceForBandwidth: bandwidth.unlocked,
+		})
+	}
+	if energy.locked > 0 || bandwidth.locked > 0 {
+		locked := tron.DelegatedResource{
+			From:                      from,
+			To:                        to,
+			FrozenBalanceForEnergy:    energy.locked,
+			FrozenBalanceForBandwidth: bandwidth.locked,
+		}
+		if energy.locked > 0 {
+			locked.ExpireTimeForEnergy = c.blockTime(energy.expireBlock).UnixMilli()
+		}
+		if bandwidth.locked > 0 {
+			locked.ExpireTimeForBandwidth = c.blockTime(bandwidth.expireBlock).UnixMilli()
+		}
+		resources = append(resources, locked)
+	}
+	return resources
+}
+
+// DelegateEnergy 构建、签名并广播委托交易
+func (c *Chain) DelegateEnergy(ctx context.Context, req *tron.EnergyDelegationRequest) (*tron.EnergyDelegationResponse, error) {
+	txID, err := c.submit(ctx, OpDelegate, txDelegate, req.FromAddress, req.ToAddress, req.Resource, req.Amount, lockPeriod(req.Lock, req.LockPeriod))
+	if err != nil {
+		return &tron.EnergyDelegationResponse{Success: false, Error: err.Error()}, fmt.Errorf("energy delegation failed: %w", err)
+	}
+	return &tron.EnergyDelegationResponse{Success: true, TxID: txID, Message: "delegate resource transaction broadcast"}, nil
+}
+
+// CancelEnergyDelegation 构建、签名并广播回收交易
+func (c *Chain) CancelEnergyDelegation(ctx context.Context, req *tron.CancelDelegationRequest) (*tron.CancelDelegationResponse, error) {
+	txID, err := c.submit(ctx, OpUndelegate, txUndelegate, req.FromAddress, req.ToAddress, req.Resource, req.Amount, 0)
+	if err != nil {
+		return &tron.CancelDelegationResponse{Success: false, Error: err.Error()}, fmt.Errorf("cancel energy delegation failed: %w", err)
+	}
+	return &tron.CancelDelegationResponse{Success: true, TxID: txID, Message: "undelegate resource transaction broadcast"}, nil
+}
+
+// BuildDelegation 构建并签名委托交易，不广播
+func (c *Chain) BuildDelegation(ctx context.Context, req *tron.EnergyDelegationRequest) (*tron.Transaction, error) {
+	tx, err := c.resourceTransaction(txDelegate, req.FromAddress, req.ToAddress, req.Resource, req.Amount, lockPeriod(req.Lock, req.LockPeriod))
+	if err != nil {
+		return nil, err
+	}
+	return c.sign(ctx, OpDelegate, tx)
+}
+
+// BuildUndelegation 构建并签名回收交易，不广播
+func (c *Chain) BuildUndelegation(ctx context.Context, req *tron.CancelDelegationRequest) (*tron.Transaction, error) {
+	tx, err := c.resourceTransaction(txUndelegate, req.FromAddress, req.ToAddress, req.Resource, req.Amount, 0)
+	if err != nil {
+		return nil, err
+	}
+	return c.sign(ctx, OpUndelegate, tx)
+}
+
+// BroadcastTransaction 广播已签名交易，与节点一致，重复广播返回成功
+func (c *Chain) BroadcastTransaction(ctx context.Context, signed *tron.Transaction) (*tron.BroadcastResponse, error) {
+	if err := c.broadcastSigned(signed); err != nil && !errors.Is(errorKind(err), tron.ErrDuplicateTransaction) {
+		return &tron.BroadcastResponse{TxID: signed.TxID}, fmt.Errorf("broadcast rejected: %w", err)
+	}
+	return &tron.BroadcastResponse{Result: true, TxID: signed.TxID}, nil
+}
+
+// lockPeriod 按节点规则确定锁定期：不锁定为0，锁定但未指定锁定期时使用默认值
+func lockPeriod(lock bool, period int64) int64 {
+	if !lock {
+		return 0
+	}
+	if period == 0 {
+		return defaultLockPeriod
+	}
+	return period
+}
+
+// submit 进程内的委托/回收交易构建、签名、广播流程，resource 为空时为能量（与 tron.TronClient 一致）
+func (c *Chain) submit(ctx context.Context, op Operation, kind txKind, owner, receiver, resource, amount string, lock int64) (string, error) {
+	tx, err := c.resourceTransaction(kind, owner, receiver, resource, amount, lock)
+	if err != nil {
+		return "", err
+	}
+	return c.signAndBroadcast(ctx, op, tx)
+}
+
+// resourceTransaction 校验请求参数并生成待构建的委托/回收交易
+func (c *Chain) resourceTransaction(kind txKind, owner, receiver, resource, amount string, lock int64) (*transaction, error) {
+	balance, err := strconv.ParseInt(amount, 10, 64)
+	if err != nil || balance <= 0 {
+		return nil, fmt.Errorf("invalid amount: %s", amount)
+	}
+	resource, err = tron.NormalizeResource(resource)
+	if err != nil {
+		return nil, err
+	}
+	ownerAddr, err := address.Normalize(owner)
+	if err != nil {
+		return nil, fmt.Errorf("invalid owner address %q: %w", owner, err)
+	}
+	receiverAddr, err := address.Normalize(receiver)
+	if err != nil {
+		return nil, fmt.Errorf("invalid receiver address %q: %w", receiver, err)
+	}
+	return &transaction{kind: kind, owner: ownerAddr, receiver: receiverAddr, resource: resource, balance: balance, lock: lock}, nil
+}
+
+// signAndBroadcast 构建交易，由进程内配置的签名者签名后广播
+func (c *Chain) signAndBroadcast(ctx context.Context, op Operation, tx *transaction) (string, error) {
+	signed, err := c.sign(ctx, op, tx)
+	if err != nil {
+		return "", err
+	}
+	if err := c.broadcastSigned(signed); err != nil {
+		return "", err
+	}
+	return signed.TxID, nil
+}
+
+// sign 构建交易并由进程内配置的签名者签名，tx 的权限ID使用进程内配置
+// 签名者可能是远程签名服务，签名期间不持有锁
+func (c *Chain) sign(ctx context.Context, op Operation, tx *transaction) (*tron.Transaction, error) {
+	c.mu.Lock()
+	signers := c.signers
+	if len(signers) == 0 {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("signer not configured, cannot sign transaction")
+	}
+	if err := c.takeFailure(op); err != nil {
+		c.mu.Unlock()
+		return nil, err
+	}
+	tx.permissionID = c.permissionID
+	tx, err := c.build(tx)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	signed := &tron.Transaction{Visible: true, TxID: tx.id, RawDataHex: tx.rawHex}
+	for _, signer := range signers {
+		if err := signer.Sign(ctx, signed); err != nil {
+			return nil, fmt.Errorf("failed to sign transaction with %s: %w", signer.Address(), err)
+		}
+	}
+	return signed, nil
+}
+
+// broadcastSigned 从签名恢复签名地址校验权限后广播
+func (c *Chain) broadcastSigned(signed *tron.Transaction) error {
+	addresses, err := recoverSigners(signed)
+	if err != nil {
+		return &tron.APIError{Kind: tron.ErrSignature, Code: "SIGERROR", Message: err.Error()}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.takeFailure(OpBroadcast); err != nil {
+		return err
+	}
+	return c.broadcast(signed.TxID, addresses)
+}
+
+// CheckConfirmation 查询交易确认状态
+func (c *Chain) CheckConfirmation(ctx context.Context, txID string) (*tron.Confirmation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.takeFailure(OpTransactionInfo); err != nil {
+		return nil, err
+	}
+
+	info := c.transactionInfo(txID, false)
+	if !info.Found() {
+		return &tron.Confirmation{TxID: txID, State: tron.ConfirmationNotFound}, nil
+	}
+
+	confirmation := &tron.Confirmation{
+		TxID:           txID,
+		State:          tron.ConfirmationConfirmed,
+		BlockNumber:    info.BlockNumber,
+		BlockTimeStamp: info.BlockTimeStamp,
+		Fee:            info.Fee,
+		Result:         info.ResultText(),
+		Receipt:        info.Receipt,
+	}
+	switch {
+	case !c.transactionInfo(txID, true).Found():
+		confirmation.State = tron.ConfirmationInBlock
+	case info.Failed():
+		confirmation.State = tron.ConfirmationFailed
+	}
+	return confirmation, nil
+}
+
+// GetSolidifiedTransactionInfo 获取已固化的交易回执
+func (c *Chain) GetSolidifiedTransactionInfo(ctx context.Context, txID string) (*tron.TransactionInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.takeFailure(OpTransactionInfo); err != nil {
+		return nil, err
+	}
+	return c.transactionInfo(txID, true), nil
+}
+
+// ConfirmMissing 模拟链只有一个节点，查询不到回执即未被收录
+func (c *Chain) ConfirmMissing(ctx context.Context, txID string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.takeFailure(OpTransactionInfo); err != nil {
+		return false, err
+	}
+	return !c.transactionInfo(txID, false).Found(), nil
+}
+
+// errorKind 返回 err 的错误分类，非 *tron.APIError 时返回 nil
+func errorKind(err error) error {
+	var apiErr *tron.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Kind
+	}
+	return nil
+}
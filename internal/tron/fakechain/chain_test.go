@@ -0,0 +1,684 @@
+package fakechain
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"lending-trx/internal/tron"
+	"lending-trx/internal/units"
+)
+
+const (
+	testPrivateKey  = "b5a4cea271ff424d7c31dc12a3e43e401df7a40d7412a15750f3f0b6b5449a28"
+	otherPrivateKey = "8e812436a0e3323166e1f0e8ba79e19e217b2c4a53c970d4cca0cfb1078979df"
+	testReceiver    = "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+	testInactive    = "TNPeeaaFB7K9cmo4uQpcU32zGK8G1NYqeL"
+)
+
+// newTestChain 创建模拟链，委托账户为 testPrivateKey 对应的地址，已质押 10000 TRX
+func newTestChain(t *testing.T) (*Chain, string) {
+	t.Helper()
+	chain := New()
+	if err := chain.SetPrivateKey(testPrivateKey); err != nil {
+		t.Fatalf("设置私钥失败: %v", err)
+	}
+	owner := chain.SignerAddress()
+	if owner == testInactive {
+		t.Fatal("测试私钥地址与未激活地址冲突")
+	}
+	chain.CreateAccount(owner, 20000*units.SunPerTRX)
+	chain.CreateAccount(testReceiver, 0)
+	if err := chain.Stake(owner, 10000*units.SunPerTRX); err != nil {
+		t.Fatalf("质押失败: %v", err)
+	}
+	return chain, owner
+}
+
+// runDelegationCycle 通过 tron.Client 完成一次委托和回收
+func runDelegationCycle(t *testing.T, chain *Chain, client tron.Client, owner string) {
+	t.Helper()
+	ctx := context.Background()
+
+	price, err := tron.NewEnergyPricer(client, time.Minute).Price(ctx, owner)
+	if err != nil {
+		t.Fatalf("获取能量价格失败: %v", err)
+	}
+	balance, err := price.BalanceForEnergy(65000)
+	if err != nil {
+		t.Fatalf("换算失败: %v", err)
+	}
+
+	available, err := client.GetCanDelegatedMaxSize(ctx, owner, tron.ResourceEnergy)
+	if err != nil || available < balance {
+		t.Fatalf("可委托余额不足: available=%d, balance=%d, err=%v", available, balance, err)
+	}
+
+	delegation, err := client.DelegateEnergy(ctx, &tron.EnergyDelegationRequest{
+		FromAddress: owner,
+		ToAddress:   testReceiver,
+		Amount:      strconv.FormatInt(balance, 10),
+	})
+	if err != nil {
+		t.Fatalf("委托失败: %v", err)
+	}
+
+	confirmation, err := client.CheckConfirmation(ctx, delegation.TxID)
+	if err != nil || confirmation.State != tron.ConfirmationNotFound {
+		t.Fatalf("期望打包前交易未找到，实际为 %+v, %v", confirmation, err)
+	}
+
+	chain.MineBlock()
+
+	confirmation, err = client.CheckConfirmation(ctx, delegation.TxID)
+	if err != nil || confirmation.State != tron.ConfirmationConfirmed {
+		t.Fatalf("期望委托交易已确认，实际为 %+v, %v", confirmation, err)
+	}
+	if got := chain.Delegated(owner, testReceiver); got != balance {
+		t.Errorf("期望委托金额为%d，实际为%d", balance, got)
+	}
+
+	resources, err := client.GetDelegatedResourceV2(ctx, owner, testReceiver)
+	if err != nil {
+		t.Fatalf("查询委托失败: %v", err)
+	}
+	if got := tron.DelegatedBalance(resources, tron.ResourceEnergy); got != balance {
+		t.Errorf("期望链上委托金额为%d，实际为%d", balance, got)
+	}
+
+	info, err := client.GetAccountInfo(ctx, testReceiver)
+	if err != nil {
+		t.Fatalf("获取接收方账户失败: %v", err)
+	}
+	if energy, _ := strconv.ParseInt(info.Energy, 10, 64); energy < 65000 {
+		t.Errorf("期望接收方获得至少65000能量，实际为%d", energy)
+	}
+
+	reclaim, err := client.CancelEnergyDelegation(ctx, &tron.CancelDelegationRequest{
+		FromAddress: owner,
+		ToAddress:   testReceiver,
+		Amount:      strconv.FormatInt(balance, 10),
+	})
+	if err != nil {
+		t.Fatalf("回收失败: %v", err)
+	}
+	chain.MineBlock()
+
+	confirmation, err = client.CheckConfirmation(ctx, reclaim.TxID)
+	if err != nil || confirmation.State != tron.ConfirmationConfirmed {
+		t.Fatalf("期望回收交易已确认，实际为 %+v, %v", confirmation, err)
+	}
+	if got := chain.Delegated(owner, testReceiver); got != 0 {
+		t.Errorf("期望回收后委托金额为0，实际为%d", got)
+	}
+	if resources, _ := client.GetDelegatedResourceV2(ctx, owner, testReceiver); len(resources) != 0 {
+		t.Errorf("期望回收后没有委托记录，实际为%+v", resources)
+	}
+	if account, _ := chain.Account(owner); account.FrozenEnergy != 10000*units.SunPerTRX {
+		t.Errorf("期望回收后质押金额恢复为%d，实际为%d", 10000*units.SunPerTRX, account.FrozenEnergy)
+	}
+}
+
+// newHTTPClient 启动 HTTP 模拟节点，返回使用该节点的 TronClient
+func newHTTPClient(t *testing.T, chain *Chain) *tron.TronClient {
+	t.Helper()
+	server := httptest.NewServer(chain.Handler())
+	t.Cleanup(server.Close)
+
+	client := tron.NewTronClient(server.URL, "")
+	client.SetRetryPolicy(tron.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+	if err := client.SetPrivateKey(testPrivateKey); err != nil {
+		t.Fatalf("设置私钥失败: %v", err)
+	}
+	return client
+}
+
+func TestDelegationCycleInProcess(t *testing.T) {
+	chain, owner := newTestChain(t)
+	runDelegationCycle(t, chain, chain, owner)
+}
+
+func TestDelegationCycleHTTP(t *testing.T) {
+	chain, owner := newTestChain(t)
+	runDelegationCycle(t, chain, newHTTPClient(t, chain), owner)
+}
+
+func TestDelegateValidationErrors(t *testing.T) {
+	chain, owner := newTestChain(t)
+	clients := map[string]tron.Client{
+		"in-process": chain,
+		"http":       newHTTPClient(t, chain),
+	}
+
+	for name, client := range clients {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			_, err := client.DelegateEnergy(ctx, &tron.EnergyDelegationRequest{
+				FromAddress: owner,
+				ToAddress:   testInactive,
+				Amount:      "1000000",
+			})
+			if !errors.Is(err, tron.ErrAccountNotActivated) {
+				t.Errorf("期望接收方未激活错误，实际为 %v", err)
+			}
+
+			_, err = client.DelegateEnergy(ctx, &tron.EnergyDelegationRequest{
+				FromAddress: owner,
+				ToAddress:   testReceiver,
+				Amount:      strconv.FormatInt(20000*units.SunPerTRX, 10),
+			})
+			if !errors.Is(err, tron.ErrInsufficientFrozenBalance) {
+				t.Errorf("期望质押余额不足错误，实际为 %v", err)
+			}
+
+			_, err = client.CancelEnergyDelegation(ctx, &tron.CancelDelegationRequest{
+				FromAddress: owner,
+				ToAddress:   testReceiver,
+				Amount:      "1000000",
+			})
+			if !errors.Is(err, tron.ErrInsufficientDelegatedBalance) {
+				t.Errorf("期望已委托余额不足错误，实际为 %v", err)
+			}
+		})
+	}
+}
+
+func TestInjectedFailures(t *testing.T) {
+	chain, owner := newTestChain(t)
+	ctx := context.Background()
+	req := &tron.EnergyDelegationRequest{FromAddress: owner, ToAddress: testReceiver, Amount: "1000000"}
+
+	// 进程内：故障原样返回
+	chain.FailNext(OpDelegate, &tron.APIError{Kind: tron.ErrRateLimited, StatusCode: 429})
+	if _, err := chain.DelegateEnergy(ctx, req); !errors.Is(err, tron.ErrRateLimited) {
+		t.Errorf("期望限流错误，实际为 %v", err)
+	}
+
+	// HTTP：广播限流一次后，客户端重试同一笔交易成功
+	client := newHTTPClient(t, chain)
+	chain.FailNext(OpBroadcast, &tron.APIError{Kind: tron.ErrRateLimited})
+	resp, err := client.DelegateEnergy(ctx, req)
+	if err != nil {
+		t.Fatalf("期望重试后委托成功，实际为 %v", err)
+	}
+	chain.MineBlock()
+	if got := chain.Delegated(owner, testReceiver); got != 1000000 {
+		t.Errorf("期望只委托一次，委托金额为1000000，实际为%d", got)
+	}
+
+	// 回执显示执行失败
+	chain.FailNextExecution(false)
+	resp, err = chain.DelegateEnergy(ctx, req)
+	if err != nil {
+		t.Fatalf("委托失败: %v", err)
+	}
+	chain.MineBlock()
+	confirmation, _ := chain.CheckConfirmation(ctx, resp.TxID)
+	if confirmation.State != tron.ConfirmationFailed {
+		t.Errorf("期望回执显示失败，实际为 %s", confirmation.State)
+	}
+	if got := chain.Delegated(owner, testReceiver); got != 1000000 {
+		t.Errorf("期望失败的交易不改变委托金额，实际为%d", got)
+	}
+}
+
+func TestBuildThenBroadcast(t *testing.T) {
+	chain, owner := newTestChain(t)
+	ctx := context.Background()
+	req := &tron.EnergyDelegationRequest{FromAddress: owner, ToAddress: testReceiver, Amount: "1000000"}
+
+	for name, client := range map[string]tron.Client{"in-process": chain, "http": newHTTPClient(t, chain)} {
+		t.Run(name, func(t *testing.T) {
+			before := chain.Delegated(owner, testReceiver)
+			signed, err := client.BuildDelegation(ctx, req)
+			if err != nil {
+				t.Fatalf("构建委托交易失败: %v", err)
+			}
+			expiration, err := signed.Expiration()
+			if err != nil || expiration <= chain.Now().UnixMilli() {
+				t.Errorf("期望过期时间晚于当前区块时间，实际为 %d, %v", expiration, err)
+			}
+
+			// 未广播的交易不会上链
+			chain.MineBlock()
+			if confirmation, _ := client.CheckConfirmation(ctx, signed.TxID); confirmation.State != tron.ConfirmationNotFound {
+				t.Errorf("期望未广播的交易未找到，实际为 %s", confirmation.State)
+			}
+
+			// 广播失败后重复广播同一交易只委托一次
+			chain.FailNext(OpBroadcast, &tron.APIError{Kind: tron.ErrTransient, StatusCode: 502})
+			if _, err := chain.BroadcastTransaction(ctx, signed); err == nil {
+				t.Fatal("期望广播失败")
+			}
+			for i := 0; i < 2; i++ {
+				if _, err := client.BroadcastTransaction(ctx, signed); err != nil {
+					t.Fatalf("第%d次广播失败: %v", i+1, err)
+				}
+			}
+			chain.MineBlock()
+			if got := chain.Delegated(owner, testReceiver) - before; got != 1000000 {
+				t.Errorf("期望只委托一次，委托金额为1000000，实际为%d", got)
+			}
+		})
+	}
+}
+
+func TestSolidityLagAndDroppedTransactions(t *testing.T) {
+	chain, owner := newTestChain(t)
+	client := newHTTPClient(t, chain)
+	ctx := context.Background()
+	req := &tron.EnergyDelegationRequest{FromAddress: owner, ToAddress: testReceiver, Amount: "1000000"}
+
+	chain.SetSolidityLag(2)
+	resp, err := client.DelegateEnergy(ctx, req)
+	if err != nil {
+		t.Fatalf("委托失败: %v", err)
+	}
+	chain.MineBlock()
+
+	confirmation, err := client.CheckConfirmation(ctx, resp.TxID)
+	if err != nil || confirmation.State != tron.ConfirmationInBlock {
+		t.Fatalf("期望已打包未固化，实际为 %+v, %v", confirmation, err)
+	}
+
+	chain.MineBlock()
+	chain.MineBlock()
+	confirmation, _ = client.CheckConfirmation(ctx, resp.TxID)
+	if confirmation.State != tron.ConfirmationConfirmed {
+		t.Errorf("期望固化后已确认，实际为 %s", confirmation.State)
+	}
+
+	// 未打包的交易被丢弃后查询不到
+	resp, err = client.DelegateEnergy(ctx, req)
+	if err != nil {
+		t.Fatalf("委托失败: %v", err)
+	}
+	chain.DropPending()
+	chain.MineBlock()
+	confirmation, _ = client.CheckConfirmation(ctx, resp.TxID)
+	if confirmation.State != tron.ConfirmationNotFound {
+		t.Errorf("期望丢弃的交易未找到，实际为 %s", confirmation.State)
+	}
+}
+
+func TestBroadcastRejectsWrongSigner(t *testing.T) {
+	chain, owner := newTestChain(t)
+	client := newHTTPClient(t, chain)
+	if err := client.SetPrivateKey(otherPrivateKey); err != nil {
+		t.Fatalf("设置私钥失败: %v", err)
+	}
+
+	_, err := client.DelegateEnergy(context.Background(), &tron.EnergyDelegationRequest{
+		FromAddress: owner,
+		ToAddress:   testReceiver,
+		Amount:      "1000000",
+	})
+	if !errors.Is(err, tron.ErrSignature) {
+		t.Errorf("期望签名错误，实际为 %v", err)
+	}
+}
+
+func TestLockedDelegation(t *testing.T) {
+	chain, owner := newTestChain(t)
+	clients := map[string]tron.Client{
+		"in-process": chain,
+		"http":       newHTTPClient(t, chain),
+	}
+
+	for name, client := range clients {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			delegate := func(amount, lockPeriod int64) {
+				t.Helper()
+				_, err := client.DelegateEnergy(ctx, &tron.EnergyDelegationRequest{
+					FromAddress: owner,
+					ToAddress:   testReceiver,
+					Amount:      strconv.FormatInt(amount, 10),
+					Lock:        lockPeriod > 0,
+					LockPeriod:  lockPeriod,
+				})
+				if err != nil {
+					t.Fatalf("委托失败: %v", err)
+				}
+				chain.MineBlock()
+			}
+			reclaim := func(amount int64) error {
+				_, err := client.CancelEnergyDelegation(ctx, &tron.CancelDelegationRequest{
+					FromAddress: owner,
+					ToAddress:   testReceiver,
+					Amount:      strconv.FormatInt(amount, 10),
+				})
+				return err
+			}
+
+			delegate(2*units.SunPerTRX, 0)
+			delegate(3*units.SunPerTRX, 100)
+
+			locked, expireBlock := chain.Locked(owner, testReceiver)
+			if locked != 3*units.SunPerTRX || expireBlock != chain.BlockNumber()+100 {
+				t.Errorf("期望锁定3 TRX至区块%d，实际为%d至区块%d", chain.BlockNumber()+100, locked, expireBlock)
+			}
+
+			resources, err := client.GetDelegatedResourceV2(ctx, owner, testReceiver)
+			if err != nil {
+				t.Fatalf("查询委托失败: %v", err)
+			}
+			if len(resources) != 2 {
+				t.Fatalf("期望锁定和未锁定各一条委托记录，实际为%+v", resources)
+			}
+			if got := tron.ReclaimableBalance(resources, tron.ResourceEnergy, chain.Now()); got != 2*units.SunPerTRX {
+				t.Errorf("期望锁定期内可回收2 TRX，实际为%d", got)
+			}
+
+			// 锁定期内只能回收未锁定部分
+			if err := reclaim(5 * units.SunPerTRX); !errors.Is(err, tron.ErrInsufficientDelegatedBalance) {
+				t.Errorf("期望锁定期内回收失败，实际为 %v", err)
+			}
+			if err := reclaim(2 * units.SunPerTRX); err != nil {
+				t.Fatalf("回收未锁定部分失败: %v", err)
+			}
+			chain.MineBlock()
+
+			// 锁定到期后可以回收锁定部分
+			chain.SkipBlocks(100)
+			resources, _ = client.GetDelegatedResourceV2(ctx, owner, testReceiver)
+			if got := tron.ReclaimableBalance(resources, tron.ResourceEnergy, chain.Now()); got != 3*units.SunPerTRX {
+				t.Errorf("期望锁定到期后可回收3 TRX，实际为%d", got)
+			}
+			if err := reclaim(3 * units.SunPerTRX); err != nil {
+				t.Fatalf("锁定到期后回收失败: %v", err)
+			}
+			chain.MineBlock()
+			if got := chain.Delegated(owner, testReceiver); got != 0 {
+				t.Errorf("期望回收后委托金额为0，实际为%d", got)
+			}
+		})
+	}
+}
+
+func TestLockPeriodValidation(t *testing.T) {
+	chain, owner := newTestChain(t)
+	ctx := context.Background()
+	req := &tron.EnergyDelegationRequest{FromAddress: owner, ToAddress: testReceiver, Amount: "1000000", Lock: true, LockPeriod: 1000}
+
+	if _, err := chain.DelegateEnergy(ctx, req); err != nil {
+		t.Fatalf("委托失败: %v", err)
+	}
+	chain.MineBlock()
+
+	// 新的锁定期短于上一次锁定的剩余时间
+	req.LockPeriod = 10
+	if _, err := chain.DelegateEnergy(ctx, req); !errors.Is(err, tron.ErrContractValidate) {
+		t.Errorf("期望锁定期过短的校验错误，实际为 %v", err)
+	}
+
+	// 超过锁定期上限的请求在客户端即被拒绝
+	req.LockPeriod = tron.MaxLockPeriod + 1
+	if _, err := newHTTPClient(t, chain).DelegateEnergy(ctx, req); err == nil {
+		t.Error("期望超过上限的锁定期返回错误")
+	}
+}
+
+func TestMultiSignatureCustomPermission(t *testing.T) {
+	chain, owner := newTestChain(t)
+	hotKey, err := tron.ParsePrivateKey(otherPrivateKey)
+	if err != nil {
+		t.Fatalf("解析私钥失败: %v", err)
+	}
+	hotAddress := signerAddress(hotKey)
+
+	// 自定义权限：只允许委托和回收，需 owner 私钥和热钱包私钥共同签名
+	if err := chain.SetPermission(owner, tron.Permission{
+		Type: "Active", ID: 3, PermissionName: "delegate", Threshold: 2,
+		Operations: tron.OperationsFor(tron.ContractTypeDelegateResource, tron.ContractTypeUnDelegateResource),
+		Keys:       []tron.PermissionKey{{Address: owner, Weight: 1}, {Address: hotAddress, Weight: 1}},
+	}); err != nil {
+		t.Fatalf("设置权限失败: %v", err)
+	}
+
+	clients := map[string]tron.Client{
+		"in-process": chain,
+		"http":       newHTTPClient(t, chain),
+	}
+	for name, client := range clients {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			req := &tron.EnergyDelegationRequest{FromAddress: owner, ToAddress: testReceiver, Amount: "1000000"}
+
+			// 权重不足
+			if err := client.SetSigners([]string{otherPrivateKey}, 3); err != nil {
+				t.Fatalf("设置签名私钥失败: %v", err)
+			}
+			if err := tron.VerifySigners(ctx, client, owner); err == nil {
+				t.Error("期望权重不足时检查失败")
+			}
+			if _, err := client.DelegateEnergy(ctx, req); !errors.Is(err, tron.ErrSignature) {
+				t.Errorf("期望权重不足时签名错误，实际为 %v", err)
+			}
+
+			// 两个私钥共同签名
+			if err := client.SetSigners([]string{testPrivateKey, otherPrivateKey}, 3); err != nil {
+				t.Fatalf("设置签名私钥失败: %v", err)
+			}
+			if err := tron.VerifySigners(ctx, client, owner); err != nil {
+				t.Errorf("期望权限检查通过，实际为 %v", err)
+			}
+			before := chain.Delegated(owner, testReceiver)
+			if _, err := client.DelegateEnergy(ctx, req); err != nil {
+				t.Fatalf("多签委托失败: %v", err)
+			}
+			chain.MineBlock()
+			if got := chain.Delegated(owner, testReceiver); got != before+1000000 {
+				t.Errorf("期望委托金额为%d，实际为%d", before+1000000, got)
+			}
+
+			// 默认 active 权限只有 owner 一个公钥，热钱包私钥不在其中
+			if err := client.SetSigners([]string{otherPrivateKey}, 2); err != nil {
+				t.Fatalf("设置签名私钥失败: %v", err)
+			}
+			if _, err := client.DelegateEnergy(ctx, req); !errors.Is(err, tron.ErrSignature) {
+				t.Errorf("期望签名地址不在权限中时签名错误，实际为 %v", err)
+			}
+		})
+	}
+}
+
+func TestStakingManager(t *testing.T) {
+	for _, mode := range []string{"in-process", "http"} {
+		t.Run(mode, func(t *testing.T) {
+			chain, owner := newTestChain(t)
+			chain.SetUnfreezeDelay(10)
+			var client tron.Client = chain
+			if mode == "http" {
+				client = newHTTPClient(t, chain)
+			}
+			ctx := context.Background()
+
+			// 可用余额 10000 TRX，保留 1000 TRX，质押其余部分
+			manager := tron.NewStakingManager(client, tron.StakingConfig{Owner: owner, Reserve: 1000 * units.SunPerTRX, MinFreeze: 10 * units.SunPerTRX})
+			result, err := manager.Run(ctx, chain.Now())
+			if err != nil {
+				t.Fatalf("质押管理失败: %v", err)
+			}
+			if result.Plan.Freeze != 9000*units.SunPerTRX || result.FreezeTxID == "" {
+				t.Fatalf("期望质押9000 TRX，实际为%+v", result)
+			}
+			chain.MineBlock()
+			account, _ := chain.Account(owner)
+			if account.Balance != 1000*units.SunPerTRX || account.FrozenEnergy != 19000*units.SunPerTRX {
+				t.Errorf("期望可用余额1000 TRX、质押19000 TRX，实际为%d、%d", account.Balance, account.FrozenEnergy)
+			}
+
+			// 保留余额提高后解质押差额，到期后提取
+			manager = tron.NewStakingManager(client, tron.StakingConfig{Owner: owner, Reserve: 1500 * units.SunPerTRX, MinFreeze: 10 * units.SunPerTRX})
+			result, err = manager.Run(ctx, chain.Now())
+			if err != nil || result.Plan.Unfreeze != 500*units.SunPerTRX {
+				t.Fatalf("期望解质押500 TRX，实际为%+v, %v", result, err)
+			}
+			chain.MineBlock()
+
+			// 解质押未到期，不重复解质押
+			if result, err = manager.Run(ctx, chain.Now()); err != nil || result.Plan != (tron.StakingPlan{}) {
+				t.Errorf("期望解质押进行中时不操作，实际为%+v, %v", result, err)
+			}
+
+			chain.SkipBlocks(10)
+			result, err = manager.Run(ctx, chain.Now())
+			if err != nil || !result.Plan.Withdraw || result.WithdrawTxID == "" {
+				t.Fatalf("期望提取到期的解质押，实际为%+v, %v", result, err)
+			}
+			chain.MineBlock()
+			if account, _ := chain.Account(owner); account.Balance != 1500*units.SunPerTRX {
+				t.Errorf("期望提取后可用余额为1500 TRX，实际为%d", account.Balance)
+			}
+		})
+	}
+}
+
+// runBandwidthCycle 同一接收方同时委托能量和带宽，按资源分别回收
+func runBandwidthCycle(t *testing.T, chain *Chain, client tron.Client, owner string) {
+	t.Helper()
+	ctx := context.Background()
+	if err := chain.StakeBandwidth(owner, 5000*units.SunPerTRX); err != nil {
+		t.Fatalf("质押带宽失败: %v", err)
+	}
+
+	price, err := tron.NewEnergyPricer(client, time.Minute).Price(ctx, owner)
+	if err != nil {
+		t.Fatalf("获取价格失败: %v", err)
+	}
+	balance, err := price.BalanceFor(tron.ResourceBandwidth, 5000)
+	if err != nil {
+		t.Fatalf("换算带宽失败: %v", err)
+	}
+	if available, err := client.GetCanDelegatedMaxSize(ctx, owner, tron.ResourceBandwidth); err != nil || available != 5000*units.SunPerTRX {
+		t.Fatalf("期望可委托带宽质押为%d，实际为%d, %v", 5000*units.SunPerTRX, available, err)
+	}
+
+	for _, resource := range []string{tron.ResourceBandwidth, tron.ResourceEnergy} {
+		if _, err := client.DelegateEnergy(ctx, &tron.EnergyDelegationRequest{
+			FromAddress: owner,
+			ToAddress:   testReceiver,
+			Amount:      strconv.FormatInt(balance, 10),
+			Resource:    resource,
+		}); err != nil {
+			t.Fatalf("委托%s失败: %v", resource, err)
+		}
+	}
+	chain.MineBlock()
+
+	resources, err := client.GetDelegatedResourceV2(ctx, owner, testReceiver)
+	if err != nil {
+		t.Fatalf("查询委托失败: %v", err)
+	}
+	if got := tron.DelegatedBalance(resources, tron.ResourceBandwidth); got != balance {
+		t.Errorf("期望链上带宽委托为%d，实际为%d", balance, got)
+	}
+	if got := tron.DelegatedBalance(resources, tron.ResourceEnergy); got != balance {
+		t.Errorf("期望链上能量委托为%d，实际为%d", balance, got)
+	}
+	resource, err := client.GetAccountResource(ctx, testReceiver)
+	if err != nil || resource.NetLimit < 5000 {
+		t.Errorf("期望接收方获得至少5000带宽，实际为%+v, %v", resource, err)
+	}
+
+	// 回收带宽不影响能量委托
+	if _, err := client.CancelEnergyDelegation(ctx, &tron.CancelDelegationRequest{
+		FromAddress: owner,
+		ToAddress:   testReceiver,
+		Amount:      strconv.FormatInt(balance, 10),
+		Resource:    tron.ResourceBandwidth,
+	}); err != nil {
+		t.Fatalf("回收带宽失败: %v", err)
+	}
+	chain.MineBlock()
+	if got := chain.DelegatedResource(owner, testReceiver, tron.ResourceBandwidth); got != 0 {
+		t.Errorf("期望带宽委托已回收，实际为%d", got)
+	}
+	if got := chain.Delegated(owner, testReceiver); got != balance {
+		t.Errorf("期望能量委托保持%d，实际为%d", balance, got)
+	}
+
+	// 带宽已全部回收，再次回收返回已委托余额不足
+	_, err = client.CancelEnergyDelegation(ctx, &tron.CancelDelegationRequest{
+		FromAddress: owner,
+		ToAddress:   testReceiver,
+		Amount:      strconv.FormatInt(balance, 10),
+		Resource:    tron.ResourceBandwidth,
+	})
+	if !errors.Is(err, tron.ErrInsufficientDelegatedBalance) {
+		t.Errorf("期望返回已委托余额不足，实际为%v", err)
+	}
+}
+
+func TestBandwidthDelegationInProcess(t *testing.T) {
+	chain, owner := newTestChain(t)
+	runBandwidthCycle(t, chain, chain, owner)
+}
+
+func TestBandwidthDelegationHTTP(t *testing.T) {
+	chain, owner := newTestChain(t)
+	runBandwidthCycle(t, chain, newHTTPClient(t, chain), owner)
+}
+
+// runBlockQueries 最新区块、固化区块和按高度查询区块，重组后同高度区块哈希变化
+func runBlockQueries(t *testing.T, chain *Chain, client tron.Client) {
+	t.Helper()
+	ctx := context.Background()
+	chain.SetSolidityLag(19)
+	latest := chain.SkipBlocks(30)
+
+	now, err := client.GetNowBlock(ctx)
+	if err != nil {
+		t.Fatalf("查询最新区块失败: %v", err)
+	}
+	if now.Number() != latest || now.BlockID != chain.BlockID(latest) {
+		t.Errorf("期望最新区块为%d，实际为%d", latest, now.Number())
+	}
+	solid, err := client.GetSolidifiedNowBlock(ctx)
+	if err != nil {
+		t.Fatalf("查询固化区块失败: %v", err)
+	}
+	if solid.Number() != latest-19 {
+		t.Errorf("期望固化区块为%d，实际为%d", latest-19, solid.Number())
+	}
+
+	before, err := client.GetBlockByNum(ctx, latest-5)
+	if err != nil || !before.Found() {
+		t.Fatalf("查询区块失败: %v", err)
+	}
+	if !tron.SameBlockHash("0x"+before.BlockID, chain.BlockID(latest-5)) {
+		t.Errorf("期望区块哈希为%s，实际为%s", chain.BlockID(latest-5), before.BlockID)
+	}
+
+	chain.Reorg(latest - 5)
+	after, err := client.GetBlockByNum(ctx, latest-5)
+	if err != nil {
+		t.Fatalf("查询区块失败: %v", err)
+	}
+	if after.Number() != latest-5 || tron.SameBlockHash(after.BlockID, before.BlockID) {
+		t.Errorf("期望重组后区块%d的哈希变化，实际为%s", latest-5, after.BlockID)
+	}
+
+	missing, err := client.GetBlockByNum(ctx, latest+1)
+	if err != nil {
+		t.Fatalf("查询区块失败: %v", err)
+	}
+	if missing.Found() {
+		t.Errorf("期望尚未产生的区块不存在，实际为%s", missing.BlockID)
+	}
+}
+
+func TestBlockQueriesInProcess(t *testing.T) {
+	chain, _ := newTestChain(t)
+	runBlockQueries(t, chain, chain)
+}
+
+func TestBlockQueriesHTTP(t *testing.T) {
+	chain, _ := newTestChain(t)
+	runBlockQueries(t, chain, newHTTPClient(t, chain))
+}
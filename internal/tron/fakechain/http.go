@@ -0,0 +1,456 @@
+package fakechain
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"lending-trx/internal/address"
+	"lending-trx/internal/tron"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// Handler 返回与全节点 HTTP API 兼容的 handler，可配合 httptest.NewServer 作为 tron.TronClient 的节点
+// 支持 tron.TronClient 使用的接口：构建委托/回收交易、广播、交易回执、账户资源、链参数、最新区块
+func (c *Chain) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/wallet/delegateresource", c.handleBuild(OpDelegate, txDelegate))
+	mux.HandleFunc("/wallet/undelegateresource", c.handleBuild(OpUndelegate, txUndelegate))
+	mux.HandleFunc("/wallet/freezebalancev2", c.handleStakingBuild(OpFreeze, txFreeze))
+	mux.HandleFunc("/wallet/unfreezebalancev2", c.handleStakingBuild(OpUnfreeze, txUnfreeze))
+	mux.HandleFunc("/wallet/withdrawexpireunfreeze", c.handleStakingBuild(OpWithdraw, txWithdraw))
+	mux.HandleFunc("/wallet/broadcasttransaction", c.handleBroadcast)
+	mux.HandleFunc("/wallet/gettransactioninfobyid", c.handleTransactionInfo(false))
+	mux.HandleFunc("/walletsolidity/gettransactioninfobyid", c.handleTransactionInfo(true))
+	mux.HandleFunc("/wallet/getaccountresource", c.handleAccountResource)
+	mux.HandleFunc("/wallet/getchainparameters", c.handleChainParameters)
+	mux.HandleFunc("/wallet/getcandelegatedmaxsize", c.handleCanDelegatedMaxSize)
+	mux.HandleFunc("/wallet/getdelegatedresourcev2", c.handleDelegatedResource)
+	mux.HandleFunc("/wallet/getaccount", c.handleGetAccount)
+	mux.HandleFunc("/wallet/getnowblock", c.handleNowBlock(false))
+	mux.HandleFunc("/walletsolidity/getnowblock", c.handleNowBlock(true))
+	mux.HandleFunc("/wallet/getblockbynum", c.handleBlockByNum)
+	mux.HandleFunc("/v1/accounts/", c.handleAccount)
+	return mux
+}
+
+// buildRequest delegateresource / undelegateresource 请求参数
+type buildRequest struct {
+	OwnerAddress    string `json:"owner_address"`
+	ReceiverAddress string `json:"receiver_address"`
+	Balance         int64  `json:"balance"`
+	Resource        string `json:"resource"`
+	Lock            bool   `json:"lock"`
+	LockPeriod      int64  `json:"lock_period"`
+	PermissionID    int    `json:"Permission_id"`
+}
+
+// handleBuild 构建交易，校验失败时按节点格式返回 {"Error": "..."}
+func (c *Chain) handleBuild(op Operation, kind txKind) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req buildRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, map[string]string{"Error": err.Error()})
+			return
+		}
+
+		owner, err1 := address.Normalize(req.OwnerAddress)
+		receiver, err2 := address.Normalize(req.ReceiverAddress)
+		if err1 != nil || err2 != nil {
+			writeJSON(w, map[string]string{"Error": "class java.lang.IllegalArgumentException : invalid address"})
+			return
+		}
+
+		c.mu.Lock()
+		if err := c.takeFailure(op); err != nil {
+			c.mu.Unlock()
+			writeNodeError(w, err)
+			return
+		}
+		// 节点未指定 resource 时为带宽（枚举值0）
+		resource := req.Resource
+		if resource == "" {
+			resource = tron.ResourceBandwidth
+		}
+		tx, err := c.build(&transaction{
+			kind:         kind,
+			owner:        owner,
+			receiver:     receiver,
+			resource:     resource,
+			balance:      req.Balance,
+			lock:         lockPeriod(req.Lock, req.LockPeriod),
+			permissionID: req.PermissionID,
+		})
+		c.mu.Unlock()
+		writeBuiltTransaction(w, tx, err)
+	}
+}
+
+// stakingRequest freezebalancev2 / unfreezebalancev2 / withdrawexpireunfreeze 请求参数
+type stakingRequest struct {
+	OwnerAddress    string `json:"owner_address"`
+	FrozenBalance   int64  `json:"frozen_balance"`
+	UnfreezeBalance int64  `json:"unfreeze_balance"`
+	Resource        string `json:"resource"`
+	PermissionID    int    `json:"Permission_id"`
+}
+
+// handleStakingBuild 构建质押类交易，模拟链只支持能量
+func (c *Chain) handleStakingBuild(op Operation, kind txKind) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req stakingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, map[string]string{"Error": err.Error()})
+			return
+		}
+		owner, err := address.Normalize(req.OwnerAddress)
+		if err != nil {
+			writeJSON(w, map[string]string{"Error": "class java.lang.IllegalArgumentException : invalid address"})
+			return
+		}
+		if kind != txWithdraw && req.Resource != tron.ResourceEnergy {
+			writeNodeError(w, validationError(tron.ErrContractValidate, "fakechain only supports ENERGY resource"))
+			return
+		}
+		balance := req.FrozenBalance
+		if kind == txUnfreeze {
+			balance = req.UnfreezeBalance
+		}
+
+		c.mu.Lock()
+		if err := c.takeFailure(op); err != nil {
+			c.mu.Unlock()
+			writeNodeError(w, err)
+			return
+		}
+		tx, err := c.build(&transaction{kind: kind, owner: owner, resource: tron.ResourceEnergy, balance: balance, permissionID: req.PermissionID})
+		c.mu.Unlock()
+		writeBuiltTransaction(w, tx, err)
+	}
+}
+
+// writeBuiltTransaction 返回构建的未签名交易，校验失败时按节点格式返回 {"Error": "..."}
+func writeBuiltTransaction(w http.ResponseWriter, tx *transaction, err error) {
+	if err != nil {
+		writeNodeError(w, err)
+		return
+	}
+	writeJSON(w, tron.Transaction{
+		Visible:    true,
+		TxID:       tx.id,
+		RawData:    json.RawMessage(`{"contract":[]}`),
+		RawDataHex: tx.rawHex,
+	})
+}
+
+// handleBroadcast 验证签名后将交易放入待打包队列
+func (c *Chain) handleBroadcast(w http.ResponseWriter, r *http.Request) {
+	var tx tron.Transaction
+	if err := json.NewDecoder(r.Body).Decode(&tx); err != nil {
+		writeJSON(w, tron.BroadcastResponse{Code: "OTHER_ERROR", Message: hex.EncodeToString([]byte(err.Error()))})
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.takeFailure(OpBroadcast); err != nil {
+		writeBroadcastError(w, err)
+		return
+	}
+
+	signers, err := recoverSigners(&tx)
+	if err != nil {
+		writeBroadcastError(w, &tron.APIError{Kind: tron.ErrSignature, Code: "SIGERROR", Message: err.Error()})
+		return
+	}
+	if err := c.broadcast(tx.TxID, signers); err != nil {
+		writeBroadcastError(w, err)
+		return
+	}
+	writeJSON(w, tron.BroadcastResponse{Result: true, TxID: tx.TxID})
+}
+
+// recoverSigners 从交易的全部签名恢复签名地址
+func recoverSigners(tx *tron.Transaction) ([]string, error) {
+	if len(tx.Signature) == 0 {
+		return nil, errors.New("transaction is not signed")
+	}
+	hash, err := hex.DecodeString(tx.TxID)
+	if err != nil {
+		return nil, errors.New("invalid txID")
+	}
+	signers := make([]string, 0, len(tx.Signature))
+	for _, signature := range tx.Signature {
+		signer, err := recoverSigner(hash, signature)
+		if err != nil {
+			return nil, err
+		}
+		signers = append(signers, signer)
+	}
+	return signers, nil
+}
+
+// recoverSigner 从单个签名恢复签名地址
+func recoverSigner(hash []byte, signature string) (string, error) {
+	sig, err := hex.DecodeString(signature)
+	if err != nil || len(sig) != 65 {
+		return "", errors.New("invalid signature")
+	}
+	compact := append([]byte{sig[64] + 27}, sig[:64]...)
+	pub, _, err := ecdsa.RecoverCompact(compact, hash)
+	if err != nil {
+		return "", err
+	}
+	addr, err := address.FromPublicKey(pub.SerializeUncompressed())
+	if err != nil {
+		return "", err
+	}
+	return addr.Base58(), nil
+}
+
+// handleTransactionInfo 查询交易回执，未找到时返回空对象
+func (c *Chain) handleTransactionInfo(solid bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Value string `json:"value"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if err := c.takeFailure(OpTransactionInfo); err != nil {
+			writeNodeError(w, err)
+			return
+		}
+		writeJSON(w, c.transactionInfo(req.Value, solid))
+	}
+}
+
+// handleAccountResource 查询账户资源
+func (c *Chain) handleAccountResource(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Address string `json:"address"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	addr, _ := address.Normalize(req.Address)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.takeFailure(OpAccountResource); err != nil {
+		writeNodeError(w, err)
+		return
+	}
+	writeJSON(w, c.accountResource(addr))
+}
+
+// handleChainParameters 查询链参数
+func (c *Chain) handleChainParameters(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.takeFailure(OpChainParameters); err != nil {
+		writeNodeError(w, err)
+		return
+	}
+
+	type parameter struct {
+		Key   string `json:"key"`
+		Value int64  `json:"value"`
+	}
+	var params []parameter
+	for key, value := range c.chainParameters() {
+		params = append(params, parameter{Key: key, Value: value})
+	}
+	writeJSON(w, map[string]interface{}{"chainParameter": params})
+}
+
+// handleCanDelegatedMaxSize 查询可委托的最大质押金额
+func (c *Chain) handleCanDelegatedMaxSize(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		OwnerAddress string `json:"owner_address"`
+		Type         int    `json:"type"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	owner, _ := address.Normalize(req.OwnerAddress)
+	resource := tron.ResourceEnergy
+	if req.Type == 0 {
+		resource = tron.ResourceBandwidth
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.takeFailure(OpCanDelegateMaxSize); err != nil {
+		writeNodeError(w, err)
+		return
+	}
+	writeJSON(w, map[string]int64{"max_size": c.canDelegatedMaxSize(owner, resource)})
+}
+
+// handleDelegatedResource 查询委托记录，没有委托时返回空对象
+func (c *Chain) handleDelegatedResource(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		FromAddress string `json:"fromAddress"`
+		ToAddress   string `json:"toAddress"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	from, _ := address.Normalize(req.FromAddress)
+	to, _ := address.Normalize(req.ToAddress)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.takeFailure(OpDelegatedResource); err != nil {
+		writeNodeError(w, err)
+		return
+	}
+	resources := c.delegatedResource(from, to)
+	if len(resources) == 0 {
+		writeJSON(w, map[string]interface{}{})
+		return
+	}
+	writeJSON(w, map[string]interface{}{"delegatedResource": resources})
+}
+
+// handleGetAccount 查询账户余额、质押状态和权限（/wallet/getaccount），账户不存在时返回空对象
+func (c *Chain) handleGetAccount(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Address string `json:"address"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	addr, _ := address.Normalize(req.Address)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.takeFailure(OpAccount); err != nil {
+		writeNodeError(w, err)
+		return
+	}
+	permission, ok := c.accountPermission(addr)
+	if !ok {
+		writeJSON(w, map[string]interface{}{})
+		return
+	}
+	stake, _ := c.stakeAccount(addr)
+	writeJSON(w, map[string]interface{}{
+		"address":           addr,
+		"balance":           stake.Balance,
+		"frozenV2":          stake.FrozenV2,
+		"unfrozenV2":        stake.UnfrozenV2,
+		"owner_permission":  permission.OwnerPermission,
+		"active_permission": permission.ActivePermission,
+	})
+}
+
+// handleNowBlock 最新区块（节点健康检查、支付确认），solid 为 true 时返回最新固化区块
+func (c *Chain) handleNowBlock(solid bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if err := c.takeFailure(OpBlock); err != nil {
+			writeNodeError(w, err)
+			return
+		}
+		number := c.block
+		if solid {
+			number = c.solidBlock()
+		}
+		writeJSON(w, c.blockAt(number))
+	}
+}
+
+// handleBlockByNum 按高度查询区块，区块尚未产生时返回空对象
+func (c *Chain) handleBlockByNum(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Num int64 `json:"num"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.takeFailure(OpBlock); err != nil {
+		writeNodeError(w, err)
+		return
+	}
+	block := c.blockAt(req.Num)
+	if !block.Found() {
+		writeJSON(w, map[string]interface{}{})
+		return
+	}
+	writeJSON(w, block)
+}
+
+// handleAccount 查询账户信息（TronGrid /v1/accounts/{address}）
+func (c *Chain) handleAccount(w http.ResponseWriter, r *http.Request) {
+	addr, err := address.Normalize(strings.TrimPrefix(r.URL.Path, "/v1/accounts/"))
+	if err != nil {
+		writeNodeError(w, &tron.APIError{Kind: tron.ErrBadRequest, Message: err.Error()})
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.takeFailure(OpAccount); err != nil {
+		writeNodeError(w, err)
+		return
+	}
+	writeJSON(w, c.accountInfo(addr))
+}
+
+// writeNodeError 按节点格式返回错误 {"Error": "..."}，限流、节点故障类错误以 HTTP 状态码返回
+func writeNodeError(w http.ResponseWriter, err error) {
+	if writeHTTPError(w, err) {
+		return
+	}
+	message := err.Error()
+	var apiErr *tron.APIError
+	if errors.As(err, &apiErr) {
+		message = apiErr.Message
+	}
+	writeJSON(w, map[string]string{"Error": message})
+}
+
+// writeBroadcastError 按节点格式返回广播错误（message 为十六进制编码）
+func writeBroadcastError(w http.ResponseWriter, err error) {
+	if writeHTTPError(w, err) {
+		return
+	}
+	code, message := "OTHER_ERROR", err.Error()
+	var apiErr *tron.APIError
+	if errors.As(err, &apiErr) {
+		message = apiErr.Message
+		if apiErr.Code != "" {
+			code = apiErr.Code
+		}
+	}
+	writeJSON(w, tron.BroadcastResponse{Code: code, Message: hex.EncodeToString([]byte(message))})
+}
+
+// writeHTTPError 限流、节点故障类错误以 HTTP 状态码返回，返回 false 表示不是这类错误
+func writeHTTPError(w http.ResponseWriter, err error) bool {
+	switch kind := errorKind(err); {
+	case errors.Is(kind, tron.ErrRateLimited):
+		w.WriteHeader(http.StatusTooManyRequests)
+	case errors.Is(kind, tron.ErrTransient):
+		w.WriteHeader(http.StatusServiceUnavailable)
+	case errors.Is(kind, tron.ErrBadRequest):
+		w.WriteHeader(http.StatusBadRequest)
+	default:
+		if kind == nil {
+			// 非节点错误统一视为节点内部错误
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return true
+		}
+		return false
+	}
+	w.Write([]byte(err.Error()))
+	return true
+}
+
+// writeJSON 写入 JSON 响应
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
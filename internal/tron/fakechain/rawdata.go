@@ -0,0 +1,112 @@
+package fakechain
+
+import (
+	"encoding/binary"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"lending-trx/internal/address"
+	"lending-trx/internal/tron"
+)
+
+// contractName 交易类型对应的合约名称，用于 Any 的 type_url
+func (k txKind) contractName() string {
+	switch k {
+	case txUndelegate:
+		return "UnDelegateResourceContract"
+	case txFreeze:
+		return "FreezeBalanceV2Contract"
+	case txUnfreeze:
+		return "UnfreezeBalanceV2Contract"
+	case txWithdraw:
+		return "WithdrawExpireUnfreezeContract"
+	default:
+		return "DelegateResourceContract"
+	}
+}
+
+// encodeRawData 按节点格式将交易编码为 raw_data 的 protobuf 字节，txID 为其 sha256
+// refBlock 为引用区块高度，timestamp 为构建时间（毫秒），nonce 保证相同内容的交易 ID 不同
+func encodeRawData(tx *transaction, refBlock, timestamp, nonce int64) []byte {
+	var refBytes [8]byte
+	binary.BigEndian.PutUint64(refBytes[:], uint64(refBlock))
+
+	// google.protobuf.Any: type_url=1, value=2
+	var parameter []byte
+	parameter = protowire.AppendTag(parameter, 1, protowire.BytesType)
+	parameter = protowire.AppendString(parameter, "type.googleapis.com/protocol."+tx.kind.contractName())
+	parameter = protowire.AppendTag(parameter, 2, protowire.BytesType)
+	parameter = protowire.AppendBytes(parameter, encodeParameter(tx))
+
+	var contract []byte
+	contract = protowire.AppendTag(contract, 1, protowire.VarintType)
+	contract = protowire.AppendVarint(contract, uint64(tx.kind.contractType()))
+	contract = protowire.AppendTag(contract, 2, protowire.BytesType)
+	contract = protowire.AppendBytes(contract, parameter)
+	if tx.permissionID != tron.OwnerPermissionID {
+		contract = protowire.AppendTag(contract, 5, protowire.VarintType)
+		contract = protowire.AppendVarint(contract, uint64(tx.permissionID))
+	}
+
+	var raw []byte
+	raw = protowire.AppendTag(raw, 1, protowire.BytesType)
+	raw = protowire.AppendBytes(raw, refBytes[6:])
+	raw = protowire.AppendTag(raw, 8, protowire.VarintType)
+	raw = protowire.AppendVarint(raw, uint64(timestamp+60*1000))
+	raw = protowire.AppendTag(raw, 10, protowire.BytesType) // data，仅用于区分交易
+	raw = protowire.AppendBytes(raw, protowire.AppendVarint(nil, uint64(nonce)))
+	raw = protowire.AppendTag(raw, 11, protowire.BytesType)
+	raw = protowire.AppendBytes(raw, contract)
+	raw = protowire.AppendTag(raw, 14, protowire.VarintType)
+	raw = protowire.AppendVarint(raw, uint64(timestamp))
+	return raw
+}
+
+// encodeParameter 合约参数：owner_address 均为字段 1
+//   - Delegate/UnDelegateResourceContract: resource=2, balance=3, receiver_address=4, lock=5, lock_period=6
+//   - FreezeBalanceV2 / UnfreezeBalanceV2Contract: 金额=2, resource=3
+func encodeParameter(tx *transaction) []byte {
+	var b []byte
+	b = appendAddress(b, 1, tx.owner)
+	switch tx.kind {
+	case txDelegate, txUndelegate:
+		b = appendVarintField(b, 2, resourceCode(tx.resource))
+		b = appendVarintField(b, 3, uint64(tx.balance))
+		b = appendAddress(b, 4, tx.receiver)
+		if tx.lock > 0 {
+			b = appendVarintField(b, 5, 1)
+			b = appendVarintField(b, 6, uint64(tx.lock))
+		}
+	case txFreeze, txUnfreeze:
+		b = appendVarintField(b, 2, uint64(tx.balance))
+		b = appendVarintField(b, 3, resourceCode(tx.resource))
+	}
+	return b
+}
+
+// resourceCode 资源类型的枚举值，BANDWIDTH 为0
+func resourceCode(resource string) uint64 {
+	if resource == tron.ResourceEnergy {
+		return 1
+	}
+	return 0
+}
+
+// appendVarintField 追加 varint 字段，默认值0不编码（与 protobuf 一致）
+func appendVarintField(b []byte, num protowire.Number, value uint64) []byte {
+	if value == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, value)
+}
+
+// appendAddress 追加 21 字节的地址字段
+func appendAddress(b []byte, num protowire.Number, addr string) []byte {
+	parsed, err := address.Parse(addr)
+	if err != nil {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, parsed.Bytes())
+}
@@ -0,0 +1,180 @@
+package fakechain
+
+import (
+	"context"
+	"fmt"
+
+	"lending-trx/internal/address"
+	"lending-trx/internal/tron"
+	"lending-trx/internal/units"
+)
+
+// defaultUnfreezeDelay 解质押到可提取需要的区块数（14天），与主网参数 getUnfreezeDelayDays 一致
+const defaultUnfreezeDelay = 14 * 28800
+
+// unfreezing 进行中的解质押
+type unfreezing struct {
+	amount      int64
+	expireBlock int64 // 到期可提取的区块高度
+}
+
+// SetUnfreezeDelay 设置解质押到可提取需要的区块数
+func (c *Chain) SetUnfreezeDelay(blocks int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.unfreezeDelay = blocks
+}
+
+// validateStaking 按 java-tron 的校验规则检查质押/解质押/提取交易，调用方需持有锁
+func (c *Chain) validateStaking(tx *transaction, account *Account) error {
+	switch tx.kind {
+	case txFreeze:
+		if tx.balance < units.SunPerTRX {
+			return validationError(tron.ErrContractValidate, "frozenBalance must be greater than or equal to 1 TRX")
+		}
+		if tx.balance > account.Balance {
+			return validationError(tron.ErrInsufficientBalance, "frozenBalance must be less than or equal to accountBalance")
+		}
+	case txUnfreeze:
+		if tx.balance <= 0 || tx.balance > account.FrozenEnergy {
+			return validationError(tron.ErrInsufficientFrozenBalance, "Invalid unfreeze_balance, [%d] is invalid", tx.balance)
+		}
+		if c.pendingUnfreezing(tx.owner) >= tron.MaxUnfreezingEntries {
+			return validationError(tron.ErrContractValidate, "Invalid unfreezeBalanceV2 operate, unfreezing times is over limit")
+		}
+	case txWithdraw:
+		if c.withdrawable(tx.owner) == 0 {
+			return validationError(tron.ErrContractValidate, "no unFreeze balance to withdraw ")
+		}
+	}
+	return nil
+}
+
+// applyStaking 应用质押/解质押/提取交易的状态变化，调用方需持有锁
+func (c *Chain) applyStaking(tx *transaction, account *Account) {
+	switch tx.kind {
+	case txFreeze:
+		account.Balance -= tx.balance
+		account.FrozenEnergy += tx.balance
+		c.totalEnergyWeight += tx.balance / units.SunPerTRX
+	case txUnfreeze:
+		// 解质押时自动提取已到期的部分（与链上一致）
+		account.Balance += c.withdraw(tx.owner)
+		account.FrozenEnergy -= tx.balance
+		c.totalEnergyWeight -= tx.balance / units.SunPerTRX
+		c.unfreezing[tx.owner] = append(c.unfreezing[tx.owner], unfreezing{amount: tx.balance, expireBlock: c.block + c.unfreezeDelay})
+	case txWithdraw:
+		account.Balance += c.withdraw(tx.owner)
+	}
+}
+
+// pendingUnfreezing 进行中（未到期）的解质押条目数，调用方需持有锁
+func (c *Chain) pendingUnfreezing(owner string) int {
+	count := 0
+	for _, u := range c.unfreezing[owner] {
+		if u.expireBlock > c.block {
+			count++
+		}
+	}
+	return count
+}
+
+// withdrawable 已到期可提取的解质押金额，调用方需持有锁
+func (c *Chain) withdrawable(owner string) int64 {
+	var total int64
+	for _, u := range c.unfreezing[owner] {
+		if u.expireBlock <= c.block {
+			total += u.amount
+		}
+	}
+	return total
+}
+
+// withdraw 移除已到期的解质押条目，返回提取的金额，调用方需持有锁
+func (c *Chain) withdraw(owner string) int64 {
+	var total int64
+	remaining := c.unfreezing[owner][:0]
+	for _, u := range c.unfreezing[owner] {
+		if u.expireBlock <= c.block {
+			total += u.amount
+		} else {
+			remaining = append(remaining, u)
+		}
+	}
+	c.unfreezing[owner] = remaining
+	return total
+}
+
+// stakeAccount 生成账户余额和质押状态，账户不存在时返回 false，调用方需持有锁
+func (c *Chain) stakeAccount(addr string) (*tron.StakeAccount, bool) {
+	account, ok := c.accounts[addr]
+	if !ok {
+		return nil, false
+	}
+	stake := &tron.StakeAccount{
+		Address: addr,
+		Balance: account.Balance,
+		FrozenV2: []tron.FrozenV2{
+			{},
+			{Type: tron.ResourceEnergy, Amount: account.FrozenEnergy},
+			{Type: "TRON_POWER"},
+		},
+	}
+	for _, u := range c.unfreezing[addr] {
+		stake.UnfrozenV2 = append(stake.UnfrozenV2, tron.UnfrozenV2{
+			Type:               tron.ResourceEnergy,
+			UnfreezeAmount:     u.amount,
+			UnfreezeExpireTime: c.blockTime(u.expireBlock).UnixMilli(),
+		})
+	}
+	return stake, true
+}
+
+// GetStakeAccount 查询账户余额和质押状态
+func (c *Chain) GetStakeAccount(ctx context.Context, addr string) (*tron.StakeAccount, error) {
+	normalized, err := address.Normalize(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid account address %q: %w", addr, err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.takeFailure(OpAccount); err != nil {
+		return nil, err
+	}
+	account, ok := c.stakeAccount(normalized)
+	if !ok {
+		return nil, fmt.Errorf("account %s: %w", normalized, tron.ErrAccountNotActivated)
+	}
+	return account, nil
+}
+
+// FreezeBalanceV2 构建、签名并广播质押交易，模拟链只支持能量
+func (c *Chain) FreezeBalanceV2(ctx context.Context, owner string, amount int64, resource string) (string, error) {
+	return c.submitStaking(ctx, OpFreeze, txFreeze, owner, amount, resource)
+}
+
+// UnfreezeBalanceV2 构建、签名并广播解质押交易，模拟链只支持能量
+func (c *Chain) UnfreezeBalanceV2(ctx context.Context, owner string, amount int64, resource string) (string, error) {
+	return c.submitStaking(ctx, OpUnfreeze, txUnfreeze, owner, amount, resource)
+}
+
+// WithdrawExpireUnfreeze 构建、签名并广播提取到期解质押的交易
+func (c *Chain) WithdrawExpireUnfreeze(ctx context.Context, owner string) (string, error) {
+	return c.submitStaking(ctx, OpWithdraw, txWithdraw, owner, 0, tron.ResourceEnergy)
+}
+
+// submitStaking 进程内的质押类交易构建、签名、广播流程
+func (c *Chain) submitStaking(ctx context.Context, op Operation, kind txKind, owner string, amount int64, resource string) (string, error) {
+	if resource != tron.ResourceEnergy {
+		return "", validationError(tron.ErrContractValidate, "fakechain only supports ENERGY resource")
+	}
+	ownerAddr, err := address.Normalize(owner)
+	if err != nil {
+		return "", fmt.Errorf("invalid owner address %q: %w", owner, err)
+	}
+	txID, err := c.signAndBroadcast(ctx, op, &transaction{kind: kind, owner: ownerAddr, resource: resource, balance: amount})
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %w", op, err)
+	}
+	return txID, nil
+}
@@ -0,0 +1,226 @@
+package tron
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/crypto/sha3"
+
+	"lending-trx/internal/address"
+)
+
+// keystore 使用 Web3 Secret Storage v3 格式（与 TronLink / tronweb 导出的 keystore 相同）
+const (
+	keystoreVersion = 3
+	keystoreCipher  = "aes-128-ctr"
+	keystoreKeyLen  = 32
+
+	// KeystoreScryptN 生成 keystore 时的 scrypt 参数 N（约 256MB 内存、1 秒）
+	KeystoreScryptN = 1 << 18
+	// KeystoreLightScryptN 较快的 scrypt 参数 N，用于测试或资源受限的环境
+	KeystoreLightScryptN = 1 << 12
+)
+
+// ErrKeystorePassword keystore 密码错误（MAC 校验失败）
+var ErrKeystorePassword = errors.New("keystore password incorrect")
+
+// keystoreFile keystore 文件内容
+type keystoreFile struct {
+	Version int            `json:"version"`
+	ID      string         `json:"id,omitempty"`
+	Address string         `json:"address,omitempty"` // 地址的十六进制形式（不含 41 前缀），仅用于展示
+	Crypto  keystoreCrypto `json:"crypto"`
+}
+
+// keystoreCrypto 加密参数
+type keystoreCrypto struct {
+	Cipher       string `json:"cipher"`
+	CipherText   string `json:"ciphertext"`
+	CipherParams struct {
+		IV string `json:"iv"`
+	} `json:"cipherparams"`
+	KDF       string                 `json:"kdf"`
+	KDFParams map[string]interface{} `json:"kdfparams"`
+	MAC       string                 `json:"mac"`
+}
+
+// LoadKeystore 读取并解密 keystore 文件，返回使用其中私钥的签名者
+func LoadKeystore(path, passphrase string) (*LocalSigner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore: %w", err)
+	}
+	key, err := DecryptKeystore(data, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("keystore %s: %w", path, err)
+	}
+	return NewLocalSigner(key), nil
+}
+
+// DecryptKeystore 解密 keystore，支持 scrypt 和 pbkdf2 两种密钥派生
+func DecryptKeystore(data []byte, passphrase string) (*secp256k1.PrivateKey, error) {
+	var ks keystoreFile
+	if err := json.Unmarshal(data, &ks); err != nil {
+		return nil, fmt.Errorf("invalid keystore json: %w", err)
+	}
+	if ks.Version != keystoreVersion {
+		return nil, fmt.Errorf("unsupported keystore version %d", ks.Version)
+	}
+	if ks.Crypto.Cipher != keystoreCipher {
+		return nil, fmt.Errorf("unsupported keystore cipher %q", ks.Crypto.Cipher)
+	}
+
+	derived, err := deriveKeystoreKey(ks.Crypto, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	cipherText, err := hex.DecodeString(ks.Crypto.CipherText)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keystore ciphertext: %w", err)
+	}
+	mac, err := hex.DecodeString(ks.Crypto.MAC)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keystore mac: %w", err)
+	}
+	if !bytes.Equal(keystoreMAC(derived, cipherText), mac) {
+		return nil, ErrKeystorePassword
+	}
+	iv, err := hex.DecodeString(ks.Crypto.CipherParams.IV)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keystore iv: %w", err)
+	}
+
+	plain, err := aesCTR(derived[:16], iv, cipherText)
+	if err != nil {
+		return nil, err
+	}
+	if len(plain) != 32 {
+		return nil, fmt.Errorf("invalid keystore private key length: %d", len(plain))
+	}
+	return secp256k1.PrivKeyFromBytes(plain), nil
+}
+
+// EncryptKeystore 使用 scrypt（参数 N 为 scryptN）加密私钥，返回 keystore 文件内容
+func EncryptKeystore(key *secp256k1.PrivateKey, passphrase string, scryptN int) ([]byte, error) {
+	salt := make([]byte, 32)
+	iv := make([]byte, aes.BlockSize)
+	id := make([]byte, 16)
+	for _, buf := range [][]byte{salt, iv, id} {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("failed to generate random bytes: %w", err)
+		}
+	}
+
+	const scryptR, scryptP = 8, 1
+	derived, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, keystoreKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	cipherText, err := aesCTR(derived[:16], iv, key.Serialize())
+	if err != nil {
+		return nil, err
+	}
+
+	ks := keystoreFile{
+		Version: keystoreVersion,
+		ID:      fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:]),
+	}
+	if addr, err := address.Parse(keyAddress(key)); err == nil {
+		ks.Address = hex.EncodeToString(addr.Bytes()[1:])
+	}
+	ks.Crypto = keystoreCrypto{
+		Cipher:     keystoreCipher,
+		CipherText: hex.EncodeToString(cipherText),
+		KDF:        "scrypt",
+		KDFParams: map[string]interface{}{
+			"n":     scryptN,
+			"r":     scryptR,
+			"p":     scryptP,
+			"dklen": keystoreKeyLen,
+			"salt":  hex.EncodeToString(salt),
+		},
+		MAC: hex.EncodeToString(keystoreMAC(derived, cipherText)),
+	}
+	ks.Crypto.CipherParams.IV = hex.EncodeToString(iv)
+	return json.MarshalIndent(ks, "", "  ")
+}
+
+// deriveKeystoreKey 按 kdfparams 从密码派生密钥
+func deriveKeystoreKey(params keystoreCrypto, passphrase string) ([]byte, error) {
+	salt, err := hex.DecodeString(kdfString(params.KDFParams, "salt"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid keystore salt: %w", err)
+	}
+	dkLen := kdfInt(params.KDFParams, "dklen")
+	if dkLen < keystoreKeyLen {
+		return nil, fmt.Errorf("invalid keystore dklen %d", dkLen)
+	}
+
+	switch params.KDF {
+	case "scrypt":
+		key, err := scrypt.Key([]byte(passphrase), salt,
+			kdfInt(params.KDFParams, "n"), kdfInt(params.KDFParams, "r"), kdfInt(params.KDFParams, "p"), dkLen)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive key: %w", err)
+		}
+		return key, nil
+	case "pbkdf2":
+		if prf := kdfString(params.KDFParams, "prf"); prf != "hmac-sha256" {
+			return nil, fmt.Errorf("unsupported keystore prf %q", prf)
+		}
+		return pbkdf2.Key([]byte(passphrase), salt, kdfInt(params.KDFParams, "c"), dkLen, sha256.New), nil
+	default:
+		return nil, fmt.Errorf("unsupported keystore kdf %q", params.KDF)
+	}
+}
+
+// keystoreMAC keccak256(derivedKey[16:32] || ciphertext)
+func keystoreMAC(derived, cipherText []byte) []byte {
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(derived[16:32])
+	hash.Write(cipherText)
+	return hash.Sum(nil)
+}
+
+// aesCTR AES-128-CTR 加解密
+func aesCTR(key, iv, input []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	if len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("invalid keystore iv length %d", len(iv))
+	}
+	output := make([]byte, len(input))
+	cipher.NewCTR(block, iv).XORKeyStream(output, input)
+	return output, nil
+}
+
+// kdfInt 读取 kdfparams 中的整数参数（JSON 数字解码为 float64）
+func kdfInt(params map[string]interface{}, name string) int {
+	switch value := params[name].(type) {
+	case float64:
+		return int(value)
+	case int:
+		return value
+	default:
+		return 0
+	}
+}
+
+// kdfString 读取 kdfparams 中的字符串参数
+func kdfString(params map[string]interface{}, name string) string {
+	value, _ := params[name].(string)
+	return value
+}
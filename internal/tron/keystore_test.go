@@ -0,0 +1,60 @@
+package tron
+
+import (
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeystoreRoundTrip(t *testing.T) {
+	key, err := ParsePrivateKey(testPrivateKey)
+	if err != nil {
+		t.Fatalf("解析私钥失败: %v", err)
+	}
+	data, err := EncryptKeystore(key, "passphrase", KeystoreLightScryptN)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "keystore.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := LoadKeystore(path, "passphrase")
+	if err != nil {
+		t.Fatalf("解密失败: %v", err)
+	}
+	if signer.Address() != NewLocalSigner(key).Address() {
+		t.Errorf("期望地址为%s，实际为%s", NewLocalSigner(key).Address(), signer.Address())
+	}
+
+	if _, err := LoadKeystore(path, "wrong"); !errors.Is(err, ErrKeystorePassword) {
+		t.Errorf("期望密码错误，实际为%v", err)
+	}
+}
+
+// Web3 Secret Storage 规范中的 pbkdf2 测试向量
+func TestDecryptKeystorePBKDF2(t *testing.T) {
+	data := []byte(`{
+		"crypto": {
+			"cipher": "aes-128-ctr",
+			"cipherparams": {"iv": "6087dab2f9fdbbfaddc31a909735c1e6"},
+			"ciphertext": "5318b4d5bcd28de64ee5559e671353e16f075ecae9f99c7a79a38af5f869aa46",
+			"kdf": "pbkdf2",
+			"kdfparams": {"c": 262144, "dklen": 32, "prf": "hmac-sha256", "salt": "ae3cd4e7013836a3df6bd7241b12db061dbe2c6785853cce422d148a624ce0bd"},
+			"mac": "517ead924a9d0dc3124507e3393d175ce3ff7c1e96529c6c555ce9e51205e9b2"
+		},
+		"id": "3198bc9c-6672-5ab3-d995-4942343ae5b6",
+		"version": 3
+	}`)
+	key, err := DecryptKeystore(data, "testpassword")
+	if err != nil {
+		t.Fatalf("解密失败: %v", err)
+	}
+	expected := "7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d"
+	if got := hex.EncodeToString(key.Serialize()); got != expected {
+		t.Errorf("期望私钥为%s，实际为%s", expected, got)
+	}
+}
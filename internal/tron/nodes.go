@@ -0,0 +1,352 @@
+package tron
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// maxConsecutiveFailures 连续失败达到该次数后节点进入冷却
+	maxConsecutiveFailures = 3
+	// baseCooldown 首次冷却时间，之后按连续失败次数翻倍
+	baseCooldown = 30 * time.Second
+	// maxCooldown 最长冷却时间
+	maxCooldown = 5 * time.Minute
+	// maxBlockLag 节点区块高度落后最高节点超过该值时视为不健康
+	maxBlockLag = 20
+	// latencyWeight 平均延迟的指数加权系数
+	latencyWeight = 0.2
+)
+
+// NodeConfig 全节点配置
+type NodeConfig struct {
+	Name   string // 节点名称，用于监控展示
+	URL    string // 节点 HTTP API 地址
+	APIKey string // TRON-PRO-API-KEY，自建节点可为空
+}
+
+// NodeStatus 节点状态快照，用于监控
+type NodeStatus struct {
+	Name                string    `json:"name"`
+	URL                 string    `json:"url"`
+	Healthy             bool      `json:"healthy"`
+	Requests            int64     `json:"requests"`
+	Failures            int64     `json:"failures"`
+	ErrorRate           float64   `json:"error_rate"`
+	AvgLatencyMs        int64     `json:"avg_latency_ms"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	BlockNumber         int64     `json:"block_number"`
+	LastError           string    `json:"last_error,omitempty"`
+	LastErrorAt         time.Time `json:"last_error_at,omitempty"`
+	LastSuccessAt       time.Time `json:"last_success_at,omitempty"`
+	UnhealthyUntil      time.Time `json:"unhealthy_until,omitempty"`
+}
+
+// node 节点运行时状态
+type node struct {
+	config NodeConfig
+
+	mu                  sync.Mutex
+	requests            int64
+	failures            int64
+	consecutiveFailures int
+	avgLatency          time.Duration
+	blockNumber         int64
+	lastError           string
+	lastErrorAt         time.Time
+	lastSuccessAt       time.Time
+	unhealthyUntil      time.Time
+}
+
+// nodeError 节点层面的失败（网络错误、5xx、限流），需要切换节点
+type nodeError struct {
+	err error
+}
+
+func (e *nodeError) Error() string { return e.err.Error() }
+func (e *nodeError) Unwrap() error { return e.err }
+
+// healthy 节点当前是否可用
+func (n *node) healthy(now time.Time) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return now.After(n.unhealthyUntil)
+}
+
+// score 节点评分，越小越优先：平均延迟按错误率放大
+func (n *node) score() float64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	errorRate := 0.0
+	if n.requests > 0 {
+		errorRate = float64(n.failures) / float64(n.requests)
+	}
+	return float64(n.avgLatency) * (1 + 10*errorRate)
+}
+
+// recordSuccess 记录一次成功请求
+func (n *node) recordSuccess(latency time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.requests++
+	n.consecutiveFailures = 0
+	n.lastSuccessAt = time.Now()
+	n.unhealthyUntil = time.Time{}
+	if n.avgLatency == 0 {
+		n.avgLatency = latency
+	} else {
+		n.avgLatency = time.Duration(latencyWeight*float64(latency) + (1-latencyWeight)*float64(n.avgLatency))
+	}
+}
+
+// recordFailure 记录一次失败请求，连续失败过多时进入冷却
+func (n *node) recordFailure(err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.requests++
+	n.failures++
+	n.consecutiveFailures++
+	n.lastError = err.Error()
+	n.lastErrorAt = time.Now()
+	if n.consecutiveFailures >= maxConsecutiveFailures {
+		cooldown := baseCooldown << uint(n.consecutiveFailures-maxConsecutiveFailures)
+		if cooldown > maxCooldown || cooldown <= 0 {
+			cooldown = maxCooldown
+		}
+		n.unhealthyUntil = time.Now().Add(cooldown)
+	}
+}
+
+// markLagging 节点区块高度落后，进入冷却
+func (n *node) markLagging(lag int64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.lastError = fmt.Sprintf("node is %d blocks behind", lag)
+	n.lastErrorAt = time.Now()
+	n.unhealthyUntil = time.Now().Add(baseCooldown)
+}
+
+// status 生成状态快照
+func (n *node) status(now time.Time) NodeStatus {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	errorRate := 0.0
+	if n.requests > 0 {
+		errorRate = float64(n.failures) / float64(n.requests)
+	}
+	return NodeStatus{
+		Name:                n.config.Name,
+		URL:                 n.config.URL,
+		Healthy:             now.After(n.unhealthyUntil),
+		Requests:            n.requests,
+		Failures:            n.failures,
+		ErrorRate:           errorRate,
+		AvgLatencyMs:        n.avgLatency.Milliseconds(),
+		ConsecutiveFailures: n.consecutiveFailures,
+		BlockNumber:         n.blockNumber,
+		LastError:           n.lastError,
+		LastErrorAt:         n.lastErrorAt,
+		LastSuccessAt:       n.lastSuccessAt,
+		UnhealthyUntil:      n.unhealthyUntil,
+	}
+}
+
+// orderedNodes 按健康状态和评分排序的节点列表：健康节点在前，冷却中的节点作为最后手段
+func (c *TronClient) orderedNodes() []*node {
+	now := time.Now()
+	var healthy, unhealthy []*node
+	for _, n := range c.nodes {
+		if n.healthy(now) {
+			healthy = append(healthy, n)
+		} else {
+			unhealthy = append(unhealthy, n)
+		}
+	}
+	sort.SliceStable(healthy, func(i, j int) bool { return healthy[i].score() < healthy[j].score() })
+	return append(healthy, unhealthy...)
+}
+
+// doRequest 发送请求，所有节点都失败且错误可重试时按重试策略重试
+func (c *TronClient) doRequest(ctx context.Context, method, path string, payload interface{}, out interface{}) error {
+	return c.retryPolicy.Do(ctx, func() error {
+		return c.doRequestOnce(ctx, method, path, payload, out)
+	})
+}
+
+// doRequestOnce 按节点优先级发送请求，节点层面失败时自动切换到下一个节点
+func (c *TronClient) doRequestOnce(ctx context.Context, method, path string, payload interface{}, out interface{}) error {
+	var jsonData []byte
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to serialize request: %w", err)
+		}
+		jsonData = data
+	}
+
+	if len(c.nodes) == 0 {
+		return fmt.Errorf("no tron node configured")
+	}
+
+	var lastErr error
+	for _, n := range c.orderedNodes() {
+		start := time.Now()
+		err := c.doNodeRequest(ctx, n, method, path, jsonData, out)
+		if err == nil {
+			n.recordSuccess(time.Since(start))
+			return nil
+		}
+
+		var nodeErr *nodeError
+		if !errors.As(err, &nodeErr) {
+			// 请求本身的错误（参数错误等），换节点也不会成功
+			n.recordSuccess(time.Since(start))
+			return err
+		}
+
+		n.recordFailure(err)
+		lastErr = fmt.Errorf("node %s: %w", n.config.Name, err)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	return fmt.Errorf("all tron nodes failed, last error: %w", lastErr)
+}
+
+// doNodeRequest 向单个节点发送请求
+func (c *TronClient) doNodeRequest(ctx context.Context, n *node, method, path string, jsonData []byte, out interface{}) error {
+	var body io.Reader
+	if jsonData != nil {
+		body = bytes.NewReader(jsonData)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, n.config.URL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if jsonData != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if n.config.APIKey != "" {
+		httpReq.Header.Set("TRON-PRO-API-KEY", n.config.APIKey)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return &nodeError{&APIError{Kind: ErrTransient, Message: fmt.Sprintf("request failed: %v", err)}}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return &nodeError{&APIError{Kind: ErrTransient, Message: fmt.Sprintf("failed to read response: %v", err)}}
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return &nodeError{&APIError{Kind: ErrRateLimited, StatusCode: resp.StatusCode, Message: string(respBody)}}
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return &nodeError{&APIError{Kind: ErrTransient, StatusCode: resp.StatusCode, Message: string(respBody)}}
+	case resp.StatusCode != http.StatusOK:
+		return &APIError{Kind: ErrBadRequest, StatusCode: resp.StatusCode, Message: string(respBody)}
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		// 代理或网关返回了非 JSON 内容，视为节点异常
+		return &nodeError{&APIError{Kind: ErrTransient, StatusCode: resp.StatusCode, Message: fmt.Sprintf("failed to parse response: %v", err)}}
+	}
+
+	return nil
+}
+
+// NodeStatuses 返回所有节点的状态快照
+func (c *TronClient) NodeStatuses() []NodeStatus {
+	now := time.Now()
+	statuses := make([]NodeStatus, 0, len(c.nodes))
+	for _, n := range c.nodes {
+		statuses = append(statuses, n.status(now))
+	}
+	return statuses
+}
+
+// nowBlockResponse /wallet/getnowblock 响应中健康检查需要的字段
+type nowBlockResponse struct {
+	BlockID     string `json:"blockID"`
+	BlockHeader struct {
+		RawData struct {
+			Number    int64 `json:"number"`
+			Timestamp int64 `json:"timestamp"`
+		} `json:"raw_data"`
+	} `json:"block_header"`
+}
+
+// CheckNodes 对所有节点执行一次健康检查：探测最新区块，记录延迟，并标记区块高度落后的节点
+func (c *TronClient) CheckNodes(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, n := range c.nodes {
+		wg.Add(1)
+		go func(n *node) {
+			defer wg.Done()
+			var block nowBlockResponse
+			start := time.Now()
+			if err := c.doNodeRequest(ctx, n, "POST", "/wallet/getnowblock", []byte("{}"), &block); err != nil {
+				n.recordFailure(err)
+				return
+			}
+			n.recordSuccess(time.Since(start))
+			n.mu.Lock()
+			n.blockNumber = block.BlockHeader.RawData.Number
+			n.mu.Unlock()
+		}(n)
+	}
+	wg.Wait()
+
+	var highest int64
+	for _, n := range c.nodes {
+		n.mu.Lock()
+		if n.blockNumber > highest {
+			highest = n.blockNumber
+		}
+		n.mu.Unlock()
+	}
+	for _, n := range c.nodes {
+		n.mu.Lock()
+		lag := highest - n.blockNumber
+		hasBlock := n.blockNumber > 0
+		n.mu.Unlock()
+		if hasBlock && lag > maxBlockLag {
+			n.markLagging(lag)
+		}
+	}
+}
+
+// StartHealthCheck 启动后台健康检查，ctx 取消时退出
+func (c *TronClient) StartHealthCheck(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		c.CheckNodes(ctx)
+		for {
+			select {
+			case <-ticker.C:
+				c.CheckNodes(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
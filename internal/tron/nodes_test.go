@@ -0,0 +1,124 @@
+package tron
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDoRequestFailover(t *testing.T) {
+	var badHits, goodHits int32
+
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&badHits, 1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer bad.Close()
+
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&goodHits, 1)
+		if r.Header.Get("TRON-PRO-API-KEY") != "good-key" {
+			t.Errorf("期望使用节点自己的API Key，实际为%s", r.Header.Get("TRON-PRO-API-KEY"))
+		}
+		w.Write([]byte(`{"id":"abc","blockNumber":1}`))
+	}))
+	defer good.Close()
+
+	client := NewTronClientWithNodes([]NodeConfig{
+		{Name: "bad", URL: bad.URL, APIKey: "bad-key"},
+		{Name: "good", URL: good.URL, APIKey: "good-key"},
+	})
+
+	for i := 0; i < maxConsecutiveFailures+2; i++ {
+		info, err := client.GetTransactionInfo(context.Background(), "abc")
+		if err != nil {
+			t.Fatalf("第%d次请求失败: %v", i+1, err)
+		}
+		if !info.Found() {
+			t.Fatalf("第%d次请求期望查到交易", i+1)
+		}
+	}
+
+	// 坏节点连续失败后进入冷却，不再被优先选择
+	if atomic.LoadInt32(&badHits) != maxConsecutiveFailures {
+		t.Errorf("期望坏节点被请求%d次，实际为%d", maxConsecutiveFailures, badHits)
+	}
+
+	statuses := client.NodeStatuses()
+	if statuses[0].Healthy {
+		t.Error("期望坏节点状态为不健康")
+	}
+	if !statuses[1].Healthy {
+		t.Error("期望好节点状态为健康")
+	}
+	if statuses[0].ErrorRate != 1 {
+		t.Errorf("期望坏节点错误率为1，实际为%f", statuses[0].ErrorRate)
+	}
+}
+
+func TestDoRequestClientErrorNoFailover(t *testing.T) {
+	var secondHits int32
+
+	first := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer first.Close()
+
+	second := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&secondHits, 1)
+		w.Write([]byte(`{}`))
+	}))
+	defer second.Close()
+
+	client := NewTronClientWithNodes([]NodeConfig{{URL: first.URL}, {URL: second.URL}})
+	if _, err := client.GetTransactionInfo(context.Background(), "abc"); err == nil {
+		t.Error("期望4xx错误直接返回")
+	}
+	if secondHits != 0 {
+		t.Error("期望4xx错误不切换节点")
+	}
+}
+
+func TestCheckNodesMarksLaggingNode(t *testing.T) {
+	newBlockServer := func(number string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"blockID":"x","block_header":{"raw_data":{"number":` + number + `}}}`))
+		}))
+	}
+	ahead := newBlockServer("1000")
+	defer ahead.Close()
+	behind := newBlockServer("900")
+	defer behind.Close()
+
+	client := NewTronClientWithNodes([]NodeConfig{{URL: ahead.URL}, {URL: behind.URL}})
+	client.CheckNodes(context.Background())
+
+	statuses := client.NodeStatuses()
+	if !statuses[0].Healthy || statuses[0].BlockNumber != 1000 {
+		t.Errorf("期望领先节点健康且高度为1000，实际为%+v", statuses[0])
+	}
+	if statuses[1].Healthy {
+		t.Error("期望落后节点被标记为不健康")
+	}
+}
+
+func TestNodeConfigsFromEnv(t *testing.T) {
+	os.Setenv("TRON_API_URLS", "https://api.trongrid.io, http://10.0.0.5:8090")
+	os.Setenv("TRON_API_KEYS", "key-1,")
+	defer os.Unsetenv("TRON_API_URLS")
+	defer os.Unsetenv("TRON_API_KEYS")
+
+	configs := NodeConfigsFromEnv()
+	if len(configs) != 2 {
+		t.Fatalf("期望2个节点，实际为%d", len(configs))
+	}
+	if configs[0].APIKey != "key-1" || configs[1].APIKey != "" {
+		t.Errorf("API Key 对应关系错误: %+v", configs)
+	}
+	if configs[1].Name != "10.0.0.5:8090" {
+		t.Errorf("期望节点名称为10.0.0.5:8090，实际为%s", configs[1].Name)
+	}
+}
@@ -0,0 +1,172 @@
+package tron
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"lending-trx/internal/address"
+)
+
+// 合约类型，对应权限 operations 位图中的位
+const (
+	ContractTypeDelegateResource   = 57 // DelegateResourceContract
+	ContractTypeUnDelegateResource = 58 // UnDelegateResourceContract
+)
+
+// OwnerPermissionID owner 权限的 ID，拥有全部操作权限；自定义 active 权限的 ID 从2开始
+const OwnerPermissionID = 0
+
+// PermissionKey 权限中的公钥地址及权重
+type PermissionKey struct {
+	Address string `json:"address"`
+	Weight  int64  `json:"weight"`
+}
+
+// Permission 账户权限（owner / active）
+type Permission struct {
+	Type           string          `json:"type,omitempty"` // Owner / Active
+	ID             int             `json:"id"`
+	PermissionName string          `json:"permission_name"`
+	Threshold      int64           `json:"threshold"`            // 交易签名权重之和需达到的阈值
+	Operations     string          `json:"operations,omitempty"` // 允许的合约类型位图（32字节十六进制），owner 权限为空
+	Keys           []PermissionKey `json:"keys"`
+}
+
+// Allows 权限是否允许指定的合约类型，operations 第 n 位对应合约类型 n
+func (p *Permission) Allows(contractType int) bool {
+	if p.ID == OwnerPermissionID {
+		return true
+	}
+	operations, err := hex.DecodeString(p.Operations)
+	if err != nil || contractType/8 >= len(operations) {
+		return false
+	}
+	return operations[contractType/8]&(1<<(contractType%8)) != 0
+}
+
+// Weight 签名地址在权限中的权重之和，不在权限中的地址不计入
+func (p *Permission) Weight(signers []string) int64 {
+	var total int64
+	for _, key := range p.Keys {
+		keyAddr, err := address.Normalize(key.Address)
+		if err != nil {
+			continue
+		}
+		for _, signer := range signers {
+			if signer == keyAddr {
+				total += key.Weight
+				break
+			}
+		}
+	}
+	return total
+}
+
+// OperationsFor 生成允许指定合约类型的 operations 位图（十六进制）
+func OperationsFor(contractTypes ...int) string {
+	operations := make([]byte, 32)
+	for _, t := range contractTypes {
+		operations[t/8] |= 1 << (t % 8)
+	}
+	return hex.EncodeToString(operations)
+}
+
+// AccountPermission /wallet/getaccount 返回的账户权限
+type AccountPermission struct {
+	Address          string       `json:"address"`
+	OwnerPermission  *Permission  `json:"owner_permission"`
+	ActivePermission []Permission `json:"active_permission"`
+}
+
+// Permission 按 ID 查找权限，不存在时返回 nil
+func (a *AccountPermission) Permission(id int) *Permission {
+	if id == OwnerPermissionID {
+		return a.OwnerPermission
+	}
+	for i := range a.ActivePermission {
+		if a.ActivePermission[i].ID == id {
+			return &a.ActivePermission[i]
+		}
+	}
+	return nil
+}
+
+// GetAccountPermission 查询账户的 owner / active 权限
+func (c *TronClient) GetAccountPermission(ctx context.Context, addr string) (*AccountPermission, error) {
+	normalized, err := address.Normalize(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid account address %q: %w", addr, err)
+	}
+
+	var account AccountPermission
+	payload := map[string]interface{}{"address": normalized, "visible": true}
+	if err := c.postJSON(ctx, "/wallet/getaccount", payload, &account); err != nil {
+		return nil, fmt.Errorf("failed to get account permission: %w", err)
+	}
+	if account.Address == "" {
+		return nil, fmt.Errorf("account %s: %w", normalized, ErrAccountNotActivated)
+	}
+	return &account, nil
+}
+
+// DelegationContractTypes 委托和回收交易的合约类型
+var DelegationContractTypes = []int{ContractTypeDelegateResource, ContractTypeUnDelegateResource}
+
+// StakingContractTypes 质押管理（质押、解质押、提取）交易的合约类型
+var StakingContractTypes = []int{ContractTypeFreezeBalanceV2, ContractTypeUnfreezeBalanceV2, ContractTypeWithdrawExpireUnfreeze}
+
+// contractTypeNames 权限检查错误信息中的合约名称
+var contractTypeNames = map[int]string{
+	ContractTypeFreezeBalanceV2:        "FreezeBalanceV2Contract",
+	ContractTypeUnfreezeBalanceV2:      "UnfreezeBalanceV2Contract",
+	ContractTypeWithdrawExpireUnfreeze: "WithdrawExpireUnfreezeContract",
+	ContractTypeDelegateResource:       "DelegateResourceContract",
+	ContractTypeUnDelegateResource:     "UnDelegateResourceContract",
+}
+
+// CheckPermission 检查签名地址能否以 permissionID 对应的权限签署 contractTypes 类型的交易，未指定时为 DelegationContractTypes：
+// 权限存在、operations 包含全部合约类型、签名权重之和达到阈值
+func CheckPermission(account *AccountPermission, permissionID int, signers []string, contractTypes ...int) error {
+	permission := account.Permission(permissionID)
+	if permission == nil {
+		return fmt.Errorf("permission %d does not exist on account %s", permissionID, account.Address)
+	}
+	if len(contractTypes) == 0 {
+		contractTypes = DelegationContractTypes
+	}
+
+	var missing []string
+	for _, contractType := range contractTypes {
+		if permission.Allows(contractType) {
+			continue
+		}
+		name, ok := contractTypeNames[contractType]
+		if !ok {
+			name = fmt.Sprintf("contract type %d", contractType)
+		}
+		missing = append(missing, name)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("permission %d (%s) does not allow %s", permissionID, permission.PermissionName, strings.Join(missing, ", "))
+	}
+
+	if weight := permission.Weight(signers); weight < permission.Threshold {
+		return fmt.Errorf("permission %d (%s) threshold %d not reached, configured signers weight %d", permissionID, permission.PermissionName, permission.Threshold, weight)
+	}
+	return nil
+}
+
+// VerifySigners 查询委托账户权限，检查客户端配置的签名私钥和权限ID能否签署 contractTypes 类型的交易，未指定时为委托和回收交易
+func VerifySigners(ctx context.Context, client Client, owner string, contractTypes ...int) error {
+	signers := client.SignerAddresses()
+	if len(signers) == 0 {
+		return fmt.Errorf("no signer configured")
+	}
+	account, err := client.GetAccountPermission(ctx, owner)
+	if err != nil {
+		return err
+	}
+	return CheckPermission(account, client.PermissionID(), signers, contractTypes...)
+}
@@ -0,0 +1,105 @@
+package tron
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPermissionAllows(t *testing.T) {
+	active := &Permission{ID: 2, Operations: OperationsFor(ContractTypeDelegateResource, ContractTypeUnDelegateResource)}
+	if !active.Allows(ContractTypeDelegateResource) || !active.Allows(ContractTypeUnDelegateResource) {
+		t.Error("期望权限允许委托和回收")
+	}
+	if active.Allows(1) {
+		t.Error("期望权限不允许转账")
+	}
+
+	// operations 第57位在第7字节的第1位
+	if ops := OperationsFor(ContractTypeDelegateResource); ops[14:16] != "02" {
+		t.Errorf("期望第7字节为02，实际为%s", ops[14:16])
+	}
+
+	owner := &Permission{ID: OwnerPermissionID}
+	if !owner.Allows(ContractTypeDelegateResource) {
+		t.Error("期望 owner 权限允许全部操作")
+	}
+}
+
+func TestCheckPermission(t *testing.T) {
+	const (
+		signerA = "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+		signerB = "TNPeeaaFB7K9cmo4uQpcU32zGK8G1NYqeL"
+	)
+	account := &AccountPermission{
+		Address:         signerA,
+		OwnerPermission: &Permission{ID: OwnerPermissionID, PermissionName: "owner", Threshold: 1, Keys: []PermissionKey{{Address: signerA, Weight: 1}}},
+		ActivePermission: []Permission{
+			{
+				ID: 2, PermissionName: "delegate", Threshold: 2,
+				Operations: OperationsFor(ContractTypeDelegateResource, ContractTypeUnDelegateResource),
+				Keys:       []PermissionKey{{Address: signerA, Weight: 1}, {Address: signerB, Weight: 1}},
+			},
+			{
+				ID: 3, PermissionName: "transfer", Threshold: 1,
+				Operations: OperationsFor(1),
+				Keys:       []PermissionKey{{Address: signerB, Weight: 1}},
+			},
+		},
+	}
+
+	testCases := []struct {
+		permissionID int
+		signers      []string
+		expectedErr  string
+		description  string
+	}{
+		{0, []string{signerA}, "", "owner 权限"},
+		{2, []string{signerA, signerB}, "", "自定义权限权重达到阈值"},
+		{2, []string{signerA}, "threshold", "权重不足"},
+		{3, []string{signerB}, "does not allow", "权限不包含委托操作"},
+		{4, []string{signerA}, "does not exist", "权限不存在"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			err := CheckPermission(account, tc.permissionID, tc.signers)
+			if tc.expectedErr == "" {
+				if err != nil {
+					t.Errorf("期望无错误，实际为%v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Errorf("期望错误包含%q，实际为%v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestCheckStakingPermission(t *testing.T) {
+	const signer = "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+	keys := []PermissionKey{{Address: signer, Weight: 1}}
+	account := &AccountPermission{
+		Address:         signer,
+		OwnerPermission: &Permission{ID: OwnerPermissionID, PermissionName: "owner", Threshold: 1, Keys: keys},
+		ActivePermission: []Permission{
+			{ID: 2, PermissionName: "delegate", Threshold: 1, Operations: OperationsFor(DelegationContractTypes...), Keys: keys},
+			{ID: 3, PermissionName: "staking", Threshold: 1, Operations: OperationsFor(append(DelegationContractTypes, StakingContractTypes...)...), Keys: keys},
+		},
+	}
+
+	if err := CheckPermission(account, OwnerPermissionID, []string{signer}, StakingContractTypes...); err != nil {
+		t.Errorf("期望 owner 权限允许质押，实际为%v", err)
+	}
+	if err := CheckPermission(account, 3, []string{signer}, StakingContractTypes...); err != nil {
+		t.Errorf("期望权限3允许质押，实际为%v", err)
+	}
+	err := CheckPermission(account, 2, []string{signer}, StakingContractTypes...)
+	if err == nil || !strings.Contains(err.Error(), "FreezeBalanceV2Contract, UnfreezeBalanceV2Contract, WithdrawExpireUnfreezeContract") {
+		t.Errorf("期望缺少质押操作的错误，实际为%v", err)
+	}
+	// 未指定合约类型时检查委托和回收
+	if err := CheckPermission(account, 2, []string{signer}); err != nil {
+		t.Errorf("期望权限2允许委托和回收，实际为%v", err)
+	}
+}
@@ -0,0 +1,278 @@
+package tron
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"lending-trx/internal/address"
+)
+
+// protocol.Transaction.raw 中签名校验需要的字段编号
+const (
+	rawFieldRefBlockBytes = 1
+	rawFieldRefBlockHash  = 4
+	rawFieldExpiration    = 8
+	rawFieldContract      = 11
+	rawFieldTimestamp     = 14
+	rawFieldFeeLimit      = 18
+
+	contractFieldType         = 1
+	contractFieldParameter    = 2 // google.protobuf.Any
+	contractFieldPermissionID = 5
+	anyFieldTypeURL           = 1
+	anyFieldValue             = 2
+
+	// 各合约的 owner_address 都是字段 1
+	resourceFieldOwner      = 1
+	resourceFieldResource   = 2
+	resourceFieldBalance    = 3
+	resourceFieldReceiver   = 4
+	resourceFieldLock       = 5
+	resourceFieldLockPeriod = 6
+
+	// FreezeBalanceV2Contract / UnfreezeBalanceV2Contract
+	stakeFieldBalance  = 2
+	stakeFieldResource = 3
+)
+
+// RawData 从 raw_data_hex（protobuf）解码的交易内容
+// 签名的是 raw_data_hex 而不是 raw_data JSON，远程签名服务必须按 raw_data_hex 校验交易
+type RawData struct {
+	RefBlockBytes string // 十六进制
+	RefBlockHash  string // 十六进制
+	Expiration    int64  // 过期时间（毫秒）
+	Timestamp     int64  // 构建时间（毫秒）
+	FeeLimit      int64  // 手续费上限（SUN）
+	Contracts     []RawContract
+}
+
+// RawContract 交易中的合约
+type RawContract struct {
+	Type         int    // 合约类型，如 ContractTypeDelegateResource
+	TypeURL      string // 参数类型，如 type.googleapis.com/protocol.DelegateResourceContract
+	Parameter    []byte // 合约参数的 protobuf 编码
+	PermissionID int    // 签名使用的权限ID
+}
+
+// ResourceContract DelegateResourceContract / UnDelegateResourceContract 的参数
+type ResourceContract struct {
+	OwnerAddress    string // Base58Check
+	ReceiverAddress string // Base58Check
+	Resource        string // ENERGY / BANDWIDTH
+	Balance         int64  // 质押金额（SUN）
+	Lock            bool
+	LockPeriod      int64
+}
+
+// StakeContract FreezeBalanceV2Contract / UnfreezeBalanceV2Contract 的参数
+type StakeContract struct {
+	OwnerAddress string // Base58Check
+	Resource     string // ENERGY / BANDWIDTH
+	Balance      int64  // 质押或解质押金额（SUN）
+}
+
+// DecodeRawData 解码 raw_data_hex
+func DecodeRawData(rawDataHex string) (*RawData, error) {
+	raw, err := hex.DecodeString(rawDataHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid raw_data_hex: %w", err)
+	}
+
+	data := &RawData{}
+	err = walkFields(raw, func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error {
+		switch {
+		case num == rawFieldRefBlockBytes && typ == protowire.BytesType:
+			data.RefBlockBytes = hex.EncodeToString(value)
+		case num == rawFieldRefBlockHash && typ == protowire.BytesType:
+			data.RefBlockHash = hex.EncodeToString(value)
+		case num == rawFieldExpiration && typ == protowire.VarintType:
+			data.Expiration = int64(varint)
+		case num == rawFieldTimestamp && typ == protowire.VarintType:
+			data.Timestamp = int64(varint)
+		case num == rawFieldFeeLimit && typ == protowire.VarintType:
+			data.FeeLimit = int64(varint)
+		case num == rawFieldContract && typ == protowire.BytesType:
+			contract, err := decodeRawContract(value)
+			if err != nil {
+				return err
+			}
+			data.Contracts = append(data.Contracts, *contract)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid raw_data: %w", err)
+	}
+	return data, nil
+}
+
+// decodeRawContract 解码 protocol.Transaction.Contract
+func decodeRawContract(raw []byte) (*RawContract, error) {
+	contract := &RawContract{}
+	err := walkFields(raw, func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error {
+		switch {
+		case num == contractFieldType && typ == protowire.VarintType:
+			contract.Type = int(varint)
+		case num == contractFieldPermissionID && typ == protowire.VarintType:
+			contract.PermissionID = int(varint)
+		case num == contractFieldParameter && typ == protowire.BytesType:
+			return walkFields(value, func(num protowire.Number, typ protowire.Type, value []byte, _ uint64) error {
+				switch {
+				case num == anyFieldTypeURL && typ == protowire.BytesType:
+					contract.TypeURL = string(value)
+				case num == anyFieldValue && typ == protowire.BytesType:
+					contract.Parameter = value
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("contract: %w", err)
+	}
+	return contract, nil
+}
+
+// OwnerAddress 合约的 owner_address（各系统合约都是字段 1）
+func (c *RawContract) OwnerAddress() (string, error) {
+	var owner string
+	err := walkFields(c.Parameter, func(num protowire.Number, typ protowire.Type, value []byte, _ uint64) error {
+		if num == resourceFieldOwner && typ == protowire.BytesType {
+			addr, err := rawAddress(value)
+			if err != nil {
+				return err
+			}
+			owner = addr
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if owner == "" {
+		return "", fmt.Errorf("contract has no owner address")
+	}
+	return owner, nil
+}
+
+// ResourceContract 解码委托/回收合约的参数，其他合约类型返回错误
+func (c *RawContract) ResourceContract() (*ResourceContract, error) {
+	if c.Type != ContractTypeDelegateResource && c.Type != ContractTypeUnDelegateResource {
+		return nil, fmt.Errorf("contract type %d is not a resource delegation", c.Type)
+	}
+
+	// resource 为枚举，BANDWIDTH (0) 是默认值不会编码
+	contract := &ResourceContract{Resource: ResourceBandwidth}
+	err := walkFields(c.Parameter, func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error {
+		var err error
+		switch {
+		case num == resourceFieldOwner && typ == protowire.BytesType:
+			contract.OwnerAddress, err = rawAddress(value)
+		case num == resourceFieldReceiver && typ == protowire.BytesType:
+			contract.ReceiverAddress, err = rawAddress(value)
+		case num == resourceFieldResource && typ == protowire.VarintType:
+			contract.Resource, err = resourceName(varint)
+		case num == resourceFieldBalance && typ == protowire.VarintType:
+			contract.Balance = int64(varint)
+		case num == resourceFieldLock && typ == protowire.VarintType:
+			contract.Lock = varint != 0
+		case num == resourceFieldLockPeriod && typ == protowire.VarintType:
+			contract.LockPeriod = int64(varint)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("resource contract: %w", err)
+	}
+	if contract.OwnerAddress == "" || contract.ReceiverAddress == "" {
+		return nil, fmt.Errorf("resource contract missing owner or receiver address")
+	}
+	return contract, nil
+}
+
+// StakeContract 解码质押/解质押合约的参数，其他合约类型返回错误
+func (c *RawContract) StakeContract() (*StakeContract, error) {
+	if c.Type != ContractTypeFreezeBalanceV2 && c.Type != ContractTypeUnfreezeBalanceV2 {
+		return nil, fmt.Errorf("contract type %d is not a stake contract", c.Type)
+	}
+
+	contract := &StakeContract{Resource: ResourceBandwidth}
+	err := walkFields(c.Parameter, func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error {
+		var err error
+		switch {
+		case num == resourceFieldOwner && typ == protowire.BytesType:
+			contract.OwnerAddress, err = rawAddress(value)
+		case num == stakeFieldBalance && typ == protowire.VarintType:
+			contract.Balance = int64(varint)
+		case num == stakeFieldResource && typ == protowire.VarintType:
+			contract.Resource, err = resourceName(varint)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("stake contract: %w", err)
+	}
+	if contract.OwnerAddress == "" {
+		return nil, fmt.Errorf("stake contract missing owner address")
+	}
+	return contract, nil
+}
+
+// resourceName 资源枚举值对应的名称，BANDWIDTH 为0
+func resourceName(code uint64) (string, error) {
+	switch code {
+	case 0:
+		return ResourceBandwidth, nil
+	case 1:
+		return ResourceEnergy, nil
+	default:
+		return "", fmt.Errorf("unsupported resource code %d", code)
+	}
+}
+
+// walkFields 遍历 protobuf 消息的字段，varint 字段的值在 varint 中，bytes 字段的值在 value 中，
+// 其他类型（fixed32/fixed64）跳过
+func walkFields(raw []byte, visit func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error) error {
+	for len(raw) > 0 {
+		num, typ, n := protowire.ConsumeTag(raw)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		raw = raw[n:]
+
+		var value []byte
+		var varint uint64
+		switch typ {
+		case protowire.VarintType:
+			varint, n = protowire.ConsumeVarint(raw)
+		case protowire.BytesType:
+			value, n = protowire.ConsumeBytes(raw)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, raw)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		raw = raw[n:]
+
+		if typ != protowire.VarintType && typ != protowire.BytesType {
+			continue
+		}
+		if err := visit(num, typ, value, varint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rawAddress 将 protobuf 中的 21 字节地址转换为 Base58Check
+func rawAddress(raw []byte) (string, error) {
+	addr, err := address.Parse(hex.EncodeToString(raw))
+	if err != nil {
+		return "", fmt.Errorf("invalid address %x: %w", raw, err)
+	}
+	return addr.Base58(), nil
+}
@@ -0,0 +1,64 @@
+package tron
+
+import "testing"
+
+// 带锁定期、使用权限2的能量委托：TSoXbDPwgM1nhfbxVj53u15My8smJHWpFw → TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t，5 TRX
+const testDelegateRawHex = "0a02451a40e0ab99b983335201075a79083912730a35747970652e676f6f676c65617069732e636f6d2f70726f746f636f6c2e44656c65676174655265736f75726365436f6e7472616374123a0a1541b8a57ef5343f88712a4eee91e34290584c2d5998100118c096b102221541a614f803b6fd780986a42c78ec9c7f77e6ded13c280130b00928027080d795b98333"
+
+// 质押 5 TRX 为能量
+const testFreezeRawHex = "0a02451a40e0ab99b983335201085a5a083612560a34747970652e676f6f676c65617069732e636f6d2f70726f746f636f6c2e467265657a6542616c616e63655632436f6e7472616374121e0a1541b8a57ef5343f88712a4eee91e34290584c2d599810c096b10218017080d795b98333"
+
+func TestDecodeRawData(t *testing.T) {
+	data, err := DecodeRawData(testDelegateRawHex)
+	if err != nil {
+		t.Fatalf("解码失败: %v", err)
+	}
+	if data.RefBlockBytes != "451a" || data.Timestamp != 1753271856000 || data.Expiration != 1753271916000 {
+		t.Errorf("期望引用区块451a及构建时间，实际为%+v", data)
+	}
+	if len(data.Contracts) != 1 {
+		t.Fatalf("期望1个合约，实际为%d", len(data.Contracts))
+	}
+
+	contract := data.Contracts[0]
+	if contract.Type != ContractTypeDelegateResource || contract.PermissionID != 2 {
+		t.Errorf("期望委托合约、权限ID为2，实际为%d、%d", contract.Type, contract.PermissionID)
+	}
+	if contract.TypeURL != "type.googleapis.com/protocol.DelegateResourceContract" {
+		t.Errorf("期望合约类型为 DelegateResourceContract，实际为%s", contract.TypeURL)
+	}
+
+	resource, err := contract.ResourceContract()
+	if err != nil {
+		t.Fatalf("解码委托合约失败: %v", err)
+	}
+	expected := ResourceContract{
+		OwnerAddress:    "TSoXbDPwgM1nhfbxVj53u15My8smJHWpFw",
+		ReceiverAddress: "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t",
+		Resource:        ResourceEnergy,
+		Balance:         5000000,
+		Lock:            true,
+		LockPeriod:      1200,
+	}
+	if *resource != expected {
+		t.Errorf("期望为%+v，实际为%+v", expected, *resource)
+	}
+
+	freeze, err := DecodeRawData(testFreezeRawHex)
+	if err != nil {
+		t.Fatalf("解码失败: %v", err)
+	}
+	if freeze.Contracts[0].Type != ContractTypeFreezeBalanceV2 {
+		t.Errorf("期望质押合约，实际为%d", freeze.Contracts[0].Type)
+	}
+	if owner, err := freeze.Contracts[0].OwnerAddress(); err != nil || owner != expected.OwnerAddress {
+		t.Errorf("期望 owner 为%s，实际为%s, %v", expected.OwnerAddress, owner, err)
+	}
+	if _, err := freeze.Contracts[0].ResourceContract(); err == nil {
+		t.Error("期望质押合约不能按委托合约解码")
+	}
+
+	if _, err := DecodeRawData(testDelegateRawHex[:40]); err == nil {
+		t.Error("期望截断的数据返回错误")
+	}
+}
@@ -0,0 +1,181 @@
+package tron
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"lending-trx/internal/address"
+)
+
+// 远程签名服务接口（lending-trx signer）
+const (
+	SignerAddressPath = "/v1/address" // GET，返回签名地址
+	SignerSignPath    = "/v1/sign"    // POST，校验策略后返回签名
+)
+
+// SignerAddressResponse 签名地址响应
+type SignerAddressResponse struct {
+	Address string `json:"address"`
+}
+
+// SignRequest 签名请求，签名服务从 raw_data_hex 解码交易内容并按策略校验
+type SignRequest struct {
+	Transaction Transaction `json:"transaction"`
+}
+
+// SignResponse 签名响应，拒绝签名时只返回 Error
+type SignResponse struct {
+	Signature string `json:"signature,omitempty"` // r || s || v 的十六进制
+	Error     string `json:"error,omitempty"`
+}
+
+// RemoteSigner 通过 HTTP 或 Unix socket 调用远程签名服务，私钥不在本进程中
+type RemoteSigner struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+	address    string
+}
+
+// NewRemoteSigner 连接远程签名服务并获取签名地址
+// endpoint 为 http(s)://host:port 或 unix:///path/to/signer.sock，token 非空时以 Bearer 方式认证
+func NewRemoteSigner(ctx context.Context, endpoint, token string) (*RemoteSigner, error) {
+	s := &RemoteSigner{
+		baseURL:    strings.TrimRight(endpoint, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+	if socket, ok := strings.CutPrefix(endpoint, "unix://"); ok {
+		if socket == "" {
+			return nil, fmt.Errorf("invalid signer endpoint %q", endpoint)
+		}
+		// 请求的 host 无意义，连接固定到 socket 文件
+		s.baseURL = "http://signer"
+		s.httpClient.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		}
+	} else if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		return nil, fmt.Errorf("invalid signer endpoint %q, expected http(s):// or unix://", endpoint)
+	}
+
+	var resp SignerAddressResponse
+	if err := s.do(ctx, http.MethodGet, SignerAddressPath, nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get signer address: %w", err)
+	}
+	if resp.Address == "" {
+		return nil, fmt.Errorf("signer returned empty address")
+	}
+	// 规范为 Base58Check，与签名恢复出的地址比较
+	signerAddress, err := address.Normalize(resp.Address)
+	if err != nil {
+		return nil, fmt.Errorf("signer returned invalid address %q: %w", resp.Address, err)
+	}
+	s.address = signerAddress
+	return s, nil
+}
+
+// Address 远程签名服务的签名地址
+func (s *RemoteSigner) Address() string {
+	return s.address
+}
+
+// Sign 请求远程签名服务签名，签名服务按策略拒绝时返回 ErrSignerPolicy
+// 签名须能按 txID 恢复出签名地址，否则返回 ErrSignature，不附加到交易上
+func (s *RemoteSigner) Sign(ctx context.Context, tx *Transaction) error {
+	hash, err := computeTxID(tx.RawDataHex)
+	if err != nil {
+		return err
+	}
+
+	var resp SignResponse
+	if err := s.do(ctx, http.MethodPost, SignerSignPath, &SignRequest{Transaction: *tx}, &resp); err != nil {
+		return err
+	}
+	signature, err := hex.DecodeString(resp.Signature)
+	if err != nil || len(signature) != 65 {
+		return fmt.Errorf("signer returned invalid signature %q", resp.Signature)
+	}
+	signer, err := recoverSignatureAddress(hash, signature)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSignature, err)
+	}
+	if signer != s.address {
+		return fmt.Errorf("%w: signature recovered to %s, expected signer %s", ErrSignature, signer, s.address)
+	}
+	tx.Signature = append(tx.Signature, resp.Signature)
+	return nil
+}
+
+// do 发送请求并解析响应
+//   - 连接失败、5xx：ErrTransient，可重试
+//   - 403：ErrSignerPolicy，交易不符合签名策略
+//   - 401：ErrSignature，认证配置错误
+func (s *RemoteSigner) do(ctx context.Context, method, path string, payload interface{}, out interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to serialize request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return &APIError{Kind: ErrTransient, Message: fmt.Sprintf("signer request failed: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return &APIError{Kind: ErrTransient, Message: fmt.Sprintf("failed to read signer response: %v", err)}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		message := string(respBody)
+		var signResp SignResponse
+		if json.Unmarshal(respBody, &signResp) == nil && signResp.Error != "" {
+			message = signResp.Error
+		}
+		switch {
+		case resp.StatusCode == http.StatusForbidden:
+			return &APIError{Kind: ErrSignerPolicy, StatusCode: resp.StatusCode, Message: message}
+		case resp.StatusCode == http.StatusUnauthorized:
+			return &APIError{Kind: ErrSignature, StatusCode: resp.StatusCode, Message: message}
+		case resp.StatusCode >= http.StatusInternalServerError:
+			return &APIError{Kind: ErrTransient, StatusCode: resp.StatusCode, Message: message}
+		default:
+			return &APIError{Kind: ErrBadRequest, StatusCode: resp.StatusCode, Message: message}
+		}
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to parse signer response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package tron
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newSigningServer 模拟远程签名服务，签名地址为 testPrivateKey 的地址，签名使用 signKey
+func newSigningServer(t *testing.T, signKey string) *httptest.Server {
+	t.Helper()
+	owner, err := ParseLocalSigner(testPrivateKey)
+	if err != nil {
+		t.Fatalf("解析私钥失败: %v", err)
+	}
+	signer, err := ParseLocalSigner(signKey)
+	if err != nil {
+		t.Fatalf("解析私钥失败: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(SignerAddressPath, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(SignerAddressResponse{Address: owner.Address()})
+	})
+	mux.HandleFunc(SignerSignPath, func(w http.ResponseWriter, r *http.Request) {
+		var req SignRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if err := signer.Sign(r.Context(), &req.Transaction); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(SignResponse{Error: err.Error()})
+			return
+		}
+		json.NewEncoder(w).Encode(SignResponse{Signature: req.Transaction.Signature[0]})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRemoteSignerVerifiesSignature(t *testing.T) {
+	ctx := context.Background()
+
+	remote, err := NewRemoteSigner(ctx, newSigningServer(t, testPrivateKey).URL, "")
+	if err != nil {
+		t.Fatalf("连接签名服务失败: %v", err)
+	}
+	tx := newTestTransaction(testDelegateRawHex)
+	if err := remote.Sign(ctx, tx); err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if len(tx.Signature) != 1 {
+		t.Errorf("期望1个签名，实际为%d", len(tx.Signature))
+	}
+
+	// 签名服务用其他私钥签名，恢复出的地址与签名地址不一致
+	otherKey := "0101010101010101010101010101010101010101010101010101010101010101"
+	remote, err = NewRemoteSigner(ctx, newSigningServer(t, otherKey).URL, "")
+	if err != nil {
+		t.Fatalf("连接签名服务失败: %v", err)
+	}
+	tx = newTestTransaction(testDelegateRawHex)
+	if err := remote.Sign(ctx, tx); !errors.Is(err, ErrSignature) {
+		t.Errorf("期望签名地址不一致时返回 ErrSignature，实际为%v", err)
+	}
+	if len(tx.Signature) != 0 {
+		t.Errorf("期望不附加签名，实际为%d个", len(tx.Signature))
+	}
+}
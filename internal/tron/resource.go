@@ -0,0 +1,336 @@
+package tron
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"lending-trx/internal/address"
+	"lending-trx/internal/units"
+)
+
+const (
+	// BlockInterval 出块间隔，委托锁定期以区块数计
+	BlockInterval = 3 * time.Second
+	// MaxLockPeriod 委托锁定期上限（区块数，约30天），对应链参数 getMaxDelegateLockPeriod
+	MaxLockPeriod = 864000
+)
+
+// LockPeriodForDuration 覆盖指定时长所需的锁定期（区块数，向上取整），超过上限时返回 MaxLockPeriod
+func LockPeriodForDuration(d time.Duration) int64 {
+	if d <= 0 {
+		return 0
+	}
+	blocks := int64((d + BlockInterval - 1) / BlockInterval)
+	if blocks > MaxLockPeriod {
+		return MaxLockPeriod
+	}
+	return blocks
+}
+
+// 资源类型，对应 delegateresource 的 resource 参数
+const (
+	ResourceBandwidth = "BANDWIDTH"
+	ResourceEnergy    = "ENERGY"
+)
+
+// NormalizeResource 校验资源类型并转换为大写，空字符串视为 ENERGY（兼容没有资源类型的历史订单）
+func NormalizeResource(resource string) (string, error) {
+	switch normalized := strings.ToUpper(strings.TrimSpace(resource)); normalized {
+	case "":
+		return ResourceEnergy, nil
+	case ResourceEnergy, ResourceBandwidth:
+		return normalized, nil
+	default:
+		return "", fmt.Errorf("unsupported resource %q", resource)
+	}
+}
+
+// ChainParameters /wallet/getchainparameters 返回的链参数，key 为参数名（如 getEnergyFee）
+type ChainParameters map[string]int64
+
+// chainParametersResponse /wallet/getchainparameters 响应
+type chainParametersResponse struct {
+	ChainParameter []struct {
+		Key   string `json:"key"`
+		Value int64  `json:"value"`
+	} `json:"chainParameter"`
+}
+
+// AccountResource /wallet/getaccountresource 返回的账户资源及全网资源总量
+type AccountResource struct {
+	FreeNetUsed       int64 `json:"freeNetUsed"`
+	FreeNetLimit      int64 `json:"freeNetLimit"`
+	NetUsed           int64 `json:"NetUsed"`
+	NetLimit          int64 `json:"NetLimit"`
+	TotalNetLimit     int64 `json:"TotalNetLimit"`
+	TotalNetWeight    int64 `json:"TotalNetWeight"` // 全网为带宽质押的 TRX 总量
+	EnergyUsed        int64 `json:"EnergyUsed"`
+	EnergyLimit       int64 `json:"EnergyLimit"`
+	TotalEnergyLimit  int64 `json:"TotalEnergyLimit"`  // 全网每日能量总量
+	TotalEnergyWeight int64 `json:"TotalEnergyWeight"` // 全网为能量质押的 TRX 总量
+	TronPowerUsed     int64 `json:"tronPowerUsed"`
+	TronPowerLimit    int64 `json:"tronPowerLimit"`
+}
+
+// GetChainParameters 获取链参数
+func (c *TronClient) GetChainParameters(ctx context.Context) (ChainParameters, error) {
+	var resp chainParametersResponse
+	if err := c.postJSON(ctx, "/wallet/getchainparameters", map[string]interface{}{}, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get chain parameters: %w", err)
+	}
+	params := make(ChainParameters, len(resp.ChainParameter))
+	for _, p := range resp.ChainParameter {
+		params[p.Key] = p.Value
+	}
+	return params, nil
+}
+
+// GetAccountResource 获取账户资源信息
+func (c *TronClient) GetAccountResource(ctx context.Context, addr string) (*AccountResource, error) {
+	normalized, err := address.Normalize(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid account address %q: %w", addr, err)
+	}
+
+	var resource AccountResource
+	payload := map[string]interface{}{"address": normalized, "visible": true}
+	if err := c.postJSON(ctx, "/wallet/getaccountresource", payload, &resource); err != nil {
+		return nil, fmt.Errorf("failed to get account resource: %w", err)
+	}
+	return &resource, nil
+}
+
+// GetCanDelegatedMaxSize 获取账户当前可委托的最大质押金额（SUN）
+func (c *TronClient) GetCanDelegatedMaxSize(ctx context.Context, owner string, resource string) (int64, error) {
+	normalized, err := address.Normalize(owner)
+	if err != nil {
+		return 0, fmt.Errorf("invalid owner address %q: %w", owner, err)
+	}
+
+	resourceType := 1
+	if resource == ResourceBandwidth {
+		resourceType = 0
+	}
+
+	var resp struct {
+		MaxSize int64 `json:"max_size"`
+	}
+	payload := map[string]interface{}{"owner_address": normalized, "type": resourceType, "visible": true}
+	if err := c.postJSON(ctx, "/wallet/getcandelegatedmaxsize", payload, &resp); err != nil {
+		return 0, fmt.Errorf("failed to get delegatable balance: %w", err)
+	}
+	return resp.MaxSize, nil
+}
+
+// DelegatedResource /wallet/getdelegatedresourcev2 返回的委托记录
+// 链上委托按 (owner, receiver, resource) 聚合，锁定和未锁定的委托各一条
+type DelegatedResource struct {
+	From                      string `json:"from"`
+	To                        string `json:"to"`
+	FrozenBalanceForBandwidth int64  `json:"frozen_balance_for_bandwidth"` // 委托的带宽质押金额（SUN）
+	FrozenBalanceForEnergy    int64  `json:"frozen_balance_for_energy"`    // 委托的能量质押金额（SUN）
+	ExpireTimeForBandwidth    int64  `json:"expire_time_for_bandwidth"`    // 带宽委托锁定到期时间（毫秒），未锁定为0
+	ExpireTimeForEnergy       int64  `json:"expire_time_for_energy"`       // 能量委托锁定到期时间（毫秒），未锁定为0
+}
+
+// GetDelegatedResourceV2 查询 from 委托给 to 的资源（Stake 2.0），没有委托时返回空列表
+func (c *TronClient) GetDelegatedResourceV2(ctx context.Context, from, to string) ([]DelegatedResource, error) {
+	fromAddr, toAddr, err := normalizePair(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		DelegatedResource []DelegatedResource `json:"delegatedResource"`
+	}
+	payload := map[string]interface{}{"fromAddress": fromAddr, "toAddress": toAddr, "visible": true}
+	if err := c.postJSON(ctx, "/wallet/getdelegatedresourcev2", payload, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get delegated resource: %w", err)
+	}
+	return resp.DelegatedResource, nil
+}
+
+// ReclaimableBalance 汇总委托记录中当前可以回收的质押金额（SUN）：未锁定的部分和锁定已到期的部分
+func ReclaimableBalance(resources []DelegatedResource, resource string, now time.Time) int64 {
+	nowMs := now.UnixMilli()
+	var total int64
+	for _, r := range resources {
+		balance, expireTime := r.FrozenBalanceForEnergy, r.ExpireTimeForEnergy
+		if resource == ResourceBandwidth {
+			balance, expireTime = r.FrozenBalanceForBandwidth, r.ExpireTimeForBandwidth
+		}
+		if expireTime <= nowMs {
+			total += balance
+		}
+	}
+	return total
+}
+
+// DelegatedBalance 汇总委托记录中指定资源的质押金额（SUN）
+func DelegatedBalance(resources []DelegatedResource, resource string) int64 {
+	var total int64
+	for _, r := range resources {
+		if resource == ResourceBandwidth {
+			total += r.FrozenBalanceForBandwidth
+		} else {
+			total += r.FrozenBalanceForEnergy
+		}
+	}
+	return total
+}
+
+// EnergyPrice 能量、带宽与质押金额的换算比例
+// 每质押 1 TRX 可获得 TotalEnergyLimit / TotalEnergyWeight 能量（带宽同理），该比例随全网质押量变化
+type EnergyPrice struct {
+	TotalEnergyLimit  int64     // 全网每日能量总量
+	TotalEnergyWeight int64     // 全网为能量质押的 TRX 总量
+	EnergyFee         int64     // 燃烧 TRX 获取能量时每单位能量的价格（SUN），来自链参数 getEnergyFee
+	TotalNetLimit     int64     // 全网每日带宽总量
+	TotalNetWeight    int64     // 全网为带宽质押的 TRX 总量
+	TransactionFee    int64     // 燃烧 TRX 获取带宽时每字节的价格（SUN），来自链参数 getTransactionFee
+	FetchedAt         time.Time // 获取时间
+}
+
+// BalanceForEnergy 获得指定能量需要委托的质押金额（SUN），向上取整保证能量足额
+func (p *EnergyPrice) BalanceForEnergy(energy int64) (int64, error) {
+	if p.TotalEnergyLimit <= 0 || p.TotalEnergyWeight <= 0 {
+		return 0, fmt.Errorf("invalid energy price: limit=%d, weight=%d", p.TotalEnergyLimit, p.TotalEnergyWeight)
+	}
+	balance, ok := balanceFor(energy, p.TotalEnergyLimit, p.TotalEnergyWeight)
+	if !ok {
+		return 0, fmt.Errorf("delegation balance overflows int64 for energy %d", energy)
+	}
+	return balance, nil
+}
+
+// EnergyForBalance 委托指定质押金额（SUN）可获得的能量，向下取整
+func (p *EnergyPrice) EnergyForBalance(balance int64) int64 {
+	return resourceFor(balance, p.TotalEnergyLimit, p.TotalEnergyWeight)
+}
+
+// BalanceForBandwidth 获得指定带宽需要委托的质押金额（SUN），向上取整保证带宽足额
+func (p *EnergyPrice) BalanceForBandwidth(bandwidth int64) (int64, error) {
+	if p.TotalNetLimit <= 0 || p.TotalNetWeight <= 0 {
+		return 0, fmt.Errorf("invalid bandwidth price: limit=%d, weight=%d", p.TotalNetLimit, p.TotalNetWeight)
+	}
+	balance, ok := balanceFor(bandwidth, p.TotalNetLimit, p.TotalNetWeight)
+	if !ok {
+		return 0, fmt.Errorf("delegation balance overflows int64 for bandwidth %d", bandwidth)
+	}
+	return balance, nil
+}
+
+// BandwidthForBalance 委托指定质押金额（SUN）可获得的带宽，向下取整
+func (p *EnergyPrice) BandwidthForBalance(balance int64) int64 {
+	return resourceFor(balance, p.TotalNetLimit, p.TotalNetWeight)
+}
+
+// BalanceFor 获得指定数量的资源（能量或带宽）需要委托的质押金额（SUN）
+func (p *EnergyPrice) BalanceFor(resource string, amount int64) (int64, error) {
+	if resource == ResourceBandwidth {
+		return p.BalanceForBandwidth(amount)
+	}
+	return p.BalanceForEnergy(amount)
+}
+
+// BurnCost 不质押、直接燃烧 TRX 获取指定能量的成本（SUN）
+func (p *EnergyPrice) BurnCost(energy int64) int64 {
+	return energy * p.EnergyFee
+}
+
+// BurnCostFor 不质押、直接燃烧 TRX 获取指定数量资源（能量或带宽）的成本（SUN）
+func (p *EnergyPrice) BurnCostFor(resource string, amount int64) int64 {
+	if resource == ResourceBandwidth {
+		return amount * p.TransactionFee
+	}
+	return p.BurnCost(amount)
+}
+
+// balanceFor 获得 amount 资源需要的质押金额 ceil(amount * weight * 1e6 / limit)，中间结果可能超过 int64，使用 big.Int
+func balanceFor(amount, limit, weight int64) (int64, bool) {
+	numerator := new(big.Int).Mul(big.NewInt(amount), big.NewInt(weight))
+	numerator.Mul(numerator, big.NewInt(units.SunPerTRX))
+	balance, rem := new(big.Int).QuoRem(numerator, big.NewInt(limit), new(big.Int))
+	if rem.Sign() > 0 {
+		balance.Add(balance, big.NewInt(1))
+	}
+	if !balance.IsInt64() {
+		return 0, false
+	}
+	return balance.Int64(), true
+}
+
+// resourceFor 质押金额（SUN）可获得的资源 floor(balance * limit / (weight * 1e6))
+func resourceFor(balance, limit, weight int64) int64 {
+	if weight <= 0 {
+		return 0
+	}
+	amount := new(big.Int).Mul(big.NewInt(balance), big.NewInt(limit))
+	amount.Quo(amount, new(big.Int).Mul(big.NewInt(weight), big.NewInt(units.SunPerTRX)))
+	return amount.Int64()
+}
+
+// EnergyPricer 缓存能量换算比例，过期后从节点刷新
+type EnergyPricer struct {
+	client Client
+	ttl    time.Duration
+
+	mu    sync.Mutex
+	price *EnergyPrice
+}
+
+// NewEnergyPricer 创建能量换算器，ttl 为缓存有效期
+func NewEnergyPricer(client Client, ttl time.Duration) *EnergyPricer {
+	return &EnergyPricer{client: client, ttl: ttl}
+}
+
+// Price 返回当前能量换算比例，addr 为查询全网资源总量时使用的任一已激活账户
+// 刷新失败时若有缓存则继续使用旧值，全网质押量变化缓慢，短时间内误差可忽略
+func (p *EnergyPricer) Price(ctx context.Context, addr string) (*EnergyPrice, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.price != nil && time.Since(p.price.FetchedAt) < p.ttl {
+		return p.price, nil
+	}
+
+	price, err := p.fetch(ctx, addr)
+	if err != nil {
+		if p.price != nil {
+			return p.price, nil
+		}
+		return nil, err
+	}
+	p.price = price
+	return price, nil
+}
+
+// fetch 从节点获取全网能量、带宽总量和链参数，带宽总量在换算带宽时才校验
+func (p *EnergyPricer) fetch(ctx context.Context, addr string) (*EnergyPrice, error) {
+	resource, err := p.client.GetAccountResource(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	params, err := p.client.GetChainParameters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	price := &EnergyPrice{
+		TotalEnergyLimit:  resource.TotalEnergyLimit,
+		TotalEnergyWeight: resource.TotalEnergyWeight,
+		EnergyFee:         params["getEnergyFee"],
+		TotalNetLimit:     resource.TotalNetLimit,
+		TotalNetWeight:    resource.TotalNetWeight,
+		TransactionFee:    params["getTransactionFee"],
+		FetchedAt:         time.Now(),
+	}
+	if price.TotalEnergyLimit <= 0 || price.TotalEnergyWeight <= 0 {
+		return nil, fmt.Errorf("node returned invalid energy totals: limit=%d, weight=%d", price.TotalEnergyLimit, price.TotalEnergyWeight)
+	}
+	return price, nil
+}
@@ -0,0 +1,166 @@
+package tron
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBalanceForEnergy(t *testing.T) {
+	price := &EnergyPrice{TotalEnergyLimit: 180000000000, TotalEnergyWeight: 19000000000}
+
+	// 65000 能量 * 19e9 TRX / 180e9 = 6861.11 TRX，向上取整到 SUN
+	balance, err := price.BalanceForEnergy(65000)
+	if err != nil {
+		t.Fatalf("换算失败: %v", err)
+	}
+	if balance != 6861111112 {
+		t.Errorf("期望质押金额为6861111112，实际为%d", balance)
+	}
+
+	// 换算回能量不少于目标能量
+	if energy := price.EnergyForBalance(balance); energy < 65000 {
+		t.Errorf("期望换算回的能量不少于65000，实际为%d", energy)
+	}
+}
+
+func TestBalanceForEnergyInvalidPrice(t *testing.T) {
+	price := &EnergyPrice{TotalEnergyLimit: 0, TotalEnergyWeight: 19000000000}
+	if _, err := price.BalanceForEnergy(65000); err == nil {
+		t.Error("期望能量总量为0时返回错误")
+	}
+}
+
+func TestBalanceForBandwidth(t *testing.T) {
+	price := &EnergyPrice{TotalNetLimit: 43200000000, TotalNetWeight: 26000000000, TransactionFee: 1000}
+
+	// 5000 带宽 * 26e9 TRX / 43.2e9 = 3009.26 TRX，向上取整到 SUN
+	balance, err := price.BalanceFor(ResourceBandwidth, 5000)
+	if err != nil {
+		t.Fatalf("换算失败: %v", err)
+	}
+	if balance != 3009259260 {
+		t.Errorf("期望质押金额为3009259260，实际为%d", balance)
+	}
+	if bandwidth := price.BandwidthForBalance(balance); bandwidth < 5000 {
+		t.Errorf("期望换算回的带宽不少于5000，实际为%d", bandwidth)
+	}
+	if cost := price.BurnCostFor(ResourceBandwidth, 5000); cost != 5000000 {
+		t.Errorf("期望燃烧成本为5000000，实际为%d", cost)
+	}
+
+	// 未获取带宽总量时不能换算
+	if _, err := (&EnergyPrice{TotalEnergyLimit: 1, TotalEnergyWeight: 1}).BalanceFor(ResourceBandwidth, 5000); err == nil {
+		t.Error("期望带宽总量为0时返回错误")
+	}
+}
+
+func TestNormalizeResource(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+		valid    bool
+	}{
+		{"", ResourceEnergy, true},
+		{"energy", ResourceEnergy, true},
+		{"BANDWIDTH", ResourceBandwidth, true},
+		{"TRON_POWER", "", false},
+	}
+	for _, tc := range testCases {
+		got, err := NormalizeResource(tc.input)
+		if (err == nil) != tc.valid || got != tc.expected {
+			t.Errorf("%q: 期望为%q (valid=%v)，实际为%q, %v", tc.input, tc.expected, tc.valid, got, err)
+		}
+	}
+}
+
+func TestLockPeriodForDuration(t *testing.T) {
+	testCases := []struct {
+		duration time.Duration
+		expected int64
+	}{
+		{0, 0},
+		{time.Hour, 1200},
+		{time.Hour + time.Second, 1201},
+		{24 * time.Hour, 28800},
+		{60 * 24 * time.Hour, MaxLockPeriod},
+	}
+	for _, tc := range testCases {
+		if got := LockPeriodForDuration(tc.duration); got != tc.expected {
+			t.Errorf("%s: 期望锁定期为%d，实际为%d", tc.duration, tc.expected, got)
+		}
+	}
+}
+
+func TestReclaimableBalance(t *testing.T) {
+	now := time.Now()
+	resources := []DelegatedResource{
+		{FrozenBalanceForEnergy: 2000000},
+		{FrozenBalanceForEnergy: 3000000, ExpireTimeForEnergy: now.Add(time.Hour).UnixMilli()},
+		{FrozenBalanceForBandwidth: 5000000},
+	}
+	if got := ReclaimableBalance(resources, ResourceEnergy, now); got != 2000000 {
+		t.Errorf("期望锁定期内可回收2000000，实际为%d", got)
+	}
+	if got := ReclaimableBalance(resources, ResourceEnergy, now.Add(time.Hour)); got != 5000000 {
+		t.Errorf("期望锁定到期后可回收5000000，实际为%d", got)
+	}
+	if got := DelegatedBalance(resources, ResourceEnergy); got != 5000000 {
+		t.Errorf("期望委托合计5000000，实际为%d", got)
+	}
+}
+
+func TestEnergyPricerCache(t *testing.T) {
+	var resourceCalls int32
+	weight := int64(19000000000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/wallet/getaccountresource":
+			n := atomic.AddInt32(&resourceCalls, 1)
+			if n > 1 {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"TotalEnergyLimit":180000000000,"TotalEnergyWeight":` + itoa(weight) + `}`))
+		case "/wallet/getchainparameters":
+			w.Write([]byte(`{"chainParameter":[{"key":"getEnergyFee","value":420},{"key":"getAllowDelegateResource","value":1}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	pricer := NewEnergyPricer(NewTronClient(server.URL, ""), time.Hour)
+	ctx := context.Background()
+
+	price, err := pricer.Price(ctx, "TNPeeaaFB7K9cmo4uQpcU32zGK8G1NYqeL")
+	if err != nil {
+		t.Fatalf("获取能量价格失败: %v", err)
+	}
+	if price.TotalEnergyWeight != weight || price.EnergyFee != 420 {
+		t.Errorf("能量价格解析错误: %+v", price)
+	}
+
+	// 缓存有效期内不再请求节点
+	if _, err := pricer.Price(ctx, "TNPeeaaFB7K9cmo4uQpcU32zGK8G1NYqeL"); err != nil {
+		t.Fatalf("获取缓存的能量价格失败: %v", err)
+	}
+	if resourceCalls != 1 {
+		t.Errorf("期望请求节点1次，实际为%d", resourceCalls)
+	}
+
+	// 缓存过期且刷新失败时继续使用旧值
+	pricer.ttl = 0
+	stale, err := pricer.Price(ctx, "TNPeeaaFB7K9cmo4uQpcU32zGK8G1NYqeL")
+	if err != nil || stale != price {
+		t.Errorf("期望刷新失败时返回缓存值，实际为 %v, %v", stale, err)
+	}
+}
+
+func itoa(v int64) string {
+	return strconv.FormatInt(v, 10)
+}
@@ -0,0 +1,72 @@
+package tron
+
+import (
+	"context"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+
+	"lending-trx/internal/address"
+)
+
+// Signer 交易签名者
+// 私钥可以在进程内（LocalSigner）、加密的 keystore 文件（LoadKeystore）或远程签名服务（RemoteSigner）中，
+// 多签时每个 Signer 各签一次
+type Signer interface {
+	// Address 签名私钥对应的 TRON 地址（Base58Check）
+	Address() string
+	// Sign 对交易签名，签名追加到 tx.Signature
+	Sign(ctx context.Context, tx *Transaction) error
+}
+
+var (
+	_ Signer = (*LocalSigner)(nil)
+	_ Signer = (*RemoteSigner)(nil)
+)
+
+// LocalSigner 使用进程内私钥签名
+type LocalSigner struct {
+	key     *secp256k1.PrivateKey
+	address string
+}
+
+// NewLocalSigner 创建使用进程内私钥的签名者
+func NewLocalSigner(key *secp256k1.PrivateKey) *LocalSigner {
+	return &LocalSigner{key: key, address: keyAddress(key)}
+}
+
+// ParseLocalSigner 解析十六进制私钥并创建签名者
+func ParseLocalSigner(hexKey string) (*LocalSigner, error) {
+	key, err := ParsePrivateKey(hexKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewLocalSigner(key), nil
+}
+
+// Address 私钥对应的 TRON 地址
+func (s *LocalSigner) Address() string {
+	return s.address
+}
+
+// Sign 校验 txID 与 raw_data_hex 一致后签名
+func (s *LocalSigner) Sign(ctx context.Context, tx *Transaction) error {
+	return signTransaction(tx, s.key)
+}
+
+// keyAddress 私钥对应的 TRON 地址（Base58Check）
+func keyAddress(key *secp256k1.PrivateKey) string {
+	addr, err := address.FromPublicKey(key.PubKey().SerializeUncompressed())
+	if err != nil {
+		return ""
+	}
+	return addr.Base58()
+}
+
+// signerAddresses 签名者的地址列表
+func signerAddresses(signers []Signer) []string {
+	addresses := make([]string, 0, len(signers))
+	for _, signer := range signers {
+		addresses = append(addresses, signer.Address())
+	}
+	return addresses
+}
@@ -0,0 +1,255 @@
+package tron
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"lending-trx/internal/address"
+	"lending-trx/internal/units"
+)
+
+// Stake 2.0 质押相关合约类型，对应权限 operations 位图中的位
+const (
+	ContractTypeFreezeBalanceV2        = 54 // FreezeBalanceV2Contract
+	ContractTypeUnfreezeBalanceV2      = 55 // UnfreezeBalanceV2Contract
+	ContractTypeWithdrawExpireUnfreeze = 56 // WithdrawExpireUnfreezeContract
+)
+
+// MaxUnfreezingEntries 同一账户同时进行中的解质押（unfrozenV2）条目上限
+const MaxUnfreezingEntries = 32
+
+// FrozenV2 账户的 Stake 2.0 质押，type 为空表示带宽
+type FrozenV2 struct {
+	Type   string `json:"type,omitempty"`
+	Amount int64  `json:"amount"`
+}
+
+// UnfrozenV2 进行中的解质押，到期后需调用 withdrawexpireunfreeze 提取到可用余额
+type UnfrozenV2 struct {
+	Type               string `json:"type,omitempty"`
+	UnfreezeAmount     int64  `json:"unfreeze_amount"`
+	UnfreezeExpireTime int64  `json:"unfreeze_expire_time"` // 解质押到期时间（毫秒）
+}
+
+// StakeAccount /wallet/getaccount 返回的余额和 Stake 2.0 质押状态
+type StakeAccount struct {
+	Address    string       `json:"address"`
+	Balance    int64        `json:"balance"` // 可用余额（SUN）
+	FrozenV2   []FrozenV2   `json:"frozenV2"`
+	UnfrozenV2 []UnfrozenV2 `json:"unfrozenV2"`
+}
+
+// Frozen 指定资源的质押金额（SUN），不含已委托给他人的部分
+func (a *StakeAccount) Frozen(resource string) int64 {
+	var total int64
+	for _, f := range a.FrozenV2 {
+		if resourceOf(f.Type) == resource {
+			total += f.Amount
+		}
+	}
+	return total
+}
+
+// Unfreezing 返回 now 时尚未到期的解质押金额和已到期可提取的金额（SUN）
+func (a *StakeAccount) Unfreezing(now time.Time) (pending int64, withdrawable int64) {
+	nowMs := now.UnixMilli()
+	for _, u := range a.UnfrozenV2 {
+		if u.UnfreezeExpireTime <= nowMs {
+			withdrawable += u.UnfreezeAmount
+		} else {
+			pending += u.UnfreezeAmount
+		}
+	}
+	return pending, withdrawable
+}
+
+// resourceOf 节点省略 type 字段时为带宽（枚举值0）
+func resourceOf(resourceType string) string {
+	if resourceType == "" {
+		return ResourceBandwidth
+	}
+	return resourceType
+}
+
+// GetStakeAccount 查询账户余额和 Stake 2.0 质押状态
+func (c *TronClient) GetStakeAccount(ctx context.Context, addr string) (*StakeAccount, error) {
+	normalized, err := address.Normalize(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid account address %q: %w", addr, err)
+	}
+
+	var account StakeAccount
+	payload := map[string]interface{}{"address": normalized, "visible": true}
+	if err := c.postJSON(ctx, "/wallet/getaccount", payload, &account); err != nil {
+		return nil, fmt.Errorf("failed to get stake account: %w", err)
+	}
+	if account.Address == "" {
+		return nil, fmt.Errorf("account %s: %w", normalized, ErrAccountNotActivated)
+	}
+	return &account, nil
+}
+
+// FreezeBalanceV2 质押可用余额获取资源（Stake 2.0），amount 为质押金额（SUN），返回交易ID
+func (c *TronClient) FreezeBalanceV2(ctx context.Context, owner string, amount int64, resource string) (string, error) {
+	normalized, err := address.Normalize(owner)
+	if err != nil {
+		return "", fmt.Errorf("invalid owner address %q: %w", owner, err)
+	}
+	if resource, err = NormalizeResource(resource); err != nil {
+		return "", err
+	}
+	payload := map[string]interface{}{
+		"owner_address":  normalized,
+		"frozen_balance": amount,
+		"resource":       resource,
+		"visible":        true,
+	}
+	want := expectedContract{Type: ContractTypeFreezeBalanceV2, Owner: normalized, Resource: resource, Balance: amount}
+	broadcast, err := c.buildSignAndBroadcast(ctx, "/wallet/freezebalancev2", payload, want)
+	if err != nil {
+		return "", fmt.Errorf("freeze balance failed: %w", err)
+	}
+	return broadcast.TxID, nil
+}
+
+// UnfreezeBalanceV2 解质押（Stake 2.0），amount 为解质押金额（SUN），到期后需提取，返回交易ID
+func (c *TronClient) UnfreezeBalanceV2(ctx context.Context, owner string, amount int64, resource string) (string, error) {
+	normalized, err := address.Normalize(owner)
+	if err != nil {
+		return "", fmt.Errorf("invalid owner address %q: %w", owner, err)
+	}
+	if resource, err = NormalizeResource(resource); err != nil {
+		return "", err
+	}
+	payload := map[string]interface{}{
+		"owner_address":    normalized,
+		"unfreeze_balance": amount,
+		"resource":         resource,
+		"visible":          true,
+	}
+	want := expectedContract{Type: ContractTypeUnfreezeBalanceV2, Owner: normalized, Resource: resource, Balance: amount}
+	broadcast, err := c.buildSignAndBroadcast(ctx, "/wallet/unfreezebalancev2", payload, want)
+	if err != nil {
+		return "", fmt.Errorf("unfreeze balance failed: %w", err)
+	}
+	return broadcast.TxID, nil
+}
+
+// WithdrawExpireUnfreeze 提取已到期的解质押金额到可用余额，返回交易ID
+func (c *TronClient) WithdrawExpireUnfreeze(ctx context.Context, owner string) (string, error) {
+	normalized, err := address.Normalize(owner)
+	if err != nil {
+		return "", fmt.Errorf("invalid owner address %q: %w", owner, err)
+	}
+	payload := map[string]interface{}{"owner_address": normalized, "visible": true}
+	want := expectedContract{Type: ContractTypeWithdrawExpireUnfreeze, Owner: normalized}
+	broadcast, err := c.buildSignAndBroadcast(ctx, "/wallet/withdrawexpireunfreeze", payload, want)
+	if err != nil {
+		return "", fmt.Errorf("withdraw expire unfreeze failed: %w", err)
+	}
+	return broadcast.TxID, nil
+}
+
+// StakingConfig 质押管理配置
+type StakingConfig struct {
+	Owner     string // 委托账户地址
+	Resource  string // 质押的资源类型，默认 ENERGY
+	Reserve   int64  // 保留的可用余额（SUN），用于支付手续费和退款，不参与质押
+	MinFreeze int64  // 单次最少质押金额（SUN），超出保留余额的部分不足时不质押，链上最小 1 TRX
+}
+
+// StakingPlan 一次质押管理需要执行的操作
+type StakingPlan struct {
+	Withdraw bool  // 提取已到期的解质押
+	Freeze   int64 // 质押金额（SUN），0 表示不质押
+	Unfreeze int64 // 解质押金额（SUN），0 表示不解质押
+}
+
+// PlanStaking 根据账户状态计算需要执行的操作
+//   - idleFrozen: 已质押且未委托出去、可以解质押的金额
+//
+// 可用余额超出保留余额至少 MinFreeze 时质押超出部分；可用余额加上已到期和进行中的解质押仍低于保留余额时，
+// 从未委托的质押中解质押差额（解质押需等待链上的解质押期，到期后提取）。已到期的解质押总是提取
+func PlanStaking(account *StakeAccount, idleFrozen int64, config StakingConfig, now time.Time) StakingPlan {
+	var plan StakingPlan
+	pending, withdrawable := account.Unfreezing(now)
+	plan.Withdraw = withdrawable > 0
+
+	minFreeze := config.MinFreeze
+	if minFreeze < units.SunPerTRX {
+		minFreeze = units.SunPerTRX
+	}
+	// 提取交易尚未上链，质押只使用当前可用余额
+	if excess := account.Balance - config.Reserve; excess >= minFreeze {
+		plan.Freeze = excess
+		return plan
+	}
+
+	shortfall := config.Reserve - account.Balance - withdrawable - pending
+	if shortfall > 0 && idleFrozen > 0 && len(account.UnfrozenV2) < MaxUnfreezingEntries {
+		plan.Unfreeze = shortfall
+		if plan.Unfreeze > idleFrozen {
+			plan.Unfreeze = idleFrozen
+		}
+	}
+	return plan
+}
+
+// StakingResult 一次质押管理执行的交易
+type StakingResult struct {
+	Plan         StakingPlan
+	WithdrawTxID string
+	FreezeTxID   string
+	UnfreezeTxID string
+}
+
+// StakingManager 委托账户的质押管理：质押闲置余额、保留可用余额、提取到期的解质押
+type StakingManager struct {
+	client Client
+	config StakingConfig
+}
+
+// NewStakingManager 创建质押管理器
+func NewStakingManager(client Client, config StakingConfig) *StakingManager {
+	if config.Resource == "" {
+		config.Resource = ResourceEnergy
+	}
+	return &StakingManager{client: client, config: config}
+}
+
+// Config 返回质押管理配置
+func (m *StakingManager) Config() StakingConfig {
+	return m.config
+}
+
+// Run 查询账户状态，按 PlanStaking 的结果广播提取、质押、解质押交易，now 用于判断解质押是否到期
+// 交易广播后需等待上链，下次执行时按最新账户状态重新计算
+func (m *StakingManager) Run(ctx context.Context, now time.Time) (*StakingResult, error) {
+	account, err := m.client.GetStakeAccount(ctx, m.config.Owner)
+	if err != nil {
+		return nil, err
+	}
+	idleFrozen, err := m.client.GetCanDelegatedMaxSize(ctx, m.config.Owner, m.config.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &StakingResult{Plan: PlanStaking(account, idleFrozen, m.config, now)}
+	if result.Plan.Withdraw {
+		if result.WithdrawTxID, err = m.client.WithdrawExpireUnfreeze(ctx, m.config.Owner); err != nil {
+			return result, err
+		}
+	}
+	if result.Plan.Freeze > 0 {
+		if result.FreezeTxID, err = m.client.FreezeBalanceV2(ctx, m.config.Owner, result.Plan.Freeze, m.config.Resource); err != nil {
+			return result, err
+		}
+	}
+	if result.Plan.Unfreeze > 0 {
+		if result.UnfreezeTxID, err = m.client.UnfreezeBalanceV2(ctx, m.config.Owner, result.Plan.Unfreeze, m.config.Resource); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
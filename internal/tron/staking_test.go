@@ -0,0 +1,39 @@
+package tron
+
+import (
+	"testing"
+	"time"
+
+	"lending-trx/internal/units"
+)
+
+func TestPlanStaking(t *testing.T) {
+	now := time.Now()
+	config := StakingConfig{Reserve: 100 * units.SunPerTRX, MinFreeze: 10 * units.SunPerTRX}
+	expired := UnfrozenV2{Type: ResourceEnergy, UnfreezeAmount: 30 * units.SunPerTRX, UnfreezeExpireTime: now.Add(-time.Hour).UnixMilli()}
+	pending := UnfrozenV2{Type: ResourceEnergy, UnfreezeAmount: 40 * units.SunPerTRX, UnfreezeExpireTime: now.Add(time.Hour).UnixMilli()}
+
+	testCases := []struct {
+		balance     int64
+		unfrozen    []UnfrozenV2
+		idleFrozen  int64
+		expected    StakingPlan
+		description string
+	}{
+		{150 * units.SunPerTRX, nil, 0, StakingPlan{Freeze: 50 * units.SunPerTRX}, "质押超出保留余额的部分"},
+		{105 * units.SunPerTRX, nil, 0, StakingPlan{}, "超出部分不足最少质押金额"},
+		{100 * units.SunPerTRX, []UnfrozenV2{expired}, 0, StakingPlan{Withdraw: true}, "提取到期的解质押"},
+		{20 * units.SunPerTRX, nil, 500 * units.SunPerTRX, StakingPlan{Unfreeze: 80 * units.SunPerTRX}, "可用余额低于保留余额，解质押差额"},
+		{20 * units.SunPerTRX, []UnfrozenV2{expired, pending}, 500 * units.SunPerTRX, StakingPlan{Withdraw: true, Unfreeze: 10 * units.SunPerTRX}, "扣除已到期和进行中的解质押"},
+		{20 * units.SunPerTRX, nil, 50 * units.SunPerTRX, StakingPlan{Unfreeze: 50 * units.SunPerTRX}, "解质押不超过未委托的质押"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			account := &StakeAccount{Balance: tc.balance, UnfrozenV2: tc.unfrozen}
+			if got := PlanStaking(account, tc.idleFrozen, config, now); got != tc.expected {
+				t.Errorf("期望%+v，实际为%+v", tc.expected, got)
+			}
+		})
+	}
+}
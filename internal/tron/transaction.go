@@ -0,0 +1,183 @@
+package tron
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+
+	"lending-trx/internal/address"
+)
+
+// Transaction 节点返回的未签名/已签名交易
+// raw_data 保留原始 JSON，广播时原样回传，避免重新序列化导致字段变化
+type Transaction struct {
+	Visible    bool            `json:"visible"`
+	TxID       string          `json:"txID"`
+	RawData    json.RawMessage `json:"raw_data"`
+	RawDataHex string          `json:"raw_data_hex"`
+	Signature  []string        `json:"signature,omitempty"`
+}
+
+// Expiration 交易过期时间（毫秒），从 raw_data_hex 解码；过期后节点不再接受该交易
+func (t *Transaction) Expiration() (int64, error) {
+	raw, err := DecodeRawData(t.RawDataHex)
+	if err != nil {
+		return 0, err
+	}
+	return raw.Expiration, nil
+}
+
+// transactionResponse 构建交易接口（delegateresource 等）的响应
+// 节点校验失败时只返回 Error 字段
+type transactionResponse struct {
+	Transaction
+	Error string `json:"Error,omitempty"`
+}
+
+// BroadcastResponse 广播交易响应
+type BroadcastResponse struct {
+	Result  bool   `json:"result"`
+	TxID    string `json:"txid"`
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// expectedContract 请求对应的合约内容，签名前与节点返回的 raw_data_hex 逐项比对，不适用的字段为零值
+type expectedContract struct {
+	Type       int
+	Owner      string
+	Receiver   string // 委托/回收的接收方
+	Resource   string // ENERGY / BANDWIDTH，提取到期解质押时为空
+	Balance    int64  // 委托/回收/质押/解质押金额（SUN）
+	Lock       bool
+	LockPeriod int64
+}
+
+// verifyBuilt 校验节点构建的交易与请求一致：txID 为 raw_data_hex 的哈希，只包含一个合约，
+// 合约类型、权限ID和参数都与请求相同。节点同时控制 txID 和 raw_data_hex，不比对内容时
+// 被篡改的节点可以让热钱包签署转账或委托给其他地址的交易
+func verifyBuilt(tx *Transaction, want expectedContract, permissionID int) error {
+	hash, err := computeTxID(tx.RawDataHex)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnexpectedTransaction, err)
+	}
+	if !strings.EqualFold(hex.EncodeToString(hash), tx.TxID) {
+		return fmt.Errorf("%w: txID %s does not match raw_data_hex", ErrUnexpectedTransaction, tx.TxID)
+	}
+
+	data, err := DecodeRawData(tx.RawDataHex)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnexpectedTransaction, err)
+	}
+	if len(data.Contracts) != 1 {
+		return fmt.Errorf("%w: expected 1 contract, got %d", ErrUnexpectedTransaction, len(data.Contracts))
+	}
+	contract := &data.Contracts[0]
+	if contract.Type != want.Type || contract.TypeURL != "type.googleapis.com/protocol."+contractTypeNames[want.Type] {
+		return fmt.Errorf("%w: contract type %d (%s), expected %d", ErrUnexpectedTransaction, contract.Type, contract.TypeURL, want.Type)
+	}
+	if contract.PermissionID != permissionID {
+		return fmt.Errorf("%w: permission id %d, expected %d", ErrUnexpectedTransaction, contract.PermissionID, permissionID)
+	}
+
+	got := expectedContract{Type: contract.Type}
+	switch contract.Type {
+	case ContractTypeDelegateResource, ContractTypeUnDelegateResource:
+		resource, err := contract.ResourceContract()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrUnexpectedTransaction, err)
+		}
+		got.Owner, got.Receiver, got.Resource = resource.OwnerAddress, resource.ReceiverAddress, resource.Resource
+		got.Balance, got.Lock, got.LockPeriod = resource.Balance, resource.Lock, resource.LockPeriod
+	case ContractTypeFreezeBalanceV2, ContractTypeUnfreezeBalanceV2:
+		stake, err := contract.StakeContract()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrUnexpectedTransaction, err)
+		}
+		got.Owner, got.Resource, got.Balance = stake.OwnerAddress, stake.Resource, stake.Balance
+	default:
+		if got.Owner, err = contract.OwnerAddress(); err != nil {
+			return fmt.Errorf("%w: %v", ErrUnexpectedTransaction, err)
+		}
+	}
+	if got != want {
+		return fmt.Errorf("%w: built %+v, requested %+v", ErrUnexpectedTransaction, got, want)
+	}
+	return nil
+}
+
+// ParsePrivateKey 解析十六进制格式的 secp256k1 私钥
+func ParsePrivateKey(hexKey string) (*secp256k1.PrivateKey, error) {
+	keyBytes, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(hexKey), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key hex: %w", err)
+	}
+	if len(keyBytes) != 32 {
+		return nil, fmt.Errorf("invalid private key length: %d", len(keyBytes))
+	}
+	return secp256k1.PrivKeyFromBytes(keyBytes), nil
+}
+
+// computeTxID 计算交易ID：sha256(raw_data 的 protobuf 字节)
+func computeTxID(rawDataHex string) ([]byte, error) {
+	rawBytes, err := hex.DecodeString(rawDataHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid raw_data_hex: %w", err)
+	}
+	hash := sha256.Sum256(rawBytes)
+	return hash[:], nil
+}
+
+// signTransaction 使用本地私钥对交易签名，签名追加到 Signature 列表
+// TRON 签名格式为 r(32) || s(32) || v(1)，v 为恢复ID (0/1)
+func signTransaction(tx *Transaction, key *secp256k1.PrivateKey) error {
+	hash, err := computeTxID(tx.RawDataHex)
+	if err != nil {
+		return err
+	}
+
+	// 校验节点返回的 txID 与 raw_data_hex 一致，防止签署被篡改的交易
+	if !strings.EqualFold(hex.EncodeToString(hash), tx.TxID) {
+		return fmt.Errorf("txID mismatch: node returned %s, computed %s", tx.TxID, hex.EncodeToString(hash))
+	}
+
+	// SignCompact 返回 [27+recid][r][s]
+	compact := ecdsa.SignCompact(key, hash, false)
+	signature := make([]byte, 65)
+	copy(signature, compact[1:])
+	signature[64] = compact[0] - 27
+
+	tx.Signature = append(tx.Signature, hex.EncodeToString(signature))
+	return nil
+}
+
+// recoverSignatureAddress 从 r || s || v 格式的签名恢复签名地址（Base58Check）
+func recoverSignatureAddress(hash, signature []byte) (string, error) {
+	if len(signature) != 65 {
+		return "", fmt.Errorf("invalid signature length %d", len(signature))
+	}
+	compact := append([]byte{signature[64] + 27}, signature[:64]...)
+	pub, _, err := ecdsa.RecoverCompact(compact, hash)
+	if err != nil {
+		return "", fmt.Errorf("failed to recover public key: %w", err)
+	}
+	addr, err := address.FromPublicKey(pub.SerializeUncompressed())
+	if err != nil {
+		return "", err
+	}
+	return addr.Base58(), nil
+}
+
+// decodeNodeMessage 节点错误信息通常为十六进制编码的字符串，尝试解码
+func decodeNodeMessage(message string) string {
+	decoded, err := hex.DecodeString(message)
+	if err != nil || len(decoded) == 0 {
+		return message
+	}
+	return string(decoded)
+}
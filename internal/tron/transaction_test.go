@@ -0,0 +1,215 @@
+package tron
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+const testPrivateKey = "b5a4cea271ff424d7c31dc12a3e43e401df7a40d7412a15750f3f0b6b5449a28"
+
+func newTestTransaction(rawHex string) *Transaction {
+	rawBytes, _ := hex.DecodeString(rawHex)
+	hash := sha256.Sum256(rawBytes)
+	return &Transaction{
+		Visible:    true,
+		TxID:       hex.EncodeToString(hash[:]),
+		RawData:    json.RawMessage(`{"contract":[]}`),
+		RawDataHex: rawHex,
+	}
+}
+
+func TestSignTransaction(t *testing.T) {
+	key, err := ParsePrivateKey(testPrivateKey)
+	if err != nil {
+		t.Fatalf("解析私钥失败: %v", err)
+	}
+
+	tx := newTestTransaction("0a0202a22208f1f7b2f3e5d6c7b840a0f0c3e1a4325a")
+	if err := signTransaction(tx, key); err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+
+	if len(tx.Signature) != 1 {
+		t.Fatalf("期望1个签名，实际为%d", len(tx.Signature))
+	}
+
+	sig, _ := hex.DecodeString(tx.Signature[0])
+	if len(sig) != 65 {
+		t.Fatalf("期望签名长度为65，实际为%d", len(sig))
+	}
+	if sig[64] > 1 {
+		t.Errorf("期望恢复ID为0或1，实际为%d", sig[64])
+	}
+
+	// 通过签名恢复公钥，验证与私钥一致
+	compact := append([]byte{sig[64] + 27}, sig[:64]...)
+	hash, _ := computeTxID(tx.RawDataHex)
+	pub, _, err := ecdsa.RecoverCompact(compact, hash)
+	if err != nil {
+		t.Fatalf("恢复公钥失败: %v", err)
+	}
+	if !pub.IsEqual(key.PubKey()) {
+		t.Error("恢复的公钥与私钥不匹配")
+	}
+}
+
+func TestSignTransactionTxIDMismatch(t *testing.T) {
+	key, _ := ParsePrivateKey(testPrivateKey)
+
+	tx := newTestTransaction("0a0202a2")
+	tx.TxID = "00" + tx.TxID[2:]
+
+	if err := signTransaction(tx, key); err == nil {
+		t.Error("期望txID不匹配时返回错误")
+	}
+}
+
+func TestParsePrivateKeyInvalid(t *testing.T) {
+	for _, input := range []string{"", "zz", "0x1234"} {
+		if _, err := ParsePrivateKey(input); err == nil {
+			t.Errorf("期望私钥 %q 解析失败", input)
+		}
+	}
+}
+
+// newBuildServer 模拟节点：构建接口返回 tx，记录广播的交易
+func newBuildServer(t *testing.T, tx *Transaction, broadcasted *[]Transaction) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/wallet/delegateresource", "/wallet/freezebalancev2":
+			json.NewEncoder(w).Encode(tx)
+		case "/wallet/broadcasttransaction":
+			var signed Transaction
+			json.Unmarshal(body, &signed)
+			*broadcasted = append(*broadcasted, signed)
+			json.NewEncoder(w).Encode(BroadcastResponse{Result: true, TxID: tx.TxID})
+		default:
+			t.Errorf("意外的请求路径: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestDelegateEnergyBuildsSignsAndBroadcasts(t *testing.T) {
+	tx := newTestTransaction(testDelegateRawHex)
+	var broadcasted []Transaction
+	server := newBuildServer(t, tx, &broadcasted)
+	defer server.Close()
+
+	client := NewTronClient(server.URL, "")
+	if err := client.SetSigners([]string{testPrivateKey}, 2); err != nil {
+		t.Fatalf("设置私钥失败: %v", err)
+	}
+
+	resp, err := client.DelegateEnergy(context.Background(), &EnergyDelegationRequest{
+		FromAddress: "TSoXbDPwgM1nhfbxVj53u15My8smJHWpFw",
+		ToAddress:   "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t",
+		Amount:      "5000000",
+		Lock:        true,
+		LockPeriod:  1200,
+	})
+	if err != nil {
+		t.Fatalf("委托失败: %v", err)
+	}
+
+	if resp.TxID != tx.TxID {
+		t.Errorf("期望TxID为%s，实际为%s", tx.TxID, resp.TxID)
+	}
+	if len(broadcasted) != 1 || len(broadcasted[0].Signature) != 1 {
+		t.Errorf("期望广播1笔包含1个签名的交易，实际为%+v", broadcasted)
+	}
+}
+
+func TestBuildRejectsUnexpectedTransaction(t *testing.T) {
+	request := EnergyDelegationRequest{
+		FromAddress: "TSoXbDPwgM1nhfbxVj53u15My8smJHWpFw",
+		ToAddress:   "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t",
+		Amount:      "5000000",
+		Lock:        true,
+		LockPeriod:  1200,
+	}
+	testCases := []struct {
+		description  string
+		permissionID int
+		modify       func(req *EnergyDelegationRequest)
+	}{
+		{"其他接收方", 2, func(req *EnergyDelegationRequest) { req.ToAddress = "TNPeeaaFB7K9cmo4uQpcU32zGK8G1NYqeL" }},
+		{"其他金额", 2, func(req *EnergyDelegationRequest) { req.Amount = "1000000" }},
+		{"其他资源", 2, func(req *EnergyDelegationRequest) { req.Resource = ResourceBandwidth }},
+		{"其他锁定期", 2, func(req *EnergyDelegationRequest) { req.LockPeriod = 600 }},
+		{"不锁定", 2, func(req *EnergyDelegationRequest) { req.Lock, req.LockPeriod = false, 0 }},
+		{"其他权限", OwnerPermissionID, func(req *EnergyDelegationRequest) {}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			var broadcasted []Transaction
+			server := newBuildServer(t, newTestTransaction(testDelegateRawHex), &broadcasted)
+			defer server.Close()
+			client := NewTronClient(server.URL, "")
+			client.SetSigners([]string{testPrivateKey}, tc.permissionID)
+
+			req := request
+			tc.modify(&req)
+			if _, err := client.DelegateEnergy(context.Background(), &req); !errors.Is(err, ErrUnexpectedTransaction) {
+				t.Errorf("期望 ErrUnexpectedTransaction，实际为%v", err)
+			}
+			if len(broadcasted) != 0 {
+				t.Errorf("期望不广播，实际广播了%d笔", len(broadcasted))
+			}
+		})
+	}
+
+	// 节点返回质押交易而不是委托交易
+	var broadcasted []Transaction
+	server := newBuildServer(t, newTestTransaction(testFreezeRawHex), &broadcasted)
+	defer server.Close()
+	client := NewTronClient(server.URL, "")
+	client.SetSigners([]string{testPrivateKey}, 2)
+	if _, err := client.DelegateEnergy(context.Background(), &request); !errors.Is(err, ErrUnexpectedTransaction) {
+		t.Errorf("期望其他合约类型返回 ErrUnexpectedTransaction，实际为%v", err)
+	}
+
+	// 质押交易按金额和资源比对
+	client.SetSigners([]string{testPrivateKey}, OwnerPermissionID)
+	if _, err := client.FreezeBalanceV2(context.Background(), request.FromAddress, 5000000, ResourceBandwidth); !errors.Is(err, ErrUnexpectedTransaction) {
+		t.Errorf("期望资源不一致时返回 ErrUnexpectedTransaction，实际为%v", err)
+	}
+	if _, err := client.FreezeBalanceV2(context.Background(), request.FromAddress, 5000000, ResourceEnergy); err != nil {
+		t.Errorf("期望与请求一致的质押交易通过，实际为%v", err)
+	}
+	if len(broadcasted) != 1 {
+		t.Errorf("期望只广播一致的质押交易，实际广播了%d笔", len(broadcasted))
+	}
+}
+
+func TestDelegateEnergyNodeError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Error":"class org.tron.core.exceptions.ContractValidateException : delegateBalance must be less than or equal to available FreezeEnergyV2 balance"}`))
+	}))
+	defer server.Close()
+
+	client := NewTronClient(server.URL, "")
+	client.SetPrivateKey(testPrivateKey)
+
+	resp, err := client.DelegateEnergy(context.Background(), &EnergyDelegationRequest{
+		FromAddress: "TNPeeaaFB7K9cmo4uQpcU32zGK8G1NYqeL",
+		ToAddress:   "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t",
+		Amount:      "1000000",
+	})
+	if err == nil {
+		t.Fatal("期望节点返回错误时委托失败")
+	}
+	if resp == nil || resp.Success {
+		t.Error("期望响应 Success 为false")
+	}
+}
@@ -0,0 +1,90 @@
+package tron
+
+import (
+	"fmt"
+	"strings"
+
+	"lending-trx/internal/address"
+	"lending-trx/internal/units"
+)
+
+const (
+	// trc20TransferTopic Transfer(address,address,uint256) 事件的 topic0
+	trc20TransferTopic = "ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+	// abiWordLength ABI 编码中一个参数的十六进制长度（32字节）
+	abiWordLength = 64
+	// receiptSuccess 智能合约执行成功时 receipt.result 的值
+	receiptSuccess = "SUCCESS"
+)
+
+// TRC20Transfer 回执中 Transfer 事件表示的代币转账，地址为 Base58Check
+type TRC20Transfer struct {
+	Contract string       // 代币合约地址
+	From     string       // 转出地址
+	To       string       // 转入地址
+	Amount   units.Amount // 转账数量（代币最小单位）
+}
+
+// TRC20Transfers 解析回执中的 Transfer 事件，不是 Transfer 事件或无法解析的日志跳过
+func (i *TransactionInfo) TRC20Transfers() []TRC20Transfer {
+	var transfers []TRC20Transfer
+	for _, log := range i.Log {
+		transfer, err := decodeTransferLog(log)
+		if err != nil {
+			continue
+		}
+		transfers = append(transfers, transfer)
+	}
+	return transfers
+}
+
+// HasTRC20Transfer 回执显示合约执行成功（receipt.result 为 SUCCESS），且包含 contract 合约从 from 转给 to、数量为 amount 的 Transfer 事件
+// 只有 transfer 调用参数或推送没有执行结果的 TRC20 支付，需要按回执确认实际到账后才能履约
+func (i *TransactionInfo) HasTRC20Transfer(contract, from, to string, amount units.Amount) bool {
+	if !i.Found() || i.Failed() || i.Receipt.Result != receiptSuccess {
+		return false
+	}
+	for _, transfer := range i.TRC20Transfers() {
+		if transfer.Contract == contract && transfer.From == from && transfer.To == to && transfer.Amount.Cmp(amount) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeTransferLog 解析 Transfer 事件日志
+func decodeTransferLog(log TransactionLog) (TRC20Transfer, error) {
+	if len(log.Topics) != 3 || strings.ToLower(strings.TrimPrefix(log.Topics[0], "0x")) != trc20TransferTopic {
+		return TRC20Transfer{}, fmt.Errorf("not a transfer event")
+	}
+	contract, err := address.Normalize("0x" + strings.TrimPrefix(log.Address, "0x"))
+	if err != nil {
+		return TRC20Transfer{}, err
+	}
+	from, err := topicAddress(log.Topics[1])
+	if err != nil {
+		return TRC20Transfer{}, err
+	}
+	to, err := topicAddress(log.Topics[2])
+	if err != nil {
+		return TRC20Transfer{}, err
+	}
+	data := strings.TrimPrefix(log.Data, "0x")
+	if len(data) != abiWordLength {
+		return TRC20Transfer{}, fmt.Errorf("invalid transfer log data length %d", len(data))
+	}
+	amount, err := units.ParseHexAmount(data)
+	if err != nil {
+		return TRC20Transfer{}, err
+	}
+	return TRC20Transfer{Contract: contract, From: from, To: to, Amount: amount}, nil
+}
+
+// topicAddress 从 32 字节的 indexed 参数中取出地址（低 20 字节），返回 Base58Check
+func topicAddress(topic string) (string, error) {
+	word := strings.TrimPrefix(topic, "0x")
+	if len(word) != abiWordLength {
+		return "", fmt.Errorf("invalid address topic length %d", len(word))
+	}
+	return address.Normalize("0x" + word[abiWordLength-40:])
+}
@@ -0,0 +1,49 @@
+package tron
+
+import (
+	"testing"
+
+	"lending-trx/internal/units"
+)
+
+func TestHasTRC20Transfer(t *testing.T) {
+	const (
+		usdt  = "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+		payer = "TSoXbDPwgM1nhfbxVj53u15My8smJHWpFw"
+		shop  = "TKQbPHJ7vaPVc8KuYaQTs2ew6gb64r9xhK"
+	)
+	transferLog := TransactionLog{
+		Address: "a614f803b6fd780986a42c78ec9c7f77e6ded13c",
+		Topics: []string{
+			trc20TransferTopic,
+			"000000000000000000000000b8a57ef5343f88712a4eee91e34290584c2d5998",
+			"000000000000000000000000678637325f9be6b2264db347021432a6a7b84c10",
+		},
+		Data: "00000000000000000000000000000000000000000000000000000000004c4b40",
+	}
+	if transfers := (&TransactionInfo{Log: []TransactionLog{transferLog}}).TRC20Transfers(); len(transfers) != 1 || transfers[0].From != payer {
+		t.Fatalf("期望解析出一笔 Transfer 事件，实际为%+v", transfers)
+	}
+
+	amount, _ := units.ParseAmount("5000000")
+	testCases := []struct {
+		description string
+		info        TransactionInfo
+		amount      units.Amount
+		expected    bool
+	}{
+		{"执行成功且有匹配的 Transfer 事件", TransactionInfo{ID: "01", Receipt: TransactionReceipt{Result: "SUCCESS"}, Log: []TransactionLog{transferLog}}, amount, true},
+		{"合约执行回滚", TransactionInfo{ID: "01", Result: "FAILED", Receipt: TransactionReceipt{Result: "REVERT"}}, amount, false},
+		{"没有执行结果", TransactionInfo{ID: "01", Log: []TransactionLog{transferLog}}, amount, false},
+		{"没有 Transfer 事件", TransactionInfo{ID: "01", Receipt: TransactionReceipt{Result: "SUCCESS"}}, amount, false},
+		{"到账金额不一致", TransactionInfo{ID: "01", Receipt: TransactionReceipt{Result: "SUCCESS"}, Log: []TransactionLog{transferLog}}, units.NewAmount(1), false},
+		{"回执不存在", TransactionInfo{}, amount, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			if got := tc.info.HasTRC20Transfer(usdt, payer, shop, tc.amount); got != tc.expected {
+				t.Errorf("期望为%v，实际为%v", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package units
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	// TRXDecimals TRX 的小数位数，1 TRX = 10^6 SUN
+	TRXDecimals = 6
+	// SunPerTRX 1 TRX 对应的 SUN
+	SunPerTRX = 1_000_000
+
+	// AmountPrecision webhook_data 中 TRX 金额列 NUMERIC(36,0) 的精度
+	AmountPrecision = 36
+	// TokenAmountPrecision token_amount 列 NUMERIC(78,0) 的精度，可容纳任意 uint256
+	TokenAmountPrecision = 78
+)
+
+var (
+	// ErrInvalidAmount 金额格式错误（不是非负整数）
+	ErrInvalidAmount = errors.New("invalid amount")
+	// ErrOutOfRange 金额超出目标类型或数据库列的范围
+	ErrOutOfRange = errors.New("amount out of range")
+)
+
+// Amount 任意精度的非负整数金额，单位为最小单位（SUN 或代币最小单位）
+// 零值表示 0，运算不修改原值
+type Amount struct {
+	v *big.Int
+}
+
+// NewAmount 由 int64 创建金额，负数按 0 处理
+func NewAmount(value int64) Amount {
+	if value <= 0 {
+		return Amount{}
+	}
+	return Amount{v: big.NewInt(value)}
+}
+
+// ParseAmount 解析十进制整数字符串，空字符串为 0
+func ParseAmount(value string) (Amount, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Amount{}, nil
+	}
+	return parse(value, 10)
+}
+
+// ParseHexAmount 解析 0x 前缀（可省略）的十六进制整数，"0x" 或空字符串为 0
+func ParseHexAmount(value string) (Amount, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "0x")
+	if value == "" {
+		return Amount{}, nil
+	}
+	return parse(value, 16)
+}
+
+// parse 按进制解析非负整数，不接受符号和小数
+func parse(value string, base int) (Amount, error) {
+	if strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-") {
+		return Amount{}, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+	v, ok := new(big.Int).SetString(value, base)
+	if !ok {
+		return Amount{}, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+	return Amount{v: v}, nil
+}
+
+// int 金额的 big.Int 值，零值返回新的 0
+func (a Amount) int() *big.Int {
+	if a.v == nil {
+		return new(big.Int)
+	}
+	return a.v
+}
+
+// String 十进制字符串
+func (a Amount) String() string {
+	return a.int().String()
+}
+
+// Sign 金额为 0 时返回 0，否则返回 1
+func (a Amount) Sign() int {
+	return a.int().Sign()
+}
+
+// Cmp 比较两个金额，a < b 返回 -1，相等返回 0，a > b 返回 1
+func (a Amount) Cmp(b Amount) int {
+	return a.int().Cmp(b.int())
+}
+
+// Int64 转换为 int64，超出范围时返回 ErrOutOfRange
+func (a Amount) Int64() (int64, error) {
+	v := a.int()
+	if !v.IsInt64() {
+		return 0, fmt.Errorf("%w: %s exceeds int64", ErrOutOfRange, v)
+	}
+	return v.Int64(), nil
+}
+
+// Whole 按小数位数换算为整币数量，不足 1 个单位的部分舍去，如 1500000 SUN → 1 TRX
+func (a Amount) Whole(decimals int) Amount {
+	if a.Sign() == 0 {
+		return Amount{}
+	}
+	return Amount{v: new(big.Int).Quo(a.int(), pow10(decimals))}
+}
+
+// Format 按小数位数格式化为整币金额，保留全部小数位，如 Format(6) 将 1500000 格式化为 "1.500000"
+func (a Amount) Format(decimals int) string {
+	if decimals <= 0 {
+		return a.String()
+	}
+	whole, frac := new(big.Int).QuoRem(a.int(), pow10(decimals), new(big.Int))
+	fraction := frac.String()
+	return whole.String() + "." + strings.Repeat("0", decimals-len(fraction)) + fraction
+}
+
+// CheckPrecision 校验金额能写入 NUMERIC(precision,0) 列
+func (a Amount) CheckPrecision(precision int) error {
+	if a.Sign() != 0 && len(a.String()) > precision {
+		return fmt.Errorf("%w: %s exceeds NUMERIC(%d,0)", ErrOutOfRange, a, precision)
+	}
+	return nil
+}
+
+// Value 实现 driver.Valuer，以十进制字符串写入 NUMERIC 列
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+// Scan 实现 sql.Scanner，读取 NUMERIC 列（::TEXT）或整数列，NULL 为 0
+func (a *Amount) Scan(src interface{}) error {
+	switch value := src.(type) {
+	case nil:
+		*a = Amount{}
+		return nil
+	case int64:
+		*a = NewAmount(value)
+		return nil
+	case string:
+		parsed, err := ParseAmount(value)
+		if err != nil {
+			return err
+		}
+		*a = parsed
+		return nil
+	case []byte:
+		return a.Scan(string(value))
+	default:
+		return fmt.Errorf("cannot scan %T into units.Amount", src)
+	}
+}
+
+// FormatSun 将 SUN 金额（十进制字符串）格式化为 TRX，格式错误时返回错误
+func FormatSun(sun string) (string, error) {
+	amount, err := ParseAmount(sun)
+	if err != nil {
+		return "", err
+	}
+	return amount.Format(TRXDecimals), nil
+}
+
+// pow10 10^decimals
+func pow10(decimals int) *big.Int {
+	if decimals <= 0 {
+		return big.NewInt(1)
+	}
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+}
@@ -0,0 +1,112 @@
+package units
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{"", "0", false},
+		{"0", "0", false},
+		{"2500000", "2500000", false},
+		{"115792089237316195423570985008687907853269984665640564039457584007913129639935", "115792089237316195423570985008687907853269984665640564039457584007913129639935", false},
+		{"-1", "", true},
+		{"+1", "", true},
+		{"1.5", "", true},
+		{"abc", "", true},
+	}
+	for _, tc := range testCases {
+		amount, err := ParseAmount(tc.input)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%q: 期望错误为%v，实际为%v", tc.input, tc.wantErr, err)
+			continue
+		}
+		if err == nil && amount.String() != tc.expected {
+			t.Errorf("%q: 期望为%s，实际为%s", tc.input, tc.expected, amount)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("%q: 期望 ErrInvalidAmount，实际为%v", tc.input, err)
+		}
+	}
+}
+
+func TestParseHexAmount(t *testing.T) {
+	testCases := map[string]string{
+		"0x":                 "0",
+		"0x6":                "6",
+		"0x8000000000000000": "9223372036854775808",
+		"0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff": "115792089237316195423570985008687907853269984665640564039457584007913129639935",
+	}
+	for input, expected := range testCases {
+		amount, err := ParseHexAmount(input)
+		if err != nil || amount.String() != expected {
+			t.Errorf("%s: 期望为%s，实际为%s, %v", input, expected, amount, err)
+		}
+	}
+	if _, err := ParseHexAmount("0xzz"); err == nil {
+		t.Error("期望十六进制格式错误时返回错误")
+	}
+}
+
+func TestAmountConversions(t *testing.T) {
+	amount, _ := ParseAmount("1500000")
+	if whole, err := amount.Whole(TRXDecimals).Int64(); err != nil || whole != 1 {
+		t.Errorf("期望1500000 SUN为1 TRX，实际为%d, %v", whole, err)
+	}
+	if formatted := amount.Format(TRXDecimals); formatted != "1.500000" {
+		t.Errorf("期望格式化为1.500000，实际为%s", formatted)
+	}
+	if formatted := NewAmount(42).Format(TRXDecimals); formatted != "0.000042" {
+		t.Errorf("期望格式化为0.000042，实际为%s", formatted)
+	}
+	if formatted := (Amount{}).Format(18); formatted != "0.000000000000000000" {
+		t.Errorf("期望零值格式化为0，实际为%s", formatted)
+	}
+
+	// float64 无法精确表示的大额余额
+	large, _ := ParseAmount("9007199254740993000001")
+	if formatted := large.Format(TRXDecimals); formatted != "9007199254740993.000001" {
+		t.Errorf("期望精确格式化，实际为%s", formatted)
+	}
+	if _, err := large.Int64(); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("期望超出 int64 时返回 ErrOutOfRange，实际为%v", err)
+	}
+	if formatted, err := FormatSun("10000000"); err != nil || formatted != "10.000000" {
+		t.Errorf("期望10000000 SUN为10.000000 TRX，实际为%s, %v", formatted, err)
+	}
+	if NewAmount(-1).Sign() != 0 || NewAmount(2).Cmp(NewAmount(1)) != 1 {
+		t.Error("期望负数按0处理且比较结果正确")
+	}
+}
+
+func TestAmountPrecisionAndScan(t *testing.T) {
+	max, _ := ParseAmount("999999999999999999999999999999999999")
+	if err := max.CheckPrecision(AmountPrecision); err != nil {
+		t.Errorf("期望36位金额可写入 NUMERIC(36,0)，实际为%v", err)
+	}
+	over, _ := ParseAmount("1000000000000000000000000000000000000")
+	if err := over.CheckPrecision(AmountPrecision); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("期望37位金额返回 ErrOutOfRange，实际为%v", err)
+	}
+
+	var amount Amount
+	for src, expected := range map[interface{}]string{nil: "0", int64(7): "7", "123": "123"} {
+		if err := amount.Scan(src); err != nil || amount.String() != expected {
+			t.Errorf("%v: 期望读取为%s，实际为%s, %v", src, expected, amount, err)
+		}
+	}
+	if err := amount.Scan([]byte("456")); err != nil || amount.String() != "456" {
+		t.Errorf("期望读取为456，实际为%s, %v", amount, err)
+	}
+	if err := amount.Scan(1.5); err == nil {
+		t.Error("期望不支持的类型返回错误")
+	}
+	if value, err := max.Value(); err != nil || value != max.String() {
+		t.Errorf("期望以十进制字符串写入，实际为%v, %v", value, err)
+	}
+}
@@ -0,0 +1,116 @@
+package webhook
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"lending-trx/internal/db"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sunjiangjun/xlog"
+)
+
+// ReplayRequest POST /admin/replay 的请求体，时间为 RFC3339 格式
+type ReplayRequest struct {
+	IDs   []int64 `json:"ids"`
+	Since string  `json:"since"`
+	Until string  `json:"until"`
+	Limit int     `json:"limit"`
+}
+
+// AdminTokenFromEnv 管理接口的 Bearer 令牌 ADMIN_AUTH_TOKEN，为空时不注册管理接口
+func AdminTokenFromEnv() string {
+	return strings.TrimSpace(os.Getenv("ADMIN_AUTH_TOKEN"))
+}
+
+// AdminAuthMiddleware 校验管理接口的 Bearer 令牌
+func AdminAuthMiddleware(token string, log *xlog.XLog) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if token == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			log.Warn("Admin request rejected", "module", "webhook", "path", c.Request.URL.Path, "remote", c.ClientIP())
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RegisterAdminRoutes 注册管理接口，token 为空时不注册
+//   - POST /admin/replay: 按推送ID或接收时间重放归档的原始推送
+//   - GET /admin/ingest-queue: 处理队列积压情况
+//   - GET /admin/ingest-queue/:id: 单条推送的处理状态和请求头
+func RegisterAdminRoutes(r *gin.Engine, ctx context.Context, pool *pgxpool.Pool, log *xlog.XLog, receivers []string, token string) {
+	if token == "" {
+		return
+	}
+	l := log.WithField("module", "webhook")
+	ingester := NewIngester(ctx, pool, log, receivers)
+
+	admin := r.Group("/admin", AdminAuthMiddleware(token, log))
+	admin.POST("/replay", func(c *gin.Context) {
+		var req ReplayRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request: " + err.Error()})
+			return
+		}
+		filter, err := ParseArchiveFilter(req.IDs, req.Since, req.Until, req.Limit)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		results, err := ingester.Replay(filter)
+		if err != nil {
+			l.Error("Failed to replay webhook archive", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+			return
+		}
+
+		failed := 0
+		for _, result := range results {
+			if result.Error != "" {
+				failed++
+			}
+		}
+		l.Info("Webhook archive replayed", "replayed", len(results), "failed", failed)
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "replayed": len(results), "failed": failed, "results": results})
+	})
+
+	// 处理队列积压情况
+	admin.GET("/ingest-queue", func(c *gin.Context) {
+		depth, err := db.GetIngestQueueDepth(ctx, pool)
+		if err != nil {
+			l.Error("Failed to get ingest queue depth", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "data": depth})
+	})
+
+	// 单条推送的处理状态
+	admin.GET("/ingest-queue/:id", func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+			return
+		}
+		archive, err := db.GetWebhookArchive(ctx, pool, id)
+		if err != nil {
+			l.Error("Failed to get webhook archive", err, "archive_id", id)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+			return
+		}
+		if archive == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "data": archive})
+	})
+}
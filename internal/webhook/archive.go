@@ -0,0 +1,216 @@
+package webhook
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sunjiangjun/xlog"
+
+	"lending-trx/internal/db"
+)
+
+const (
+	// defaultArchiveRetention 原始推送默认保留30天
+	defaultArchiveRetention = 30 * 24 * time.Hour
+	// archiveCleanupInterval 清理过期推送的间隔
+	archiveCleanupInterval = time.Hour
+	// DefaultReplayLimit 单次重放的默认最大推送数
+	DefaultReplayLimit = 1000
+)
+
+// ErrNoReplaySelector 重放时未指定推送ID或时间范围
+var ErrNoReplaySelector = errors.New("ids, since or until is required")
+
+// archiveExcludedHeaders 不保存的请求头（凭据）
+var archiveExcludedHeaders = map[string]bool{
+	"Authorization": true,
+	"Cookie":        true,
+}
+
+// newArchive 构造原始推送记录：请求体 gzip 压缩，请求头去掉凭据后以 JSON 保存，provider 决定处理时使用的适配器
+func newArchive(provider string, header http.Header, remoteAddr string, body []byte) (*db.WebhookArchive, error) {
+	compressed, err := compressBody(body)
+	if err != nil {
+		return nil, err
+	}
+	headers, err := archiveHeaders(header)
+	if err != nil {
+		return nil, err
+	}
+	return &db.WebhookArchive{
+		Provider:   provider,
+		RemoteAddr: remoteAddr,
+		Headers:    headers,
+		Body:       compressed,
+		BodySize:   len(body),
+	}, nil
+}
+
+// compressBody gzip 压缩请求体
+func compressBody(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(body); err != nil {
+		return nil, fmt.Errorf("failed to compress body: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress body: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// DecompressBody 解压归档的请求体
+func DecompressBody(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress body: %w", err)
+	}
+	defer zr.Close()
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress body: %w", err)
+	}
+	return body, nil
+}
+
+// archiveHeaders 将请求头序列化为 JSON，去掉 archiveExcludedHeaders
+func archiveHeaders(header http.Header) (json.RawMessage, error) {
+	kept := make(http.Header, len(header))
+	for name, values := range header {
+		if archiveExcludedHeaders[http.CanonicalHeaderKey(name)] {
+			continue
+		}
+		kept[name] = values
+	}
+	data, err := json.Marshal(kept)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal headers: %w", err)
+	}
+	return data, nil
+}
+
+// ArchiveRetentionFromEnv 原始推送保留时长 WEBHOOK_ARCHIVE_RETENTION，默认 720h（30天），0 表示永久保留
+func ArchiveRetentionFromEnv() (time.Duration, error) {
+	value := os.Getenv("WEBHOOK_ARCHIVE_RETENTION")
+	if value == "" {
+		return defaultArchiveRetention, nil
+	}
+	retention, err := time.ParseDuration(value)
+	if err != nil || retention < 0 {
+		return 0, fmt.Errorf("invalid WEBHOOK_ARCHIVE_RETENTION %q", value)
+	}
+	return retention, nil
+}
+
+// StartArchiveCleanup 从环境变量读取保留时长并在后台定期删除过期且已处理完成的原始推送，永久保留时不启动
+func StartArchiveCleanup(ctx context.Context, pool *pgxpool.Pool, log *xlog.XLog) error {
+	retention, err := ArchiveRetentionFromEnv()
+	if err != nil {
+		return err
+	}
+	if retention == 0 {
+		log.Info("Webhook archive retention disabled, payloads are kept forever")
+		return nil
+	}
+	log.Info("Webhook archive cleanup started", "retention", retention.String())
+
+	go func() {
+		ticker := time.NewTicker(archiveCleanupInterval)
+		defer ticker.Stop()
+		for {
+			deleted, err := db.DeleteWebhookArchivesBefore(ctx, pool, time.Now().Add(-retention))
+			if err != nil {
+				log.Error("Failed to clean up webhook archive", err)
+			} else if deleted > 0 {
+				log.Info("Webhook archive cleaned up", "deleted", deleted)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// ParseArchiveFilter 解析重放条件，时间为 RFC3339 格式，必须指定推送ID或时间范围，limit 不大于0时使用 DefaultReplayLimit
+func ParseArchiveFilter(ids []int64, since, until string, limit int) (db.ArchiveFilter, error) {
+	filter := db.ArchiveFilter{IDs: ids, Limit: limit}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultReplayLimit
+	}
+	var err error
+	if since = strings.TrimSpace(since); since != "" {
+		if filter.Since, err = time.Parse(time.RFC3339, since); err != nil {
+			return db.ArchiveFilter{}, fmt.Errorf("invalid since %q: %w", since, err)
+		}
+	}
+	if until = strings.TrimSpace(until); until != "" {
+		if filter.Until, err = time.Parse(time.RFC3339, until); err != nil {
+			return db.ArchiveFilter{}, fmt.Errorf("invalid until %q: %w", until, err)
+		}
+	}
+	if len(filter.IDs) == 0 && filter.Since.IsZero() && filter.Until.IsZero() {
+		return db.ArchiveFilter{}, ErrNoReplaySelector
+	}
+	return filter, nil
+}
+
+// ReplayResult 一条原始推送的重放结果
+type ReplayResult struct {
+	ArchiveID  int64      `json:"archive_id"`
+	Inserted   int        `json:"inserted_count"`
+	Duplicates int        `json:"duplicate_count"`
+	Stats      BatchStats `json:"stats"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// Replay 按条件重新处理归档的原始推送，单条失败记录在结果中并继续处理下一条
+// 支付按 tx_hash、批次按区块范围去重，重复重放不会产生重复记录
+func (in *Ingester) Replay(filter db.ArchiveFilter) ([]ReplayResult, error) {
+	archives, err := db.ListWebhookArchives(in.ctx, in.pool, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ReplayResult, 0, len(archives))
+	for _, archive := range archives {
+		result := ReplayResult{ArchiveID: archive.ID}
+		ingested, err := in.replayOne(archive)
+		if err != nil {
+			in.log.Error("Webhook replay failed", err, "module", "webhook", "archive_id", archive.ID)
+			result.Error = err.Error()
+		} else {
+			result.Inserted, result.Duplicates, result.Stats = ingested.Inserted, ingested.Duplicates, ingested.Stats
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// replayOne 解压并重新处理一条原始推送，成功后记录重放次数
+func (in *Ingester) replayOne(archive *db.WebhookArchive) (*IngestResult, error) {
+	body, err := DecompressBody(archive.Body)
+	if err != nil {
+		return nil, err
+	}
+	result, err := in.Ingest(archive.Provider, body)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.MarkWebhookArchiveReplayed(in.ctx, in.pool, archive.ID); err != nil {
+		in.log.Error("Failed to mark webhook archive replayed", err, "module", "webhook", "archive_id", archive.ID)
+	}
+	return result, nil
+}
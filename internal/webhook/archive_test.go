@@ -0,0 +1,145 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sunjiangjun/xlog"
+)
+
+func TestCompressBody(t *testing.T) {
+	body := []byte(strings.Repeat(`{"data":[],"metadata":{"stream_id":"s1"}}`, 100))
+	compressed, err := compressBody(body)
+	if err != nil {
+		t.Fatalf("compressBody failed: %v", err)
+	}
+	if len(compressed) >= len(body) {
+		t.Errorf("Expected compressed size below %d, got %d", len(body), len(compressed))
+	}
+	restored, err := DecompressBody(compressed)
+	if err != nil || string(restored) != string(body) {
+		t.Errorf("Expected body to round-trip, got %d bytes, %v", len(restored), err)
+	}
+	if _, err := DecompressBody(body); err == nil {
+		t.Error("Expected error for uncompressed data")
+	}
+}
+
+func TestArchiveHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set(HeaderSignature, "abc")
+	header.Set("Authorization", "Bearer secret")
+	header.Set("Cookie", "session=1")
+
+	data, err := archiveHeaders(header)
+	if err != nil {
+		t.Fatalf("archiveHeaders failed: %v", err)
+	}
+	var kept http.Header
+	if err := json.Unmarshal(data, &kept); err != nil {
+		t.Fatalf("Expected JSON headers, got %s", data)
+	}
+	if kept.Get("Content-Type") != "application/json" || kept.Get(HeaderSignature) != "abc" {
+		t.Errorf("Expected content type and signature to be kept, got %s", data)
+	}
+	if kept.Get("Authorization") != "" || kept.Get("Cookie") != "" {
+		t.Errorf("Expected credentials to be dropped, got %s", data)
+	}
+}
+
+func TestArchiveRetentionFromEnv(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{"", defaultArchiveRetention, false},
+		{"0", 0, false},
+		{"168h", 168 * time.Hour, false},
+		{"-1h", 0, true},
+		{"week", 0, true},
+	}
+	for _, tc := range testCases {
+		t.Setenv("WEBHOOK_ARCHIVE_RETENTION", tc.value)
+		retention, err := ArchiveRetentionFromEnv()
+		if (err != nil) != tc.wantErr || retention != tc.expected {
+			t.Errorf("%q: expected %v (error %v), got %v, %v", tc.value, tc.expected, tc.wantErr, retention, err)
+		}
+	}
+}
+
+func TestParseArchiveFilter(t *testing.T) {
+	if _, err := ParseArchiveFilter(nil, "", "", 0); !errors.Is(err, ErrNoReplaySelector) {
+		t.Errorf("Expected ErrNoReplaySelector without ids or time range, got %v", err)
+	}
+	if _, err := ParseArchiveFilter(nil, "yesterday", "", 0); err == nil {
+		t.Error("Expected error for invalid since")
+	}
+
+	filter, err := ParseArchiveFilter([]int64{3, 5}, "", "", 0)
+	if err != nil || len(filter.IDs) != 2 || filter.Limit != DefaultReplayLimit {
+		t.Errorf("Expected ids with default limit, got %+v, %v", filter, err)
+	}
+
+	filter, err = ParseArchiveFilter(nil, "2026-01-01T00:00:00Z", "2026-01-02T00:00:00Z", 10)
+	if err != nil || filter.Limit != 10 || filter.Until.Sub(filter.Since) != 24*time.Hour {
+		t.Errorf("Expected one day range with limit 10, got %+v, %v", filter, err)
+	}
+}
+
+func TestRegisterAdminRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	RegisterAdminRoutes(r, context.Background(), nil, xlog.NewXLogger(), nil, "")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/replay", strings.NewReader(`{"ids":[1]}`)))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected admin routes to be disabled without token, got %d", w.Code)
+	}
+
+	r = gin.New()
+	RegisterAdminRoutes(r, context.Background(), nil, xlog.NewXLogger(), nil, "admin-token")
+	for _, auth := range []string{"", "Bearer wrong", "admin-token-x"} {
+		req := httptest.NewRequest(http.MethodPost, "/admin/replay", strings.NewReader(`{"ids":[1]}`))
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%q: expected 401, got %d", auth, w.Code)
+		}
+	}
+	for _, path := range []string{"/admin/ingest-queue", "/admin/ingest-queue/1"} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected 401 without token, got %d", path, w.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/ingest-queue/abc", nil)
+	req.Header.Set("Authorization", "Bearer admin-token")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid archive id, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/admin/replay", strings.NewReader(`{}`))
+	req.Header.Set("Authorization", "Bearer admin-token")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without replay selector, got %d", w.Code)
+	}
+}
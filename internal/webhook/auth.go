@@ -1,29 +1,335 @@
 package webhook
 
 import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sunjiangjun/xlog"
 )
 
-var authToken = "mysecrettoken"
+// stream 推送的签名头（QuickNode Streams）
+// 签名为 hex(HMAC-SHA256(secret, nonce + timestamp + body))
+const (
+	HeaderNonce     = "X-QN-Nonce"
+	HeaderTimestamp = "X-QN-Timestamp"
+	HeaderSignature = "X-QN-Signature"
+)
+
+// HeaderAuthorization 共享令牌推送的认证头，格式为 "Bearer <token>"
+const HeaderAuthorization = "Authorization"
+
+// defaultMaxSkew 推送时间戳与本地时间允许的最大偏差
+const defaultMaxSkew = 5 * time.Minute
+
+// 签名校验失败的原因，均返回 401
+var (
+	ErrMissingSignature = errors.New("missing signature headers")
+	ErrStaleTimestamp   = errors.New("timestamp outside allowed window")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrReplayedNonce    = errors.New("nonce already used")
+	ErrMissingToken     = errors.New("missing bearer token")
+	ErrInvalidToken     = errors.New("invalid bearer token")
+	ErrProviderDisabled = errors.New("no credentials configured for provider")
+)
+
+// RequestVerifier 校验推送请求的来源，失败时返回 401
+// 校验通过时返回 release：推送未能持久化时调用，释放请求占用的 nonce 等一次性凭据，使推送方可以原样重试
+type RequestVerifier interface {
+	Verify(header http.Header, body []byte) (release func(), err error)
+}
+
+// noRelease 不占用一次性凭据的校验方式返回的 release
+func noRelease() {}
+
+// AuthConfig webhook 签名校验配置
+type AuthConfig struct {
+	Secrets []string      // 有效的签名密钥，轮换期间新旧密钥同时有效
+	MaxSkew time.Duration // 时间戳允许的最大偏差，超出视为过期（重放）
+}
+
+// AuthConfigFromEnv 从环境变量读取签名校验配置，未配置密钥时返回错误
+//   - WEBHOOK_SECRETS: 签名密钥，逗号分隔（stream 的 security token）
+//   - WEBHOOK_AUTH_TOKEN: 未配置 WEBHOOK_SECRETS 时作为唯一密钥（兼容旧配置）
+//   - WEBHOOK_MAX_SKEW: 时间戳允许的最大偏差，默认 5m
+func AuthConfigFromEnv() (AuthConfig, error) {
+	config := AuthConfig{MaxSkew: defaultMaxSkew}
+
+	raw := os.Getenv("WEBHOOK_SECRETS")
+	if strings.TrimSpace(raw) == "" {
+		raw = os.Getenv("WEBHOOK_AUTH_TOKEN")
+	}
+	config.Secrets = splitSecrets(raw)
+	if len(config.Secrets) == 0 {
+		return AuthConfig{}, fmt.Errorf("WEBHOOK_SECRETS is required to verify webhook signatures")
+	}
+
+	if value := os.Getenv("WEBHOOK_MAX_SKEW"); value != "" {
+		skew, err := time.ParseDuration(value)
+		if err != nil || skew <= 0 {
+			return AuthConfig{}, fmt.Errorf("invalid WEBHOOK_MAX_SKEW %q", value)
+		}
+		config.MaxSkew = skew
+	}
+	return config, nil
+}
+
+// Verifier 校验 webhook 推送的 HMAC 签名、时间戳和 nonce
+// 已使用的 nonce 保存在内存中，多实例部署时各实例分别去重，重复推送由 tx_hash 唯一约束兜底
+type Verifier struct {
+	secrets [][]byte
+	maxSkew time.Duration
+	now     func() time.Time
+
+	mu     sync.Mutex
+	nonces map[string]time.Time // nonce -> 过期时间
+	expiry []usedNonce          // 按过期时间排序的已使用 nonce，过期后从队首清理
+}
+
+// usedNonce 已使用的 nonce 及其过期时间
+type usedNonce struct {
+	nonce  string
+	expire time.Time
+}
+
+// NewVerifier 创建签名校验器
+func NewVerifier(config AuthConfig) *Verifier {
+	v := &Verifier{
+		maxSkew: config.MaxSkew,
+		now:     time.Now,
+		nonces:  make(map[string]time.Time),
+	}
+	if v.maxSkew <= 0 {
+		v.maxSkew = defaultMaxSkew
+	}
+	for _, secret := range config.Secrets {
+		v.secrets = append(v.secrets, []byte(secret))
+	}
+	return v
+}
 
-func init() {
-	if v := os.Getenv("WEBHOOK_AUTH_TOKEN"); v != "" {
-		authToken = v
+// NewVerifierFromEnv 从环境变量创建签名校验器，未配置密钥时返回错误，服务不应启动
+func NewVerifierFromEnv() (*Verifier, error) {
+	config, err := AuthConfigFromEnv()
+	if err != nil {
+		return nil, err
 	}
+	return NewVerifier(config), nil
 }
 
-// AuthMiddleware gin 鉴权中间件
-func AuthMiddleware() gin.HandlerFunc {
+// Sign 计算签名 hex(HMAC-SHA256(secret, nonce + timestamp + body))
+func Sign(secret, nonce, timestamp string, body []byte) string {
+	return hex.EncodeToString(signature([]byte(secret), nonce, timestamp, body))
+}
+
+// signature HMAC-SHA256(secret, nonce + timestamp + body)
+func signature(secret []byte, nonce, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(nonce))
+	mac.Write([]byte(timestamp))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// Verify 校验请求签名：时间戳在允许范围内，签名与任一密钥匹配（常量时间比较），nonce 未使用过
+// 签名有效后才记录 nonce，伪造的请求不会占用 nonce；返回的 release 删除该 nonce
+func (v *Verifier) Verify(header http.Header, body []byte) (func(), error) {
+	nonce := header.Get(HeaderNonce)
+	timestamp := header.Get(HeaderTimestamp)
+	signed := header.Get(HeaderSignature)
+	if nonce == "" || timestamp == "" || signed == "" {
+		return nil, ErrMissingSignature
+	}
+
+	sent, err := parseTimestamp(timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStaleTimestamp, err)
+	}
+	now := v.now()
+	if skew := now.Sub(sent); skew > v.maxSkew || skew < -v.maxSkew {
+		return nil, ErrStaleTimestamp
+	}
+
+	given, err := hex.DecodeString(strings.TrimPrefix(signed, "0x"))
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	matched := false
+	for _, secret := range v.secrets {
+		// 不提前退出，各密钥的比较耗时相同
+		if hmac.Equal(signature(secret, nonce, timestamp, body), given) {
+			matched = true
+		}
+	}
+	if !matched {
+		return nil, ErrInvalidSignature
+	}
+
+	if err := v.useNonce(nonce, now); err != nil {
+		return nil, err
+	}
+	return func() { v.releaseNonce(nonce) }, nil
+}
+
+// useNonce 记录 nonce，已使用过时返回 ErrReplayedNonce
+// nonce 保留到时间窗口结束，之后的重放会因时间戳过期被拒绝；过期时间按记录顺序递增，只需从队首清理
+func (v *Verifier) useNonce(nonce string, now time.Time) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for len(v.expiry) > 0 && now.After(v.expiry[0].expire) {
+		// 释放后重新使用的 nonce 有新的过期时间，只删除与队首记录一致的
+		if v.nonces[v.expiry[0].nonce] == v.expiry[0].expire {
+			delete(v.nonces, v.expiry[0].nonce)
+		}
+		v.expiry = v.expiry[1:]
+	}
+
+	if _, ok := v.nonces[nonce]; ok {
+		return ErrReplayedNonce
+	}
+	expire := now.Add(2 * v.maxSkew)
+	v.nonces[nonce] = expire
+	v.expiry = append(v.expiry, usedNonce{nonce: nonce, expire: expire})
+	return nil
+}
+
+// releaseNonce 删除已记录的 nonce，队列中的记录到期时按过期时间忽略
+func (v *Verifier) releaseNonce(nonce string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.nonces, nonce)
+}
+
+// TokenVerifier 校验共享令牌：请求头 Authorization: Bearer <token> 与任一令牌匹配（常量时间比较）
+// 用于不提供签名的数据源（TronGrid 事件、节点区块推送），推送方或转发服务配置为携带该请求头
+type TokenVerifier struct {
+	tokens [][]byte
+}
+
+// NewTokenVerifier 创建共享令牌校验器，轮换期间新旧令牌同时有效
+func NewTokenVerifier(tokens []string) *TokenVerifier {
+	v := &TokenVerifier{}
+	for _, token := range tokens {
+		v.tokens = append(v.tokens, []byte(token))
+	}
+	return v
+}
+
+// Verify 校验 Authorization 请求头中的令牌，令牌可以重复使用，release 为空操作
+func (v *TokenVerifier) Verify(header http.Header, body []byte) (func(), error) {
+	scheme, token, ok := strings.Cut(header.Get(HeaderAuthorization), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return nil, ErrMissingToken
+	}
+	given := []byte(strings.TrimSpace(token))
+	matched := false
+	for _, expected := range v.tokens {
+		// 不提前退出，各令牌的比较耗时相同
+		if subtle.ConstantTimeCompare(expected, given) == 1 {
+			matched = true
+		}
+	}
+	if !matched {
+		return nil, ErrInvalidToken
+	}
+	return noRelease, nil
+}
+
+// ProviderAuth 各数据源的推送校验方式，QuickNode 校验 HMAC 签名，其他数据源校验共享令牌
+// 未配置的数据源拒绝所有推送
+type ProviderAuth map[string]RequestVerifier
+
+// ProviderAuthFromEnv 从环境变量读取各数据源的校验配置
+//   - QuickNode: 见 AuthConfigFromEnv，未配置密钥时返回错误
+//   - WEBHOOK_TRONGRID_TOKENS: TronGrid 事件推送的共享令牌，逗号分隔，未配置时不接受该数据源
+//   - WEBHOOK_BLOCK_TOKENS: 节点区块推送的共享令牌，逗号分隔，未配置时不接受该数据源
+func ProviderAuthFromEnv() (ProviderAuth, error) {
+	verifier, err := NewVerifierFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	auth := ProviderAuth{ProviderQuickNode: verifier}
+	for provider, env := range map[string]string{
+		ProviderTronGrid: "WEBHOOK_TRONGRID_TOKENS",
+		ProviderBlock:    "WEBHOOK_BLOCK_TOKENS",
+	} {
+		if tokens := splitSecrets(os.Getenv(env)); len(tokens) > 0 {
+			auth[provider] = NewTokenVerifier(tokens)
+		}
+	}
+	return auth, nil
+}
+
+// splitSecrets 拆分逗号分隔的密钥或令牌，忽略空项
+func splitSecrets(raw string) []string {
+	var secrets []string
+	for _, secret := range strings.Split(raw, ",") {
+		if secret = strings.TrimSpace(secret); secret != "" {
+			secrets = append(secrets, secret)
+		}
+	}
+	return secrets
+}
+
+// Verify 按数据源选择校验方式，空数据源为 ProviderQuickNode，未配置的数据源返回 ErrProviderDisabled
+func (a ProviderAuth) Verify(provider string, header http.Header, body []byte) (func(), error) {
+	if provider == "" {
+		provider = ProviderQuickNode
+	}
+	verifier, ok := a[strings.ToLower(provider)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrProviderDisabled, provider)
+	}
+	return verifier.Verify(header, body)
+}
+
+// parseTimestamp 解析 Unix 时间戳，兼容秒和毫秒
+func parseTimestamp(value string) (time.Time, error) {
+	ts, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q", value)
+	}
+	if ts > 1e12 {
+		return time.UnixMilli(ts), nil
+	}
+	return time.Unix(ts, 0), nil
+}
+
+// AuthMiddleware gin 鉴权中间件，按路由的 :provider 参数（POST /webhook 为 QuickNode）选择校验方式，
+// 校验通过后将请求体放回供后续处理读取；后续处理返回 5xx（推送未保存）时释放 nonce，推送方用同一 nonce 重试不会被拒绝
+func AuthMiddleware(auth ProviderAuth, log *xlog.XLog) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		token := c.GetHeader("X-Auth-Token")
-		if token != authToken {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "read body failed"})
+			c.Abort()
+			return
+		}
+		provider := c.Param("provider")
+		release, err := auth.Verify(provider, c.Request.Header, body)
+		if err != nil {
+			log.Warn("Webhook signature rejected", "module", "webhook", "provider", provider, "reason", err.Error(), "nonce", c.GetHeader(HeaderNonce), "remote", c.ClientIP())
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 			c.Abort()
 			return
 		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
 		c.Next()
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			release()
+		}
 	}
 }
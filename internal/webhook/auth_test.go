@@ -0,0 +1,254 @@
+package webhook
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sunjiangjun/xlog"
+)
+
+func signedHeader(secret, nonce string, ts time.Time, body []byte) http.Header {
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+	header := http.Header{}
+	header.Set(HeaderNonce, nonce)
+	header.Set(HeaderTimestamp, timestamp)
+	header.Set(HeaderSignature, Sign(secret, nonce, timestamp, body))
+	return header
+}
+
+func TestVerifierVerify(t *testing.T) {
+	now := time.Unix(1753271856, 0)
+	verifier := NewVerifier(AuthConfig{Secrets: []string{"new-secret", "old-secret"}, MaxSkew: time.Minute})
+	verifier.now = func() time.Time { return now }
+	body := []byte(`{"data":[]}`)
+
+	testCases := []struct {
+		description string
+		header      http.Header
+		body        []byte
+		expected    error
+	}{
+		{"current secret", signedHeader("new-secret", "n1", now, body), body, nil},
+		{"previous secret during rotation", signedHeader("old-secret", "n2", now.Add(-30*time.Second), body), body, nil},
+		{"replayed nonce", signedHeader("new-secret", "n1", now, body), body, ErrReplayedNonce},
+		{"unknown secret", signedHeader("other-secret", "n3", now, body), body, ErrInvalidSignature},
+		{"tampered body", signedHeader("new-secret", "n4", now, body), []byte(`{"data":[{}]}`), ErrInvalidSignature},
+		{"stale timestamp", signedHeader("new-secret", "n5", now.Add(-2*time.Minute), body), body, ErrStaleTimestamp},
+		{"future timestamp", signedHeader("new-secret", "n6", now.Add(2*time.Minute), body), body, ErrStaleTimestamp},
+		{"missing headers", http.Header{}, body, ErrMissingSignature},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			_, err := verifier.Verify(tc.header, tc.body)
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+
+	// 签名无效的请求不占用 nonce
+	if _, err := verifier.Verify(signedHeader("new-secret", "n3", now, body), body); err != nil {
+		t.Errorf("Expected nonce of rejected request to remain usable, got %v", err)
+	}
+
+	// 时间窗口结束后清理已使用的 nonce
+	now = now.Add(3 * time.Minute)
+	verifier.Verify(signedHeader("new-secret", "n7", now, body), body)
+	if len(verifier.nonces) != 1 || len(verifier.expiry) != 1 || verifier.expiry[0].nonce != "n7" {
+		t.Errorf("Expected expired nonces to be pruned, got %d entries, queue %+v", len(verifier.nonces), verifier.expiry)
+	}
+}
+
+func TestVerifierRelease(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	verifier := NewVerifier(AuthConfig{Secrets: []string{"secret"}, MaxSkew: time.Minute})
+	verifier.now = func() time.Time { return now }
+	body := []byte(`{"data":[]}`)
+
+	release, err := verifier.Verify(signedHeader("secret", "n1", now, body), body)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	release()
+
+	// 释放后同一 nonce 可以重试，重试成功后再次占用
+	now = now.Add(30 * time.Second)
+	if _, err := verifier.Verify(signedHeader("secret", "n1", now, body), body); err != nil {
+		t.Fatalf("Expected released nonce to be accepted, got %v", err)
+	}
+	if _, err := verifier.Verify(signedHeader("secret", "n1", now, body), body); !errors.Is(err, ErrReplayedNonce) {
+		t.Errorf("Expected %v, got %v", ErrReplayedNonce, err)
+	}
+
+	// 第一次记录到期时不删除重试记录的 nonce
+	now = now.Add(100 * time.Second)
+	if _, err := verifier.Verify(signedHeader("secret", "n1", now, body), body); !errors.Is(err, ErrReplayedNonce) {
+		t.Errorf("Expected retried nonce to stay recorded, got %v", err)
+	}
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	auth := ProviderAuth{
+		ProviderQuickNode: NewVerifier(AuthConfig{Secrets: []string{"secret"}}),
+		ProviderTronGrid:  NewTokenVerifier([]string{"trongrid-token"}),
+	}
+	r := gin.New()
+	handler := func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	}
+	r.POST("/webhook", AuthMiddleware(auth, xlog.NewXLogger()), handler)
+	r.POST("/webhook/:provider", AuthMiddleware(auth, xlog.NewXLogger()), handler)
+
+	body := `{"data":[]}`
+	bearer := func(token string) http.Header {
+		header := http.Header{}
+		header.Set("Content-Type", "application/json")
+		header.Set("Authorization", "Bearer "+token)
+		return header
+	}
+	testCases := []struct {
+		description string
+		path        string
+		header      http.Header
+		expected    int
+	}{
+		{"quicknode signature", "/webhook", signedHeader("secret", "n1", time.Now(), []byte(body)), http.StatusOK},
+		{"quicknode signature on provider route", "/webhook/quicknode", signedHeader("secret", "n2", time.Now(), []byte(body)), http.StatusOK},
+		{"trongrid bearer token", "/webhook/trongrid", bearer("trongrid-token"), http.StatusOK},
+		{"trongrid wrong token", "/webhook/trongrid", bearer("other-token"), http.StatusUnauthorized},
+		{"quicknode signature on trongrid route", "/webhook/trongrid", signedHeader("secret", "n3", time.Now(), []byte(body)), http.StatusUnauthorized},
+		{"trongrid token on quicknode route", "/webhook", bearer("trongrid-token"), http.StatusUnauthorized},
+		{"block provider without tokens", "/webhook/block", bearer("trongrid-token"), http.StatusUnauthorized},
+		{"static token header", "/webhook", http.Header{"X-Auth-Token": []string{"secret"}}, http.StatusUnauthorized},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(body))
+			req.Header = tc.header
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tc.expected {
+				t.Fatalf("Expected %d, got %d", tc.expected, w.Code)
+			}
+			if tc.expected == http.StatusOK && w.Body.String() != body {
+				t.Errorf("Expected body passed through, got %q", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareReleasesNonceOnServerError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	auth := ProviderAuth{ProviderQuickNode: NewVerifier(AuthConfig{Secrets: []string{"secret"}})}
+	status := http.StatusInternalServerError
+	r := gin.New()
+	r.POST("/webhook", AuthMiddleware(auth, xlog.NewXLogger()), func(c *gin.Context) {
+		c.Status(status)
+	})
+
+	body := `{"data":[]}`
+	header := signedHeader("secret", "n1", time.Now(), []byte(body))
+	send := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+		req.Header = header
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := send(); code != http.StatusInternalServerError {
+		t.Fatalf("Expected %d, got %d", http.StatusInternalServerError, code)
+	}
+	// 推送未保存，推送方用同一 nonce 重试
+	status = http.StatusAccepted
+	if code := send(); code != http.StatusAccepted {
+		t.Fatalf("Expected retry after server error to be accepted, got %d", code)
+	}
+	// 推送已保存，同一 nonce 为重放
+	if code := send(); code != http.StatusUnauthorized {
+		t.Errorf("Expected replay after accepted push to be rejected, got %d", code)
+	}
+}
+
+func TestTokenVerifier(t *testing.T) {
+	verifier := NewTokenVerifier([]string{"new-token", "old-token"})
+	testCases := []struct {
+		description   string
+		authorization string
+		expected      error
+	}{
+		{"current token", "Bearer new-token", nil},
+		{"previous token during rotation", "bearer old-token", nil},
+		{"unknown token", "Bearer other-token", ErrInvalidToken},
+		{"basic auth", "Basic bmV3LXRva2Vu", ErrMissingToken},
+		{"empty token", "Bearer ", ErrMissingToken},
+		{"missing header", "", ErrMissingToken},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			header := http.Header{}
+			if tc.authorization != "" {
+				header.Set("Authorization", tc.authorization)
+			}
+			if _, err := verifier.Verify(header, nil); !errors.Is(err, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestProviderAuthFromEnv(t *testing.T) {
+	t.Setenv("WEBHOOK_SECRETS", "")
+	t.Setenv("WEBHOOK_AUTH_TOKEN", "")
+	t.Setenv("WEBHOOK_TRONGRID_TOKENS", "tg")
+	t.Setenv("WEBHOOK_BLOCK_TOKENS", "")
+	if _, err := ProviderAuthFromEnv(); err == nil {
+		t.Error("Expected error when no QuickNode secret is configured")
+	}
+
+	t.Setenv("WEBHOOK_SECRETS", "secret")
+	auth, err := ProviderAuthFromEnv()
+	if err != nil {
+		t.Fatalf("ProviderAuthFromEnv failed: %v", err)
+	}
+	if _, ok := auth[ProviderQuickNode]; !ok {
+		t.Error("Expected QuickNode verifier")
+	}
+	if _, err := auth.Verify("TronGrid", http.Header{"Authorization": []string{"Bearer tg"}}, nil); err != nil {
+		t.Errorf("Expected TronGrid token to be accepted, got %v", err)
+	}
+	if _, err := auth.Verify(ProviderBlock, http.Header{"Authorization": []string{"Bearer tg"}}, nil); !errors.Is(err, ErrProviderDisabled) {
+		t.Errorf("Expected ErrProviderDisabled for block provider without tokens, got %v", err)
+	}
+}
+
+func TestAuthConfigFromEnv(t *testing.T) {
+	t.Setenv("WEBHOOK_SECRETS", "")
+	t.Setenv("WEBHOOK_AUTH_TOKEN", "")
+	t.Setenv("WEBHOOK_MAX_SKEW", "")
+	if _, err := AuthConfigFromEnv(); err == nil {
+		t.Error("Expected error when no secret is configured")
+	}
+
+	t.Setenv("WEBHOOK_AUTH_TOKEN", "legacy")
+	config, err := AuthConfigFromEnv()
+	if err != nil || len(config.Secrets) != 1 || config.Secrets[0] != "legacy" || config.MaxSkew != defaultMaxSkew {
+		t.Errorf("Expected legacy token as the only secret, got %+v, %v", config, err)
+	}
+
+	t.Setenv("WEBHOOK_SECRETS", "a, b")
+	t.Setenv("WEBHOOK_MAX_SKEW", "30s")
+	config, err = AuthConfigFromEnv()
+	if err != nil || len(config.Secrets) != 2 || config.Secrets[1] != "b" || config.MaxSkew != 30*time.Second {
+		t.Errorf("Expected secrets [a b] with 30s skew, got %+v, %v", config, err)
+	}
+}
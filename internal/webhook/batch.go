@@ -0,0 +1,22 @@
+package webhook
+
+import (
+	"lending-trx/internal/db"
+)
+
+// streamBatch 推送的批次记录，paymentCount 为与批次一起写入的支付数（含待核实的 TRC20 转账），与补齐任务一致；
+// metadata 没有 stream_id 或批次范围时返回 nil（不记录、不检查缺失）
+func streamBatch(metadata Metadata, stats BatchStats, paymentCount int) *db.StreamBatch {
+	if metadata.StreamID == "" || metadata.BatchStartRange <= 0 || metadata.BatchEndRange < metadata.BatchStartRange {
+		return nil
+	}
+	return &db.StreamBatch{
+		StreamID:     metadata.StreamID,
+		Network:      metadata.Network,
+		StartBlock:   metadata.BatchStartRange,
+		EndBlock:     metadata.BatchEndRange,
+		TxCount:      stats.Total,
+		PaymentCount: paymentCount,
+		Source:       db.BatchSourceStream,
+	}
+}
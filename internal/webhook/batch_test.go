@@ -0,0 +1,26 @@
+package webhook
+
+import (
+	"testing"
+
+	"lending-trx/internal/db"
+)
+
+func TestStreamBatch(t *testing.T) {
+	metadata := Metadata{BatchStartRange: 74204442, BatchEndRange: 74204443, StreamID: "stream-1", Network: "tron-mainnet"}
+	// 待核实的 TRC20 转账同样写入，计入支付数
+	batch := streamBatch(metadata, BatchStats{Total: 42, TRXPayments: 1, TokenPayments: 2, Unverified: 1, Irrelevant: 38}, 4)
+	if batch == nil {
+		t.Fatal("Expected batch to be recorded")
+	}
+	if batch.StreamID != "stream-1" || batch.StartBlock != 74204442 || batch.EndBlock != 74204443 || batch.TxCount != 42 || batch.PaymentCount != 4 || batch.Source != db.BatchSourceStream {
+		t.Errorf("Unexpected batch %+v", batch)
+	}
+
+	if streamBatch(Metadata{BatchStartRange: 1, BatchEndRange: 1}, BatchStats{}, 0) != nil {
+		t.Error("Expected no batch without stream_id")
+	}
+	if streamBatch(Metadata{StreamID: "stream-1", BatchStartRange: 5, BatchEndRange: 4}, BatchStats{}, 0) != nil {
+		t.Error("Expected no batch for invalid range")
+	}
+}
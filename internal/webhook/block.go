@@ -0,0 +1,106 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"lending-trx/internal/tron"
+)
+
+// BlockTransactions 将区块中执行成功的 TRX 转账和合约调用转换为与 stream webhook 相同的交易数据
+// 区块扫描、缺失区块补齐和 ProviderBlock 推送共用
+func BlockTransactions(block *tron.Block) []TransactionData {
+	var result []TransactionData
+	for _, tx := range block.Transactions {
+		contract := tx.Contract()
+		if contract == nil || !tx.Succeeded() {
+			continue
+		}
+		value := contract.Parameter.Value
+		data := TransactionData{
+			BlockHash:   "0x" + block.BlockID,
+			BlockNumber: hexInt(block.Number()),
+			From:        value.OwnerAddress,
+			Hash:        "0x" + tx.TxID,
+			Input:       "0x",
+			Status:      "0x1", // 只转换执行成功的交易；区块不包含事件日志，TRC20 转账仍需按回执核实
+
+			Timestamp: hexInt(block.Timestamp() / 1000), // stream 推送的时间戳为秒
+			Memo:      tx.RawData.Data,
+		}
+		switch contract.Type {
+		case tron.ContractTransfer:
+			data.To = value.ToAddress
+			data.Value = hexInt(value.Amount)
+		case tron.ContractTriggerSmartContract:
+			data.To = value.ContractAddress
+			data.Input = "0x" + value.Data
+			data.Value = hexInt(value.CallValue)
+		default:
+			continue
+		}
+		result = append(result, data)
+	}
+	return result
+}
+
+// hexInt 将整数编码为 0x 十六进制字符串
+func hexInt(value int64) string {
+	return "0x" + strconv.FormatInt(value, 16)
+}
+
+// blockParser TRON 节点区块格式的推送，接受单个区块、区块数组或 /wallet/getblockbylimitnext 的 {"block": [...]}
+type blockParser struct{}
+
+// Parse 解析区块中的 TransferContract / TriggerSmartContract 交易，批次范围为区块高度范围
+func (blockParser) Parse(body []byte) (*ParsedBatch, error) {
+	blocks, err := decodeBlocks(body)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &ParsedBatch{}
+	for i := range blocks {
+		if !blocks[i].Found() {
+			return nil, fmt.Errorf("block %d has no blockID", i)
+		}
+		number := blocks[i].Number()
+		if batch.Metadata.BatchStartRange == 0 || number < batch.Metadata.BatchStartRange {
+			batch.Metadata.BatchStartRange = number
+		}
+		if number > batch.Metadata.BatchEndRange {
+			batch.Metadata.BatchEndRange = number
+		}
+		batch.Transactions = append(batch.Transactions, streamTransactions(BlockTransactions(&blocks[i]))...)
+	}
+	return batch, nil
+}
+
+// decodeBlocks 按推送的 JSON 形式解码区块列表
+func decodeBlocks(body []byte) ([]tron.Block, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var blocks []tron.Block
+		if err := json.Unmarshal(body, &blocks); err != nil {
+			return nil, err
+		}
+		return blocks, nil
+	}
+
+	var wrapper struct {
+		Block []tron.Block `json:"block"`
+	}
+	if err := json.Unmarshal(body, &wrapper); err != nil {
+		return nil, err
+	}
+	if wrapper.Block != nil {
+		return wrapper.Block, nil
+	}
+	var block tron.Block
+	if err := json.Unmarshal(body, &block); err != nil {
+		return nil, err
+	}
+	return []tron.Block{block}, nil
+}
@@ -0,0 +1,201 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"lending-trx/internal/address"
+	"lending-trx/internal/units"
+)
+
+// ErrNoReceivers 没有配置收款地址
+var ErrNoReceivers = errors.New("no receiving address configured")
+
+// TxClass 交易相对收款地址的分类
+type TxClass string
+
+const (
+	ClassTRXPayment   TxClass = "trx_payment"   // 转入收款地址的 TRX 转账
+	ClassTokenPayment TxClass = "token_payment" // 转入收款地址的 TRC20 转账
+	// ClassUnverified 转入收款地址但推送缺少执行结果或 Transfer 事件的 TRC20 转账，照常记录，委托前按交易回执核实
+	ClassUnverified TxClass = "unverified_token_payment"
+	ClassIrrelevant TxClass = "irrelevant" // 与收款地址无关的交易
+	ClassFailed     TxClass = "failed"     // 执行失败的交易
+	ClassInvalid    TxClass = "invalid"    // 无法解析的交易
+)
+
+// IsPayment 是否为需要记录的支付
+func (c TxClass) IsPayment() bool {
+	return c == ClassTRXPayment || c == ClassTokenPayment || c == ClassUnverified
+}
+
+// BatchStats 一批推送的分类统计，随响应返回并记录日志
+type BatchStats struct {
+	Total         int `json:"total"`
+	TRXPayments   int `json:"trx_payments"`
+	TokenPayments int `json:"token_payments"`
+	Unverified    int `json:"unverified_token_payments"`
+	Irrelevant    int `json:"skipped_irrelevant"`
+	Failed        int `json:"skipped_failed"`
+	Invalid       int `json:"skipped_invalid"`
+}
+
+// Skipped 跳过的交易数
+func (s BatchStats) Skipped() int {
+	return s.Irrelevant + s.Failed + s.Invalid
+}
+
+// add 按分类计数
+func (s *BatchStats) add(class TxClass) {
+	s.Total++
+	switch class {
+	case ClassTRXPayment:
+		s.TRXPayments++
+	case ClassTokenPayment:
+		s.TokenPayments++
+	case ClassUnverified:
+		s.Unverified++
+	case ClassIrrelevant:
+		s.Irrelevant++
+	case ClassFailed:
+		s.Failed++
+	case ClassInvalid:
+		s.Invalid++
+	}
+}
+
+// ReceiversFromEnv 从环境变量读取收款地址，stream webhook 和区块扫描共用
+//   - RECEIVING_ADDRESSES: 收款地址（逗号分隔），未设置时使用 DELEGATION_FROM_ADDRESS
+func ReceiversFromEnv() ([]string, error) {
+	value := os.Getenv("RECEIVING_ADDRESSES")
+	if value == "" {
+		value = os.Getenv("DELEGATION_FROM_ADDRESS")
+	}
+	return ParseReceivers(value)
+}
+
+// ParseReceivers 解析逗号分隔的收款地址，统一为 Base58Check
+func ParseReceivers(value string) ([]string, error) {
+	var receivers []string
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		normalized, err := address.Normalize(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid receiving address %q: %w", entry, err)
+		}
+		receivers = append(receivers, normalized)
+	}
+	if len(receivers) == 0 {
+		return nil, ErrNoReceivers
+	}
+	return receivers, nil
+}
+
+// ClassifyTransaction 解析 stream 格式的交易并按收款地址分类，receivers 为 Base58Check 形式
+// 只有 ClassTRXPayment / ClassTokenPayment 返回的 WebhookData 需要记录；ClassInvalid 时返回解析错误
+func ClassifyTransaction(tx TransactionData, receivers []string) (WebhookData, TxClass, error) {
+	parsed := streamTransactions([]TransactionData{tx})[0]
+	class, err := classifyParsed(parsed, receivers)
+	if class == ClassInvalid || class == ClassFailed {
+		return WebhookData{}, class, err
+	}
+	return parsed.Data, class, nil
+}
+
+// ClassifyBatch 分类一批 stream 格式的交易，返回转入收款地址的支付和分类统计
+func ClassifyBatch(txs []TransactionData, receivers []string) ([]WebhookData, BatchStats) {
+	return ClassifyParsed(streamTransactions(txs), receivers)
+}
+
+// ClassifyParsed 分类适配器解析出的一批交易，返回转入收款地址的支付和分类统计
+// 区块数据集包含整个区块的交易，无法解析的交易不可能是合法支付，跳过而不拒绝整批推送；
+// 金额超出数据库列范围的支付同样计入 Invalid，只返回能写入的支付，避免一笔异常金额导致整批写入失败
+func ClassifyParsed(txs []ParsedTransaction, receivers []string) ([]WebhookData, BatchStats) {
+	var payments []WebhookData
+	var stats BatchStats
+	for _, tx := range txs {
+		class, _ := classifyParsed(tx, receivers)
+		stats.add(class)
+		if class.IsPayment() {
+			payments = append(payments, tx.Data)
+		}
+	}
+	return payments, stats
+}
+
+// classifyParsed 按执行结果和收款地址分类一笔交易，执行失败优先于解析错误；ClassInvalid 时返回原因
+func classifyParsed(tx ParsedTransaction, receivers []string) (TxClass, error) {
+	switch {
+	case tx.Failed:
+		return ClassFailed, tx.Err
+	case tx.Err != nil:
+		return ClassInvalid, tx.Err
+	case !containsAddress(receivers, tx.Data.ToAddress):
+		return ClassIrrelevant, nil
+	}
+	if err := checkAmounts(tx.Data); err != nil {
+		return ClassInvalid, fmt.Errorf("tx %s: %w", tx.Data.TxHash, err)
+	}
+	switch {
+	case tx.Data.TokenContract != "" && tx.Unverified:
+		return ClassUnverified, nil
+	case tx.Data.TokenContract != "":
+		return ClassTokenPayment, nil
+	default:
+		return ClassTRXPayment, nil
+	}
+}
+
+// checkAmounts 校验支付金额能写入 webhook_data 的 NUMERIC 列（value 为 NUMERIC(36,0)，token_amount 为 NUMERIC(78,0)）
+func checkAmounts(data WebhookData) error {
+	value, err := units.ParseAmount(data.Value)
+	if err != nil {
+		return fmt.Errorf("value: %w", err)
+	}
+	if err := value.CheckPrecision(units.AmountPrecision); err != nil {
+		return fmt.Errorf("value: %w", err)
+	}
+	if data.TokenContract == "" {
+		return nil
+	}
+	tokenAmount, err := units.ParseAmount(data.TokenAmount)
+	if err != nil {
+		return fmt.Errorf("token amount: %w", err)
+	}
+	if err := tokenAmount.CheckPrecision(units.TokenAmountPrecision); err != nil {
+		return fmt.Errorf("token amount: %w", err)
+	}
+	return nil
+}
+
+// hasStatus 数据源是否提供了执行结果
+func hasStatus(tx TransactionData) bool {
+	return strings.TrimPrefix(tx.Status, "0x") != ""
+}
+
+// txFailed 数据源提供了执行结果且交易失败（status 为 0x0）；没有执行结果的 TRC20 转账按 ClassUnverified 处理
+func txFailed(tx TransactionData) bool {
+	if !hasStatus(tx) {
+		return false
+	}
+	status, err := hexToInt64(tx.Status)
+	return err == nil && status == 0
+}
+
+// containsAddress addr 是否在 addresses 中，空地址不匹配
+func containsAddress(addresses []string, addr string) bool {
+	if addr == "" {
+		return false
+	}
+	for _, candidate := range addresses {
+		if candidate == addr {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package webhook
+
+import (
+	"errors"
+	"testing"
+
+	"lending-trx/internal/units"
+)
+
+func TestClassifyBatch(t *testing.T) {
+	const receiver = "TKQbPHJ7vaPVc8KuYaQTs2ew6gb64r9xhK"
+	transferToReceiver := "0xa9059cbb" +
+		"000000000000000000000000678637325f9be6b2264db347021432a6a7b84c10" +
+		"00000000000000000000000000000000000000000000000000000000004c4b40"
+	transferToPayer := "0xa9059cbb" +
+		"000000000000000000000000b8a57ef5343f88712a4eee91e34290584c2d5998" +
+		"00000000000000000000000000000000000000000000000000000000004c4b40"
+	base := TransactionData{
+		BlockNumber: "0x46c451a",
+		From:        "0xb8a57ef5343f88712a4eee91e34290584c2d5998",
+		Timestamp:   "0x6880ce30",
+		Input:       "0x",
+	}
+	tx := func(hash, to, value, input, status string) TransactionData {
+		data := base
+		data.Hash, data.To, data.Value, data.Input, data.Status = hash, to, value, input, status
+		return data
+	}
+
+	txs := []TransactionData{
+		tx("0x01", "0x678637325f9be6b2264db347021432a6a7b84c10", "0x6", "0x", "0x1"),            // TRX to receiver
+		tx("0x02", "0xa614f803b6fd780986a42c78ec9c7f77e6ded13c", "0x0", transferToReceiver, ""), // USDT to receiver
+		tx("0x03", "0xa614f803b6fd780986a42c78ec9c7f77e6ded13c", "0x0", transferToPayer, ""),    // USDT elsewhere
+		tx("0x04", "0x678637325f9be6b2264db347021432a6a7b84c10", "0x6", "0x", "0x0"),            // failed
+		tx("0x05", "0x1234", "0x6", "0x", ""),                                                   // invalid address
+		tx("0x06", "0xb8a57ef5343f88712a4eee91e34290584c2d5998", "0x6", "0x", ""),               // TRX elsewhere
+		tx("0x07", "", "0x0", "0x", ""),                                                         // contract creation
+	}
+	// USDT to receiver with status and Transfer log
+	verified := tx("0x08", "0xa614f803b6fd780986a42c78ec9c7f77e6ded13c", "0x0", transferToReceiver, "0x1")
+	verified.Logs = []LogData{{
+		Address: "0xa614f803b6fd780986a42c78ec9c7f77e6ded13c",
+		Topics: []string{
+			"0x" + trc20TransferTopic,
+			"0x000000000000000000000000b8a57ef5343f88712a4eee91e34290584c2d5998",
+			"0x000000000000000000000000678637325f9be6b2264db347021432a6a7b84c10",
+		},
+		Data: "0x00000000000000000000000000000000000000000000000000000000004c4b40",
+	}}
+	txs = append(txs, verified)
+
+	payments, stats := ClassifyBatch(txs, []string{receiver})
+	if len(payments) != 3 || payments[0].TxHash != "0x01" || payments[1].TxHash != "0x02" || payments[2].TxHash != "0x08" {
+		t.Fatalf("Expected payments 0x01, 0x02 and 0x08, got %+v", payments)
+	}
+	if payments[1].TokenContract != "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t" {
+		t.Errorf("Expected USDT payment, got %+v", payments[1])
+	}
+	// 0x02 has neither status nor Transfer log, it is recorded but must be verified against the receipt
+	want := BatchStats{Total: 8, TRXPayments: 1, TokenPayments: 1, Unverified: 1, Irrelevant: 3, Failed: 1, Invalid: 1}
+	if stats != want {
+		t.Errorf("Expected stats %+v, got %+v", want, stats)
+	}
+	if stats.Skipped() != 5 {
+		t.Errorf("Expected 5 skipped, got %d", stats.Skipped())
+	}
+}
+
+func TestClassifyTransactionInvalid(t *testing.T) {
+	_, class, err := ClassifyTransaction(TransactionData{BlockNumber: "0xzz"}, []string{"TKQbPHJ7vaPVc8KuYaQTs2ew6gb64r9xhK"})
+	if class != ClassInvalid || err == nil {
+		t.Errorf("Expected invalid class with error, got %s, %v", class, err)
+	}
+}
+
+func TestClassifyAmountOutOfRange(t *testing.T) {
+	const receiver = "TKQbPHJ7vaPVc8KuYaQTs2ew6gb64r9xhK"
+	tx := func(hash, value string) TransactionData {
+		return TransactionData{
+			BlockNumber: "0x46c451a",
+			Hash:        hash,
+			From:        "0xb8a57ef5343f88712a4eee91e34290584c2d5998",
+			To:          "0x678637325f9be6b2264db347021432a6a7b84c10",
+			Timestamp:   "0x6880ce30",
+			Value:       value,
+			Input:       "0x",
+			Status:      "0x1",
+		}
+	}
+	// 2^128-1 has 39 digits and does not fit NUMERIC(36,0)
+	overflow := tx("0x02", "0xffffffffffffffffffffffffffffffff")
+	payments, stats := ClassifyBatch([]TransactionData{tx("0x01", "0x6"), overflow, tx("0x03", "0x7")}, []string{receiver})
+	if len(payments) != 2 || payments[0].TxHash != "0x01" || payments[1].TxHash != "0x03" {
+		t.Fatalf("Expected payments 0x01 and 0x03, got %+v", payments)
+	}
+	want := BatchStats{Total: 3, TRXPayments: 2, Invalid: 1}
+	if stats != want {
+		t.Errorf("Expected stats %+v, got %+v", want, stats)
+	}
+
+	_, class, err := ClassifyTransaction(overflow, []string{receiver})
+	if class != ClassInvalid || !errors.Is(err, units.ErrOutOfRange) {
+		t.Errorf("Expected invalid class with ErrOutOfRange, got %s, %v", class, err)
+	}
+}
+
+func TestReceiversFromEnv(t *testing.T) {
+	t.Setenv("RECEIVING_ADDRESSES", "")
+	t.Setenv("DELEGATION_FROM_ADDRESS", "0x678637325f9be6b2264db347021432a6a7b84c10")
+	receivers, err := ReceiversFromEnv()
+	if err != nil || len(receivers) != 1 || receivers[0] != "TKQbPHJ7vaPVc8KuYaQTs2ew6gb64r9xhK" {
+		t.Errorf("Expected delegation address as receiver, got %v, %v", receivers, err)
+	}
+
+	t.Setenv("RECEIVING_ADDRESSES", "TKQbPHJ7vaPVc8KuYaQTs2ew6gb64r9xhK, TSoXbDPwgM1nhfbxVj53u15My8smJHWpFw")
+	if receivers, err = ReceiversFromEnv(); err != nil || len(receivers) != 2 {
+		t.Errorf("Expected 2 receivers, got %v, %v", receivers, err)
+	}
+
+	t.Setenv("RECEIVING_ADDRESSES", "")
+	t.Setenv("DELEGATION_FROM_ADDRESS", "")
+	if _, err := ReceiversFromEnv(); err != ErrNoReceivers {
+		t.Errorf("Expected ErrNoReceivers, got %v", err)
+	}
+}
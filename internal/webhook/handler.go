@@ -8,11 +8,12 @@ import (
 	"strings"
 	"time"
 
-	"encoding/json"
 	"io"
 
+	"lending-trx/internal/address"
 	"lending-trx/internal/db"
 	"lending-trx/internal/tron"
+	"lending-trx/internal/units"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -27,22 +28,32 @@ type WebhookRequest struct {
 
 // TransactionData 表示单个交易数据
 type TransactionData struct {
-	BlockHash        string `json:"blockHash"`
-	BlockNumber      string `json:"blockNumber"`
-	From             string `json:"from"`
-	Gas              string `json:"gas"`
-	GasPrice         string `json:"gasPrice"`
-	Hash             string `json:"hash"`
-	Input            string `json:"input"`
-	Nonce            string `json:"nonce"`
-	R                string `json:"r"`
-	S                string `json:"s"`
-	Timestamp        string `json:"timestamp"`
-	To               string `json:"to"`
-	TransactionIndex string `json:"transactionIndex"`
-	Type             string `json:"type"`
-	V                string `json:"v"`
-	Value            string `json:"value"`
+	BlockHash        string    `json:"blockHash"`
+	BlockNumber      string    `json:"blockNumber"`
+	From             string    `json:"from"`
+	Gas              string    `json:"gas"`
+	GasPrice         string    `json:"gasPrice"`
+	Hash             string    `json:"hash"`
+	Input            string    `json:"input"`
+	Nonce            string    `json:"nonce"`
+	R                string    `json:"r"`
+	S                string    `json:"s"`
+	Timestamp        string    `json:"timestamp"`
+	To               string    `json:"to"`
+	TransactionIndex string    `json:"transactionIndex"`
+	Type             string    `json:"type"`
+	V                string    `json:"v"`
+	Value            string    `json:"value"`
+	Logs             []LogData `json:"logs,omitempty"`   // 交易事件日志（数据源提供时）
+	Memo             string    `json:"memo,omitempty"`   // 交易备注 raw_data.data（十六进制，数据源提供时）
+	Status           string    `json:"status,omitempty"` // 执行结果 0x1 成功 / 0x0 失败（数据源提供时）
+}
+
+// LogData 表示交易的事件日志
+type LogData struct {
+	Address string   `json:"address"`
+	Topics  []string `json:"topics"`
+	Data    string   `json:"data"`
 }
 
 // Metadata 表示元数据
@@ -61,65 +72,119 @@ type Metadata struct {
 }
 
 type WebhookData struct {
-	BlockHeight int64  `json:"blockNumber"`
-	TxHash      string `json:"hash"`
-	FromAddress string `json:"from"`
-	ToAddress   string `json:"to"`
-	Value       string `json:"value"`
-	BlockTime   int64  `json:"timestamp"`
-	ExpireTime  int64  `json:"expire_time"`
-	Status      int16  `json:"status"`
+	BlockHeight   int64  `json:"blockNumber"`
+	BlockHash     string `json:"blockHash"`
+	TxHash        string `json:"hash"`
+	FromAddress   string `json:"from"`
+	ToAddress     string `json:"to"`
+	Value         string `json:"value"`
+	TokenContract string `json:"token_contract,omitempty"` // TRC20 支付的代币合约，TRX 支付为空
+	TokenAmount   string `json:"token_amount,omitempty"`   // TRC20 支付的代币数量（最小单位）
+	Beneficiary   string `json:"beneficiary,omitempty"`    // 交易备注中指定的受益地址，委托给该地址而不是付款方
+	BlockTime     int64  `json:"timestamp"`
+	ExpireTime    int64  `json:"expire_time"`
+	Status        int16  `json:"status"`
 }
 
-// ParseWebhookData 解析webhook请求数据，返回WebhookData数组
+// ParseWebhookData 解析 QuickNode stream 格式的webhook请求数据，返回WebhookData数组，其他数据源见 ParserFor
 func ParseWebhookData(body []byte) ([]WebhookData, error) {
-	var request WebhookRequest
-	if err := json.Unmarshal(body, &request); err != nil {
+	batch, err := quickNodeParser{}.Parse(body)
+	if err != nil {
 		return nil, err
 	}
 
 	var result []WebhookData
-	for _, tx := range request.Data {
-		webhookData, err := convertTransactionToWebhookData(tx)
-		if err != nil {
-			return nil, err
+	for _, tx := range batch.Transactions {
+		if tx.Err != nil {
+			return nil, tx.Err
 		}
-		result = append(result, webhookData)
+		result = append(result, tx.Data)
 	}
 
 	return result, nil
 }
 
-// convertTransactionToWebhookData 将TransactionData转换为WebhookData
-func convertTransactionToWebhookData(tx TransactionData) (WebhookData, error) {
+// ConvertTransaction 将TransactionData转换为WebhookData，stream webhook 和区块扫描共用
+func ConvertTransaction(tx TransactionData) (WebhookData, error) {
+	data, _, err := convertTransaction(tx)
+	return data, err
+}
+
+// convertTransaction 转换交易数据，同时返回解析出的 TRC20 转账（不是 TRC20 转账时为 nil）
+func convertTransaction(tx TransactionData) (WebhookData, *TokenTransfer, error) {
 	// 转换blockNumber从hex字符串到int64
 	blockHeight, err := hexToInt64(tx.BlockNumber)
 	if err != nil {
-		return WebhookData{}, err
+		return WebhookData{}, nil, err
 	}
 
 	// 转换timestamp从hex字符串到int64
 	blockTime, err := hexToInt64(tx.Timestamp)
 	if err != nil {
-		return WebhookData{}, err
+		return WebhookData{}, nil, err
 	}
 
 	// 转换value从hex字符串到十进制字符串
 	value, err := hexToString(tx.Value)
 	if err != nil {
-		return WebhookData{}, err
+		return WebhookData{}, nil, err
 	}
 
-	return WebhookData{
+	// 地址统一转换为 Base58Check 规范形式
+	fromAddress, err := normalizeAddress(tx.From)
+	if err != nil {
+		return WebhookData{}, nil, err
+	}
+	toAddress, err := normalizeAddress(tx.To)
+	if err != nil {
+		return WebhookData{}, nil, err
+	}
+
+	data := WebhookData{
 		BlockHeight: blockHeight,
+		BlockHash:   tx.BlockHash,
 		TxHash:      tx.Hash,
-		FromAddress: tx.From,
-		ToAddress:   tx.To,
+		FromAddress: fromAddress,
+		ToAddress:   toAddress,
 		Value:       value,
 		BlockTime:   blockTime,
 		ExpireTime:  blockTime + 3600, // BlockTime + 1小时 (3600秒)
 		Status:      0,                // 默认状态
-	}, nil
+	}
+
+	// TRC20 转账：交易的 to 是代币合约，收款地址和金额在 input / Transfer 事件中
+	transfer, err := decodeTokenTransfer(tx)
+	if err != nil {
+		return WebhookData{}, nil, err
+	}
+	if transfer != nil {
+		data.ToAddress = transfer.To
+		data.TokenContract = transfer.Contract
+		data.TokenAmount = transfer.Amount
+	}
+
+	// 备注中的受益地址：从交易所提现等无法用自己钱包付款时，资源委托给备注中的地址
+	// 受益地址为收款地址本身时忽略（委托方不能委托给自己）
+	if beneficiary := parseBeneficiary(tx.Memo); beneficiary != data.ToAddress {
+		data.Beneficiary = beneficiary
+	}
+	return data, transfer, nil
+}
+
+// normalizeAddress 将 0x/41 十六进制或 Base58 地址转换为 Base58Check，空地址（如合约创建）保持为空
+func normalizeAddress(addr string) (string, error) {
+	if addr == "" {
+		return "", nil
+	}
+	return address.Normalize(addr)
+}
+
+// canonicalAddress 尽力将地址转换为 Base58Check，无法解析时保留原值
+func canonicalAddress(addr string) string {
+	if normalized, err := address.Normalize(addr); err == nil {
+		return normalized
+	}
+	return addr
 }
 
 // hexToInt64 将十六进制字符串转换为int64
@@ -139,36 +204,32 @@ func hexToInt64(hexStr string) (int64, error) {
 	return value, nil
 }
 
-// hexToString 将十六进制字符串转换为十进制字符串
+// hexToString 将十六进制字符串转换为十进制字符串，使用任意精度整数，不会溢出
 func hexToString(hexStr string) (string, error) {
-	// 移除0x前缀
-	hexStr = strings.TrimPrefix(hexStr, "0x")
-	if hexStr == "" {
-		return "0", nil
-	}
-
-	// 转换为十进制
-	value, err := strconv.ParseInt(hexStr, 16, 64)
+	value, err := units.ParseHexAmount(hexStr)
 	if err != nil {
 		return "0", err
 	}
-
-	return strconv.FormatInt(value, 10), nil
+	return value.String(), nil
 }
 
 // ConvertToWebhookDataModel 将WebhookData转换为WebhookDataModel
 func ConvertToWebhookDataModel(data WebhookData) *db.WebhookDataModel {
 	return &db.WebhookDataModel{
-		BlockHeight: data.BlockHeight,
-		TxHash:      data.TxHash,
-		FromAddress: data.FromAddress,
-		ToAddress:   data.ToAddress,
-		Value:       data.Value,
-		BlockTime:   data.BlockTime,
-		ExpireTime:  data.ExpireTime,
-		Status:      data.Status,
-		CreateTime:  time.Now().Format("2006-01-02 15:04:05"),
-		UpdateTime:  time.Now().Format("2006-01-02 15:04:05"),
+		BlockHeight:   data.BlockHeight,
+		BlockHash:     data.BlockHash,
+		TxHash:        data.TxHash,
+		FromAddress:   canonicalAddress(data.FromAddress),
+		ToAddress:     canonicalAddress(data.ToAddress),
+		Value:         data.Value,
+		TokenContract: canonicalAddress(data.TokenContract),
+		TokenAmount:   data.TokenAmount,
+		Beneficiary:   data.Beneficiary,
+		BlockTime:     data.BlockTime,
+		ExpireTime:    data.ExpireTime,
+		Status:        data.Status,
+		CreateTime:    time.Now().Format("2006-01-02 15:04:05"),
+		UpdateTime:    time.Now().Format("2006-01-02 15:04:05"),
 	}
 }
 
@@ -181,12 +242,21 @@ func ConvertToWebhookDataModelSlice(dataList []WebhookData) []*db.WebhookDataMod
 	return result
 }
 
-// RegisterRoutes 注册 webhook 路由
-func RegisterRoutes(r *gin.Engine, ctx context.Context, pool *pgxpool.Pool, log *xlog.XLog) {
+// RegisterRoutes 注册 webhook 路由、查询接口和管理接口（配置 ADMIN_AUTH_TOKEN 时）
+func RegisterRoutes(r *gin.Engine, ctx context.Context, pool *pgxpool.Pool, log *xlog.XLog, tronClient *tron.TronClient, auth ProviderAuth, queue *IngestQueue) {
+	RegisterWebhookRoutes(r, ctx, pool, log, auth, queue)
+	RegisterAdminRoutes(r, ctx, pool, log, queue.ingester.receivers, AdminTokenFromEnv())
+	RegisterAPIRoutes(r, ctx, log, tronClient)
+}
+
+// RegisterWebhookRoutes 注册接收 stream 推送的 webhook 路由，使用区块扫描时不注册
+// auth 按数据源校验推送的 HMAC 签名或共享令牌，见 ProviderAuthFromEnv；通过校验的原始推送保存到 webhook_archive，
+// 由 queue 在后台按数据源适配器（见 ParserFor）解析写入，见 StartIngestQueue
+func RegisterWebhookRoutes(r *gin.Engine, ctx context.Context, pool *pgxpool.Pool, log *xlog.XLog, auth ProviderAuth, queue *IngestQueue) {
 	l := log.WithField("module", "webhook")
 
-	// Webhook 处理路由
-	r.POST("/webhook", AuthMiddleware(), func(c *gin.Context) {
+	// Webhook 处理函数，POST /webhook 为 QuickNode stream 格式，POST /webhook/:provider 按数据源选择适配器
+	handleWebhook := func(c *gin.Context) {
 
 		/*
 
@@ -228,6 +298,15 @@ func RegisterRoutes(r *gin.Engine, ctx context.Context, pool *pgxpool.Pool, log
 
 		*/
 
+		provider := strings.ToLower(c.Param("provider"))
+		if provider == "" {
+			provider = ProviderQuickNode
+		}
+		if _, err := ParserFor(provider); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "unknown provider"})
+			return
+		}
+
 		// 读取请求体
 		body, err := io.ReadAll(c.Request.Body)
 		if err != nil {
@@ -235,28 +314,32 @@ func RegisterRoutes(r *gin.Engine, ctx context.Context, pool *pgxpool.Pool, log
 			c.JSON(http.StatusBadRequest, gin.H{"error": "read body failed"})
 			return
 		}
-		// 打印请求体内容
-		l.Info("Webhook request body", string(body))
 
-		// 使用工具函数解析webhook数据
-		webhookDataList, err := ParseWebhookData(body)
+		// 原始推送持久化到处理队列后立即返回，由后台协程解析写入；保存失败时返回 500 由推送方重试
+		archive, err := newArchive(provider, c.Request.Header, c.ClientIP(), body)
 		if err != nil {
-			l.Error("Failed to parse webhook data", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json format"})
+			l.Error("Failed to archive webhook payload", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "archive failed"})
 			return
 		}
-
-		// 转换为WebhookDataModel并批量插入
-		webhookDataModels := ConvertToWebhookDataModelSlice(webhookDataList)
-		err = db.BatchInsertWebhookData(ctx, pool, webhookDataModels)
+		archiveID, err := queue.Enqueue(archive)
 		if err != nil {
-			l.Error("Failed to batch insert into database", err)
+			l.Error("Failed to enqueue webhook payload", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
 			return
 		}
+		l.Info("Webhook payload queued", "provider", provider, "archive_id", archiveID, "size", len(body))
 
-		c.JSON(http.StatusOK, gin.H{"status": "ok", "inserted_count": len(webhookDataList)})
-	})
+		c.JSON(http.StatusAccepted, gin.H{"status": "queued", "archive_id": archiveID})
+	}
+	r.POST("/webhook", AuthMiddleware(auth, log), handleWebhook)
+	r.POST("/webhook/:provider", AuthMiddleware(auth, log), handleWebhook)
+
+}
+
+// RegisterAPIRoutes 注册委托账户和节点状态查询接口
+func RegisterAPIRoutes(r *gin.Engine, ctx context.Context, log *xlog.XLog, tronClient *tron.TronClient) {
+	l := log.WithField("module", "webhook")
 
 	// 查询委托方账户信息的路由
 	r.GET("/api/delegation-account", func(c *gin.Context) {
@@ -267,14 +350,12 @@ func RegisterRoutes(r *gin.Engine, ctx context.Context, pool *pgxpool.Pool, log
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "delegation address not configured"})
 			return
 		}
-
-		// 创建 Tron 客户端
-		baseURL := os.Getenv("TRON_API_URL")
-		if baseURL == "" {
-			baseURL = "https://api.trongrid.io"
+		delegationFromAddress, err := address.Normalize(delegationFromAddress)
+		if err != nil {
+			l.Error("Invalid DELEGATION_FROM_ADDRESS", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid delegation address"})
+			return
 		}
-		apiKey := os.Getenv("TRON_API_KEY")
-		tronClient := tron.NewTronClient(baseURL, apiKey)
 
 		// 获取账户信息
 		accountInfo, err := tronClient.GetAccountInfo(ctx, delegationFromAddress)
@@ -284,16 +365,48 @@ func RegisterRoutes(r *gin.Engine, ctx context.Context, pool *pgxpool.Pool, log
 			return
 		}
 
+		// 带宽状态
+		resource, err := tronClient.GetAccountResource(ctx, delegationFromAddress)
+		if err != nil {
+			l.Error("Failed to get delegation account resource", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get account resource"})
+			return
+		}
+
+		// 可委托库存：能量和带宽各自可委托的质押金额（SUN）
+		delegatable := make(map[string]int64, 2)
+		for _, res := range []string{tron.ResourceEnergy, tron.ResourceBandwidth} {
+			size, err := tronClient.GetCanDelegatedMaxSize(ctx, delegationFromAddress, res)
+			if err != nil {
+				l.Error("Failed to get delegatable balance", err, "resource", res)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get delegatable balance"})
+				return
+			}
+			delegatable[res] = size
+		}
+
 		// 返回账户信息
 		c.JSON(http.StatusOK, gin.H{
 			"status": "ok",
 			"data": gin.H{
-				"address":      accountInfo.Address,
-				"balance":      accountInfo.Balance,
-				"energy":       accountInfo.Energy,
-				"energy_limit": accountInfo.EnergyLimit,
-				"energy_used":  accountInfo.EnergyUsed,
+				"address":               accountInfo.Address,
+				"balance":               accountInfo.Balance,
+				"energy":                accountInfo.Energy,
+				"energy_limit":          accountInfo.EnergyLimit,
+				"energy_used":           accountInfo.EnergyUsed,
+				"bandwidth":             strconv.FormatInt(resource.NetLimit-resource.NetUsed, 10),
+				"bandwidth_limit":       strconv.FormatInt(resource.NetLimit, 10),
+				"bandwidth_used":        strconv.FormatInt(resource.NetUsed, 10),
+				"delegatable_energy":    strconv.FormatInt(delegatable[tron.ResourceEnergy], 10),
+				"delegatable_bandwidth": strconv.FormatInt(delegatable[tron.ResourceBandwidth], 10),
 			},
 		})
 	})
+	// 查询 TRON 全节点健康状态的路由
+	r.GET("/api/tron-nodes", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"status": "ok",
+			"data":   tronClient.NodeStatuses(),
+		})
+	})
 }
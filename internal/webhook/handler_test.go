@@ -62,6 +62,11 @@ func TestParseWebhookData(t *testing.T) {
 		t.Errorf("Expected BlockHeight %d, got %d", expectedBlockHeight, data.BlockHeight)
 	}
 
+	expectedBlockHash := "0x00000000046c451a6f749bf87f4be6c3bc49bcdfc85e309f0529907a4de697f9"
+	if data.BlockHash != expectedBlockHash {
+		t.Errorf("Expected BlockHash %s, got %s", expectedBlockHash, data.BlockHash)
+	}
+
 	expectedBlockTime := int64(1753271856) // 0x6880ce30 的十进制值
 	if data.BlockTime != expectedBlockTime {
 		t.Errorf("Expected BlockTime %d, got %d", expectedBlockTime, data.BlockTime)
@@ -77,12 +82,12 @@ func TestParseWebhookData(t *testing.T) {
 		t.Errorf("Expected TxHash %s, got %s", expectedTxHash, data.TxHash)
 	}
 
-	expectedFrom := "0xb8a57ef5343f88712a4eee91e34290584c2d5998"
+	expectedFrom := "TSoXbDPwgM1nhfbxVj53u15My8smJHWpFw" // 0xb8a57ef5343f88712a4eee91e34290584c2d5998
 	if data.FromAddress != expectedFrom {
 		t.Errorf("Expected FromAddress %s, got %s", expectedFrom, data.FromAddress)
 	}
 
-	expectedTo := "0x678637325f9be6b2264db347021432a6a7b84c10"
+	expectedTo := "TKQbPHJ7vaPVc8KuYaQTs2ew6gb64r9xhK" // 0x678637325f9be6b2264db347021432a6a7b84c10
 	if data.ToAddress != expectedTo {
 		t.Errorf("Expected ToAddress %s, got %s", expectedTo, data.ToAddress)
 	}
@@ -132,6 +137,9 @@ func TestHexToString(t *testing.T) {
 		{"0x", "0"},
 		{"", "0"},
 		{"0x1a", "26"},
+		// 超出 int64 的金额不溢出
+		{"0x8000000000000000", "9223372036854775808"},
+		{"0xffffffffffffffffffffffff", "79228162514264337593543950335"},
 	}
 
 	for _, test := range tests {
@@ -146,6 +154,14 @@ func TestHexToString(t *testing.T) {
 	}
 }
 
+func TestHexToStringInvalid(t *testing.T) {
+	for _, input := range []string{"0xzz", "-0x1", "0x-1"} {
+		if _, err := hexToString(input); err == nil {
+			t.Errorf("Expected error for %q", input)
+		}
+	}
+}
+
 func TestConvertToWebhookDataModel(t *testing.T) {
 	// 创建测试数据
 	webhookData := WebhookData{
@@ -171,12 +187,13 @@ func TestConvertToWebhookDataModel(t *testing.T) {
 		t.Errorf("Expected TxHash %s, got %s", webhookData.TxHash, result.TxHash)
 	}
 
-	if result.FromAddress != webhookData.FromAddress {
-		t.Errorf("Expected FromAddress %s, got %s", webhookData.FromAddress, result.FromAddress)
+	// 地址存储前统一转换为 Base58Check
+	if result.FromAddress != "TSoXbDPwgM1nhfbxVj53u15My8smJHWpFw" {
+		t.Errorf("Expected FromAddress %s, got %s", "TSoXbDPwgM1nhfbxVj53u15My8smJHWpFw", result.FromAddress)
 	}
 
-	if result.ToAddress != webhookData.ToAddress {
-		t.Errorf("Expected ToAddress %s, got %s", webhookData.ToAddress, result.ToAddress)
+	if result.ToAddress != "TKQbPHJ7vaPVc8KuYaQTs2ew6gb64r9xhK" {
+		t.Errorf("Expected ToAddress %s, got %s", "TKQbPHJ7vaPVc8KuYaQTs2ew6gb64r9xhK", result.ToAddress)
 	}
 
 	if result.Value != webhookData.Value {
@@ -250,3 +267,188 @@ func TestConvertToWebhookDataModelSlice(t *testing.T) {
 		}
 	}
 }
+
+func TestParseWebhookDataInvalidAddress(t *testing.T) {
+	testJSON := `{"data":[{"blockNumber":"0x46c451a","from":"0x1234","hash":"0xabc","timestamp":"0x6880ce30","to":"0x678637325f9be6b2264db347021432a6a7b84c10","value":"0x6"}]}`
+
+	if _, err := ParseWebhookData([]byte(testJSON)); err == nil {
+		t.Error("Expected error for invalid from address")
+	}
+}
+
+func TestParseWebhookDataTRC20Transfer(t *testing.T) {
+	// transfer(TKQbPHJ7vaPVc8KuYaQTs2ew6gb64r9xhK, 5000000) 调用 USDT 合约
+	input := "0xa9059cbb" +
+		"000000000000000000000000678637325f9be6b2264db347021432a6a7b84c10" +
+		"00000000000000000000000000000000000000000000000000000000004c4b40"
+	testJSON := `{
+		"data": [
+			{
+				"blockNumber": "0x46c451a",
+				"from": "0xb8a57ef5343f88712a4eee91e34290584c2d5998",
+				"hash": "0x17e1f7519110b58ed7cdfbfccbe5b6d35ca00d7c59b21bb72ba96a77ce25675e",
+				"input": "` + input + `",
+				"timestamp": "0x6880ce30",
+				"to": "0xa614f803b6fd780986a42c78ec9c7f77e6ded13c",
+				"value": "0x0"
+			}
+		]
+	}`
+
+	result, err := ParseWebhookData([]byte(testJSON))
+	if err != nil {
+		t.Fatalf("ParseWebhookData failed: %v", err)
+	}
+	if len(result) != 1 {
+		t.Fatalf("Expected 1 transaction, got %d", len(result))
+	}
+	data := result[0]
+
+	if data.FromAddress != "TSoXbDPwgM1nhfbxVj53u15My8smJHWpFw" {
+		t.Errorf("Expected FromAddress TSoXbDPwgM1nhfbxVj53u15My8smJHWpFw, got %s", data.FromAddress)
+	}
+	// 收款地址取自 transfer 参数，而不是交易的 to（代币合约）
+	if data.ToAddress != "TKQbPHJ7vaPVc8KuYaQTs2ew6gb64r9xhK" {
+		t.Errorf("Expected ToAddress TKQbPHJ7vaPVc8KuYaQTs2ew6gb64r9xhK, got %s", data.ToAddress)
+	}
+	if data.TokenContract != "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t" {
+		t.Errorf("Expected TokenContract TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t, got %s", data.TokenContract)
+	}
+	if data.TokenAmount != "5000000" {
+		t.Errorf("Expected TokenAmount 5000000, got %s", data.TokenAmount)
+	}
+	if data.Value != "0" {
+		t.Errorf("Expected Value 0, got %s", data.Value)
+	}
+
+	model := ConvertToWebhookDataModel(data)
+	if model.TokenContract != data.TokenContract || model.TokenAmount != data.TokenAmount {
+		t.Errorf("Expected token %s %s on model, got %s %s", data.TokenContract, data.TokenAmount, model.TokenContract, model.TokenAmount)
+	}
+}
+
+func TestParseWebhookDataTransferLog(t *testing.T) {
+	// Transfer 事件的金额优先于 input（如收取手续费的代币实际到账更少）
+	input := "0xa9059cbb" +
+		"000000000000000000000000678637325f9be6b2264db347021432a6a7b84c10" +
+		"00000000000000000000000000000000000000000000000000000000004c4b40"
+	testJSON := `{
+		"data": [
+			{
+				"blockNumber": "0x46c451a",
+				"from": "0xb8a57ef5343f88712a4eee91e34290584c2d5998",
+				"hash": "0x27e1f7519110b58ed7cdfbfccbe5b6d35ca00d7c59b21bb72ba96a77ce25675e",
+				"input": "` + input + `",
+				"timestamp": "0x6880ce30",
+				"to": "0xa614f803b6fd780986a42c78ec9c7f77e6ded13c",
+				"value": "0x0",
+				"logs": [
+					{
+						"address": "0xa614f803b6fd780986a42c78ec9c7f77e6ded13c",
+						"topics": [
+							"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+							"0x000000000000000000000000b8a57ef5343f88712a4eee91e34290584c2d5998",
+							"0x000000000000000000000000678637325f9be6b2264db347021432a6a7b84c10"
+						],
+						"data": "0x00000000000000000000000000000000000000000000000000000000004c4a38"
+					}
+				]
+			}
+		]
+	}`
+
+	result, err := ParseWebhookData([]byte(testJSON))
+	if err != nil {
+		t.Fatalf("ParseWebhookData failed: %v", err)
+	}
+	data := result[0]
+	if data.ToAddress != "TKQbPHJ7vaPVc8KuYaQTs2ew6gb64r9xhK" {
+		t.Errorf("Expected ToAddress TKQbPHJ7vaPVc8KuYaQTs2ew6gb64r9xhK, got %s", data.ToAddress)
+	}
+	if data.TokenContract != "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t" {
+		t.Errorf("Expected TokenContract TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t, got %s", data.TokenContract)
+	}
+	if data.TokenAmount != "4999736" {
+		t.Errorf("Expected TokenAmount 4999736, got %s", data.TokenAmount)
+	}
+}
+
+func TestDecodeTRC20TransferInput(t *testing.T) {
+	// 非 transfer 调用
+	if _, _, ok, err := decodeTRC20TransferInput("0x"); ok || err != nil {
+		t.Errorf("Expected plain transfer to be ignored, got ok=%v err=%v", ok, err)
+	}
+	if _, _, ok, err := decodeTRC20TransferInput("0x095ea7b3"); ok || err != nil {
+		t.Errorf("Expected approve call to be ignored, got ok=%v err=%v", ok, err)
+	}
+	// 参数被截断
+	if _, _, _, err := decodeTRC20TransferInput("0xa9059cbb0000"); err == nil {
+		t.Error("Expected error for truncated transfer input")
+	}
+}
+
+func TestParseBeneficiary(t *testing.T) {
+	testCases := []struct {
+		description string
+		memo        string
+		expected    string
+	}{
+		{"hex encoded address", "54536f5862445077674d316e68666278566a35337531354d7938736d4a4857704677", "TSoXbDPwgM1nhfbxVj53u15My8smJHWpFw"},
+		{"hex encoded with prefix text", "0x746f3a5452374e48716a654b5178475443693871385a5934704c386f74537a676a4c6a3674", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"},
+		{"plain text", "energy for TSoXbDPwgM1nhfbxVj53u15My8smJHWpFw please", "TSoXbDPwgM1nhfbxVj53u15My8smJHWpFw"},
+		{"bad checksum", "TSoXbDPwgM1nhfbxVj53u15My8smJHWpFx", ""},
+		{"exchange order id", "123456789", ""},
+		{"empty", "", ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			if got := parseBeneficiary(tc.memo); got != tc.expected {
+				t.Errorf("Expected beneficiary %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseWebhookDataMemoBeneficiary(t *testing.T) {
+	testJSON := `{
+		"data": [
+			{
+				"blockNumber": "0x46c451a",
+				"from": "0xb8a57ef5343f88712a4eee91e34290584c2d5998",
+				"hash": "0x27e1f7519110b58ed7cdfbfccbe5b6d35ca00d7c59b21bb72ba96a77ce25675e",
+				"timestamp": "0x6880ce30",
+				"to": "0x678637325f9be6b2264db347021432a6a7b84c10",
+				"value": "0x1e8480",
+				"memo": "746f3a5452374e48716a654b5178475443693871385a5934704c386f74537a676a4c6a3674"
+			},
+			{
+				"blockNumber": "0x46c451a",
+				"from": "0xb8a57ef5343f88712a4eee91e34290584c2d5998",
+				"hash": "0x37e1f7519110b58ed7cdfbfccbe5b6d35ca00d7c59b21bb72ba96a77ce25675e",
+				"timestamp": "0x6880ce30",
+				"to": "0x678637325f9be6b2264db347021432a6a7b84c10",
+				"value": "0x1e8480",
+				"memo": "TKQbPHJ7vaPVc8KuYaQTs2ew6gb64r9xhK"
+			}
+		]
+	}`
+
+	result, err := ParseWebhookData([]byte(testJSON))
+	if err != nil {
+		t.Fatalf("ParseWebhookData failed: %v", err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("Expected 2 transactions, got %d", len(result))
+	}
+	if result[0].Beneficiary != "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t" {
+		t.Errorf("Expected beneficiary TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t, got %s", result[0].Beneficiary)
+	}
+	model := ConvertToWebhookDataModel(result[0])
+	if model.Beneficiary != result[0].Beneficiary || model.Receiver() != result[0].Beneficiary {
+		t.Errorf("Expected model receiver %s, got %s", result[0].Beneficiary, model.Receiver())
+	}
+	// 备注中的地址是收款地址本身时忽略
+	if result[1].Beneficiary != "" {
+		t.Errorf("Expected receiving address in memo to be ignored, got %s", result[1].Beneficiary)
+	}
+}
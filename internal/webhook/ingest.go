@@ -0,0 +1,89 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sunjiangjun/xlog"
+
+	"lending-trx/internal/db"
+)
+
+// ErrInvalidPayload 推送内容无法解析或没有对应的数据源适配器，重试也不会成功
+var ErrInvalidPayload = errors.New("invalid webhook payload")
+
+// IngestResult 一次推送的处理结果
+type IngestResult struct {
+	Inserted   int        `json:"inserted_count"`  // 实际写入的支付数
+	Duplicates int        `json:"duplicate_count"` // tx_hash 已存在、由数据库忽略的支付数（重复推送或重放）
+	Stats      BatchStats `json:"stats"`
+}
+
+// Ingester 处理 stream 推送：按收款地址分类，写入支付和批次记录
+// POST /webhook 和归档重放共用，重复处理同一推送是幂等的（tx_hash 和批次范围唯一）
+type Ingester struct {
+	ctx       context.Context
+	pool      *pgxpool.Pool
+	log       *xlog.XLog
+	receivers []string
+}
+
+// NewIngester 创建推送处理器，receivers 为 Base58Check 收款地址，见 ReceiversFromEnv
+func NewIngester(ctx context.Context, pool *pgxpool.Pool, log *xlog.XLog, receivers []string) *Ingester {
+	return &Ingester{ctx: ctx, pool: pool, log: log, receivers: receivers}
+}
+
+// Ingest 按数据源适配器解析推送请求体并写入转入收款地址的支付，见 ParserFor
+// 请求体无法解析或数据源未知时返回 ErrInvalidPayload
+func (in *Ingester) Ingest(provider string, body []byte) (*IngestResult, error) {
+	parser, err := ParserFor(provider)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	parsed, err := parser.Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	metadata := parsed.Metadata
+
+	// 区块数据集包含整个区块的交易，只记录转入收款地址的支付
+	payments, stats := ClassifyParsed(parsed.Transactions, in.receivers)
+	in.log.Info("Webhook batch classified",
+		"module", "webhook",
+		"provider", provider,
+		"stream_id", metadata.StreamID,
+		"batch_start", metadata.BatchStartRange,
+		"batch_end", metadata.BatchEndRange,
+		"total", stats.Total,
+		"trx_payments", stats.TRXPayments,
+		"token_payments", stats.TokenPayments,
+		"unverified_token_payments", stats.Unverified,
+		"skipped_irrelevant", stats.Irrelevant,
+		"skipped_failed", stats.Failed,
+		"skipped_invalid", stats.Invalid,
+	)
+
+	// 多个处理协程并发写入，批次可能乱序提交，缺失告警由补齐任务按已提交的范围延迟检查（见 scanner.Backfiller）
+	batch := streamBatch(metadata, stats, len(payments))
+
+	// 支付和批次记录在同一事务中写入
+	models := ConvertToWebhookDataModelSlice(payments)
+	var inserted int64
+	err = db.WithTransaction(in.ctx, in.pool, func(tx pgx.Tx) error {
+		var err error
+		if inserted, err = db.BatchInsertWebhookDataTx(in.ctx, tx, models); err != nil {
+			return err
+		}
+		if batch == nil {
+			return nil
+		}
+		return db.InsertStreamBatchTx(in.ctx, tx, batch)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert payments: %w", err)
+	}
+	return &IngestResult{Inserted: int(inserted), Duplicates: len(payments) - int(inserted), Stats: stats}, nil
+}
@@ -0,0 +1,44 @@
+package webhook
+
+import (
+	"encoding/hex"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"lending-trx/internal/address"
+)
+
+// base58AddressLength Base58Check 形式的 TRON 地址长度
+const base58AddressLength = 34
+
+// decodeMemo 将交易备注（raw_data.data）转换为文本
+// 节点和 stream 以十六进制返回备注，无法按十六进制解码或解码结果不是 UTF-8 时按原文处理
+func decodeMemo(memo string) string {
+	memo = strings.TrimSpace(memo)
+	raw, err := hex.DecodeString(strings.TrimPrefix(memo, "0x"))
+	if err == nil && utf8.Valid(raw) {
+		return string(raw)
+	}
+	return memo
+}
+
+// parseBeneficiary 从交易备注中解析受益地址：取第一个校验和有效的 Base58Check 地址（T 开头，34 个字符），
+// 允许前后有其他文字（如 "to:TXXX"、"energy for TXXX"），没有有效地址时返回空
+func parseBeneficiary(memo string) string {
+	if memo == "" {
+		return ""
+	}
+	tokens := strings.FieldsFunc(decodeMemo(memo), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, token := range tokens {
+		if len(token) != base58AddressLength || token[0] != 'T' {
+			continue
+		}
+		if addr, err := address.Parse(token); err == nil {
+			return addr.Base58()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,85 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// 推送数据源，对应 POST /webhook/:provider
+const (
+	ProviderQuickNode = "quicknode" // QuickNode stream（EVM 风格 JSON：hash、十六进制 blockNumber、0x 地址），POST /webhook 的默认格式
+	ProviderTronGrid  = "trongrid"  // TronGrid 事件服务（Base58 地址、十进制数值）
+	ProviderBlock     = "block"     // TRON 节点区块（/wallet/getblockbynum、/wallet/getblockbylimitnext，visible=true）
+)
+
+// ErrUnknownProvider 没有对应数据源的适配器
+var ErrUnknownProvider = errors.New("unknown webhook provider")
+
+// ParsedTransaction 适配器解析出的一笔交易
+type ParsedTransaction struct {
+	Data   WebhookData // 统一格式的交易数据
+	Failed bool        // 数据源标明交易执行失败
+	// Unverified TRC20 转账缺少执行结果或 Transfer 事件，无法从推送确认已到账，委托前按交易回执核实
+	Unverified bool
+	Err        error // 无法解析的原因，非空时 Data 无效
+}
+
+// ParsedBatch 一次推送解析出的交易和批次信息
+type ParsedBatch struct {
+	Metadata     Metadata // 批次信息，数据源不提供 stream_id 时不记录批次、不检查缺失
+	Transactions []ParsedTransaction
+}
+
+// PayloadParser 推送数据源适配器，将各数据源的推送解析为统一的 WebhookData
+// 推送整体格式错误时返回错误，单笔交易无法解析记录在 ParsedTransaction.Err，不影响同批的其他交易
+type PayloadParser interface {
+	Parse(body []byte) (*ParsedBatch, error)
+}
+
+// parsers 已注册的数据源适配器
+var parsers = map[string]PayloadParser{
+	ProviderQuickNode: quickNodeParser{},
+	ProviderTronGrid:  tronGridParser{},
+	ProviderBlock:     blockParser{},
+}
+
+// ParserFor 按数据源名称返回适配器，空名称为 ProviderQuickNode
+func ParserFor(provider string) (PayloadParser, error) {
+	if provider == "" {
+		provider = ProviderQuickNode
+	}
+	parser, ok := parsers[strings.ToLower(provider)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, provider)
+	}
+	return parser, nil
+}
+
+// quickNodeParser QuickNode stream 推送，见 WebhookRequest
+type quickNodeParser struct{}
+
+// Parse 解析 {"data": [...], "metadata": {...}}
+func (quickNodeParser) Parse(body []byte) (*ParsedBatch, error) {
+	var request WebhookRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, err
+	}
+	return &ParsedBatch{Metadata: request.Metadata, Transactions: streamTransactions(request.Data)}, nil
+}
+
+// streamTransactions 将 stream 格式的交易转换为统一格式
+func streamTransactions(txs []TransactionData) []ParsedTransaction {
+	result := make([]ParsedTransaction, len(txs))
+	for i, tx := range txs {
+		data, transfer, err := convertTransaction(tx)
+		result[i] = ParsedTransaction{
+			Data:       data,
+			Failed:     txFailed(tx),
+			Unverified: transfer != nil && (!transfer.FromLog || !hasStatus(tx)),
+			Err:        err,
+		}
+	}
+	return result
+}
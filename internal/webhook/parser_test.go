@@ -0,0 +1,137 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"lending-trx/internal/address"
+)
+
+const (
+	testReceiver = "TKQbPHJ7vaPVc8KuYaQTs2ew6gb64r9xhK" // 0x678637325f9be6b2264db347021432a6a7b84c10
+	testUSDT     = "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+)
+
+var testPayer = address.MustParse("0xb8a57ef5343f88712a4eee91e34290584c2d5998").Base58()
+
+func TestParserFor(t *testing.T) {
+	for _, provider := range []string{"", "quicknode", "TronGrid", "block"} {
+		if _, err := ParserFor(provider); err != nil {
+			t.Errorf("%q: expected parser, got %v", provider, err)
+		}
+	}
+	if _, err := ParserFor("alchemy"); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("Expected ErrUnknownProvider, got %v", err)
+	}
+}
+
+func TestTronGridParser(t *testing.T) {
+	body := fmt.Sprintf(`{"data":[
+		{"block_number":74204442,"block_timestamp":1753271856000,"contract_address":"%[1]s","event_name":"Transfer",
+		 "transaction_id":"07E1F7519110B58ED7CDFBFCCBE5B6D35CA00D7C59B21BB72BA96A77CE25675E",
+		 "result":{"from":"%[2]s","to":"%[3]s","value":"115792089237316195423570985008687907853269984665640564039457584007913129639935"}},
+		{"block_number":74204443,"block_timestamp":1753271859000,"contract_address":"%[1]s","event_name":"Approval",
+		 "transaction_id":"aa","result":{"owner":"%[2]s","spender":"%[3]s","value":"1"}},
+		{"block_number":74204443,"block_timestamp":1753271859000,"contract_address":"%[1]s","event_name":"Transfer",
+		 "transaction_id":"bb","result":{"from":"%[2]s","to":"%[3]s","value":"0x10"}}
+	],"success":true}`, testUSDT, testPayer, testReceiver)
+
+	parser, _ := ParserFor(ProviderTronGrid)
+	batch, err := parser.Parse([]byte(body))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if batch.Metadata.BatchStartRange != 74204442 || batch.Metadata.BatchEndRange != 74204443 {
+		t.Errorf("Expected block range 74204442-74204443, got %+v", batch.Metadata)
+	}
+
+	payments, stats := ClassifyParsed(batch.Transactions, []string{testReceiver})
+	// 事件不包含执行结果，TRC20 转账需要按回执核实
+	want := BatchStats{Total: 3, Unverified: 1, Irrelevant: 1, Invalid: 1}
+	if stats != want {
+		t.Errorf("Expected stats %+v, got %+v", want, stats)
+	}
+	if len(payments) != 1 {
+		t.Fatalf("Expected 1 payment, got %+v", payments)
+	}
+	payment := payments[0]
+	if payment.TxHash != "0x07e1f7519110b58ed7cdfbfccbe5b6d35ca00d7c59b21bb72ba96a77ce25675e" {
+		t.Errorf("Expected lowercase 0x tx hash matching the stream format, got %s", payment.TxHash)
+	}
+	if payment.FromAddress != testPayer || payment.TokenContract != testUSDT || payment.Value != "0" {
+		t.Errorf("Unexpected payment %+v", payment)
+	}
+	if payment.TokenAmount != "115792089237316195423570985008687907853269984665640564039457584007913129639935" {
+		t.Errorf("Expected decimal amount without overflow, got %s", payment.TokenAmount)
+	}
+	if payment.BlockHeight != 74204442 || payment.BlockTime != 1753271856 || payment.ExpireTime != 1753271856+3600 {
+		t.Errorf("Unexpected block fields %+v", payment)
+	}
+
+	// 事件数组形式
+	if batch, err := parser.Parse([]byte(`[]`)); err != nil || len(batch.Transactions) != 0 {
+		t.Errorf("Expected empty event array to parse, got %+v, %v", batch, err)
+	}
+	if _, err := parser.Parse([]byte(`{"data":`)); err == nil {
+		t.Error("Expected error for malformed JSON")
+	}
+}
+
+func TestBlockParser(t *testing.T) {
+	block := func(number int64, txs string) string {
+		return fmt.Sprintf(`{"blockID":"%016x","block_header":{"raw_data":{"number":%d,"timestamp":1753271856000}},"transactions":[%s]}`, number, number, txs)
+	}
+	transfer := func(id, to string, amount int64, ret string) string {
+		return fmt.Sprintf(`{"txID":"%s","ret":[{"contractRet":"%s"}],"raw_data":{"contract":[{"type":"TransferContract",
+			"parameter":{"value":{"owner_address":"%s","to_address":"%s","amount":%d}}}]}}`, id, ret, testPayer, to, amount)
+	}
+	first := block(74204442, transfer("01", testReceiver, 5000000, "SUCCESS")+","+transfer("02", testPayer, 1, "SUCCESS"))
+	second := block(74204443, transfer("03", testReceiver, 1, "REVERT"))
+
+	parser, _ := ParserFor(ProviderBlock)
+	for _, body := range []string{`{"block":[` + first + `,` + second + `]}`, `[` + first + `,` + second + `]`} {
+		batch, err := parser.Parse([]byte(body))
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		if batch.Metadata.BatchStartRange != 74204442 || batch.Metadata.BatchEndRange != 74204443 {
+			t.Errorf("Expected block range 74204442-74204443, got %+v", batch.Metadata)
+		}
+		payments, stats := ClassifyParsed(batch.Transactions, []string{testReceiver})
+		if len(payments) != 1 || payments[0].TxHash != "0x01" || payments[0].Value != "5000000" || payments[0].BlockHash != "0x"+fmt.Sprintf("%016x", 74204442) {
+			t.Errorf("Expected TRX payment 0x01, got %+v", payments)
+		}
+		if stats.Total != 2 || stats.Irrelevant != 1 {
+			t.Errorf("Expected 2 transactions with 1 irrelevant, got %+v", stats)
+		}
+	}
+
+	// 单个区块
+	batch, err := parser.Parse([]byte(first))
+	if err != nil || len(batch.Transactions) != 2 {
+		t.Errorf("Expected single block with 2 transactions, got %+v, %v", batch, err)
+	}
+	if _, err := parser.Parse([]byte(`{}`)); err == nil {
+		t.Error("Expected error for block without blockID")
+	}
+}
+
+func TestQuickNodeParserMatchesParseWebhookData(t *testing.T) {
+	body := []byte(`{"data":[{"blockNumber":"0x46c451a","hash":"0x01","from":"0xb8a57ef5343f88712a4eee91e34290584c2d5998",
+		"to":"0x678637325f9be6b2264db347021432a6a7b84c10","timestamp":"0x6880ce30","value":"0x6","status":"0x0"}],
+		"metadata":{"stream_id":"s1","batch_start_range":74204442,"batch_end_range":74204442}}`)
+	parser, _ := ParserFor("")
+	batch, err := parser.Parse(body)
+	if err != nil || batch.Metadata.StreamID != "s1" || len(batch.Transactions) != 1 {
+		t.Fatalf("Expected one transaction with stream metadata, got %+v, %v", batch, err)
+	}
+	if !batch.Transactions[0].Failed {
+		t.Error("Expected status 0x0 to mark the transaction failed")
+	}
+
+	data, err := ParseWebhookData(body)
+	if err != nil || len(data) != 1 || data[0] != batch.Transactions[0].Data {
+		t.Errorf("Expected ParseWebhookData to match the adapter, got %+v, %v", data, err)
+	}
+}
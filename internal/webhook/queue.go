@@ -0,0 +1,189 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sunjiangjun/xlog"
+
+	"lending-trx/internal/db"
+)
+
+const (
+	// defaultIngestWorkers 默认的处理协程数
+	defaultIngestWorkers = 4
+	// defaultIngestPollInterval 队列为空时检查新推送的间隔，收到推送时立即唤醒
+	defaultIngestPollInterval = time.Second
+	// defaultIngestMaxAttempts 默认的最大处理次数，用尽后标记为最终失败
+	defaultIngestMaxAttempts = 5
+	// ingestClaimTimeout 处理中超过该时长的推送视为处理进程已退出，重新领取
+	ingestClaimTimeout = 5 * time.Minute
+	// ingestRetryBase / ingestRetryMax 失败重试的退避时长，每次翻倍
+	ingestRetryBase = 5 * time.Second
+	ingestRetryMax  = 5 * time.Minute
+)
+
+// QueueConfig 异步处理队列配置
+type QueueConfig struct {
+	Workers      int           // 处理协程数
+	PollInterval time.Duration // 队列为空时的检查间隔
+	MaxAttempts  int           // 最大处理次数
+}
+
+// QueueConfigFromEnv 从环境变量读取处理队列配置
+//   - INGEST_WORKERS: 处理协程数，默认 4
+//   - INGEST_POLL_INTERVAL: 队列为空时的检查间隔，默认 1s
+//   - INGEST_MAX_ATTEMPTS: 数据库错误等可重试失败的最大处理次数，默认 5
+func QueueConfigFromEnv() (QueueConfig, error) {
+	config := QueueConfig{
+		Workers:      defaultIngestWorkers,
+		PollInterval: defaultIngestPollInterval,
+		MaxAttempts:  defaultIngestMaxAttempts,
+	}
+	if value := os.Getenv("INGEST_WORKERS"); value != "" {
+		workers, err := strconv.Atoi(value)
+		if err != nil || workers <= 0 {
+			return QueueConfig{}, fmt.Errorf("invalid INGEST_WORKERS %q", value)
+		}
+		config.Workers = workers
+	}
+	if value := os.Getenv("INGEST_POLL_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval <= 0 {
+			return QueueConfig{}, fmt.Errorf("invalid INGEST_POLL_INTERVAL %q", value)
+		}
+		config.PollInterval = interval
+	}
+	if value := os.Getenv("INGEST_MAX_ATTEMPTS"); value != "" {
+		attempts, err := strconv.Atoi(value)
+		if err != nil || attempts <= 0 {
+			return QueueConfig{}, fmt.Errorf("invalid INGEST_MAX_ATTEMPTS %q", value)
+		}
+		config.MaxAttempts = attempts
+	}
+	return config, nil
+}
+
+// IngestQueue 异步处理队列：POST /webhook 将原始推送保存到 webhook_archive 后立即返回，
+// 后台协程领取待处理的推送解析写入，数据库变慢或批次很大时不影响推送方的投递
+type IngestQueue struct {
+	ingester *Ingester
+	config   QueueConfig
+	wake     chan struct{}
+}
+
+// NewIngestQueue 创建处理队列
+func NewIngestQueue(ingester *Ingester, config QueueConfig) *IngestQueue {
+	return &IngestQueue{ingester: ingester, config: config, wake: make(chan struct{}, config.Workers)}
+}
+
+// StartIngestQueue 从环境变量读取配置并在后台启动处理协程，receivers 见 ReceiversFromEnv
+func StartIngestQueue(ctx context.Context, pool *pgxpool.Pool, log *xlog.XLog, receivers []string) (*IngestQueue, error) {
+	config, err := QueueConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	q := NewIngestQueue(NewIngester(ctx, pool, log, receivers), config)
+	for i := 0; i < config.Workers; i++ {
+		go q.run()
+	}
+	log.Info("Webhook ingest queue started", "workers", config.Workers, "max_attempts", config.MaxAttempts)
+	return q, nil
+}
+
+// Enqueue 保存原始推送并唤醒处理协程，返回归档ID；返回成功后推送不会丢失
+func (q *IngestQueue) Enqueue(archive *db.WebhookArchive) (int64, error) {
+	id, err := db.InsertWebhookArchive(q.ingester.ctx, q.ingester.pool, archive)
+	if err != nil {
+		return 0, err
+	}
+	q.notify()
+	return id, nil
+}
+
+// notify 唤醒一个空闲的处理协程，都在忙时不阻塞
+func (q *IngestQueue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run 处理协程：持续领取推送，队列为空时等待唤醒或 PollInterval
+func (q *IngestQueue) run() {
+	ticker := time.NewTicker(q.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		processed, err := q.processNext()
+		if err != nil {
+			q.ingester.log.Error("Webhook ingest queue failed", err, "module", "webhook")
+		}
+		if processed {
+			continue
+		}
+		select {
+		case <-q.ingester.ctx.Done():
+			return
+		case <-q.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// processNext 领取并处理一条推送，没有待处理的推送时返回 false
+func (q *IngestQueue) processNext() (bool, error) {
+	in := q.ingester
+	archive, err := db.ClaimWebhookArchive(in.ctx, in.pool, ingestClaimTimeout)
+	if err != nil || archive == nil {
+		return false, err
+	}
+
+	result, err := q.ingest(archive)
+	if err == nil {
+		in.log.Info("Webhook batch processed",
+			"module", "webhook",
+			"archive_id", archive.ID,
+			"attempts", archive.Attempts,
+			"inserted", result.Inserted,
+			"duplicates", result.Duplicates,
+			"skipped", result.Stats.Skipped(),
+			"queue_latency", time.Since(archive.ReceiveTime).String(),
+		)
+		return true, db.CompleteWebhookArchive(in.ctx, in.pool, archive.ID, result.Inserted, result.Stats.Skipped())
+	}
+
+	// 无法解析的推送重试也不会成功，数据库错误等按退避时长重试
+	if errors.Is(err, ErrInvalidPayload) || archive.Attempts >= q.config.MaxAttempts {
+		in.log.Error("Webhook batch failed", err, "module", "webhook", "archive_id", archive.ID, "attempts", archive.Attempts)
+		return true, db.FailWebhookArchive(in.ctx, in.pool, archive.ID, err.Error())
+	}
+	delay := retryDelay(archive.Attempts)
+	in.log.Warn("Webhook batch will be retried", "module", "webhook", "archive_id", archive.ID, "attempts", archive.Attempts, "reason", err.Error(), "retry_in", delay.String())
+	return true, db.RetryWebhookArchive(in.ctx, in.pool, archive.ID, err.Error(), delay)
+}
+
+// ingest 解压并处理一条推送
+func (q *IngestQueue) ingest(archive *db.WebhookArchive) (*IngestResult, error) {
+	body, err := DecompressBody(archive.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	return q.ingester.Ingest(archive.Provider, body)
+}
+
+// retryDelay 第 attempts 次处理失败后的重试等待时长，从 ingestRetryBase 开始翻倍，不超过 ingestRetryMax
+func retryDelay(attempts int) time.Duration {
+	delay := ingestRetryBase
+	for i := 1; i < attempts && delay < ingestRetryMax; i++ {
+		delay *= 2
+	}
+	if delay > ingestRetryMax {
+		delay = ingestRetryMax
+	}
+	return delay
+}
@@ -0,0 +1,58 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueueConfigFromEnv(t *testing.T) {
+	t.Setenv("INGEST_WORKERS", "")
+	t.Setenv("INGEST_POLL_INTERVAL", "")
+	t.Setenv("INGEST_MAX_ATTEMPTS", "")
+	config, err := QueueConfigFromEnv()
+	if err != nil || config.Workers != defaultIngestWorkers || config.PollInterval != defaultIngestPollInterval || config.MaxAttempts != defaultIngestMaxAttempts {
+		t.Errorf("Expected defaults, got %+v, %v", config, err)
+	}
+
+	t.Setenv("INGEST_WORKERS", "8")
+	t.Setenv("INGEST_POLL_INTERVAL", "200ms")
+	t.Setenv("INGEST_MAX_ATTEMPTS", "3")
+	config, err = QueueConfigFromEnv()
+	if err != nil || config.Workers != 8 || config.PollInterval != 200*time.Millisecond || config.MaxAttempts != 3 {
+		t.Errorf("Expected 8 workers, 200ms poll and 3 attempts, got %+v, %v", config, err)
+	}
+
+	for name, value := range map[string]string{"INGEST_WORKERS": "0", "INGEST_POLL_INTERVAL": "fast", "INGEST_MAX_ATTEMPTS": "-1"} {
+		t.Setenv(name, value)
+		if _, err := QueueConfigFromEnv(); err == nil {
+			t.Errorf("Expected error for %s=%q", name, value)
+		}
+		t.Setenv(name, "")
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	testCases := map[int]time.Duration{
+		1:  5 * time.Second,
+		2:  10 * time.Second,
+		4:  40 * time.Second,
+		7:  ingestRetryMax,
+		20: ingestRetryMax,
+	}
+	for attempts, expected := range testCases {
+		if delay := retryDelay(attempts); delay != expected {
+			t.Errorf("attempt %d: expected %v, got %v", attempts, expected, delay)
+		}
+	}
+}
+
+func TestIngestQueueNotify(t *testing.T) {
+	q := NewIngestQueue(nil, QueueConfig{Workers: 2})
+	// 处理协程都在忙时唤醒信号不阻塞推送处理
+	for i := 0; i < 5; i++ {
+		q.notify()
+	}
+	if len(q.wake) != 2 {
+		t.Errorf("Expected wake buffer to be capped at 2 workers, got %d", len(q.wake))
+	}
+}
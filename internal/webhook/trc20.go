@@ -0,0 +1,143 @@
+package webhook
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"lending-trx/internal/units"
+)
+
+const (
+	// trc20TransferSelector transfer(address,uint256) 的方法选择器
+	trc20TransferSelector = "a9059cbb"
+	// trc20TransferTopic Transfer(address,address,uint256) 事件的 topic0
+	trc20TransferTopic = "ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+	// abiWordLength ABI 编码中一个参数的十六进制长度（32字节）
+	abiWordLength = 64
+)
+
+// TokenTransfer 解析出的 TRC20 转账
+type TokenTransfer struct {
+	Contract string // 代币合约地址
+	From     string // 转出地址
+	To       string // 转入地址
+	Amount   string // 转账数量（代币最小单位，十进制字符串）
+	FromLog  bool   // 来自 Transfer 事件（反映实际到账）；否则来自 transfer 调用参数，执行结果未知
+}
+
+// decodeTRC20TransferInput 解析 transfer(address,uint256) 调用的 input，不是 transfer 调用时 ok 为 false
+// 返回的地址为 0x 十六进制形式
+func decodeTRC20TransferInput(input string) (to string, amount string, ok bool, err error) {
+	data := strings.ToLower(strings.TrimPrefix(input, "0x"))
+	if !strings.HasPrefix(data, trc20TransferSelector) {
+		return "", "", false, nil
+	}
+	args := data[len(trc20TransferSelector):]
+	if len(args) < 2*abiWordLength {
+		return "", "", false, fmt.Errorf("invalid transfer input length %d", len(data))
+	}
+	if to, err = abiAddress(args[:abiWordLength]); err != nil {
+		return "", "", false, err
+	}
+	if amount, err = abiUint256(args[abiWordLength : 2*abiWordLength]); err != nil {
+		return "", "", false, err
+	}
+	return to, amount, true, nil
+}
+
+// decodeTransferLog 解析 Transfer 事件日志，不是 Transfer 事件时 ok 为 false
+func decodeTransferLog(log LogData) (transfer TokenTransfer, ok bool, err error) {
+	if len(log.Topics) != 3 || strings.ToLower(strings.TrimPrefix(log.Topics[0], "0x")) != trc20TransferTopic {
+		return TokenTransfer{}, false, nil
+	}
+	if transfer.From, err = abiAddress(strings.TrimPrefix(log.Topics[1], "0x")); err != nil {
+		return TokenTransfer{}, false, err
+	}
+	if transfer.To, err = abiAddress(strings.TrimPrefix(log.Topics[2], "0x")); err != nil {
+		return TokenTransfer{}, false, err
+	}
+	data := strings.TrimPrefix(log.Data, "0x")
+	if len(data) != abiWordLength {
+		return TokenTransfer{}, false, fmt.Errorf("invalid transfer log data length %d", len(data))
+	}
+	if transfer.Amount, err = abiUint256(data); err != nil {
+		return TokenTransfer{}, false, err
+	}
+	transfer.Contract = log.Address
+	return transfer, true, nil
+}
+
+// decodeTokenTransfer 解析交易中付款方发起的 TRC20 转账
+// 有事件日志时以 Transfer 事件为准（反映实际到账金额），否则解析调用合约的 transfer input（调用可能已回滚，见 TokenTransfer.FromLog）；
+// 不是 TRC20 转账时返回 nil
+func decodeTokenTransfer(tx TransactionData) (*TokenTransfer, error) {
+	if len(tx.Logs) > 0 {
+		from, err := normalizeAddress(tx.From)
+		if err != nil {
+			return nil, err
+		}
+		for _, log := range tx.Logs {
+			transfer, ok, err := decodeTransferLog(log)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode transfer log: %w", err)
+			}
+			if !ok {
+				continue
+			}
+			if transfer.From, err = normalizeAddress(transfer.From); err != nil {
+				return nil, err
+			}
+			if transfer.From != from {
+				continue
+			}
+			if transfer.To, err = normalizeAddress(transfer.To); err != nil {
+				return nil, err
+			}
+			if transfer.Contract, err = normalizeAddress(transfer.Contract); err != nil {
+				return nil, err
+			}
+			transfer.FromLog = true
+			return &transfer, nil
+		}
+	}
+
+	to, amount, ok, err := decodeTRC20TransferInput(tx.Input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode transfer input: %w", err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	transfer := &TokenTransfer{Amount: amount}
+	if transfer.Contract, err = normalizeAddress(tx.To); err != nil {
+		return nil, err
+	}
+	if transfer.From, err = normalizeAddress(tx.From); err != nil {
+		return nil, err
+	}
+	if transfer.To, err = normalizeAddress(to); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// abiAddress 从 32 字节的 ABI 参数中取出地址（低 20 字节），返回 0x 十六进制形式
+func abiAddress(word string) (string, error) {
+	if len(word) != abiWordLength {
+		return "", fmt.Errorf("invalid address word length %d", len(word))
+	}
+	if _, ok := new(big.Int).SetString(word, 16); !ok {
+		return "", fmt.Errorf("invalid address word %q", word)
+	}
+	return "0x" + word[abiWordLength-40:], nil
+}
+
+// abiUint256 将 32 字节的 ABI 参数转换为十进制字符串
+func abiUint256(word string) (string, error) {
+	value, err := units.ParseHexAmount(word)
+	if err != nil {
+		return "", fmt.Errorf("invalid uint256 word %q", word)
+	}
+	return value.String(), nil
+}
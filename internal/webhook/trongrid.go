@@ -0,0 +1,109 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"lending-trx/internal/units"
+)
+
+// trc20TransferEvent TRC20 Transfer 事件名
+const trc20TransferEvent = "Transfer"
+
+// tronGridEvent TronGrid 事件服务的合约事件（/v1/contracts/{address}/events 和事件订阅推送的格式）
+type tronGridEvent struct {
+	BlockNumber     int64             `json:"block_number"`
+	BlockTimestamp  int64             `json:"block_timestamp"`  // 区块时间（毫秒）
+	ContractAddress string            `json:"contract_address"` // 合约地址（Base58Check）
+	EventName       string            `json:"event_name"`
+	TransactionID   string            `json:"transaction_id"` // 交易哈希，不带 0x 前缀
+	Result          map[string]string `json:"result"`         // 事件参数，Transfer 为 from / to / value（十进制）
+}
+
+// tronGridParser TronGrid 事件服务推送，接受事件数组或 {"data": [...]}
+// 只有 TRC20 Transfer 事件可能是支付，其他事件按与收款地址无关处理；事件不包含区块哈希，不做回滚检查
+type tronGridParser struct{}
+
+// Parse 解析合约事件
+func (tronGridParser) Parse(body []byte) (*ParsedBatch, error) {
+	events, err := decodeTronGridEvents(body)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &ParsedBatch{Transactions: make([]ParsedTransaction, len(events))}
+	for i, event := range events {
+		data, err := convertTronGridEvent(event)
+		// 事件不包含交易执行结果，TRC20 转账都需要按交易回执核实
+		batch.Transactions[i] = ParsedTransaction{Data: data, Unverified: data.TokenContract != "", Err: err}
+		if batch.Metadata.BatchStartRange == 0 || event.BlockNumber < batch.Metadata.BatchStartRange {
+			batch.Metadata.BatchStartRange = event.BlockNumber
+		}
+		if event.BlockNumber > batch.Metadata.BatchEndRange {
+			batch.Metadata.BatchEndRange = event.BlockNumber
+		}
+	}
+	return batch, nil
+}
+
+// decodeTronGridEvents 按推送的 JSON 形式解码事件列表
+func decodeTronGridEvents(body []byte) ([]tronGridEvent, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var events []tronGridEvent
+		if err := json.Unmarshal(body, &events); err != nil {
+			return nil, err
+		}
+		return events, nil
+	}
+	var response struct {
+		Data []tronGridEvent `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// convertTronGridEvent 将合约事件转换为 WebhookData，非 Transfer 事件的 ToAddress 为空
+func convertTronGridEvent(event tronGridEvent) (WebhookData, error) {
+	if event.TransactionID == "" {
+		return WebhookData{}, fmt.Errorf("event without transaction_id")
+	}
+	blockTime := event.BlockTimestamp / 1000
+	data := WebhookData{
+		BlockHeight: event.BlockNumber,
+		TxHash:      "0x" + strings.ToLower(strings.TrimPrefix(event.TransactionID, "0x")), // 与 stream 推送的 hash 一致，按 tx_hash 去重
+		Value:       "0",
+		BlockTime:   blockTime,
+		ExpireTime:  blockTime + 3600, // BlockTime + 1小时 (3600秒)
+		Status:      0,
+	}
+	if event.EventName != trc20TransferEvent {
+		return data, nil
+	}
+
+	contract, err := normalizeAddress(event.ContractAddress)
+	if err != nil {
+		return WebhookData{}, err
+	}
+	from, err := normalizeAddress(event.Result["from"])
+	if err != nil {
+		return WebhookData{}, err
+	}
+	to, err := normalizeAddress(event.Result["to"])
+	if err != nil {
+		return WebhookData{}, err
+	}
+	amount, err := units.ParseAmount(event.Result["value"])
+	if err != nil {
+		return WebhookData{}, err
+	}
+	data.FromAddress = from
+	data.ToAddress = to
+	data.TokenContract = contract
+	data.TokenAmount = amount.String()
+	return data, nil
+}
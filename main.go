@@ -13,6 +13,8 @@ import (
 
 	"lending-trx/internal/cronjob"
 	"lending-trx/internal/db"
+	"lending-trx/internal/scanner"
+	"lending-trx/internal/tron"
 	"lending-trx/internal/webhook"
 )
 
@@ -24,6 +26,20 @@ func main() {
 
 	fmt.Println("🚀 启动TRX委托服务...")
 
+	auth, err := webhook.ProviderAuthFromEnv()
+	if err != nil {
+		log.Fatal("❌ Webhook 签名配置错误:", err)
+	}
+	receivers, err := webhook.ReceiversFromEnv()
+	if err != nil {
+		log.Fatal("❌ 收款地址配置错误:", err)
+	}
+
+	// 远程签名服务不签署质押交易，启用质押管理时必须使用本地私钥或 keystore
+	if err := cronjob.CheckStakingConfig(); err != nil {
+		log.Fatal("❌ 质押管理配置错误:", err)
+	}
+
 	ctx := context.Background()
 	pool, err := db.InitDB(ctx)
 	if err != nil {
@@ -37,12 +53,32 @@ func main() {
 		BuildFormatter(xlog.FORMAT_JSON).
 		BuildFile("logs/lending-trx.log", 24*time.Hour)
 
+	// 创建共享的 Tron 客户端，并启动节点健康检查
+	tronClient := tron.NewTronClientFromEnv()
+	tronClient.StartHealthCheck(ctx, tronHealthCheckInterval())
+
 	// 启动定时任务
-	cronjob.StartCron(ctx, pool, LOG)
+	cronjob.StartCron(ctx, pool, LOG, tronClient)
+
+	// 定期清理过期的原始推送
+	if err := webhook.StartArchiveCleanup(ctx, pool, LOG); err != nil {
+		log.Fatal("❌ 原始推送清理任务启动失败:", err)
+	}
+
+	// 补齐 stream 推送缺失的区块
+	if err := scanner.StartBackfill(ctx, pool, LOG, tronClient, receivers); err != nil {
+		log.Fatal("❌ Stream 补齐任务启动失败:", err)
+	}
+
+	// 启动 webhook 处理队列
+	queue, err := webhook.StartIngestQueue(ctx, pool, LOG, receivers)
+	if err != nil {
+		log.Fatal("❌ Webhook 处理队列启动失败:", err)
+	}
 
 	// 启动 gin HTTP 服务
 	r := gin.Default()
-	webhook.RegisterRoutes(r, ctx, pool, LOG)
+	webhook.RegisterRoutes(r, ctx, pool, LOG, tronClient, auth, queue)
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -52,7 +88,18 @@ func main() {
 	fmt.Printf("✅ TRX委托服务已启动，监听端口: %s\n", port)
 	fmt.Printf("📡 API地址: http://localhost:%s\n", port)
 	fmt.Printf("📊 委托账户查询: http://localhost:%s/api/delegation-account\n", port)
+	fmt.Printf("🛰️ 节点状态查询: http://localhost:%s/api/tron-nodes\n", port)
 	fmt.Printf("📝 日志文件: logs/lending-trx.log\n")
 
 	r.Run(":" + port)
 }
+
+// tronHealthCheckInterval 节点健康检查间隔，默认30秒
+func tronHealthCheckInterval() time.Duration {
+	if value := os.Getenv("TRON_HEALTH_CHECK_INTERVAL"); value != "" {
+		if interval, err := time.ParseDuration(value); err == nil && interval > 0 {
+			return interval
+		}
+	}
+	return 30 * time.Second
+}
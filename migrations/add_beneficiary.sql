@@ -0,0 +1,14 @@
+-- 添加受益地址字段到 webhook_data 表
+-- 支付交易备注（raw_data.data）中指定的 TRON 地址，资源委托给该地址而不是付款方，回收时从该地址回收
+-- 为空时（历史订单、没有备注或备注中没有有效地址）委托给付款方 from_address
+
+ALTER TABLE webhook_data ADD COLUMN IF NOT EXISTS beneficiary VARCHAR(64);
+
+-- 验证字段添加成功
+SELECT 
+    column_name, 
+    data_type, 
+    is_nullable
+FROM information_schema.columns 
+WHERE table_name = 'webhook_data' 
+AND column_name = 'beneficiary';
@@ -0,0 +1,14 @@
+-- 添加区块哈希字段到 webhook_data 表
+-- 支付所在区块的哈希，委托前与主链同高度区块比对，不一致说明支付已被链重组回滚，订单标记为已回滚 (status=9)
+-- 历史订单没有区块哈希，只检查确认深度和固化
+
+ALTER TABLE webhook_data ADD COLUMN IF NOT EXISTS block_hash VARCHAR(128);
+
+-- 验证字段添加成功
+SELECT 
+    column_name, 
+    data_type, 
+    is_nullable
+FROM information_schema.columns 
+WHERE table_name = 'webhook_data' 
+AND column_name = 'block_hash';
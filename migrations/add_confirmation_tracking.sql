@@ -0,0 +1,23 @@
+-- 添加链上确认跟踪字段到 webhook_data 表
+-- 委托/回收交易广播后需等待固化，记录回执中的区块高度、手续费和执行结果
+
+ALTER TABLE webhook_data ADD COLUMN IF NOT EXISTS delegate_block_number BIGINT;
+ALTER TABLE webhook_data ADD COLUMN IF NOT EXISTS delegate_fee BIGINT;
+ALTER TABLE webhook_data ADD COLUMN IF NOT EXISTS delegate_result VARCHAR(255);
+ALTER TABLE webhook_data ADD COLUMN IF NOT EXISTS reclaim_tx_id VARCHAR(255) UNIQUE;
+ALTER TABLE webhook_data ADD COLUMN IF NOT EXISTS reclaim_block_number BIGINT;
+ALTER TABLE webhook_data ADD COLUMN IF NOT EXISTS reclaim_fee BIGINT;
+ALTER TABLE webhook_data ADD COLUMN IF NOT EXISTS reclaim_result VARCHAR(255);
+
+-- 确认跟踪按状态轮询
+CREATE INDEX IF NOT EXISTS idx_webhook_data_status ON webhook_data(status);
+
+-- 验证字段添加成功
+SELECT 
+    column_name, 
+    data_type, 
+    is_nullable
+FROM information_schema.columns 
+WHERE table_name = 'webhook_data' 
+AND column_name IN ('delegate_block_number', 'delegate_fee', 'delegate_result',
+                    'reclaim_tx_id', 'reclaim_block_number', 'reclaim_fee', 'reclaim_result');
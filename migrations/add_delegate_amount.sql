@@ -0,0 +1,13 @@
+-- 添加 delegate_amount 字段到 webhook_data 表
+-- 记录实际委托的质押金额（SUN），回收时 undelegateresource 需要按金额回收
+
+ALTER TABLE webhook_data ADD COLUMN IF NOT EXISTS delegate_amount NUMERIC(36,0);
+
+-- 验证字段添加成功
+SELECT 
+    column_name, 
+    data_type, 
+    is_nullable
+FROM information_schema.columns 
+WHERE table_name = 'webhook_data' 
+AND column_name = 'delegate_amount';
@@ -0,0 +1,15 @@
+-- 添加委托锁定字段到 webhook_data 表
+-- 委托时可按租期锁定（Stake 2.0 delegateresource 的 lock / lock_period），锁定期内无法 undelegate
+-- lock_period 为锁定期（区块数，每块3秒），lock_expire_time 为委托交易上链后计算的锁定到期时间（毫秒时间戳）
+
+ALTER TABLE webhook_data ADD COLUMN IF NOT EXISTS lock_period BIGINT;
+ALTER TABLE webhook_data ADD COLUMN IF NOT EXISTS lock_expire_time BIGINT;
+
+-- 验证字段添加成功
+SELECT 
+    column_name, 
+    data_type, 
+    is_nullable
+FROM information_schema.columns 
+WHERE table_name = 'webhook_data' 
+AND column_name IN ('lock_period', 'lock_expire_time');
@@ -0,0 +1,19 @@
+-- 添加交易资源消耗字段到 webhook_data 表
+-- 委托/回收交易回执中的带宽消耗、带宽燃烧和能量燃烧，delegate_fee / reclaim_fee 为燃烧的 TRX 合计
+-- 用于按订单和支付档位统计毛利（支付金额 - 链上成本，见 db.QueryOrderMargins / db.QueryTierMargins）
+
+ALTER TABLE webhook_data ADD COLUMN IF NOT EXISTS delegate_net_usage BIGINT;
+ALTER TABLE webhook_data ADD COLUMN IF NOT EXISTS delegate_net_fee BIGINT;
+ALTER TABLE webhook_data ADD COLUMN IF NOT EXISTS delegate_energy_fee BIGINT;
+ALTER TABLE webhook_data ADD COLUMN IF NOT EXISTS reclaim_net_usage BIGINT;
+ALTER TABLE webhook_data ADD COLUMN IF NOT EXISTS reclaim_net_fee BIGINT;
+ALTER TABLE webhook_data ADD COLUMN IF NOT EXISTS reclaim_energy_fee BIGINT;
+
+-- 验证字段添加成功
+SELECT 
+    column_name, 
+    data_type, 
+    is_nullable
+FROM information_schema.columns 
+WHERE table_name = 'webhook_data' 
+AND column_name IN ('delegate_net_usage', 'delegate_net_fee', 'delegate_energy_fee', 'reclaim_net_usage', 'reclaim_net_fee', 'reclaim_energy_fee');
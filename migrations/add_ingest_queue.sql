@@ -0,0 +1,26 @@
+-- webhook_archive 作为异步处理队列
+-- POST /webhook 保存原始推送后立即返回，后台处理队列从 webhook_archive 领取待处理的推送解析写入，
+-- 并记录每条推送的处理状态、尝试次数和失败原因；已有的推送均已同步处理，标记为 done
+
+ALTER TABLE webhook_archive ADD COLUMN IF NOT EXISTS ingest_status VARCHAR(16) NOT NULL DEFAULT 'done';
+ALTER TABLE webhook_archive ALTER COLUMN ingest_status SET DEFAULT 'pending';
+ALTER TABLE webhook_archive ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE webhook_archive ADD COLUMN IF NOT EXISTS available_time TIMESTAMP NOT NULL DEFAULT NOW();
+ALTER TABLE webhook_archive ADD COLUMN IF NOT EXISTS claim_time TIMESTAMP;
+ALTER TABLE webhook_archive ADD COLUMN IF NOT EXISTS processed_time TIMESTAMP;
+ALTER TABLE webhook_archive ADD COLUMN IF NOT EXISTS inserted_count INT NOT NULL DEFAULT 0;
+ALTER TABLE webhook_archive ADD COLUMN IF NOT EXISTS skipped_count INT NOT NULL DEFAULT 0;
+ALTER TABLE webhook_archive ADD COLUMN IF NOT EXISTS last_error TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_webhook_archive_queue ON webhook_archive (available_time)
+  WHERE ingest_status IN ('pending', 'processing');
+
+-- 验证字段添加成功
+SELECT
+    column_name,
+    data_type,
+    is_nullable,
+    column_default
+FROM information_schema.columns
+WHERE table_name = 'webhook_archive'
+  AND column_name IN ('ingest_status', 'attempts', 'available_time', 'claim_time', 'processed_time', 'inserted_count', 'skipped_count', 'last_error');
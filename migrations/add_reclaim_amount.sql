@@ -0,0 +1,14 @@
+-- 添加回收金额字段到 webhook_data 表
+-- 同一接收方的委托在链上按 (owner, receiver, resource) 聚合，回收按金额而不是按交易
+-- 记录每个订单实际 undelegate 的金额，回收中的金额在计算同一接收方的剩余委托时需要扣除
+
+ALTER TABLE webhook_data ADD COLUMN IF NOT EXISTS reclaim_amount NUMERIC(36,0);
+
+-- 验证字段添加成功
+SELECT 
+    column_name, 
+    data_type, 
+    is_nullable
+FROM information_schema.columns 
+WHERE table_name = 'webhook_data' 
+AND column_name = 'reclaim_amount';
@@ -0,0 +1,14 @@
+-- 添加资源类型字段到 webhook_data 表
+-- 订单可租用能量 (ENERGY) 或带宽 (BANDWIDTH)，委托时按支付档位确定，回收时按该资源 undelegate
+-- 历史订单没有资源类型，均为能量
+
+ALTER TABLE webhook_data ADD COLUMN IF NOT EXISTS resource VARCHAR(16);
+
+-- 验证字段添加成功
+SELECT 
+    column_name, 
+    data_type, 
+    is_nullable
+FROM information_schema.columns 
+WHERE table_name = 'webhook_data' 
+AND column_name = 'resource';
@@ -0,0 +1,18 @@
+-- 添加区块扫描进度表
+-- 内置区块扫描（server --ingest=scanner）按区块高度顺序处理，已处理的最后一个区块记录在 scan_cursor，
+-- 与该批区块的 webhook_data 在同一事务中写入，重启后从下一个区块继续
+
+CREATE TABLE IF NOT EXISTS scan_cursor (
+  name VARCHAR(64) PRIMARY KEY,
+  block_number BIGINT NOT NULL,
+  block_hash VARCHAR(128),
+  update_time TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- 验证表创建成功
+SELECT 
+    column_name, 
+    data_type, 
+    is_nullable
+FROM information_schema.columns 
+WHERE table_name = 'scan_cursor';
@@ -0,0 +1,25 @@
+-- 添加 stream 批次记录表
+-- 每批 stream 推送的区块范围（metadata 的 batch_start_range / batch_end_range）记录在 stream_batches，
+-- 按 stream_id 检查范围是否连续，缺失的区块由补齐任务从节点拉取后以 source = 'backfill' 记录
+
+CREATE TABLE IF NOT EXISTS stream_batches (
+  id SERIAL PRIMARY KEY,
+  stream_id VARCHAR(64) NOT NULL,
+  network VARCHAR(64),
+  start_block BIGINT NOT NULL,
+  end_block BIGINT NOT NULL,
+  tx_count INT NOT NULL DEFAULT 0,
+  payment_count INT NOT NULL DEFAULT 0,
+  source VARCHAR(16) NOT NULL DEFAULT 'stream',
+  create_time TIMESTAMP NOT NULL DEFAULT NOW(),
+  UNIQUE (stream_id, start_block, end_block)
+);
+CREATE INDEX IF NOT EXISTS idx_stream_batches_range ON stream_batches (stream_id, end_block);
+
+-- 验证表创建成功
+SELECT 
+    column_name, 
+    data_type, 
+    is_nullable
+FROM information_schema.columns 
+WHERE table_name = 'stream_batches';
@@ -0,0 +1,15 @@
+-- 添加 TRC20 支付字段到 webhook_data 表
+-- token_contract: 支付代币的合约地址（如 USDT），TRX 支付为 NULL
+-- token_amount: 支付的代币数量（最小单位，uint256 最大 78 位十进制）
+
+ALTER TABLE webhook_data ADD COLUMN IF NOT EXISTS token_contract VARCHAR(64);
+ALTER TABLE webhook_data ADD COLUMN IF NOT EXISTS token_amount NUMERIC(78,0);
+
+-- 验证字段添加成功
+SELECT 
+    column_name, 
+    data_type, 
+    is_nullable
+FROM information_schema.columns 
+WHERE table_name = 'webhook_data' 
+AND column_name IN ('token_contract', 'token_amount');
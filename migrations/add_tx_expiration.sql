@@ -0,0 +1,15 @@
+-- 添加委托/回收交易过期时间字段到 webhook_data 表
+-- 交易签名后、广播前与交易ID一起保存，广播结果不确定时由确认跟踪等待交易过期后再判断是否丢弃
+-- delegate_tx_expiration / reclaim_tx_expiration 为交易 raw_data.expiration（毫秒时间戳）
+
+ALTER TABLE webhook_data ADD COLUMN IF NOT EXISTS delegate_tx_expiration BIGINT;
+ALTER TABLE webhook_data ADD COLUMN IF NOT EXISTS reclaim_tx_expiration BIGINT;
+
+-- 验证字段添加成功
+SELECT 
+    column_name, 
+    data_type, 
+    is_nullable
+FROM information_schema.columns 
+WHERE table_name = 'webhook_data' 
+AND column_name IN ('delegate_tx_expiration', 'reclaim_tx_expiration');
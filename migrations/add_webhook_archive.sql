@@ -0,0 +1,23 @@
+-- 添加原始 webhook 推送归档表
+-- 每次通过签名校验的推送（请求体 gzip 压缩、请求头、接收时间）保存在 webhook_archive，
+-- 修复解析逻辑后可通过 lending-trx replay 或 POST /admin/replay 重新处理，超过 WEBHOOK_ARCHIVE_RETENTION 的记录定期删除
+
+CREATE TABLE IF NOT EXISTS webhook_archive (
+  id BIGSERIAL PRIMARY KEY,
+  receive_time TIMESTAMP NOT NULL DEFAULT NOW(),
+  remote_addr VARCHAR(64),
+  headers JSONB,
+  body BYTEA NOT NULL,
+  body_size INT NOT NULL,
+  replay_count INT NOT NULL DEFAULT 0,
+  last_replay_time TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_archive_receive_time ON webhook_archive (receive_time);
+
+-- 验证表创建成功
+SELECT 
+    column_name, 
+    data_type, 
+    is_nullable
+FROM information_schema.columns 
+WHERE table_name = 'webhook_archive';
@@ -0,0 +1,15 @@
+-- 为 webhook_archive 添加推送数据源
+-- POST /webhook/:provider 按数据源选择解析适配器（quicknode / trongrid / block），处理队列和重放按记录的数据源解析；
+-- 已有的推送均来自 POST /webhook，为 quicknode 格式
+
+ALTER TABLE webhook_archive ADD COLUMN IF NOT EXISTS provider VARCHAR(32) NOT NULL DEFAULT 'quicknode';
+
+-- 验证字段添加成功
+SELECT
+    column_name,
+    data_type,
+    is_nullable,
+    column_default
+FROM information_schema.columns
+WHERE table_name = 'webhook_archive'
+  AND column_name = 'provider';
@@ -0,0 +1,10 @@
+-- 统一 webhook_data.expire_time 的单位为秒级时间戳
+-- expire_time 写入时为区块时间（秒）+ 3600，查询过期订单时按秒比较；将以毫秒写入的历史记录转换为秒
+
+UPDATE webhook_data SET expire_time = expire_time / 1000 WHERE expire_time >= 1000000000000;
+
+-- 验证没有毫秒时间戳的记录
+SELECT 
+    COUNT(*) AS millisecond_rows
+FROM webhook_data 
+WHERE expire_time >= 1000000000000;
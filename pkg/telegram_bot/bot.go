@@ -10,6 +10,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"lending-trx/internal/units"
 )
 
 // TelegramBot Telegram Bot结构体
@@ -84,11 +86,16 @@ type SendMessageResponse struct {
 type DelegationAccountInfo struct {
 	Status string `json:"status"`
 	Data   struct {
-		Address     string `json:"address"`
-		Balance     string `json:"balance"`
-		Energy      string `json:"energy"`
-		EnergyLimit string `json:"energy_limit"`
-		EnergyUsed  string `json:"energy_used"`
+		Address              string `json:"address"`
+		Balance              string `json:"balance"`
+		Energy               string `json:"energy"`
+		EnergyLimit          string `json:"energy_limit"`
+		EnergyUsed           string `json:"energy_used"`
+		Bandwidth            string `json:"bandwidth"`
+		BandwidthLimit       string `json:"bandwidth_limit"`
+		BandwidthUsed        string `json:"bandwidth_used"`
+		DelegatableEnergy    string `json:"delegatable_energy"`    // 可委托的能量质押金额（SUN）
+		DelegatableBandwidth string `json:"delegatable_bandwidth"` // 可委托的带宽质押金额（SUN）
 	} `json:"data"`
 }
 
@@ -207,12 +214,11 @@ func (bot *TelegramBot) FormatAccountInfo(info *DelegationAccountInfo) string {
 		return "❌ 获取账户信息失败"
 	}
 
-	// 解析余额 (SUN -> TRX)
-	balanceSUN, err := strconv.ParseInt(info.Data.Balance, 10, 64)
+	// 解析余额 (SUN)，按任意精度整数换算为 TRX，不经过 float64
+	balance, err := units.ParseAmount(info.Data.Balance)
 	if err != nil {
 		return "❌ 解析余额失败"
 	}
-	balanceTRX := float64(balanceSUN) / 1000000.0
 
 	// 解析能量信息
 	energy, err := strconv.ParseInt(info.Data.Energy, 10, 64)
@@ -236,6 +242,13 @@ func (bot *TelegramBot) FormatAccountInfo(info *DelegationAccountInfo) string {
 		usagePercent = float64(energyUsed) / float64(energyLimit) * 100
 	}
 
+	// 解析带宽和可委托库存，旧版本服务不返回这些字段时按0处理
+	bandwidth := parseOptionalInt(info.Data.Bandwidth)
+	bandwidthLimit := parseOptionalInt(info.Data.BandwidthLimit)
+	bandwidthUsed := parseOptionalInt(info.Data.BandwidthUsed)
+	delegatableEnergy := parseOptionalAmount(info.Data.DelegatableEnergy)
+	delegatableBandwidth := parseOptionalAmount(info.Data.DelegatableBandwidth)
+
 	// 构建消息
 	message := fmt.Sprintf(`📊 <b>委托账户状态报告</b>
 
@@ -243,7 +256,7 @@ func (bot *TelegramBot) FormatAccountInfo(info *DelegationAccountInfo) string {
 <code>%s</code>
 
 💰 <b>账户余额:</b>
-%.6f TRX
+%s TRX
 
 ⚡ <b>能量状态:</b>
 • 可用能量: %d
@@ -251,21 +264,35 @@ func (bot *TelegramBot) FormatAccountInfo(info *DelegationAccountInfo) string {
 • 已用能量: %d
 • 使用率: %.2f%%
 
+📶 <b>带宽状态:</b>
+• 可用带宽: %d
+• 带宽限制: %d
+• 已用带宽: %d
+
+📦 <b>可委托库存:</b>
+• 能量: %s TRX
+• 带宽: %s TRX
+
 📈 <b>状态:</b>
 • 余额状态: %s
 • 能量状态: %s`,
 		info.Data.Address,
-		balanceTRX,
+		balance.Format(units.TRXDecimals),
 		energy,
 		energyLimit,
 		energyUsed,
 		usagePercent,
-		getBalanceStatus(balanceTRX),
+		bandwidth,
+		bandwidthLimit,
+		bandwidthUsed,
+		delegatableEnergy.Format(units.TRXDecimals),
+		delegatableBandwidth.Format(units.TRXDecimals),
+		getBalanceStatus(balance),
 		getEnergyStatus(energy),
 	)
 
 	// 添加告警信息
-	alerts := getAlerts(balanceTRX, energy)
+	alerts := getAlerts(balance, energy)
 	if alerts != "" {
 		message += "\n\n🚨 <b>告警信息:</b>\n" + alerts
 	}
@@ -273,9 +300,30 @@ func (bot *TelegramBot) FormatAccountInfo(info *DelegationAccountInfo) string {
 	return message
 }
 
+// parseOptionalInt 解析可选的整数字段，为空或格式错误时返回0
+func parseOptionalInt(value string) int64 {
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return parsed
+}
+
+// parseOptionalAmount 解析可选的金额字段（SUN），为空或格式错误时返回0
+func parseOptionalAmount(value string) units.Amount {
+	amount, err := units.ParseAmount(value)
+	if err != nil {
+		return units.Amount{}
+	}
+	return amount
+}
+
+// minBalance 余额告警阈值 10 TRX
+var minBalance = units.NewAmount(10 * units.SunPerTRX)
+
 // getBalanceStatus 获取余额状态
-func getBalanceStatus(balanceTRX float64) string {
-	if balanceTRX < 10 {
+func getBalanceStatus(balance units.Amount) string {
+	if balance.Cmp(minBalance) < 0 {
 		return "⚠️ 余额不足"
 	}
 	return "✅ 余额充足"
@@ -290,10 +338,10 @@ func getEnergyStatus(energy int64) string {
 }
 
 // getAlerts 获取告警信息
-func getAlerts(balanceTRX float64, energy int64) string {
+func getAlerts(balance units.Amount, energy int64) string {
 	var alerts []string
 
-	if balanceTRX < 10 {
+	if balance.Cmp(minBalance) < 0 {
 		alerts = append(alerts, "• 账户余额不足 (少于10 TRX)")
 	}
 